@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// The severities of the queries (the problem.severity property of their rules).
+const (
+	severityError          = "error"
+	severityWarning        = "warning"
+	severityRecommendation = "recommendation"
+)
+
+// RuleAlertCount is the number of alerts of a rule (a.k.a. query) in an analysis.
+type RuleAlertCount struct {
+	RuleID   string
+	Name     string
+	Severity string
+	Count    int
+}
+
+// sarifRule is a rule of the tool of a SARIF 2.1.0 run.
+type sarifRule struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	ShortDescription struct {
+		Text string `json:"text"`
+	} `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// severity returns the severity of the rule: its problem.severity property,
+// else the one of the SARIF level of the result (or of the rule).
+func (rule *sarifRule) severity(level string) string {
+	if severity, ok := rule.Properties["problem.severity"].(string); ok && severity != "" {
+		return severity
+	}
+	if level == "" {
+		level = rule.DefaultConfiguration.Level
+	}
+	switch level {
+	case "error":
+		return severityError
+	case "note":
+		return severityRecommendation
+	}
+	// "warning" is the default level.
+	return severityWarning
+}
+
+// displayName returns the name of the query of the rule.
+func (rule *sarifRule) displayName() string {
+	switch {
+	case rule.ShortDescription.Text != "":
+		return rule.ShortDescription.Text
+	case rule.Name != "":
+		return rule.Name
+	}
+	return rule.ID
+}
+
+// countSARIFAlertsByRule counts the results of the SARIF log by rule,
+// in the order in which the rules first appear.
+func countSARIFAlertsByRule(sarif []byte) ([]*RuleAlertCount, error) {
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []*sarifRule `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(sarif, &log); err != nil {
+		return nil, fmt.Errorf("error while parsing SARIF: %w", err)
+	}
+	counts := make([]*RuleAlertCount, 0)
+	byRule := make(map[string]*RuleAlertCount)
+	for _, run := range log.Runs {
+		rules := make(map[string]*sarifRule)
+		for _, rule := range run.Tool.Driver.Rules {
+			rules[rule.ID] = rule
+		}
+		for _, res := range run.Results {
+			count, ok := byRule[res.RuleID]
+			if !ok {
+				rule, ok := rules[res.RuleID]
+				if !ok {
+					rule = &sarifRule{ID: res.RuleID}
+				}
+				count = &RuleAlertCount{
+					RuleID:   res.RuleID,
+					Name:     rule.displayName(),
+					Severity: rule.severity(res.Level),
+				}
+				byRule[res.RuleID] = count
+				counts = append(counts, count)
+			}
+			count.Count++
+		}
+	}
+	return counts, nil
+}
+
+// SeverityCounts contains the number of alerts per severity.
+type SeverityCounts struct {
+	Error          int `json:"error"`
+	Warning        int `json:"warning"`
+	Recommendation int `json:"recommendation"`
+	Other          int `json:"other,omitempty"`
+}
+
+func (sc *SeverityCounts) add(severity string, count int) {
+	switch severity {
+	case severityError:
+		sc.Error += count
+	case severityWarning:
+		sc.Warning += count
+	case severityRecommendation:
+		sc.Recommendation += count
+	default:
+		sc.Other += count
+	}
+}
+
+// Total returns the number of alerts of all severities.
+func (sc *SeverityCounts) Total() int {
+	return sc.Error + sc.Warning + sc.Recommendation + sc.Other
+}
+
+// ProjectAlertSummary contains the alert counts of a project for a language.
+type ProjectAlertSummary struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Lang string `json:"lang"`
+	SeverityCounts
+	Total int `json:"total"`
+}
+
+// RuleAlertSummary contains the alert counts of a query (a.k.a. rule)
+// across all projects.
+type RuleAlertSummary struct {
+	QueryID   string `json:"queryId"`
+	QueryName string `json:"queryName"`
+	Lang      string `json:"lang"`
+	Severity  string `json:"severity"`
+	Alerts    int    `json:"alerts"`
+	Projects  int    `json:"projects"`
+}
+
+// AlertSummary aggregates the alert counts of many projects.
+type AlertSummary struct {
+	Total    SeverityCounts         `json:"total"`
+	Projects []*ProjectAlertSummary `json:"projects"`
+	Rules    []*RuleAlertSummary    `json:"rules"`
+
+	rules map[string]*RuleAlertSummary
+}
+
+func NewAlertSummary() *AlertSummary {
+	return &AlertSummary{
+		Projects: make([]*ProjectAlertSummary, 0),
+		Rules:    make([]*RuleAlertSummary, 0),
+		rules:    make(map[string]*RuleAlertSummary),
+	}
+}
+
+// Add adds the alert counts of a project for a language to the summary.
+func (sum *AlertSummary) Add(pr *lgtm.Project, lang string, counts []*RuleAlertCount) {
+	prSum := &ProjectAlertSummary{
+		Name: pr.DisplayName,
+		URL:  pr.ExternalURL.URL,
+		Lang: lang,
+	}
+	for _, count := range counts {
+		if count.Count == 0 {
+			continue
+		}
+		prSum.add(count.Severity, count.Count)
+		sum.Total.add(count.Severity, count.Count)
+
+		ruleKey := lang + "/" + count.RuleID
+		rule, ok := sum.rules[ruleKey]
+		if !ok {
+			rule = &RuleAlertSummary{
+				QueryID:   count.RuleID,
+				QueryName: count.Name,
+				Lang:      lang,
+				Severity:  count.Severity,
+			}
+			sum.rules[ruleKey] = rule
+			sum.Rules = append(sum.Rules, rule)
+		}
+		rule.Alerts += count.Count
+		rule.Projects++
+	}
+	prSum.Total = prSum.SeverityCounts.Total()
+	sum.Projects = append(sum.Projects, prSum)
+}
+
+// Sort ranks projects and rules from the noisiest to the quietest.
+func (sum *AlertSummary) Sort() {
+	sort.SliceStable(sum.Projects, func(i, j int) bool {
+		a, b := sum.Projects[i], sum.Projects[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.Error != b.Error {
+			return a.Error > b.Error
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(sum.Rules, func(i, j int) bool {
+		a, b := sum.Rules[i], sum.Rules[j]
+		if a.Alerts != b.Alerts {
+			return a.Alerts > b.Alerts
+		}
+		return a.QueryName < b.QueryName
+	})
+}
+
+// Print prints the top noisiest projects and rules.
+func (sum *AlertSummary) Print(top int) {
+	Errorln(Bold("PROJECT | LANG | ERRORS | WARNINGS | RECOMMENDATIONS | TOTAL"))
+	for i, pr := range sum.Projects {
+		if top > 0 && i >= top {
+			break
+		}
+		Sfln(
+			"%s | %s | %v | %v | %v | %v",
+			pr.Name,
+			pr.Lang,
+			pr.Error,
+			pr.Warning,
+			pr.Recommendation,
+			pr.Total,
+		)
+	}
+	Ln()
+	Errorln(Bold("RULE | LANG | SEVERITY | ALERTS | PROJECTS"))
+	for i, rule := range sum.Rules {
+		if top > 0 && i >= top {
+			break
+		}
+		Sfln(
+			"%s | %s | %s | %v | %v",
+			rule.QueryName,
+			rule.Lang,
+			rule.Severity,
+			rule.Alerts,
+			rule.Projects,
+		)
+	}
+	Successf(
+		"Total: %v alerts (%v errors, %v warnings, %v recommendations) in %v projects",
+		sum.Total.Total(),
+		sum.Total.Error,
+		sum.Total.Warning,
+		sum.Total.Recommendation,
+		len(sum.Projects),
+	)
+}
+
+// WriteCSV writes both the project and the rule rankings as CSV;
+// the first column tells whether a row is about a project or a rule.
+func (sum *AlertSummary) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"kind", "name", "lang", "url_or_query_id", "severity", "error", "warning", "recommendation", "total", "projects"})
+	if err != nil {
+		return err
+	}
+	for _, pr := range sum.Projects {
+		err := writer.Write([]string{
+			"project",
+			pr.Name,
+			pr.Lang,
+			pr.URL,
+			"",
+			strconv.Itoa(pr.Error),
+			strconv.Itoa(pr.Warning),
+			strconv.Itoa(pr.Recommendation),
+			strconv.Itoa(pr.Total),
+			"1",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, rule := range sum.Rules {
+		var counts SeverityCounts
+		counts.add(rule.Severity, rule.Alerts)
+		err := writer.Write([]string{
+			"rule",
+			rule.QueryName,
+			rule.Lang,
+			rule.QueryID,
+			rule.Severity,
+			strconv.Itoa(counts.Error),
+			strconv.Itoa(counts.Warning),
+			strconv.Itoa(counts.Recommendation),
+			strconv.Itoa(rule.Alerts),
+			strconv.Itoa(rule.Projects),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
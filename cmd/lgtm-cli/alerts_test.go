@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+const testRulesSARIF = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {
+      "name": "LGTM.com",
+      "rules": [
+        {"id": "com.lgtm/javascript-queries:js/xss", "shortDescription": {"text": "Client-side cross-site scripting"}, "properties": {"problem.severity": "error"}},
+        {"id": "js/unused-local-variable", "name": "js/unused-local-variable", "defaultConfiguration": {"level": "note"}},
+        {"id": "js/useless-assignment"}
+      ]
+    }},
+    "results": [
+      {"ruleId": "com.lgtm/javascript-queries:js/xss", "level": "warning"},
+      {"ruleId": "js/unused-local-variable"},
+      {"ruleId": "com.lgtm/javascript-queries:js/xss"},
+      {"ruleId": "js/useless-assignment", "level": "error"},
+      {"ruleId": "js/no-rule"}
+    ]
+  }]
+}`
+
+func TestCountSARIFAlertsByRule(t *testing.T) {
+	counts, err := countSARIFAlertsByRule([]byte(testRulesSARIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RuleAlertCount{
+		{RuleID: "com.lgtm/javascript-queries:js/xss", Name: "Client-side cross-site scripting", Severity: severityError, Count: 2},
+		{RuleID: "js/unused-local-variable", Name: "js/unused-local-variable", Severity: severityRecommendation, Count: 1},
+		{RuleID: "js/useless-assignment", Name: "js/useless-assignment", Severity: severityError, Count: 1},
+		{RuleID: "js/no-rule", Name: "js/no-rule", Severity: severityWarning, Count: 1},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("got %v rules, want %v", len(counts), len(want))
+	}
+	for i := range want {
+		if *counts[i] != want[i] {
+			t.Errorf("counts[%v] = %+v, want %+v", i, *counts[i], want[i])
+		}
+	}
+
+	if _, err := countSARIFAlertsByRule([]byte("not json")); err == nil {
+		t.Errorf("invalid SARIF should fail")
+	}
+}
+
+func TestAlertSummary(t *testing.T) {
+	counts, err := countSARIFAlertsByRule([]byte(testRulesSARIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	foo := &lgtm.Project{DisplayName: "foo", ExternalURL: lgtm.ExternalURL{URL: "https://github.com/a/foo"}}
+	bar := &lgtm.Project{DisplayName: "bar", ExternalURL: lgtm.ExternalURL{URL: "https://github.com/a/bar"}}
+
+	summary := NewAlertSummary()
+	summary.Add(foo, lgtm.LangJavaScript, counts[:1])
+	summary.Add(bar, lgtm.LangJavaScript, counts)
+	summary.Sort()
+
+	if summary.Total.Total() != 7 || summary.Total.Error != 5 || summary.Total.Recommendation != 1 || summary.Total.Warning != 1 {
+		t.Errorf("unexpected total: %+v", summary.Total)
+	}
+	if len(summary.Projects) != 2 || summary.Projects[0].Name != "bar" || summary.Projects[0].Total != 5 {
+		t.Errorf("unexpected projects ranking: %+v", summary.Projects)
+	}
+	top := summary.Rules[0]
+	if top.QueryID != "com.lgtm/javascript-queries:js/xss" || top.Alerts != 4 || top.Projects != 2 {
+		t.Errorf("unexpected top rule: %+v", top)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// TrackedAlert is an alert of a snapshot of the alerts of a project.
+type TrackedAlert struct {
+	Lang    string `json:"lang"`
+	RuleID  string `json:"ruleId"`
+	Message string `json:"message"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	// Fingerprint identifies the alert across commits
+	// (the line of an alert changes when the code above it changes).
+	Fingerprint string `json:"fingerprint"`
+}
+
+// String returns a one-line description of the alert.
+func (alert *TrackedAlert) String() string {
+	return Sf("[%s] %s %s:%v: %s", alert.Lang, alert.RuleID, alert.File, alert.Line, alert.Message)
+}
+
+// AlertsSnapshot is the set of alerts of a project at a point in time.
+type AlertsSnapshot struct {
+	Project string    `json:"project"`
+	Key     string    `json:"key"`
+	TakenAt time.Time `json:"takenAt"`
+	// Commits are the analyzed commits, by language.
+	Commits map[string]string `json:"commits"`
+	Alerts  []*TrackedAlert   `json:"alerts"`
+}
+
+// alertsSnapshotFilename returns the name of the snapshot file
+// of the project (e.g. g_kubernetes_kubernetes.alerts.json).
+func alertsSnapshotFilename(pr *lgtm.Project) string {
+	slug := strings.NewReplacer("/", "_", ":", "_").Replace(pr.Slug)
+	return Sf("%s.alerts.json", slug)
+}
+
+// sarifLog contains the parts of a SARIF 2.1.0 log needed to track alerts.
+type sarifLog struct {
+	Runs []struct {
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+			PartialFingerprints map[string]string `json:"partialFingerprints"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// parseSARIFAlerts returns the alerts of the SARIF log
+// whose rule is of the language.
+func parseSARIFAlerts(lang string, sarif []byte) ([]*TrackedAlert, error) {
+	var log sarifLog
+	if err := json.Unmarshal(sarif, &log); err != nil {
+		return nil, fmt.Errorf("error while parsing SARIF: %w", err)
+	}
+	alerts := make([]*TrackedAlert, 0)
+	for _, run := range log.Runs {
+		for _, res := range run.Results {
+			if sarifRuleLanguage(res.RuleID) != lang {
+				continue
+			}
+			alert := &TrackedAlert{
+				Lang:    lang,
+				RuleID:  res.RuleID,
+				Message: res.Message.Text,
+			}
+			if len(res.Locations) > 0 {
+				loc := res.Locations[0].PhysicalLocation
+				alert.File = loc.ArtifactLocation.URI
+				alert.Line = loc.Region.StartLine
+			}
+			if hash := res.PartialFingerprints["primaryLocationLineHash"]; hash != "" {
+				alert.Fingerprint = Sf("%s|%s|%s|%s", lang, alert.RuleID, alert.File, hash)
+			} else {
+				alert.Fingerprint = Sf("%s|%s|%s|%s", lang, alert.RuleID, alert.File, alert.Message)
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+// takeAlertsSnapshot gets the alerts of the latest analysis
+// of the project for each of the languages.
+func takeAlertsSnapshot(cl *lgtm.Client, pr *lgtm.Project, langs []string) (*AlertsSnapshot, error) {
+	snap := &AlertsSnapshot{
+		Project: pr.ExternalURL.URL,
+		Key:     pr.Key,
+		TakenAt: time.Now().UTC(),
+		Commits: make(map[string]string),
+		Alerts:  make([]*TrackedAlert, 0),
+	}
+	for _, lang := range langs {
+		exp, err := getProjectSARIF(cl, pr, lang)
+		if err != nil {
+			return nil, fmt.Errorf("error while getting %s alerts: %w", lang, err)
+		}
+		alerts, err := parseSARIFAlerts(lang, exp.SARIF)
+		if err != nil {
+			return nil, err
+		}
+		snap.Commits[lang] = exp.CommitID
+		snap.Alerts = append(snap.Alerts, alerts...)
+	}
+	return snap, nil
+}
+
+// loadAlertsSnapshot loads the snapshot from the file;
+// it returns nil (and no error) if the file does not exist.
+func loadAlertsSnapshot(path string) (*AlertsSnapshot, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap AlertsSnapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	return &snap, nil
+}
+
+// MergeLanguages adds to the snapshot the alerts (and commits) of the languages
+// of the previous snapshot that are not in it (e.g. because the snapshot
+// was taken with --lang), so that they are kept for the next runs.
+func (snap *AlertsSnapshot) MergeLanguages(previous *AlertsSnapshot) {
+	if previous == nil {
+		return
+	}
+	missing := make(map[string]bool)
+	for lang, commit := range previous.Commits {
+		if _, ok := snap.Commits[lang]; !ok {
+			missing[lang] = true
+			snap.Commits[lang] = commit
+		}
+	}
+	for _, alert := range previous.Alerts {
+		if missing[alert.Lang] {
+			snap.Alerts = append(snap.Alerts, alert)
+		}
+	}
+}
+
+// WriteToFile saves the snapshot as json to the provided file.
+func (snap *AlertsSnapshot) WriteToFile(path string) error {
+	js, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(path, js, 0644)
+}
+
+// AlertsDiff contains the alerts that appeared and disappeared
+// between two snapshots of the alerts of a project.
+type AlertsDiff struct {
+	Project string          `json:"project"`
+	Since   time.Time       `json:"since"`
+	Until   time.Time       `json:"until"`
+	New     []*TrackedAlert `json:"new"`
+	Fixed   []*TrackedAlert `json:"fixed"`
+	// Unchanged is the number of alerts in both snapshots.
+	Unchanged int `json:"unchanged"`
+}
+
+// diffAlertsSnapshots compares the alerts of the two snapshots of a project;
+// only the languages of both snapshots are compared (the alerts of a language
+// that is not in the previous snapshot are a baseline, not new alerts).
+func diffAlertsSnapshots(previous *AlertsSnapshot, current *AlertsSnapshot) *AlertsDiff {
+	diff := &AlertsDiff{
+		Project: current.Project,
+		Since:   previous.TakenAt,
+		Until:   current.TakenAt,
+		New:     make([]*TrackedAlert, 0),
+		Fixed:   make([]*TrackedAlert, 0),
+	}
+	// Alerts with the same fingerprint are counted,
+	// so that duplicates are compared as a multiset.
+	before := make(map[string]int)
+	for _, alert := range previous.Alerts {
+		before[alert.Fingerprint]++
+	}
+	after := make(map[string]int)
+	for _, alert := range current.Alerts {
+		if _, ok := previous.Commits[alert.Lang]; !ok {
+			continue
+		}
+		after[alert.Fingerprint]++
+		if after[alert.Fingerprint] > before[alert.Fingerprint] {
+			diff.New = append(diff.New, alert)
+		} else {
+			diff.Unchanged++
+		}
+	}
+	seen := make(map[string]int)
+	for _, alert := range previous.Alerts {
+		if _, ok := current.Commits[alert.Lang]; !ok {
+			continue
+		}
+		seen[alert.Fingerprint]++
+		if seen[alert.Fingerprint] > after[alert.Fingerprint] {
+			diff.Fixed = append(diff.Fixed, alert)
+		}
+	}
+	sortTrackedAlerts(diff.New)
+	sortTrackedAlerts(diff.Fixed)
+	return diff
+}
+
+func sortTrackedAlerts(alerts []*TrackedAlert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].File != alerts[j].File {
+			return alerts[i].File < alerts[j].File
+		}
+		return alerts[i].Line < alerts[j].Line
+	})
+}
+
+// Print prints the new and fixed alerts.
+func (diff *AlertsDiff) Print() {
+	Infof(
+		"%s: %v new, %v fixed, %v unchanged alerts since %s",
+		diff.Project,
+		len(diff.New),
+		len(diff.Fixed),
+		diff.Unchanged,
+		diff.Since.Format(time.RFC3339),
+	)
+	for _, alert := range diff.New {
+		Sfln("+ %s", alert)
+	}
+	for _, alert := range diff.Fixed {
+		Sfln("- %s", alert)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+)
+
+func newTestAlert(lang string, fingerprint string) *TrackedAlert {
+	return &TrackedAlert{Lang: lang, Fingerprint: fingerprint}
+}
+
+func TestParseSARIFAlertsKeepsTheLanguage(t *testing.T) {
+	alerts, err := parseSARIFAlerts("python", []byte(testAnalysisSARIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("got %v alerts, want 1: %v", len(alerts), alerts)
+	}
+	if alerts[0].RuleID != "com.lgtm/python-queries:py/sql-injection" || alerts[0].Lang != "python" {
+		t.Errorf("unexpected alert: %s", alerts[0])
+	}
+}
+
+func TestDiffAlertsSnapshotsWithLanguageSubset(t *testing.T) {
+	previous := &AlertsSnapshot{
+		Commits: map[string]string{"go": "a1", "javascript": "b1"},
+		Alerts: []*TrackedAlert{
+			newTestAlert("go", "go-1"),
+			newTestAlert("go", "go-2"),
+			newTestAlert("javascript", "js-1"),
+		},
+	}
+	// Taken with --lang=go,python:
+	current := &AlertsSnapshot{
+		Commits: map[string]string{"go": "a2", "python": "c2"},
+		Alerts: []*TrackedAlert{
+			newTestAlert("go", "go-2"),
+			newTestAlert("go", "go-3"),
+			newTestAlert("python", "py-1"),
+		},
+	}
+
+	diff := diffAlertsSnapshots(previous, current)
+	if len(diff.New) != 1 || diff.New[0].Fingerprint != "go-3" {
+		t.Errorf("New = %v, want [go-3] (python is a baseline)", diff.New)
+	}
+	if len(diff.Fixed) != 1 || diff.Fixed[0].Fingerprint != "go-1" {
+		t.Errorf("Fixed = %v, want [go-1] (javascript was not compared)", diff.Fixed)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("Unchanged = %v, want 1", diff.Unchanged)
+	}
+
+	current.MergeLanguages(previous)
+	wantCommits := map[string]string{"go": "a2", "javascript": "b1", "python": "c2"}
+	for lang, commit := range wantCommits {
+		if current.Commits[lang] != commit {
+			t.Errorf("Commits[%s] = %q, want %q", lang, current.Commits[lang], commit)
+		}
+	}
+	fingerprints := make(map[string]int)
+	for _, alert := range current.Alerts {
+		fingerprints[alert.Fingerprint]++
+	}
+	for _, want := range []string{"go-2", "go-3", "py-1", "js-1"} {
+		if fingerprints[want] != 1 {
+			t.Errorf("merged snapshot has %v %s alerts, want 1", fingerprints[want], want)
+		}
+	}
+	if fingerprints["go-1"] != 0 {
+		t.Errorf("merged snapshot kept the fixed go-1 alert")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// githubRepoLinkRegex matches the links to GitHub repos
+// (and to any page inside a repo, e.g. .../tree/main/docs).
+var githubRepoLinkRegex = regexp.MustCompile(`https?://(?:www\.)?github\.com/([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)`)
+
+// githubReservedOwners are the first path elements of github.com pages
+// that are not owners of repos.
+var githubReservedOwners = map[string]bool{
+	"about":            true,
+	"apps":             true,
+	"collections":      true,
+	"contact":          true,
+	"customer-stories": true,
+	"enterprise":       true,
+	"events":           true,
+	"explore":          true,
+	"features":         true,
+	"issues":           true,
+	"login":            true,
+	"marketplace":      true,
+	"new":              true,
+	"notifications":    true,
+	"orgs":             true,
+	"pricing":          true,
+	"pulls":            true,
+	"search":           true,
+	"settings":         true,
+	"site":             true,
+	"sponsors":         true,
+	"topics":           true,
+	"trending":         true,
+	"users":            true,
+}
+
+// extractGithubRepoLinks returns the URLs of the GitHub repos
+// linked in the markdown, deduplicated (case-insensitively) and in order
+// of first appearance.
+func extractGithubRepoLinks(markdown string) []string {
+	repoURLs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range githubRepoLinkRegex.FindAllStringSubmatch(markdown, -1) {
+		owner, repo := match[1], strings.TrimSuffix(strings.TrimRight(match[2], "."), ".git")
+		if repo == "" || githubReservedOwners[ToLower(owner)] {
+			continue
+		}
+		repoURL := githubHost + "/" + owner + "/" + repo
+		if seen[ToLower(repoURL)] {
+			continue
+		}
+		seen[ToLower(repoURL)] = true
+		repoURLs = append(repoURLs, repoURL)
+	}
+	return repoURLs
+}
+
+// fetchAwesomeList returns the markdown of the awesome list, and the URL
+// of its repo (if known); the target is a GitHub repo (owner/repo, or its URL),
+// whose README is used, or the URL of a markdown file
+// (raw, or a GitHub blob URL).
+func fetchAwesomeList(target string) (string, string, error) {
+	if isAwesomeListRepo(target) {
+		parsed, err := urlparse.Parse(target, true)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid awesome list %q: %w", target, err)
+		}
+		if parsed.Hostname != "github.com" {
+			return "", "", fmt.Errorf("invalid awesome list %q: not a GitHub repo", target)
+		}
+		readme, _, err := ghRawClient.Repositories.GetReadme(context.Background(), parsed.User, parsed.Repo, nil)
+		if err != nil {
+			return "", "", fmt.Errorf("error while getting README of %s: %w", parsed.URL(), err)
+		}
+		content, err := readme.GetContent()
+		if err != nil {
+			return "", "", fmt.Errorf("error while decoding README of %s: %w", parsed.URL(), err)
+		}
+		return content, parsed.URL(), nil
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid awesome list URL %q: %w", target, err)
+	}
+	resp, err := webHTTPClient.Get(rawQueryURL(parsed))
+	if err != nil {
+		return "", "", fmt.Errorf("error while downloading awesome list: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("error while downloading awesome list: status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("error while downloading awesome list: %w", err)
+	}
+	var listRepoURL string
+	if parsed.Host == "github.com" || parsed.Host == "raw.githubusercontent.com" {
+		parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(parts) >= 2 {
+			listRepoURL = githubHost + "/" + parts[0] + "/" + parts[1]
+		}
+	}
+	return string(body), listRepoURL, nil
+}
+
+// isAwesomeListRepo returns true if the target is a repo
+// (e.g. avelino/awesome-go, or https://github.com/avelino/awesome-go)
+// instead of the URL of a markdown file.
+func isAwesomeListRepo(target string) bool {
+	if !isQueryURL(target) {
+		return true
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host != "github.com" {
+		return false
+	}
+	return strings.Count(strings.Trim(parsed.Path, "/"), "/") == 1
+}
+
+// lookupAwesomeListRepos gets the GitHub metadata of the repos
+// (with at most maxWorkers concurrent requests), and returns
+// the repos that are neither forks nor archived (in the same order);
+// the repos are identified by their current URL, so that renamed
+// repos are followed by their new name.
+// The repos that cannot be looked up are skipped.
+func lookupAwesomeListRepos(ctx context.Context, repoURLs []string, maxWorkers int64) []*github.Repository {
+	meta := fetchRepoMetadata(ctx, repoURLs, maxWorkers)
+	found := make([]*github.Repository, len(repoURLs))
+	for i, repoURL := range repoURLs {
+		repo := meta.Get(repoURL)
+		if repo == nil {
+			continue
+		}
+		if repo.GetFork() {
+			// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+			Debugf("Skipping fork %s", repo.GetFullName())
+			continue
+		}
+		if repo.GetArchived() {
+			Debugf("Skipping archived %s", repo.GetFullName())
+			continue
+		}
+		found[i] = repo
+	}
+
+	repos := make([]*github.Repository, 0)
+	seen := make(map[string]bool)
+	for _, repo := range found {
+		if repo == nil {
+			continue
+		}
+		// Links to the old and new names of a renamed repo are the same repo:
+		if seen[ToLower(repo.GetHTMLURL())] {
+			continue
+		}
+		seen[ToLower(repo.GetHTMLURL())] = true
+		repos = append(repos, repo)
+	}
+	return repos
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	. "github.com/gagliardetto/utilz"
+)
+
+// RepoBlacklist contains the glob patterns of repos that must never be
+// followed/queried/rebuilt.
+// A nil *RepoBlacklist is valid and matches nothing.
+type RepoBlacklist struct {
+	patterns []string
+}
+
+// LoadBlacklistFromFilepaths loads the blacklist patterns from the provided files;
+// patterns are one per line; empty lines and lines starting with # are ignored.
+func LoadBlacklistFromFilepaths(paths ...string) (*RepoBlacklist, error) {
+	raw := make([]string, 0)
+	for _, path := range paths {
+		lines, err := readPatternLines(path)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading blacklist file %q: %w", path, err)
+		}
+		raw = append(raw, lines...)
+	}
+	patterns, err := urlparse.CompileRepoURLPatterns(Deduplicate(raw))
+	if err != nil {
+		return nil, fmt.Errorf("error while compiling blacklist patterns: %w", err)
+	}
+	return &RepoBlacklist{
+		patterns: patterns,
+	}, nil
+}
+
+func readPatternLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	res := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res, scanner.Err()
+}
+
+// compileExceptPatterns compiles the provided patterns, plus the ones
+// in the provided files (one per line), into repo URL patterns.
+func compileExceptPatterns(patterns []string, filepaths []string) ([]string, error) {
+	raws := append([]string{}, patterns...)
+	for _, path := range filepaths {
+		err := ReadConfigLinesAsString(path, func(line string) bool {
+			raws = append(raws, line)
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error while reading %s: %w", path, err)
+		}
+	}
+	return urlparse.CompileRepoURLPatterns(Deduplicate(raws))
+}
+
+// Len returns the number of patterns in the blacklist.
+func (bl *RepoBlacklist) Len() int {
+	if bl == nil {
+		return 0
+	}
+	return len(bl.patterns)
+}
+
+// Match returns the pattern that matches the provided repo URL (if any).
+func (bl *RepoBlacklist) Match(repoURL string) (string, bool) {
+	if bl == nil || len(bl.patterns) == 0 {
+		return "", false
+	}
+	return HasMatch(trimDotGit(repoURL), bl.patterns)
+}
+
+// Filter removes the blacklisted repos from the provided list.
+func (bl *RepoBlacklist) Filter(repoURLs []string) []string {
+	if bl == nil || len(bl.patterns) == 0 {
+		return repoURLs
+	}
+	res := make([]string, 0, len(repoURLs))
+	for _, repoURL := range repoURLs {
+		if pattern, isBlacklisted := bl.Match(repoURL); isBlacklisted {
+			Warnf(
+				"%s is blacklisted (by pattern %q); skipping",
+				trimGithubPrefix(repoURL),
+				pattern,
+			)
+			continue
+		}
+		res = append(res, repoURL)
+	}
+	return res
+}
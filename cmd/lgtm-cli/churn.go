@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// ProjectChurn is the churn of a project in a language,
+// as ranked by the churn command.
+type ProjectChurn struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Lang  string `json:"lang"`
+	Churn int    `json:"churn"`
+}
+
+// rankProjectsByChurn returns the churn of the projects, one row per
+// project and language (only the langs, if not empty), sorted by churn
+// (descending); the rows with less than minChurn churn are excluded.
+func rankProjectsByChurn(projects []*lgtm.Project, langs []string, minChurn int) []*ProjectChurn {
+	rows := make([]*ProjectChurn, 0)
+	for _, pr := range projects {
+		for _, churn := range pr.TotalLanguageChurn {
+			if len(langs) > 0 && !SliceContains(langs, ToLower(churn.Lang)) {
+				continue
+			}
+			if churn.Churn < minChurn {
+				continue
+			}
+			rows = append(rows, &ProjectChurn{
+				Name:  pr.DisplayName,
+				URL:   pr.ExternalURL.URL,
+				Lang:  churn.Lang,
+				Churn: churn.Churn,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Lang < b.Lang
+	})
+	return rows
+}
+
+// writeProjectChurnCSV writes the rows as CSV, with a header.
+func writeProjectChurnCSV(w io.Writer, rows []*ProjectChurn) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "url", "lang", "churn"})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := writer.Write([]string{
+			row.Name,
+			row.URL,
+			row.Lang,
+			strconv.Itoa(row.Churn),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// printProjectChurn prints the rows as a table.
+func printProjectChurn(rows []*ProjectChurn) {
+	Errorln(Bold("PROJECT | URL | LANG | CHURN"))
+	for _, row := range rows {
+		Sfln("%s | %s | %s | %v", row.Name, row.URL, row.Lang, row.Churn)
+	}
+}
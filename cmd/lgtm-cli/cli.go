@@ -0,0 +1,8272 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/bianconiglio"
+	"github.com/gagliardetto/depnet/depnetloader"
+	"github.com/gagliardetto/eta"
+	ghc "github.com/gagliardetto/gh-client"
+	"github.com/gagliardetto/lgtm-cli/internal/cache"
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/githubutil"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	"github.com/gagliardetto/ref"
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+	"github.com/hako/durafmt"
+	"github.com/urfave/cli"
+	"go.uber.org/ratelimit"
+)
+
+const (
+	githubHost = "https://github.com"
+)
+
+var (
+	ghClient    *ghc.Client
+	ghRawClient *github.Client
+)
+
+var gitCommitSHA = ""
+
+func main() {
+	ctx, abortRun := context.WithCancel(newInterruptContext())
+	limitGuard := &projectLimitGuard{abort: abortRun}
+
+	var configFilepath string
+	var profileName string
+	var fileConf *lgtm.Config
+	var conf *lgtm.Config
+	var metricsListenAddr string
+	var client *lgtm.Client
+	var waitDuration time.Duration
+	var ignoreFollowedErrors bool
+	var noCache bool
+	var maxDuration time.Duration
+	var runTimeBox *timeBox
+	var blacklistFilepaths cli.StringSlice
+	var blacklist *RepoBlacklist
+	var githubCacheDir string
+	var noGithubCache bool
+	var requestTimeout time.Duration
+	var connectTimeout time.Duration
+	var githubRequestTimeout time.Duration
+	var githubConnectTimeout time.Duration
+	var slowRequestThreshold time.Duration
+	var stopAtLimit bool
+	var tagsFilepath string
+	var runsFilepath string
+	var queueFilepath string
+	var lgtmProxy string
+	var githubProxy string
+	var userAgent string
+	var extraHeaders cli.StringSlice
+	var extraCookies cli.StringSlice
+	var adaptivePacing bool
+	var pacingMin time.Duration
+	var pacingMax time.Duration
+	var pacingBacklog int
+	var pacer *FollowPacer
+	var progressJSON bool
+	var progressFilepath string
+	var notifyWebhook string
+	var notifyCmd string
+	var notifyFailureRate float64
+	var notifyMinAttempts int
+	var distribute bool
+	var distributeProfiles string
+	var sessions *SessionPool
+	var recordHTTPDir string
+	var replayHTTPDir string
+	var keyCacheFilepath string
+	var noKeyCache bool
+
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	follower := func(u string, etac *eta.ETA) (prj *lgtm.Envelope, err error) {
+		defer func() {
+			progress.Emit("follow", u, etac, err)
+			notifier.Record("follow", err)
+		}()
+		defer etac.Done(1)
+
+		averagedETA := etac.GetETA()
+		thisETA := durafmt.Parse(averagedETA.Round(time.Second)).String()
+
+		Infof(
+			"[%s](%v/%v) Following %s ...; ETA %s",
+			etac.GetFormattedPercentDone(),
+			etac.GetDone()+1,
+			etac.GetTotal(),
+			u,
+			thisETA,
+		)
+
+		followClient := client
+		var sess *PooledSession
+		if sessions != nil {
+			sess = sessions.Next()
+			if sess == nil {
+				return nil, errors.New("all the profiles have reached the follow limit")
+			}
+			followClient = sess.Client
+			defer func() {
+				sessions.Record(sess, err)
+			}()
+		}
+
+		prj, err = followClient.FollowProject(u)
+		if err != nil {
+			metrics.Inc("errors_total", "op", "follow")
+			switch lgtm.ClassifyError(err) {
+			case lgtm.ErrorKindNotFound:
+				Warnf(
+					"%s was %s.",
+					u,
+					OrangeBG(Bold("not found")),
+				)
+			case lgtm.ErrorKindFork:
+				Warnf(
+					"%s "+OrangeBG(Bold("is a fork")),
+					u,
+				)
+			case lgtm.ErrorKindAlreadyFollowed:
+				Warnf(
+					"%s is "+OrangeBG(Bold("already followed")),
+					u,
+				)
+			case lgtm.ErrorKindQuotaExceeded:
+				ee := lgtm.AsStatusResponseError(err)
+				if sess != nil {
+					// Keep following with the other profiles:
+					Warnf("Profile %q has reached the follow limit: %s", sess.Name, ee.Message)
+					if sessions.Disable(sess) == 0 {
+						limitGuard.Reached(ee, configFilepath, sess.Name, sess.Conf.FollowLimit)
+					}
+				} else {
+					limitGuard.Reached(ee, configFilepath, profileName, conf.FollowLimit)
+				}
+			default:
+				Errorf(
+					"Error while following project %s : %s",
+					u,
+					err,
+				)
+			}
+		} else {
+			metrics.Inc("follows_total")
+			var knownOrNew string
+			if prj.IsKnown() {
+				knownOrNew = OrangeBG("[KNO]")
+			} else {
+				knownOrNew = LimeBG("[NEW]")
+			}
+			var via string
+			if sess != nil {
+				via = Sf(" (profile %q)", sess.Name)
+			}
+			Successf(
+				"[%s](%v/%v) Followed %s %s%s; ETA %s",
+				etac.GetFormattedPercentDone(),
+				etac.GetDone()+1,
+				etac.GetTotal(),
+				knownOrNew,
+				u,
+				via,
+				thisETA,
+			)
+		}
+		return prj, err
+	}
+
+	// getFollowedCache returns the followed projects; when the follows
+	// are distributed, the projects followed by any of the profiles.
+	getFollowedCache := func(dont bool) (*lgtm.FollowedProjectCache, error) {
+		if sessions != nil {
+			return sessions.GetFollowedCache(dont)
+		}
+		return getFollowedCacheOf(client, dont)
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	app := &cli.App{
+		Name:        "lgtm-cli",
+		Version:     gitCommitSHA,
+		Description: "Unofficial lgtm.com CLI — https://github.com/gagliardetto/lgtm-cli",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "conf",
+				Usage:       "Path to credentials.json file",
+				Destination: &configFilepath,
+			},
+			&cli.StringFlag{
+				Name:        "profile",
+				Usage:       "Name of the config profile (lgtm.com account) to use.",
+				EnvVar:      "LGTM_CLI_PROFILE",
+				Destination: &profileName,
+			},
+			&cli.DurationFlag{
+				Name:        "max-duration",
+				Usage:       "Max duration of the run (e.g. 55m): when it approaches, no new operations are started, and the run exits successfully once the in-flight ones complete.",
+				Destination: &maxDuration,
+			},
+			&cli.BoolFlag{
+				Name:        "yes",
+				Usage:       "Answer yes to all confirmation prompts (especially destructive commands still require their own --force flag).",
+				EnvVar:      "LGTM_CLI_YES",
+				Destination: &assumeYes,
+			},
+			&cli.DurationFlag{
+				Name:        "wait",
+				Usage:       "Wait duration between requests.",
+				Destination: &waitDuration,
+			},
+			&cli.BoolFlag{
+				Name:        "ignore-followed-errors",
+				Usage:       "Ignore errors that happen while getting list of followed projects (when that is acceptable).",
+				Destination: &ignoreFollowedErrors,
+			},
+			&cli.BoolFlag{
+				Name:        "nocache",
+				Usage:       "Don't fetch the list of followed projects.",
+				Destination: &noCache,
+			},
+			&cli.StringSliceFlag{
+				Name:  "blacklist",
+				Usage: "Filepath to text file with glob patterns of repos to never follow/query/rebuild (can use flag multiple times).",
+				Value: &blacklistFilepaths,
+			},
+			&cli.StringFlag{
+				Name:        "github-cache-dir",
+				Usage:       "Directory where to cache GitHub API responses (revalidated with ETags).",
+				Value:       githubutil.DefaultCacheDir(),
+				Destination: &githubCacheDir,
+			},
+			&cli.StringFlag{
+				Name:        "metrics-listen",
+				Usage:       "Address on which to expose Prometheus metrics at /metrics (e.g. :9090).",
+				Destination: &metricsListenAddr,
+			},
+			&cli.BoolFlag{
+				Name:        "no-github-cache",
+				Usage:       "Don't cache GitHub API responses.",
+				Destination: &noGithubCache,
+			},
+			&cli.DurationFlag{
+				Name:        "timeout",
+				Usage:       "Timeout of lgtm.com requests (default: timeouts.request from config, or 5m).",
+				Destination: &requestTimeout,
+			},
+			&cli.DurationFlag{
+				Name:        "connect-timeout",
+				Usage:       "Timeout for connecting to lgtm.com (default: timeouts.connect from config, or 30s).",
+				Destination: &connectTimeout,
+			},
+			&cli.DurationFlag{
+				Name:        "github-timeout",
+				Usage:       "Timeout of GitHub API requests (default: timeouts.github_request from config, or 5m).",
+				Destination: &githubRequestTimeout,
+			},
+			&cli.DurationFlag{
+				Name:        "github-connect-timeout",
+				Usage:       "Timeout for connecting to the GitHub API (default: timeouts.github_connect from config, or 30s).",
+				Destination: &githubConnectTimeout,
+			},
+			&cli.BoolFlag{
+				Name:        "stop-at-limit",
+				Usage:       "Only follow projects up to the follow limit of the account (the others are saved to a file).",
+				Destination: &stopAtLimit,
+			},
+			&cli.StringFlag{
+				Name:        "tags-file",
+				Usage:       "Filepath of the local project tags (see the tag command).",
+				Value:       DefaultTagsFilepath(),
+				Destination: &tagsFilepath,
+			},
+			&cli.StringFlag{
+				Name:        "proxy",
+				Usage:       "Proxy of lgtm.com requests: http://, https:// or socks5:// URL, or none (default: proxies.lgtm from config, or the HTTPS_PROXY env var).",
+				Destination: &lgtmProxy,
+			},
+			&cli.StringFlag{
+				Name:        "github-proxy",
+				Usage:       "Proxy of GitHub, pkg.go.dev and all other requests: http://, https:// or socks5:// URL, or none (default: proxies.github from config, or the HTTPS_PROXY env var).",
+				Destination: &githubProxy,
+			},
+			&cli.StringFlag{
+				Name:        "user-agent",
+				Usage:       "User-agent of lgtm.com requests (default: headers.user_agent from config, or a built-in browser user-agent).",
+				Destination: &userAgent,
+			},
+			&cli.StringSliceFlag{
+				Name:  "header",
+				Usage: "Extra header of lgtm.com requests, as \"Name: value\" (can use flag multiple times; overrides headers.extra from config).",
+				Value: &extraHeaders,
+			},
+			&cli.StringSliceFlag{
+				Name:  "cookie",
+				Usage: "Extra cookie of lgtm.com requests, as name=value (can use flag multiple times; overrides headers.cookies from config).",
+				Value: &extraCookies,
+			},
+			&cli.StringFlag{
+				Name:        "runs-file",
+				Usage:       "Filepath of the saved query runs (see query --save-run and the runs command).",
+				Value:       DefaultRunsFilepath(),
+				Destination: &runsFilepath,
+			},
+			&cli.StringFlag{
+				Name:        "queue-file",
+				Usage:       "Filepath of the follow queue (see --enqueue-only and the queue command).",
+				Value:       DefaultQueueFilepath(),
+				Destination: &queueFilepath,
+			},
+			&cli.DurationFlag{
+				Name:        "slow-request",
+				Usage:       "Warn about requests that take longer than this duration (0 to disable).",
+				Value:       time.Minute,
+				Destination: &slowRequestThreshold,
+			},
+			&cli.BoolFlag{
+				Name:        "adaptive-pacing",
+				Usage:       "Instead of waiting --wait after each new follow, adapt the wait to the build backlog of the recent follows.",
+				Destination: &adaptivePacing,
+			},
+			&cli.DurationFlag{
+				Name:        "pacing-min",
+				Usage:       "With --adaptive-pacing, min wait duration after each new follow.",
+				Value:       5 * time.Second,
+				Destination: &pacingMin,
+			},
+			&cli.DurationFlag{
+				Name:        "pacing-max",
+				Usage:       "With --adaptive-pacing, max wait duration after each new follow.",
+				Value:       10 * time.Minute,
+				Destination: &pacingMax,
+			},
+			&cli.IntFlag{
+				Name:        "pacing-backlog",
+				Usage:       "With --adaptive-pacing, target number of recent new follows with a queued build.",
+				Value:       20,
+				Destination: &pacingBacklog,
+			},
+			&cli.BoolFlag{
+				Name:        "progress-json",
+				Usage:       "Emit newline-delimited JSON progress events (type, target, done, total, eta, outcome) to stderr during long operations.",
+				Destination: &progressJSON,
+			},
+			&cli.StringFlag{
+				Name:        "progress-file",
+				Usage:       "Emit the --progress-json events to this file (or named pipe) instead of stderr.",
+				Destination: &progressFilepath,
+			},
+			&cli.StringFlag{
+				Name:        "notify-webhook",
+				Usage:       "URL to which POST a json notification (Slack-compatible) when the follows or build attempts complete, or when their failure rate exceeds --notify-failure-rate.",
+				Destination: &notifyWebhook,
+			},
+			&cli.StringFlag{
+				Name:        "notify-cmd",
+				Usage:       "Shell command to run for each notification (the notification is passed as json on stdin, and in the LGTM_NOTIFY_* env vars).",
+				Destination: &notifyCmd,
+			},
+			&cli.Float64Flag{
+				Name:        "notify-failure-rate",
+				Usage:       "Notify (once per run) when more than this fraction of the follows or build attempts failed.",
+				Value:       0.5,
+				Destination: &notifyFailureRate,
+			},
+			&cli.IntFlag{
+				Name:        "notify-min-attempts",
+				Usage:       "Min number of follows or build attempts before the failure rate is checked.",
+				Value:       20,
+				Destination: &notifyMinAttempts,
+			},
+			&cli.BoolFlag{
+				Name:        "distribute",
+				Usage:       "Distribute the follows of follow commands across the sessions of several profiles (weighted round-robin, one rate limiter per session).",
+				Destination: &distribute,
+			},
+			&cli.StringFlag{
+				Name:        "profiles",
+				Usage:       "With --distribute, comma-separated profiles (name, or name:weight) among which to distribute the follows (default: all the profiles of the config).",
+				Destination: &distributeProfiles,
+			},
+			&cli.StringFlag{
+				Name:        "record-http",
+				Usage:       "Directory to which save all the lgtm.com requests and responses (one json file each; without the credentials), to replay them with --replay-http.",
+				Destination: &recordHTTPDir,
+			},
+			&cli.StringFlag{
+				Name:        "replay-http",
+				Usage:       "Directory of the lgtm.com responses recorded with --record-http to serve instead of sending the requests (no config needed).",
+				Destination: &replayHTTPDir,
+			},
+			&cli.StringFlag{
+				Name:        "key-cache-file",
+				Usage:       "Filepath of the cache of the keys of the projects (by slug), shared by all commands to avoid looking up the same repos by slug over and over.",
+				Value:       cache.DefaultKeyCacheFilepath(),
+				Destination: &keyCacheFilepath,
+			},
+			&cli.BoolFlag{
+				Name:        "no-key-cache",
+				Usage:       "Don't use (nor update) the cache of the keys of the projects.",
+				Destination: &noKeyCache,
+			},
+		},
+		Before: func(c *cli.Context) error {
+
+			if noCache {
+				ignoreFollowedErrors = true
+			}
+			if maxDuration > 0 {
+				runTimeBox = startTimeBox(maxDuration, abortRun)
+			}
+
+			if metricsListenAddr != "" {
+				go func() {
+					if err := ServeMetrics(metricsListenAddr); err != nil {
+						Errorf("Error while serving metrics on %s: %s", metricsListenAddr, err)
+					}
+				}()
+				Infof("Serving metrics on %s/metrics", metricsListenAddr)
+			}
+
+			if progressJSON || progressFilepath != "" {
+				var err error
+				progress, err = NewProgressEmitter(progressFilepath)
+				if err != nil {
+					Fatalf("Error while opening progress file: %s", err)
+				}
+			}
+
+			if notifyWebhook != "" || notifyCmd != "" {
+				var err error
+				notifier, err = NewNotifier(notifyWebhook, notifyCmd, notifyFailureRate, notifyMinAttempts)
+				if err != nil {
+					Fatalf("Invalid --notify-failure-rate: %s", err)
+				}
+			}
+
+			if len(blacklistFilepaths) > 0 {
+				var err error
+				blacklist, err = LoadBlacklistFromFilepaths(blacklistFilepaths...)
+				if err != nil {
+					Fatalf("Error while loading blacklist: %s", err)
+				}
+				Infof("Loaded %v blacklist patterns", blacklist.Len())
+			}
+
+			// The recordings must not depend on the state of the key cache:
+			if !noKeyCache && recordHTTPDir == "" && replayHTTPDir == "" {
+				var err error
+				keyCache, err = cache.LoadProjectKeyCache(keyCacheFilepath)
+				if err != nil {
+					Warnf("Could not load the key cache (continuing without it): %s", err)
+				}
+			}
+
+			configFilepathFromEnv := os.Getenv("LGTM_CLI_CONFIG")
+
+			// Without a config file, the credentials can be supplied
+			// entirely via env vars (e.g. in CI):
+			credentialsFromEnv := lgtm.HasEnvCredentials()
+			if recordHTTPDir != "" && replayHTTPDir != "" {
+				Fatalf("Cannot use --record-http and --replay-http together")
+			}
+			if configFilepath == "" && configFilepathFromEnv == "" && !credentialsFromEnv && replayHTTPDir == "" {
+				Errorf("No config provided. Please specify the path to the config file with the LGTM_CLI_CONFIG env var.")
+				return errors.New(c.App.Usage)
+			}
+
+			// If the conf flag is not set, use env variable:
+			if configFilepath == "" {
+				configFilepath = configFilepathFromEnv
+			}
+
+			var err error
+			if configFilepath != "" {
+				fileConf, err = lgtm.LoadConfigFromFile(configFilepath)
+				if err != nil {
+					Fatalf("Wrror while loading config: %s", err)
+				}
+			} else if replayHTTPDir != "" && !credentialsFromEnv {
+				fileConf = newReplayConfig()
+			} else {
+				fileConf = &lgtm.Config{}
+			}
+
+			timeouts := fileConf.Timeouts
+			if timeouts == nil {
+				timeouts = &lgtm.TimeoutsConfig{}
+			}
+			proxies := fileConf.Proxies
+			if proxies == nil {
+				proxies = &lgtm.ProxiesConfig{}
+			}
+			lgtm.Proxy, err = lgtm.ParseProxy(pickProxy(lgtmProxy, proxies.LGTM))
+			if err != nil {
+				Fatalf("Error while setting up lgtm.com proxy: %s", err)
+			}
+			webProxy, err := lgtm.ParseProxy(pickProxy(githubProxy, proxies.GitHub))
+			if err != nil {
+				Fatalf("Error while setting up GitHub proxy: %s", err)
+			}
+			lgtm.HeaderOverrides, err = parseHeaderOverrides(userAgent, extraHeaders, extraCookies)
+			if err != nil {
+				Fatalf("Error while setting up lgtm.com headers: %s", err)
+			}
+
+			{ // Setup the lgtm.com http client:
+				lgtm.Timeout = pickTimeout(requestTimeout, timeouts.Request, lgtm.Timeout)
+				lgtm.ConnectTimeout = pickTimeout(connectTimeout, timeouts.Connect, lgtm.ConnectTimeout)
+				lgtm.HTTPClient = lgtm.NewHTTP()
+				// Record the number and duration of the lgtm.com API requests:
+				lgtm.HTTPClient.Transport = &metricsTransport{
+					transport: &slowRequestTransport{
+						transport: lgtm.HTTPClient.Transport,
+						threshold: slowRequestThreshold,
+					},
+				}
+				if recordHTTPDir != "" {
+					recorder, err := newRecordingTransport(lgtm.HTTPClient.Transport, recordHTTPDir)
+					if err != nil {
+						Fatalf("Error while setting up --record-http: %s", err)
+					}
+					lgtm.HTTPClient.Transport = recorder
+					Infof("Recording the lgtm.com responses to %s", recordHTTPDir)
+				}
+				if replayHTTPDir != "" {
+					replayer, err := newReplayTransport(replayHTTPDir)
+					if err != nil {
+						Fatalf("Error while setting up --replay-http: %s", err)
+					}
+					lgtm.HTTPClient.Transport = &metricsTransport{transport: replayer}
+					// Nothing is sent, so there is no need to limit the rate:
+					lgtm.RateLimiter = ratelimit.NewUnlimited()
+					Infof("Replaying %v lgtm.com responses from %s", replayer.Len(), replayHTTPDir)
+				}
+			}
+			{ // Setup the http client of GitHub and all other websites:
+				webTransport := lgtm.NewHTTPTransportWithConnectTimeout(
+					pickTimeout(githubConnectTimeout, timeouts.GitHubConnect, 30*time.Second),
+				)
+				webTransport.Proxy = webProxy
+				setDefaultTransportProxy(webProxy)
+				webHTTPClient = &http.Client{
+					Timeout: pickTimeout(githubRequestTimeout, timeouts.GitHubRequest, 5*time.Minute),
+					Transport: &metricsTransport{
+						transport: &slowRequestTransport{
+							transport: webTransport,
+							threshold: slowRequestThreshold,
+						},
+					},
+				}
+			}
+
+			switch c.Args().First() {
+			case "profiles", "login", "tag", "runs":
+				// These commands don't need a valid session.
+				return nil
+			}
+			conf, err = fileConf.GetProfile(profileName)
+			if err != nil {
+				Fatalf("Error while selecting profile: %s", err)
+			}
+			if credentialsFromEnv {
+				conf = conf.WithEnv()
+			}
+			if err := conf.Validate(); err != nil {
+				Fatalf("Config is not valid: %s", err)
+			}
+			lgtmAPIHost = conf.GetBaseHost()
+
+			client, err = lgtm.NewClient(conf)
+			if err != nil {
+				panic(err)
+			}
+			// Don't start new requests once the run is interrupted:
+			client = client.WithContext(ctx)
+
+			// Setup a new github client:
+			ghClient = ghc.NewClient(conf.GitHub.Token)
+			if noGithubCache {
+				githubCacheDir = ""
+			}
+			ghRawClient = githubutil.NewClient(
+				conf.GitHub.Token,
+				githubCacheDir,
+				webHTTPClient.Transport,
+				webHTTPClient.Timeout,
+			)
+
+			ghc.ResponseCallback = func(resp *github.Response) {
+				if resp == nil {
+					return
+				}
+				metrics.Set("github_rate_remaining", float64(resp.Rate.Remaining))
+				if resp.Rate.Remaining < 1000 {
+					Warnf(
+						"GitHub API rate: remaining %v/%v; resetting in %s",
+						resp.Rate.Remaining,
+						resp.Rate.Limit,
+						resp.Rate.Reset.Sub(time.Now()).Round(time.Second),
+					)
+				}
+			}
+			githubutil.ResponseCallback = ghc.ResponseCallback
+			githubutil.RateLimitCallback = func(wait time.Duration) {
+				Warnf("GitHub API rate limit reached; waiting %s", wait.Round(time.Second))
+			}
+			githubutil.TruncatedSearchCallback = func(windowQuery string, total int) {
+				Warnf(
+					"%s has %v results, but only the first %v can be enumerated",
+					windowQuery,
+					total,
+					githubutil.MaxSearchResults,
+				)
+			}
+
+			// Check whether the lgtm.com session is stale:
+			{
+				user, err := client.GetLoggedInUser()
+				if err != nil {
+					if lgtm.IsStaleSession(err) {
+						Errorln(RedBG("Fatal authentication error:"))
+						Errorln("Your lgtm.com session is stale.")
+						Errorln("Please get new session tokens and version by following this tutorial, and save them with the login command:")
+						Errorln("https://github.com/gagliardetto/lgtm-cli#chrome-where-to-find-the-lgtmcom-api-credentials")
+						os.Exit(ExitCodeAuth)
+					} else {
+						panic(err)
+					}
+				}
+				Errorln(Sf("Logged in as %s", Shakespeare(user.Person.Slug)))
+			}
+
+			if adaptivePacing {
+				if pacingMin <= 0 || pacingMax < pacingMin {
+					return errors.New("--pacing-min must be positive, and not greater than --pacing-max")
+				}
+				pacer = NewAdaptiveFollowPacer(ctx, pacingMin, pacingMax, pacingBacklog, time.Minute)
+			} else {
+				pacer = NewFixedFollowPacer(ctx, waitDuration)
+			}
+
+			if distributeProfiles != "" && !distribute {
+				return errors.New("--profiles requires --distribute")
+			}
+			if distribute {
+				if !isFollowCommand(c.Args()) {
+					Warnf("--distribute only applies to follow commands; ignoring it")
+					return nil
+				}
+				if adaptivePacing {
+					return errors.New("--adaptive-pacing is not supported with --distribute")
+				}
+				names, weights := fileConf.ProfileNames(), map[string]int{}
+				for _, name := range names {
+					weights[name] = 1
+				}
+				if distributeProfiles != "" {
+					names, weights, err = parseProfileWeights(distributeProfiles)
+					if err != nil {
+						return err
+					}
+				}
+				sessions, err = NewSessionPool(fileConf, names, weights, fileConf.SelectedProfileName(profileName), client, conf)
+				if err != nil {
+					Fatalf("Error while setting up the profiles to distribute the follows: %s", err)
+				}
+				// Each profile still waits about --wait between its own new follows:
+				pacer = NewFixedFollowPacer(ctx, waitDuration/time.Duration(sessions.Len()))
+				Infof("Distributing the follows across %v profiles", sessions.Len())
+			}
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			if sessions != nil {
+				sessions.PrintReport()
+			}
+			notifier.Completed()
+			if err := keyCache.Save(); err != nil {
+				Warnf("Could not save the key cache: %s", err)
+			}
+			return nil
+		},
+		Commands: []cli.Command{
+			{
+				Name:  "login",
+				Usage: "Check the provided lgtm.com credentials (taken from the browser) and save them to the config file (in the selected profile).",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "nonce",
+						Usage: "Value of the nonce header; if not set, the one from the config is used.",
+					},
+					&cli.StringFlag{
+						Name:  "short-session",
+						Usage: "Value of the lgtm_short_session cookie; if not set, the one from the config is used.",
+					},
+					&cli.StringFlag{
+						Name:  "long-session",
+						Usage: "Value of the lgtm_long_session cookie; if not set, the one from the config is used.",
+					},
+					&cli.StringFlag{
+						Name:  "api-version",
+						Usage: "Value of the apiVersion parameter; if not set, the one from the config is used.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					current, err := fileConf.GetProfile(profileName)
+					if err != nil {
+						Fatalf("Error while selecting profile: %s", err)
+					}
+					current = current.WithEnv()
+					sess := &lgtm.LGTMSession{}
+					if current.Session != nil {
+						*sess = *current.Session
+					}
+					if nonce := c.String("nonce"); nonce != "" {
+						sess.Nonce = nonce
+					}
+					if shortSession := c.String("short-session"); shortSession != "" {
+						sess.ShortSession = shortSession
+					}
+					if longSession := c.String("long-session"); longSession != "" {
+						sess.LongSession = longSession
+					}
+					if apiVersion := c.String("api-version"); apiVersion != "" {
+						current.APIVersion = apiVersion
+					}
+					current.Session = sess
+					if err := current.Validate(); err != nil {
+						Fatalf("Credentials are not valid: %s", err)
+					}
+
+					client, err = lgtm.NewClient(current)
+					if err != nil {
+						panic(err)
+					}
+					client = client.WithContext(ctx)
+					user, err := client.GetLoggedInUser()
+					if err != nil {
+						Fatalf("Credentials were rejected by lgtm.com: %s", err)
+					}
+					if configFilepath == "" {
+						Successf(
+							"Logged in as %s; the credentials were not saved, as there is no config file",
+							Shakespeare(user.Person.Slug),
+						)
+						return nil
+					}
+					if err := lgtm.SaveSessionToFile(configFilepath, profileName, sess, current.APIVersion); err != nil {
+						Fatalf("Error while saving the credentials: %s", err)
+					}
+					Successf(
+						"Logged in as %s; saved the credentials to %s",
+						Shakespeare(user.Person.Slug),
+						configFilepath,
+					)
+					return nil
+				},
+			},
+			{
+				Name:  "profiles",
+				Usage: "List the profiles (lgtm.com accounts) in the config.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Check whether the session of each profile is valid.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					names := fileConf.ProfileNames()
+					if len(names) == 0 {
+						Infof("No profiles in config %s", configFilepath)
+						return nil
+					}
+
+					selected := fileConf.SelectedProfileName(profileName)
+
+					for _, name := range names {
+						line := name
+						if name == fileConf.DefaultProfile {
+							line += " (default)"
+						}
+						if name == selected {
+							line = Lime("* ") + Bold(line)
+						} else {
+							line = "  " + line
+						}
+
+						if c.Bool("check") {
+							profileConf, err := fileConf.GetProfile(name)
+							if err != nil {
+								panic(err)
+							}
+							status := checkProfileSession(profileConf)
+							line += " — " + status
+						}
+						Ln(line)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "unfollow-all",
+				Usage: "Unfollow all currently followed repositories (a.k.a. \"projects\").",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "no-projects",
+						Usage: "Don't unfollow projects.",
+					},
+					&cli.BoolFlag{
+						Name:  "no-proto",
+						Usage: "Don't unfollow proto projects.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "except, keep",
+						Usage: "Don't unfollow repos that match this pattern; example: kubernetes/* (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "except-file",
+						Usage: "Filepath to text file with patterns of repos not to unfollow (can use flag multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					exceptPatterns, err := compileExceptPatterns(
+						mustStringSliceNotNil(c.StringSlice("except")),
+						mustStringSliceNotNil(c.StringSlice("except-file")),
+					)
+					if err != nil {
+						panic(err)
+					}
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					projects := filterExceptProjects(cache.Projects(), exceptPatterns)
+					protoProjects := filterExceptProto(cache.ProtoProjects(), exceptPatterns)
+					if len(exceptPatterns) > 0 {
+						Infof(
+							"%v projects and %v proto-projects match the --except patterns, and will be kept",
+							cache.NumProjects()-len(projects),
+							cache.NumProto()-len(protoProjects),
+						)
+					}
+
+					var total int
+					if !c.Bool("no-projects") {
+						total += len(projects)
+					}
+					if !c.Bool("no-proto") {
+						total += len(protoProjects)
+					}
+
+					Infof("%v repos will be unfollowed", total)
+
+					if total == 0 {
+						return nil
+					}
+					if !c.Bool("force") {
+						user, err := client.GetLoggedInUser()
+						if err != nil {
+							panic(err)
+						}
+						mustConfirmTyped(user.Person.Slug, Sf("This will unfollow %v repos.", total))
+					}
+					Infof("Starting to unfollow ...")
+
+					etac := eta.New(int64(total))
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+
+					if !c.Bool("no-projects") {
+						Infof("Unfollowing projects ...")
+						for _, pr := range projects {
+							unfollower.Unfollow(false, pr.Key, pr.ExternalURL.URL, etac)
+						}
+					}
+					if !c.Bool("no-proto") {
+						Infof("Unfollowing proto projects ...")
+						for _, proto := range protoProjects {
+							unfollower.Unfollow(true, proto.Key, proto.CloneURL, etac)
+						}
+					}
+
+					return unfollower.Wait()
+				},
+			},
+			{
+				Name:  "top-repos",
+				Usage: "Discover the repos of a language, beyond the 1K results limit of GitHub searches.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "stars",
+						Usage: "Star bucket to search in, as a GitHub search range; example: 100..999 (can use flag multiple times; default: 10..99, 100..999, >=1000).",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only repos created on or after this date (YYYY-MM-DD).",
+						Value: "2008-01-01",
+					},
+					&cli.StringFlag{
+						Name:  "until",
+						Usage: "Only repos created on or before this date (YYYY-MM-DD; default: today).",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of repos to discover (0 for no limit).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of discovered repositories.",
+					},
+					&cli.BoolFlag{
+						Name:  "follow",
+						Usage: "Follow the discovered repos as they are found.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist; requires --follow).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					lang := ToLower(c.Args().First())
+					if lang == "" {
+						return errors.New("language not provided")
+					}
+					limit := c.Int("limit")
+					doFollow := c.Bool("follow")
+
+					since, err := time.Parse("2006-01-02", c.String("since"))
+					if err != nil {
+						return fmt.Errorf("invalid --since: %w", err)
+					}
+					until := time.Now()
+					if c.String("until") != "" {
+						until, err = time.Parse("2006-01-02", c.String("until"))
+						if err != nil {
+							return fmt.Errorf("invalid --until: %w", err)
+						}
+					}
+					starBuckets := mustStringSliceNotNil(c.StringSlice("stars"))
+					if len(starBuckets) == 0 {
+						starBuckets = []string{"10..99", "100..999", ">=1000"}
+					}
+
+					var cache *lgtm.FollowedProjectCache
+					if doFollow {
+						if !c.Bool("y") {
+							mustConfirmYes("Do you want to follow all the discovered repos?")
+						}
+						cache, err = getFollowedCacheOf(client, noCache)
+						if err != nil || cache == nil {
+							if ignoreFollowedErrors {
+								Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+								cache = nil
+							} else {
+								panic(err)
+							}
+						}
+					}
+
+					writer := writtableTargetListToTempFile(c.String("output"), "top-repos")
+					defer func() {
+						if err := writer.Close(); err != nil {
+							Errorf("Error while saving list of targets: %s", err)
+						}
+					}()
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+
+					seen := make(map[string]bool)
+					discovered := 0
+					followedNew := 0
+					failed := 0
+					etac := eta.New(int64(limit))
+				BucketLoop:
+					for _, stars := range starBuckets {
+						query := Sf("language:%s fork:false archived:false stars:%s", lang, stars)
+						Infof("Searching %s ...", ShakespeareBG(query))
+						err := githubutil.SearchReposSliced(ghRawClient, query, since, until, func(repo *github.Repository) bool {
+							if ctx.Err() != nil {
+								Warnf("Stopped; the search was interrupted")
+								return false
+							}
+							repoURL := repo.GetHTMLURL()
+							if seen[repoURL] {
+								return true
+							}
+							seen[repoURL] = true
+							if _, isBlacklisted := blacklist.Match(repoURL); isBlacklisted {
+								return true
+							}
+							discovered++
+							writer.WriteLine(repoURL)
+
+							if doFollow {
+								if cache != nil && cache.HasAny(repoURL) {
+									// Already followed; skip.
+									listAdder.AddProject(cache.GetProject(repoURL))
+								} else {
+									followETA := etac
+									if limit == 0 {
+										// The total is unknown:
+										followETA = eta.New(1)
+									}
+									envelope, err := follower(repoURL, followETA)
+									if err != nil {
+										failed++
+										return limit == 0 || discovered < limit
+									}
+									listAdder.AddEnvelope(envelope)
+									if envelope != nil && !envelope.IsKnown() {
+										// If the project was NOT already known to lgtm.com,
+										// sleep to avoid triggering too many new builds:
+										followedNew++
+										pacer.Wait(envelope)
+									}
+								}
+							}
+
+							return limit == 0 || discovered < limit
+						})
+						if err != nil {
+							panic(err)
+						}
+						if ctx.Err() != nil || (limit > 0 && discovered >= limit) {
+							break BucketLoop
+						}
+					}
+
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					if doFollow {
+						Successf("Discovered %v repos; followed %v new projects", discovered, followedNew)
+						if failed > 0 {
+							return partialFailuref("failed to follow %v of %v discovered repos", failed, discovered)
+						}
+					} else {
+						Successf("Discovered %v repos", discovered)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "unfollow",
+				Usage: "Unfollow one or more projects.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (can use flag multiple times; - for stdin).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Unfollow the projects that have this local tag (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "except",
+						Usage: "Don't unfollow repos that match this pattern; example: kubernetes/* (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "except-file",
+						Usage: "Filepath to text file with patterns of repos not to unfollow (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lang",
+						Usage: "Unfollow the projects whose analyzed languages are all among these (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "without-lang",
+						Usage: "Unfollow the projects that don't have any of these analyzed languages (can use flag multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "keys-file",
+						Usage: "Filepath to text file with lgtm.com project keys to unfollow, one per line (can use flag multiple times; - for stdin).",
+					},
+					&cli.BoolFlag{
+						Name:  "proto",
+						Usage: "The keys of --keys-file are proto-project keys.",
+					},
+					&cli.BoolFlag{
+						Name:  "archived",
+						Usage: "Unfollow the projects whose repo is archived on GitHub.",
+					},
+					&cli.Int64Flag{
+						Name:  "github-concurrency",
+						Usage: "With --archived, max number of concurrent GitHub lookups.",
+						Value: 8,
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the projects that would be unfollowed.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.IsSet("keys-file") {
+						// Unfollow by key, without resolving any URL:
+						keys := Deduplicate(mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("keys-file"))...))
+						if err := validateProjectKeys(keys); err != nil {
+							return err
+						}
+						isProto := c.Bool("proto")
+						kind := "projects"
+						if isProto {
+							kind = "proto-projects"
+						}
+						Infof("Will unfollow %v %s by key", len(keys), kind)
+						if len(keys) == 0 {
+							return nil
+						}
+						if !c.Bool("force") {
+							mustConfirmYes(Sf("Do you want to unfollow %v %s?", len(keys), kind))
+						}
+
+						lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+						unfollower := NewUnfollower(ctx, client, 6)
+						etac := eta.New(int64(len(keys)))
+						for _, key := range keys {
+							unfollower.Unfollow(isProto, key, key, etac)
+						}
+						return unfollower.Wait()
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					hasRepoListFilepath := c.IsSet("f")
+					if hasRepoListFilepath {
+						// Load repo list from file(s):
+						repoListFilepaths := mustStringSliceNotNil(c.StringSlice("f"))
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(repoListFilepaths...)...)
+					}
+					repoURLsRaw = append(repoURLsRaw, mustLoadTaggedURLs(tagsFilepath, c.StringSlice("tag"))...)
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+
+					// Compile list of patterns:
+					repoURLPatterns, err := urlparse.CompileRepoURLPatterns(repoURLsRaw)
+					if err != nil {
+						panic(err)
+					}
+
+					exceptPatterns, err := compileExceptPatterns(
+						mustStringSliceNotNil(c.StringSlice("except")),
+						mustStringSliceNotNil(c.StringSlice("except-file")),
+					)
+					if err != nil {
+						panic(err)
+					}
+					hasExcept := len(exceptPatterns) > 0
+					onlyLangs := lowerAll(mustStringSliceNotNil(c.StringSlice("lang")))
+					withoutLangs := lowerAll(mustStringSliceNotNil(c.StringSlice("without-lang")))
+					hasLangFilter := len(onlyLangs) > 0 || len(withoutLangs) > 0
+					archived := c.Bool("archived")
+					if archived && c.Int64("github-concurrency") < 1 {
+						return errors.New("--github-concurrency must be at least 1")
+					}
+					dryRun := c.Bool("dry-run")
+					if hasExcept && len(repoURLPatterns) == 0 {
+						// Only the except-patterns were provided:
+						// unfollow everything else.
+						repoURLPatterns = []string{urlparse.AnyRepoURLPattern}
+					}
+
+					matchAllPatterns := urlparse.GlobsThatMatchEverything(repoURLPatterns)
+					if len(matchAllPatterns) > 0 {
+						if hasExcept {
+							Infof("The following patterns will match all followed projects, and consequently *all* followed projects (except the ones that match %s) will be unfollowed.", Sq(exceptPatterns))
+						} else {
+							Infof("The following patterns will match all followed projects, and consequently *all* followed projects will be unfollowed.")
+						}
+						Infof("%s", Sq(matchAllPatterns))
+						mustConfirmDestructive(c.Bool("force"), "Do you really want to unfollow all projects?")
+					}
+					if (hasLangFilter || archived) && len(repoURLPatterns) == 0 {
+						// Only the languages (or --archived) were provided:
+						// select among all followed projects.
+						repoURLPatterns = []string{urlparse.AnyRepoURLPattern}
+					}
+
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if hasExcept || hasLangFilter || archived || dryRun {
+							// Cannot tell what to unfollow without the list of followed projects.
+							panic(fmt.Errorf("--except, --lang, --without-lang, --archived and --dry-run require the list of followed projects: %w", err))
+						}
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					}
+					if hasCache {
+						// We got the list of followed projects, so we can use it:
+
+						// Match projects against list of repos followed:
+						projectsToBeUnfollowed := ref.Filter(cache.Projects(),
+							func(i int, pr *lgtm.Project) bool {
+								_, isToBeUnfollowed := HasMatch(pr.ExternalURL.URL, repoURLPatterns)
+								return isToBeUnfollowed
+							}).([]*lgtm.Project)
+						projectsToBeUnfollowed = filterExceptProjects(projectsToBeUnfollowed, exceptPatterns)
+
+						protoToBeUnfollowed := ref.Filter(cache.ProtoProjects(),
+							func(i int, pr *lgtm.ProtoProject) bool {
+								_, isToBeUnfollowed := HasMatch(trimDotGit(pr.CloneURL), repoURLPatterns)
+								return isToBeUnfollowed
+							}).([]*lgtm.ProtoProject)
+						protoToBeUnfollowed = filterExceptProto(protoToBeUnfollowed, exceptPatterns)
+						if hasLangFilter {
+							projectsToBeUnfollowed = filterProjectsByLanguages(projectsToBeUnfollowed, onlyLangs, withoutLangs)
+							// Proto-projects don't have analyzed languages:
+							protoToBeUnfollowed = nil
+						}
+						// repoMeta contains the GitHub metadata of the repos checked with --archived:
+						var repoMeta RepoMetadata
+						if archived {
+							Infof(
+								"Checking whether the repos of %v projects and %v proto-projects are archived...",
+								len(projectsToBeUnfollowed),
+								len(protoToBeUnfollowed),
+							)
+							projectsToBeUnfollowed, protoToBeUnfollowed, repoMeta = filterArchived(
+								ctx,
+								projectsToBeUnfollowed,
+								protoToBeUnfollowed,
+								c.Int64("github-concurrency"),
+							)
+						}
+
+						Infof(
+							"Will unfollow %v projects and %v proto-projects...",
+							len(projectsToBeUnfollowed),
+							len(protoToBeUnfollowed),
+						)
+						total := len(projectsToBeUnfollowed) + len(protoToBeUnfollowed)
+						if total == 0 {
+							return nil
+						}
+						if dryRun {
+							describe := func(repoURL string) string {
+								if repo := repoMeta.Get(repoURL); repo != nil {
+									return Sf("%s (archived; last pushed %s)", repoURL, repo.GetPushedAt().Format("2006-01-02"))
+								}
+								return repoURL
+							}
+							for _, pr := range projectsToBeUnfollowed {
+								Sfln("%s", describe(pr.ExternalURL.URL))
+							}
+							for _, pr := range protoToBeUnfollowed {
+								Sfln("%s (proto-project)", describe(trimDotGit(pr.CloneURL)))
+							}
+							return nil
+						}
+						if (hasLangFilter || archived) && !c.Bool("force") {
+							mustConfirmYes(Sf("Do you want to unfollow %v projects?", total))
+						}
+
+						etac := eta.New(int64(total))
+
+						// Unfollow projects:
+						for _, pr := range projectsToBeUnfollowed {
+							message := pr.ExternalURL.URL
+
+							pattern, matched := HasMatch(pr.ExternalURL.URL, repoURLPatterns)
+							if matched {
+								message += " " + Sf("(matched from %s pattern)", Lime(pattern))
+							}
+
+							unfollower.Unfollow(false, pr.Key, message, etac)
+						}
+						// Unfollow proto-projects:
+						for _, pr := range protoToBeUnfollowed {
+							message := pr.CloneURL
+
+							pattern, matched := HasMatch(trimDotGit(pr.CloneURL), repoURLPatterns)
+							if matched {
+								message += " " + Sf("(matched from %s pattern)", Lime(pattern))
+							}
+
+							unfollower.Unfollow(true, pr.Key, message, etac)
+						}
+					} else {
+						// we don't have the cache, so let's unfollow anything we can
+						// with the information we have:
+						projectKeys := make(map[string]string)
+						for _, res := range resolveProjectsBySlug(ctx, client, completeRepoURLs(repoURLPatterns), defaultResolveConcurrency) {
+							switch {
+							case res.Project != nil:
+								projectKeys[res.Project.ExternalURL.URL] = res.Project.Key
+							case res.NotBuilt:
+								Warnf(
+									"Project %s is not a built project.",
+									trimGithubPrefix(res.URL),
+								)
+							default:
+								// General error
+								panic(res.Err())
+							}
+						}
+
+						if len(projectKeys) > 0 {
+							etac := eta.New(int64(len(projectKeys)))
+							for projectURL, projectKey := range projectKeys {
+								unfollower.Unfollow(false, projectKey, projectURL, etac)
+							}
+						}
+					}
+
+					return unfollower.Wait()
+				},
+			},
+			{
+				Name:  "audit-duplicates",
+				Usage: "Find the repos that are followed both as a proto-project and as a built project.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "unfollow",
+						Usage: "Unfollow the redundant proto-projects.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the report (json).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					duplicates := findDuplicateProtos(cache.Projects(), cache.ProtoProjects())
+					for _, dup := range duplicates {
+						Sfln("%s (proto %s)", dup.URL, dup.CloneURL)
+					}
+					Infof("Found %v proto-projects that are also followed as built projects", len(duplicates))
+
+					if output := c.String("output"); output != "" {
+						if err := writeDuplicateProtos(output, duplicates); err != nil {
+							panic(err)
+						}
+						Successf("Saved report to %s", output)
+					}
+					if len(duplicates) == 0 || !c.Bool("unfollow") {
+						return nil
+					}
+
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to unfollow %v redundant proto-projects?", len(duplicates)))
+					}
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+					etac := eta.New(int64(len(duplicates)))
+					for _, dup := range duplicates {
+						unfollower.Unfollow(true, dup.ProtoKey, dup.CloneURL, etac)
+					}
+					return unfollower.Wait()
+				},
+			},
+			{
+				Name:  "audit-renames",
+				Usage: "Find the followed projects whose GitHub repos were renamed, transferred or deleted.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "Follow the new location of the renamed repos, and unfollow the stale entries.",
+					},
+					&cli.BoolFlag{
+						Name:  "unfollow-gone",
+						Usage: "With --fix, also unfollow the projects whose repos don't exist anymore.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the report (json).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					renamed := make([]*RenamedRepo, 0)
+					check := func(isProto bool, key string, repoURL string) {
+						res, err := checkRenamed(isProto, key, repoURL)
+						if err != nil {
+							metrics.Inc("errors_total", "op", "audit-renames")
+							Errorf("Error while getting repo %s: %s", repoURL, err)
+							return
+						}
+						if res == nil {
+							return
+						}
+						if res.Gone {
+							Warnf("%s does not exist anymore", repoURL)
+						} else {
+							Warnf("%s has moved to %s", repoURL, res.NewURL)
+						}
+						renamed = append(renamed, res)
+					}
+
+					Infof("Checking %v projects and %v proto-projects...", cache.NumProjects(), cache.NumProto())
+					for _, pr := range cache.Projects() {
+						if ctx.Err() != nil {
+							break
+						}
+						check(false, pr.Key, pr.ExternalURL.URL)
+					}
+					for _, proto := range cache.ProtoProjects() {
+						if ctx.Err() != nil {
+							break
+						}
+						check(true, proto.Key, trimDotGit(proto.CloneURL))
+					}
+					Infof("Found %v renamed or deleted repos", len(renamed))
+
+					writeReport := func() {
+						if output := c.String("output"); output != "" {
+							if err := writeRenamedRepos(output, renamed); err != nil {
+								panic(err)
+							}
+							Successf("Saved report to %s", output)
+						}
+					}
+					if len(renamed) == 0 || !c.Bool("fix") {
+						for _, res := range renamed {
+							if res.Gone {
+								Sfln("%s (gone)", res.URL)
+							} else {
+								Sfln("%s -> %s", res.URL, res.NewURL)
+							}
+						}
+						writeReport()
+						return nil
+					}
+
+					unfollowGone := c.Bool("unfollow-gone")
+					toFix := ref.Filter(renamed,
+						func(i int, res *RenamedRepo) bool {
+							return !res.Gone || unfollowGone
+						}).([]*RenamedRepo)
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to fix %v followed projects?", len(toFix)))
+					}
+
+					// Follow the new locations first, so that the stale entries
+					// are only unfollowed if the new ones are followed:
+					toBeUnfollowed := make([]*RenamedRepo, 0)
+					// sameProject is the number of renamed repos whose new URL
+					// is the project that is already followed (nothing to unfollow):
+					sameProject := 0
+					followETA := eta.New(int64(len(toFix)))
+					for i, res := range toFix {
+						if res.Gone {
+							followETA.Done(1)
+							toBeUnfollowed = append(toBeUnfollowed, res)
+							continue
+						}
+						if ctx.Err() != nil {
+							Warnf("Stopped; %v renamed repos were not fixed", len(toFix)-i)
+							break
+						}
+						envelope, err := follower(res.NewURL, followETA)
+						if err != nil {
+							continue
+						}
+						if envelope != nil && envelope.Key() == res.Key {
+							// lgtm.com resolved the new URL to the followed project:
+							// unfollowing the old key would unfollow it.
+							Infof("%s is the same project as %s; not unfollowing it", res.NewURL, res.URL)
+							sameProject++
+							continue
+						}
+						toBeUnfollowed = append(toBeUnfollowed, res)
+					}
+
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+					unfollowETA := eta.New(int64(len(toBeUnfollowed)))
+					for _, res := range toBeUnfollowed {
+						unfollower.Unfollow(res.IsProto, res.Key, res.URL, unfollowETA)
+					}
+					err = unfollower.Wait()
+					writeReport()
+					if err != nil {
+						return err
+					}
+					if notFixed := len(toFix) - len(toBeUnfollowed) - sameProject; notFixed > 0 {
+						return partialFailuref("%v of %v renamed repos could not be fixed", notFixed, len(toFix))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "export-sarif",
+				Usage:     "Export the alerts of followed projects as SARIF 2.1.0 files (one per language), and optionally upload them to GitHub code scanning.",
+				ArgsUsage: "[repo URLs...]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "f",
+						Usage: "Filepath to text file with list of repos.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lang",
+						Usage: "Languages to export (default: all the languages of the project).",
+					},
+					&cli.StringFlag{
+						Name:  "output-dir, o",
+						Usage: "Directory in which to save the SARIF files.",
+						Value: ".",
+					},
+					&cli.BoolFlag{
+						Name:  "upload",
+						Usage: "Upload the SARIF files to GitHub code scanning (the token needs the security_events scope).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					upload := c.Bool("upload")
+					if upload && conf.GitHub.Token == "" {
+						return errors.New("a GitHub token is required to upload SARIF files")
+					}
+					langs := lowerAll(mustStringSliceNotNil(c.StringSlice("lang")))
+					outputDir := c.String("output-dir")
+					if err := os.MkdirAll(outputDir, 0755); err != nil {
+						panic(err)
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+					if len(repoURLsRaw) == 0 {
+						return errors.New("no repos provided")
+					}
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					var total, failed int
+					for _, raw := range repoURLsRaw {
+						if ctx.Err() != nil {
+							Warnf("Stopped")
+							break
+						}
+						parsed, err := urlparse.Parse(raw, true)
+						if err != nil {
+							panic(err)
+						}
+						pr := cache.GetProject(parsed.URL())
+						if pr == nil {
+							Warnf("Skipping %s: not followed as a built project", parsed.URL())
+							continue
+						}
+						projectLangs := langs
+						if len(projectLangs) == 0 {
+							projectLangs = pr.Languages
+						}
+						for _, lang := range projectLangs {
+							if !pr.SupportsLanguage(lang) {
+								Warnf("Skipping %s: not a language of %s", lang, parsed.URL())
+								continue
+							}
+							total++
+							exp, err := getProjectSARIF(client, pr, lang)
+							if err != nil {
+								failed++
+								metrics.Inc("errors_total", "op", "export-sarif")
+								Errorf("Error while getting %s alerts of %s: %s", lang, parsed.URL(), err)
+								continue
+							}
+							path, err := exp.WriteToDir(outputDir)
+							if err != nil {
+								panic(err)
+							}
+							Successf("Saved %s alerts of %s (commit %s) to %s", lang, parsed.URL(), exp.CommitID, path)
+
+							if upload {
+								id, err := exp.UploadToGitHub()
+								if err != nil {
+									failed++
+									metrics.Inc("errors_total", "op", "upload-sarif")
+									Errorf("Error while uploading %s: %s", path, err)
+									continue
+								}
+								Successf("Uploaded %s to GitHub code scanning (id %s)", path, id)
+							}
+						}
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v exports failed", failed, total)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "alerts-diff",
+				Usage:     "Snapshot the alerts of followed projects, and print the alerts that are new or fixed since the previous snapshot.",
+				ArgsUsage: "[repo URLs...]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "f",
+						Usage: "Filepath to text file with list of repos.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lang",
+						Usage: "Languages to track (default: all the languages of the project).",
+					},
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "Directory in which the snapshots are saved (one file per project).",
+						Value: ".",
+					},
+					&cli.BoolFlag{
+						Name:  "no-save",
+						Usage: "Don't replace the previous snapshot with the new one.",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the diffs as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					langs := lowerAll(mustStringSliceNotNil(c.StringSlice("lang")))
+					dir := c.String("dir")
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+					if len(repoURLsRaw) == 0 {
+						return errors.New("no repos provided")
+					}
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					diffs := make([]*AlertsDiff, 0)
+					var failed int
+					for _, raw := range repoURLsRaw {
+						if ctx.Err() != nil {
+							Warnf("Stopped")
+							break
+						}
+						parsed, err := urlparse.Parse(raw, true)
+						if err != nil {
+							panic(err)
+						}
+						pr := cache.GetProject(parsed.URL())
+						if pr == nil {
+							Warnf("Skipping %s: not followed as a built project", parsed.URL())
+							continue
+						}
+						projectLangs := make([]string, 0)
+						for _, lang := range langs {
+							if pr.SupportsLanguage(lang) {
+								projectLangs = append(projectLangs, lang)
+							}
+						}
+						if len(langs) == 0 {
+							projectLangs = pr.Languages
+						}
+						if len(projectLangs) == 0 {
+							Warnf("Skipping %s: none of the languages is a language of the project", parsed.URL())
+							continue
+						}
+
+						path := filepath.Join(dir, alertsSnapshotFilename(pr))
+						previous, err := loadAlertsSnapshot(path)
+						if err != nil {
+							panic(err)
+						}
+						took := NewTimer()
+						Infof("Getting alerts of %s...", parsed.URL())
+						current, err := takeAlertsSnapshot(client, pr, projectLangs)
+						if err != nil {
+							failed++
+							metrics.Inc("errors_total", "op", "alerts-diff")
+							Errorf("Error while getting alerts of %s: %s", parsed.URL(), err)
+							continue
+						}
+						Infof("Got %v alerts; took %s", len(current.Alerts), took())
+
+						if previous == nil {
+							Successf("No previous snapshot of %s: saving %v alerts as baseline.", parsed.URL(), len(current.Alerts))
+						} else {
+							diff := diffAlertsSnapshots(previous, current)
+							diffs = append(diffs, diff)
+							if !c.Bool("json") {
+								diff.Print()
+							}
+						}
+						if c.Bool("no-save") && previous != nil {
+							continue
+						}
+						current.MergeLanguages(previous)
+						if err := current.WriteToFile(path); err != nil {
+							panic(err)
+						}
+						Successf("Saved snapshot to %s", path)
+					}
+					if c.Bool("json") {
+						JSON(true, diffs)
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v projects could not be snapshotted", failed, len(repoURLsRaw))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "download-snapshot",
+				Usage:     "Download the CodeQL databases of the latest analysis of followed projects (one zip per language), to run queries locally with the CodeQL CLI.",
+				ArgsUsage: "[repo URLs...]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "f",
+						Usage: "Filepath to text file with list of repos.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lang",
+						Usage: "Languages of the databases to download (default: all the languages of the project).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Directory in which to save the databases.",
+						Value: ".",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					langs := lowerAll(mustStringSliceNotNil(c.StringSlice("lang")))
+					outputDir := c.String("output")
+					if err := os.MkdirAll(outputDir, 0755); err != nil {
+						panic(err)
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+					if len(repoURLsRaw) == 0 {
+						return errors.New("no repos provided")
+					}
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					var total, failed int
+					for _, raw := range repoURLsRaw {
+						if ctx.Err() != nil {
+							Warnf("Stopped")
+							break
+						}
+						parsed, err := urlparse.Parse(raw, true)
+						if err != nil {
+							panic(err)
+						}
+						pr := cache.GetProject(parsed.URL())
+						if pr == nil {
+							Warnf("Skipping %s: not followed as a built project", parsed.URL())
+							continue
+						}
+						projectLangs := langs
+						if len(projectLangs) == 0 {
+							projectLangs = pr.Languages
+						}
+						for _, lang := range projectLangs {
+							if !pr.SupportsLanguage(lang) {
+								Warnf("Skipping %s: not a language of %s", lang, parsed.URL())
+								continue
+							}
+							total++
+							took := NewTimer()
+							Infof("Downloading %s database of %s...", lang, parsed.URL())
+							path, size, err := downloadSnapshot(client, pr, lang, outputDir)
+							if err != nil {
+								failed++
+								metrics.Inc("errors_total", "op", "download-snapshot")
+								Errorf("Error while downloading %s database of %s: %s", lang, parsed.URL(), err)
+								continue
+							}
+							Successf("Saved %s database of %s (%.1f MB) to %s; took %s", lang, parsed.URL(), float64(size)/1e6, path, took())
+						}
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v downloads failed", failed, total)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "export-to-github-stars",
+				Usage: "Star on GitHub the repos of the projects of a list (GitHub lists have no public API, so only stars are supported).",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "list",
+						Usage: "Name of the list whose repos to star.",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the repos that would be starred.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					listName := c.String("list")
+					if listName == "" {
+						return errors.New("--list is required")
+					}
+					if conf.GitHub.Token == "" {
+						return errors.New("a GitHub token is required to star repos")
+					}
+
+					Infof("Getting projects of %q list...", listName)
+					projects, err := client.GetProjectsInSelection(listName)
+					if err != nil {
+						panic(err)
+					}
+
+					repos := make([]*urlparse.GitURL, 0)
+					for _, pr := range projects {
+						parsed, err := urlparse.Parse(pr.ExternalURL.URL, true)
+						if err != nil {
+							Warnf("Skipping %s: %s", pr.ExternalURL.URL, err)
+							continue
+						}
+						if parsed.Hostname != "github.com" {
+							Warnf("Skipping %s: not a GitHub repo", parsed.URL())
+							continue
+						}
+						repos = append(repos, parsed)
+					}
+
+					toBeStarred := make([]*urlparse.GitURL, 0)
+					for _, repo := range repos {
+						if ctx.Err() != nil {
+							return ctx.Err()
+						}
+						starred, err := githubutil.IsStarred(ghRawClient, repo.User, repo.Repo)
+						if err != nil {
+							panic(err)
+						}
+						if !starred {
+							toBeStarred = append(toBeStarred, repo)
+						}
+					}
+					Infof(
+						"%v of %v GitHub repos of %q list are already starred; %v to be starred",
+						len(repos)-len(toBeStarred),
+						len(repos),
+						listName,
+						len(toBeStarred),
+					)
+					if len(toBeStarred) == 0 {
+						return nil
+					}
+					if c.Bool("dry-run") {
+						for _, repo := range toBeStarred {
+							Sfln("%s", repo.URL())
+						}
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to star %v repos on GitHub?", len(toBeStarred)))
+					}
+
+					var failed int
+					for i, repo := range toBeStarred {
+						if ctx.Err() != nil {
+							Warnf("Stopped; %v repos were not starred", len(toBeStarred)-i)
+							break
+						}
+						if err := githubutil.StarRepo(ghRawClient, repo.User, repo.Repo); err != nil {
+							failed++
+							metrics.Inc("errors_total", "op", "star")
+							Errorf("Error while starring %s: %s", repo.URL(), err)
+							continue
+						}
+						Successf("Starred %s", repo.URL())
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v repos could not be starred", failed, len(toBeStarred))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "unfollow-forks",
+				Usage: "Unfollow followed projects that are forks on GitHub.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the forks, without unfollowing them.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					type fork struct {
+						isProto bool
+						key     string
+						url     string
+						parent  string
+					}
+					forks := make([]*fork, 0)
+
+					checkFork := func(isProto bool, key string, repoURL string) {
+						parsed, err := urlparse.Parse(repoURL, true)
+						if err != nil || parsed.Hostname != "github.com" {
+							// Only GitHub repos can be checked.
+							return
+						}
+						repo, err := githubutil.GetRepo(ghRawClient, parsed.User, parsed.Repo)
+						if err != nil {
+							Errorf("Error while getting repo %s: %s", repoURL, err)
+							return
+						}
+						if repo == nil || !repo.GetFork() {
+							return
+						}
+						Warnf("%s is a fork of %s", repoURL, repo.GetParent().GetFullName())
+						forks = append(forks, &fork{
+							isProto: isProto,
+							key:     key,
+							url:     repoURL,
+							parent:  repo.GetParent().GetHTMLURL(),
+						})
+					}
+
+					Infof("Checking %v projects and %v proto-projects...", cache.NumProjects(), cache.NumProto())
+					for _, pr := range cache.Projects() {
+						checkFork(false, pr.Key, pr.ExternalURL.URL)
+					}
+					for _, proto := range cache.ProtoProjects() {
+						checkFork(true, proto.Key, trimDotGit(proto.CloneURL))
+					}
+
+					Infof("Found %v followed forks", len(forks))
+					if len(forks) == 0 || c.Bool("dry-run") {
+						for _, fork := range forks {
+							Sfln("%s (fork of %s)", fork.url, fork.parent)
+						}
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to unfollow %v forks?", len(forks)))
+					}
+
+					etac := eta.New(int64(len(forks)))
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+					for _, fork := range forks {
+						unfollower.Unfollow(fork.isProto, fork.key, fork.url, etac)
+					}
+					return unfollower.Wait()
+				},
+			},
+			{
+				Name:  "unfollow-duplicate-forks",
+				Usage: "Unfollow the followed forks (on GitHub) whose parent repo is followed too; optionally follow the missing parents first.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "follow-parent",
+						Usage: "Also replace the forks whose parent is not followed: follow the parent, and unfollow the fork once the parent is followed.",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent GitHub repo lookups.",
+						Value: 8,
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the forks and parents, without following or unfollowing anything.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					maxWorkers := c.Int64("concurrency")
+					if maxWorkers < 1 {
+						Fatalf("--concurrency must be at least 1")
+					}
+					followParent := c.Bool("follow-parent")
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					Infof("Checking %v projects and %v proto-projects...", cache.NumProjects(), cache.NumProto())
+					forks := findFollowedForks(ctx, cache, maxWorkers)
+					if ctx.Err() != nil {
+						Warnf("Stopped before all the projects were checked")
+						return nil
+					}
+
+					duplicates := make([]*FollowedFork, 0)
+					// The forks whose parent is not followed, by parent:
+					orphansByParent := make(map[string][]*FollowedFork)
+					parentsToFollow := make([]string, 0)
+					for _, fork := range forks {
+						if fork.ParentFollowed {
+							duplicates = append(duplicates, fork)
+							continue
+						}
+						if _, ok := orphansByParent[fork.ParentURL]; !ok {
+							parentsToFollow = append(parentsToFollow, fork.ParentURL)
+						}
+						orphansByParent[fork.ParentURL] = append(orphansByParent[fork.ParentURL], fork)
+					}
+					Infof(
+						"Found %v followed forks: %v with a followed parent, %v without",
+						len(forks),
+						len(duplicates),
+						len(forks)-len(duplicates),
+					)
+					if followParent {
+						parentsToFollow = blacklist.Filter(parentsToFollow)
+						parentsToFollow = applyFollowQuota(client, cache, parentsToFollow, stopAtLimit, "unfollow-duplicate-forks")
+					} else {
+						parentsToFollow = nil
+					}
+
+					for _, fork := range duplicates {
+						Sfln("%s (fork of %s, followed)", fork.URL, fork.ParentURL)
+					}
+					for _, parentURL := range parentsToFollow {
+						for _, fork := range orphansByParent[parentURL] {
+							Sfln("%s (fork of %s, to be followed)", fork.URL, parentURL)
+						}
+					}
+					if !followParent && len(forks) > len(duplicates) {
+						Infof("Use --follow-parent to also replace the %v forks whose parent is not followed", len(forks)-len(duplicates))
+					}
+					if len(duplicates)+len(parentsToFollow) == 0 || c.Bool("dry-run") {
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf(
+							"Do you want to follow %v parents, and unfollow up to %v forks?",
+							len(parentsToFollow),
+							len(duplicates)+countForksOfParents(orphansByParent, parentsToFollow),
+						))
+					}
+
+					toBeUnfollowed := duplicates
+					if len(parentsToFollow) > 0 {
+						// Only unfollow the forks whose parent could be followed,
+						// so that no coverage is lost:
+						etac := eta.New(int64(len(parentsToFollow)))
+						for i, parentURL := range parentsToFollow {
+							if stopOnInterrupt(ctx, "unfollow-duplicate-forks", parentsToFollow[i:]) {
+								break
+							}
+							envelope, err := follower(parentURL, etac)
+							if err != nil && !lgtm.IsAlreadyFollowed(err) {
+								Warnf("Not unfollowing the forks of %s, since it could not be followed", parentURL)
+								continue
+							}
+							toBeUnfollowed = append(toBeUnfollowed, orphansByParent[parentURL]...)
+							if envelope != nil && !envelope.IsKnown() {
+								pacer.Wait(envelope)
+							}
+						}
+					}
+					if len(toBeUnfollowed) == 0 {
+						return nil
+					}
+
+					etac := eta.New(int64(len(toBeUnfollowed)))
+					lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+					unfollower := NewUnfollower(ctx, client, 6)
+					for _, fork := range toBeUnfollowed {
+						unfollower.Unfollow(fork.IsProto, fork.Key, fork.URL, etac)
+					}
+					return unfollower.Wait()
+				},
+			},
+			{
+				Name:  "follow",
+				Usage: "Follow one or more projects.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (- for stdin).",
+					},
+					&cli.StringFlag{
+						Name:  "lang, l",
+						Usage: "Filter github repos by language.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude, e",
+						Usage: "Exclude repo(s) by glob; example: kubernetes/website (can use flag multiple times).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.IntFlag{
+						Name:  "start",
+						Usage: "Start following from project N of the final list (one-indexed).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "retry-failed",
+						Usage: "Filepath to a report file of failed follows (written by a previous run) to retry.",
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries for projects that failed with transient errors (timeouts, 5xx).",
+						Value: 3,
+					},
+					&cli.StringFlag{
+						Name:  "failed-output",
+						Usage: "Filepath to which save the list of repositories that could not be followed.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "verify",
+						Usage: "After following, check that the projects show up among the followed projects (and follow again the missing ones, up to --retries times).",
+					},
+					&cli.BoolFlag{
+						Name:  "languages-detect",
+						Usage: "When following the repos of an owner with --lang, look up the languages of each repo (instead of only its primary language), and only follow the repos with at least --min-lang-bytes of the language.",
+					},
+					&cli.IntFlag{
+						Name:  "min-lang-bytes",
+						Usage: "With --languages-detect, min bytes of code of the language.",
+						Value: 10000,
+					},
+					&cli.Int64Flag{
+						Name:  "detect-concurrency",
+						Usage: "With --languages-detect, max number of concurrent language lookups.",
+						Value: 8,
+					},
+					&cli.StringFlag{
+						Name:  "order",
+						Usage: "Order in which to follow the repos: stars, size, pushed (most recent first), alpha or random (the repos without GitHub metadata, i.e. not expanded from an owner, come last).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					lang := ToLower(c.String("lang"))
+					languagesDetect := c.Bool("languages-detect")
+					if languagesDetect && lang == "" {
+						return errors.New("--languages-detect requires --lang")
+					}
+					if languagesDetect && c.Int64("detect-concurrency") < 1 {
+						panic("--detect-concurrency must be at least 1")
+					}
+					order := c.String("order")
+					if err := validateFollowOrder(order); err != nil {
+						return err
+					}
+					// repoMeta contains the GitHub metadata of the repos
+					// fetched while expanding owners and globs (used for --order):
+					repoMeta := make(RepoMetadata)
+					// ownerRepoURLs returns the repos of the owner that have the language (if set):
+					ownerRepoURLs := func(owner string) ([]string, error) {
+						repoLang := lang
+						if languagesDetect {
+							repoLang = ""
+						}
+						repos, err := githubOwnerRepos(owner, repoLang)
+						if err != nil {
+							return nil, err
+						}
+						all := repoMeta.Add(repos...)
+						if !languagesDetect {
+							return all, nil
+						}
+						Infof("Looking up the languages of %v repos of %s ...", len(all), owner)
+						withLang := filterReposByLanguageBytes(ctx, all, lang, c.Int("min-lang-bytes"), c.Int64("detect-concurrency"))
+						Infof("%v of %v repos of %s have at least %v bytes of %s", len(withLang), len(all), owner, c.Int("min-lang-bytes"), lang)
+						return withLang, nil
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					hasRepoListFilepath := c.IsSet("f")
+					if hasRepoListFilepath {
+						repoListFilepaths := mustStringSliceNotNil(c.StringSlice("f"))
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(repoListFilepaths...)...)
+					}
+					if c.IsSet("retry-failed") {
+						// Load the repos that failed in a previous run:
+						reportFilepaths := mustStringSliceNotNil(c.StringSlice("retry-failed"))
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(reportFilepaths...)...)
+					}
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+
+					excludedPatterns, err := urlparse.CompileRepoURLPatterns(mustStringSliceNotNil(c.StringSlice("exclude")))
+					if err != nil {
+						panic(err)
+					}
+
+					repoURLs := make([]string, 0)
+					for _, raw := range repoURLsRaw {
+						if urlparse.IsGlob(raw) {
+							// Expand the glob against the repos of the owner:
+							patterns, err := urlparse.CompileRepoURLPatterns([]string{raw})
+							if err != nil {
+								panic(err)
+							}
+							parsed, err := urlparse.Parse(raw, false)
+							if err != nil {
+								panic(err)
+							}
+							if urlparse.IsGlob(parsed.User) {
+								panic(fmt.Errorf("invalid pattern %q: the owner cannot be a glob", raw))
+							}
+							ownerURLs, err := ownerRepoURLs(parsed.User)
+							if err != nil {
+								panic(err)
+							}
+							matched := ref.Filter(ownerURLs,
+								func(i int, repoURL string) bool {
+									_, isMatch := HasMatch(repoURL, patterns)
+									return isMatch
+								}).([]string)
+							Debugf("%v repos of %s match %s", len(matched), parsed.User, raw)
+							repoURLs = append(repoURLs, matched...)
+							continue
+						}
+
+						owner, isWholeUser, err := urlparse.IsUserOnly(raw)
+						if err != nil {
+							panic(err)
+						}
+						if isWholeUser {
+							ownerURLs, err := ownerRepoURLs(owner)
+							if err != nil {
+								panic(err)
+							}
+							repoURLs = append(repoURLs, ownerURLs...)
+						} else {
+							parsed, err := urlparse.Parse(raw, false)
+							if err != nil {
+								panic(err)
+							}
+							repoURLs = append(repoURLs, parsed.URL())
+						}
+					}
+
+					if len(excludedPatterns) > 0 {
+						repoURLs = ref.Filter(repoURLs,
+							func(i int, repoURL string) bool {
+								pattern, isExcluded := HasMatch(repoURL, excludedPatterns)
+								if isExcluded {
+									Debugf("%s is excluded (by pattern %q)", repoURL, pattern)
+								}
+								return !isExcluded
+							}).([]string)
+					}
+					repoURLs = Deduplicate(repoURLs)
+					if order != "" {
+						repoURLs = repoMeta.Sort(repoURLs, order)
+					}
+
+					start := c.Int("start")
+					{ // Trim repoURLs if --start is provided.
+						if start > 0 && start > len(repoURLs) {
+							Fatalf(
+								"Got %v projects, but the --start flag value is set to %v",
+								len(repoURLs),
+								start,
+							)
+						}
+						if start > 0 {
+							Infof("Skipping %v projects", start-1)
+							repoURLs = repoURLs[start-1:]
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, repoMeta)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := make([]string, 0)
+					failed := make([]string, 0)
+					// retryQueue contains the repos that failed with a transient error:
+					retryQueue := make([]string, 0)
+
+					// remaining contains the repos that were not followed
+					// because the run was interrupted:
+					var remaining []string
+
+					followAll := func(repoURLs []string) {
+						etac := eta.New(int64(len(repoURLs)))
+						for i, repoURL := range repoURLs {
+							if ctx.Err() != nil {
+								remaining = append(remaining, repoURLs[i:]...)
+								return
+							}
+							envelope, err := follower(repoURL, etac)
+							if err != nil {
+								if lgtm.ClassifyError(err).IsRetryable() {
+									retryQueue = append(retryQueue, repoURL)
+								} else {
+									failed = append(failed, repoURL)
+								}
+								continue
+							}
+							followed = append(followed, repoURL)
+							listAdder.AddEnvelope(envelope)
+							if envelope != nil {
+								// If the project was NOT already known to lgtm.com,
+								// sleep to avoid triggering too many new builds:
+								isNew := !envelope.IsKnown()
+								if isNew {
+									followedNew++
+									pacer.Wait(envelope)
+								}
+							}
+						}
+					}
+
+					// Follow repos:
+					followAll(toBeFollowed)
+
+					// Retry the repos that failed with transient errors:
+					maxRetries := c.Int("retries")
+					for attempt := 1; attempt <= maxRetries && len(retryQueue) > 0 && ctx.Err() == nil; attempt++ {
+						backoff := lgtm.RetryBackoff(attempt)
+						Infof(
+							"Retrying %v projects that failed with transient errors in %s (attempt %v/%v) ...",
+							len(retryQueue),
+							backoff,
+							attempt,
+							maxRetries,
+						)
+						select {
+						case <-time.After(backoff):
+						case <-ctx.Done():
+						}
+
+						toBeRetried := retryQueue
+						retryQueue = make([]string, 0)
+						followAll(toBeRetried)
+					}
+					if ctx.Err() != nil {
+						// Retries were not attempted:
+						remaining = append(remaining, retryQueue...)
+					} else {
+						failed = append(failed, retryQueue...)
+					}
+					stopOnInterrupt(ctx, "follow", remaining)
+
+					if c.Bool("verify") && ctx.Err() == nil && len(followed) > 0 {
+						unverified, err := verifyFollows(ctx, client, followed, maxRetries, follower)
+						if err != nil {
+							panic(fmt.Errorf("error while verifying follows: %w", err))
+						}
+						if len(unverified) > 0 {
+							Errorf("%v projects were followed, but don't show up among the followed projects:", len(unverified))
+							for _, repoURL := range unverified {
+								Errorf("    %s", repoURL)
+							}
+							failed = append(failed, unverified...)
+						} else {
+							Successf("Verified that all %v projects are followed", len(followed))
+						}
+					}
+
+					if len(failed) > 0 {
+						saveTargetListToTempFile(c.String("failed-output"), "follow-failed", failed)
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", totalToBeFollowed-len(failed)-len(remaining), followedNew)
+					if len(failed) > 0 {
+						return partialFailuref("failed to follow %v projects", len(failed))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "follow-url",
+				Usage:     "Follow repos by clone URL, submitted verbatim (e.g. git:// URLs, or self-hosted git servers).",
+				ArgsUsage: "<clone-urls...>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of clone URLs (- for stdin).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the keys of the followed projects and proto-projects (json).",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cloneURLs := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						cloneURLs = append(cloneURLs, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					cloneURLs = Deduplicate(cloneURLs)
+					if len(cloneURLs) == 0 {
+						Fatalf("No clone URLs provided.")
+					}
+					for _, u := range cloneURLs {
+						if err := validateCloneURL(u); err != nil {
+							panic(err)
+						}
+					}
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+
+					results := make([]*FollowedURL, 0, len(cloneURLs))
+					failed := 0
+					etac := eta.New(int64(len(cloneURLs)))
+					for i, u := range cloneURLs {
+						if stopOnInterrupt(ctx, "follow-url", cloneURLs[i:]) {
+							break
+						}
+						envelope, err := follower(u, etac)
+						listAdder.AddEnvelope(envelope)
+						res := newFollowedURL(u, envelope, err)
+						results = append(results, res)
+						if err != nil {
+							failed++
+							continue
+						}
+						if res.IsProto {
+							Infof("%s: proto-project %s", u, res.Key)
+						} else {
+							Infof("%s: project %s", u, res.Key)
+						}
+						if envelope != nil && !envelope.IsKnown() {
+							pacer.Wait(envelope)
+						}
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					if output := c.String("output"); output != "" {
+						if err := writeFollowedURLs(output, results); err != nil {
+							panic(err)
+						}
+						Successf("Saved results to %s", output)
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v clone URLs could not be followed", failed, len(results))
+					}
+					Successf("Followed %v clone URLs", len(results))
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-lang",
+				Usage: "Follow projects by language.",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of projects to get and follow.",
+					},
+					&cli.IntFlag{
+						Name:  "start",
+						Usage: "Start following from project N of the final list (one-indexed).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					lang := c.Args().First()
+					if lang == "" {
+						Fatalf("Must provide a language")
+					}
+					limit := c.Int("limit")
+					start := c.Int("start")
+					force := c.Bool("y")
+
+					repoURLs := make([]string, 0)
+					stars := make(map[string]int)
+					{
+						Debugf("Getting list of repos for language: %s ...", lang)
+
+						repos, err := GithubListAllReposByLanguage(lang, limit)
+						if err != nil {
+							Fatalf("error while getting repo list for language %q: %s", lang, err)
+						}
+
+						Debugf("%s has %v repos", lang, len(repos))
+					RepoLoop:
+						for _, repo := range repos {
+							//repoURLs = append(repoURLs, repo.GetFullName()) // e.g. "kubernetes/dashboard"
+							isFork := repo.GetFork()
+							// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+							if isFork {
+								Warnf("Skipping fork %s", repo.GetFullName())
+								continue RepoLoop
+							}
+
+							repoURLs = append(repoURLs, repo.GetHTMLURL()) // e.g. "https://github.com/kubernetes/dashboard"
+							stars[repo.GetHTMLURL()] = repo.GetStargazersCount()
+						}
+					}
+					{ // Trim repoURLs if --start is provided.
+						if start > 0 && start > len(repoURLs) {
+							Fatalf(
+								"Got %v projects, but the --start flag value is set to %v",
+								len(repoURLs),
+								start,
+							)
+						}
+						if start > 0 {
+							Infof("Skipping %v projects", start-1)
+							repoURLs = repoURLs[start-1:]
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-lang", toBeFollowed, stars, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-lang")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-lang", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-lang", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-meta-search",
+				Usage: "Follow projects by custom search on repositories meta.",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of projects to get and follow.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					query := c.Args().First()
+					if query == "" {
+						Fataln("must provide a query string")
+					}
+					if !strings.Contains(query, "fork:false") {
+						Warnf("The provided query does not exclude forks (lgtm.com does not support scanning forks).")
+						Warnf("The results will contain forks, and that will reduce the number of usable results (the API can only return 1K results max).")
+						Warnf("You can exclude forks by adding fork:false to your query.")
+					}
+					limit := c.Int("limit")
+					force := c.Bool("y")
+
+					repoURLs := make([]string, 0)
+					stars := make(map[string]int)
+					{
+						Debugf("Getting list of repos for search: %s ...", ShakespeareBG(query))
+						repos, err := GithubListReposByMetaSearch(query, limit)
+						if err != nil {
+							Fatalf("error while getting repo list for search %q: %s", query, err)
+						}
+
+						Debugf("Search %s has returned %v repos", ShakespeareBG(query), len(repos))
+					RepoLoop:
+						for _, repo := range repos {
+							//repoURLs = append(repoURLs, repo.GetFullName()) // e.g. "kubernetes/dashboard"
+							isFork := repo.GetFork()
+							// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+							if isFork {
+								Warnf("Skipping fork %s", repo.GetFullName())
+								continue RepoLoop
+							}
+
+							repoURLs = append(repoURLs, repo.GetHTMLURL()) // e.g. "https://github.com/kubernetes/dashboard"
+							stars[repo.GetHTMLURL()] = repo.GetStargazersCount()
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-meta-search", toBeFollowed, stars, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-meta-search")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-meta-search", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-meta-search", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// if the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-code-search",
+				Usage: "Follow projects by custom search on repositories code.",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of code results.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					query := c.Args().First()
+					if query == "" {
+						Fataln("Must provide a query string")
+					}
+					limit := c.Int("limit")
+					force := c.Bool("y")
+
+					repoURLs := make([]string, 0)
+					stars := make(map[string]int)
+					{
+						Debugf("Getting list of repos for search: %s ...", ShakespeareBG(query))
+						repos, err := GithubListReposByCodeSearch(query, limit)
+						if err != nil {
+							Fatalf("error while getting repo list for search %q: %s", query, err)
+						}
+
+						Debugf("Search %s has returned %v repos", ShakespeareBG(query), len(repos))
+					RepoLoop:
+						for _, repo := range repos {
+							//repoURLs = append(repoURLs, repo.GetFullName()) // e.g. "kubernetes/dashboard"
+							isFork := repo.GetFork()
+							// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+							if isFork {
+								Warnf("Skipping fork %s", repo.GetFullName())
+								continue RepoLoop
+							}
+
+							repoURLs = append(repoURLs, repo.GetHTMLURL()) // e.g. "https://github.com/kubernetes/dashboard"
+							stars[repo.GetHTMLURL()] = repo.GetStargazersCount()
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-code-search", toBeFollowed, stars, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-code-search")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-code-search", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-code-search", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "follow-by-awesome-list",
+				Usage:     "Follow the GitHub repos linked in an awesome list (e.g. avelino/awesome-go).",
+				ArgsUsage: "<repo or markdown URL>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of repos to follow (in order of appearance in the list).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent lookups of the linked repos on GitHub.",
+						Value: 8,
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					target := c.Args().First()
+					if target == "" {
+						Fatalf("Must provide an awesome list (repo, or URL of a markdown file)")
+					}
+					if c.Int64("concurrency") < 1 {
+						return errors.New("--concurrency must be at least 1")
+					}
+					limit := c.Int("limit")
+					force := c.Bool("y")
+
+					markdown, listRepoURL, err := fetchAwesomeList(target)
+					if err != nil {
+						panic(err)
+					}
+					linked := make([]string, 0)
+					for _, repoURL := range extractGithubRepoLinks(markdown) {
+						// Skip the links of the list to itself:
+						if listRepoURL != "" && strings.EqualFold(repoURL, listRepoURL) {
+							continue
+						}
+						linked = append(linked, repoURL)
+					}
+					Infof("Found links to %v repos in %s", len(linked), target)
+
+					linked = blacklist.Filter(linked)
+					if limit > 0 && len(linked) > limit {
+						linked = linked[:limit]
+					}
+
+					repoMeta := make(RepoMetadata)
+					repos := lookupAwesomeListRepos(ctx, linked, c.Int64("concurrency"))
+					repoURLs := repoMeta.Add(repos...)
+					if skipped := len(linked) - len(repoURLs); skipped > 0 {
+						Infof("Skipped %v forks, archived, renamed (already linked) or missing repos", skipped)
+					}
+					stars := make(map[string]int)
+					for _, repo := range repos {
+						stars[repo.GetHTMLURL()] = repo.GetStargazersCount()
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-awesome-list", toBeFollowed, stars, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-awesome-list")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, repoMeta)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-awesome-list", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-awesome-list", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "follow-by-org-members",
+				Usage:     "Follow the personal repos (forks excluded) of the public members of a GitHub org.",
+				ArgsUsage: "<org>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "lang, l",
+						Usage: "Filter github repos by language.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-member",
+						Usage: "Skip the repos of this member (can use flag multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-archived",
+						Usage: "Skip archived repos.",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of repos to follow (the most starred first).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					org := strings.TrimSpace(c.Args().First())
+					if org == "" {
+						Fatalf("Must provide a GitHub org")
+					}
+					lang := c.String("lang")
+					limit := c.Int("limit")
+					force := c.Bool("y")
+					excludedMembers := lowerAll(mustStringSliceNotNil(c.StringSlice("exclude-member")))
+
+					members, err := githubutil.ListOrgPublicMembers(ghRawClient, org)
+					if err != nil {
+						panic(err)
+					}
+					Infof("%s has %v public members", org, len(members))
+
+					repoMeta := make(RepoMetadata)
+					repoURLs := make([]string, 0)
+					stars := make(map[string]int)
+					var failedMembers int
+					for memberIndex, member := range members {
+						if SliceContains(excludedMembers, ToLower(member)) {
+							Debugf("Skipping excluded member %s", member)
+							continue
+						}
+						Infof("Getting repos of %s (%v/%v)...", member, memberIndex+1, len(members))
+						repos, err := githubOwnerRepos(member, lang)
+						if err != nil {
+							metrics.Inc("errors_total", "op", "list_repos")
+							Errorf("%s", err)
+							failedMembers++
+							continue
+						}
+						for _, repo := range repos {
+							if c.Bool("skip-archived") && repo.GetArchived() {
+								Debugf("Skipping archived %s", repo.GetFullName())
+								continue
+							}
+							repoURLs = append(repoURLs, repoMeta.Add(repo)...)
+							stars[repo.GetHTMLURL()] = repo.GetStargazersCount()
+						}
+					}
+					if failedMembers > 0 {
+						Warnf("Could not get the repos of %v members", failedMembers)
+					}
+
+					// The most starred first (so that --limit keeps them):
+					repoURLs = repoMeta.Sort(repoURLs, "stars")
+					Infof("Found %v repos of members of %s", len(repoURLs), org)
+
+					repoURLs = blacklist.Filter(repoURLs)
+					if limit > 0 && len(repoURLs) > limit {
+						repoURLs = repoURLs[:limit]
+					}
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-org-members", toBeFollowed, stars, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-org-members")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, repoMeta)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-org-members", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-org-members", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-go-modules",
+				Usage: "Follow the repositories of the Go modules listed in one or more files (one module path per line).",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					filepaths := []string(c.Args())
+					if len(filepaths) == 0 {
+						Fataln("Must provide at least one file with Go module paths")
+					}
+					force := c.Bool("y")
+
+					modulePaths := Deduplicate(mustLoadTargetsFromFilepaths(filepaths...))
+					Infof("Resolving the repositories of %v Go modules...", len(modulePaths))
+
+					repoURLs := make([]string, 0)
+					{
+						resolver := NewGoRepoResolver()
+						for _, modulePath := range modulePaths {
+							repoURL, err := resolver.Resolve(modulePath)
+							if err != nil {
+								Warnf("Could not resolve repository of %s: %s; skipping", modulePath, err)
+								continue
+							}
+							Debugf("%s is in %s", modulePath, repoURL)
+							repoURLs = append(repoURLs, repoURL)
+						}
+						repoURLs = Deduplicate(repoURLs)
+						Infof("%v Go modules are in %v repos", len(modulePaths), len(repoURLs))
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-go-modules", toBeFollowed, nil, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-go-modules")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-go-modules", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-go-modules", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "follow-by-gomod",
+				Usage:     "Follow the repositories of the dependencies of a local Go module (go.mod).",
+				ArgsUsage: "[go.mod files or directories]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "recursive, r",
+						Usage: "Use all the go.mod files in the provided directories and their subdirectories.",
+					},
+					&cli.BoolFlag{
+						Name:  "indirect",
+						Usage: "Also follow the indirect dependencies.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					paths := []string(c.Args())
+					if len(paths) == 0 {
+						paths = []string{"go.mod"}
+					}
+					force := c.Bool("y")
+
+					goModFilepaths := make([]string, 0)
+					for _, path := range paths {
+						info, err := os.Stat(path)
+						if err != nil {
+							panic(err)
+						}
+						if !info.IsDir() {
+							goModFilepaths = append(goModFilepaths, path)
+							continue
+						}
+						if !c.Bool("recursive") {
+							goModFilepaths = append(goModFilepaths, filepath.Join(path, "go.mod"))
+							continue
+						}
+						found, err := findGoModFiles(path)
+						if err != nil {
+							panic(err)
+						}
+						goModFilepaths = append(goModFilepaths, found...)
+					}
+
+					mods := make([]*GoModFile, 0, len(goModFilepaths))
+					for _, path := range goModFilepaths {
+						mod, err := loadGoModFile(path)
+						if err != nil {
+							panic(err)
+						}
+						Debugf("%s: module %s with %v requires", path, mod.Module, len(mod.Requires))
+						mods = append(mods, mod)
+					}
+
+					modulePaths := Deduplicate(goModDependencies(mods, c.Bool("indirect")))
+					Infof("Resolving the repositories of %v Go modules required by %v go.mod files...", len(modulePaths), len(mods))
+
+					repoURLs := make([]string, 0)
+					{
+						resolver := NewGoRepoResolver()
+						for _, modulePath := range modulePaths {
+							repoURL, err := resolver.Resolve(modulePath)
+							if err != nil {
+								Warnf("Could not resolve repository of %s: %s; skipping", modulePath, err)
+								continue
+							}
+							Debugf("%s is in %s", modulePath, repoURL)
+							repoURLs = append(repoURLs, repoURL)
+						}
+						repoURLs = Deduplicate(repoURLs)
+						Infof("%v Go modules are in %v repos", len(modulePaths), len(repoURLs))
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-gomod", toBeFollowed, nil, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-gomod")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-gomod", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-gomod", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "follow-by-sbom",
+				Usage:     "Follow the repositories of the packages listed in one or more SBOMs (SPDX or CycloneDX).",
+				ArgsUsage: "[SBOM files]",
+				Flags: []cli.Flag{
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent package registry lookups.",
+						Value: 8,
+					},
+					&cli.StringFlag{
+						Name:  "unresolved-output",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the packages whose repository could not be resolved (json).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					sbomFilepaths := []string(c.Args())
+					if len(sbomFilepaths) == 0 {
+						Fataln("Must provide at least one SBOM file")
+					}
+					force := c.Bool("y")
+					maxWorkers := c.Int64("concurrency")
+					if maxWorkers < 1 {
+						Fatalf("--concurrency must be at least 1")
+					}
+
+					packages := make([]*SBOMPackage, 0)
+					{
+						seen := make(map[string]bool)
+						for _, sbomFilepath := range sbomFilepaths {
+							content, err := ioutil.ReadFile(sbomFilepath)
+							if err != nil {
+								panic(err)
+							}
+							parsed, format, err := parseSBOM(content)
+							if err != nil {
+								Fatalf("Error while parsing %s: %s", sbomFilepath, err)
+							}
+							Infof("%s: %v packages (%s)", sbomFilepath, len(parsed), format)
+							for _, pkg := range parsed {
+								// The same package can be listed in more than one SBOM:
+								if seen[pkg.ID()] {
+									continue
+								}
+								seen[pkg.ID()] = true
+								packages = append(packages, pkg)
+							}
+						}
+					}
+					Infof("Resolving the repositories of %v packages...", len(packages))
+
+					repoURLs := make([]string, 0)
+					{
+						resolutions := resolveSBOMPackages(ctx, packages, maxWorkers)
+						unresolved := make([]*SBOMResolution, 0)
+						for _, resolution := range resolutions {
+							if resolution.RepoURL == "" {
+								unresolved = append(unresolved, resolution)
+								continue
+							}
+							repoURLs = append(repoURLs, resolution.RepoURL)
+						}
+						repoURLs = Deduplicate(repoURLs)
+						Infof("%v packages are in %v repos", len(packages)-len(unresolved), len(repoURLs))
+						if len(unresolved) > 0 {
+							Warnf("Could not resolve the repository of %v packages (use --debug to see them)", len(unresolved))
+							if output := c.String("unresolved-output"); output != "" {
+								js, err := json.MarshalIndent(unresolved, "", "  ")
+								if err != nil {
+									panic(err)
+								}
+								if err := writeOutputFile(output, js, "application/json"); err != nil {
+									panic(err)
+								}
+								Infof("Saved the unresolved packages to %s", output)
+							}
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-sbom", toBeFollowed, nil, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-sbom")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-sbom", toBeFollowed)
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+					listAdder.AddFollowed(cache, repoURLs)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-sbom", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						listAdder.AddEnvelope(envelope)
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					if err := listAdder.Close(); err != nil {
+						panic(err)
+					}
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-go-imported-by",
+				Usage: "Follow Go projects that import a specific Go package.",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of repos to follow (only the first page of the importedby tab of pkg.go.dev is read, so there can be fewer).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.BoolFlag{
+						Name:  "interactive",
+						Usage: "For each repo, show its stars, language and description, and ask whether to follow it (y/n/a(ll)/q(uit)); the approved repos are followed afterwards.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					pkg := c.Args().First()
+					if pkg == "" {
+						Fataln("Must provide a package")
+					}
+					limit := c.Int("limit")
+					force := c.Bool("y")
+
+					repoURLs := make([]string, 0)
+					{
+						Debugf("Getting list of importers of %s Go package ...", ShakespeareBG(pkg))
+						repos, err := GetImportersOfGolangPackage(pkg, limit)
+						if err != nil {
+							Fatalf("Error while getting go package importers' list %q: %s", pkg, err)
+						}
+
+						Debugf("%s is imported by %v repos", ShakespeareBG(pkg), len(repos))
+						repoURLs = append(repoURLs, repos...)
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					toBeFollowed := repoURLs
+					cache, err := getFollowedCache(noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					} else {
+						// Exclude already-followed projects:
+						toBeFollowed = cache.RemoveFollowed(repoURLs)
+					}
+					if c.Bool("enqueue-only") {
+						return enqueueFollowTargets(queueFilepath, "follow-by-go-imported-by", toBeFollowed, nil, c.Int("priority"))
+					}
+					toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, stopAtLimit, "follow-by-go-imported-by")
+					if c.Bool("interactive") {
+						toBeFollowed = curateFollowTargets(toBeFollowed, nil)
+					}
+					totalToBeFollowed := len(toBeFollowed)
+					Infof("Will follow %v projects...", totalToBeFollowed)
+					if !force {
+						mustConfirmYes("Do you want to continue?")
+					}
+
+					// Write toBeFollowed to temp file:
+					saveTargetListToTempFile(c.String("output"), "follow-by-code-search", toBeFollowed)
+
+					followedNew := 0
+					followed := 0
+					failed := 0
+
+					etac := eta.New(int64(totalToBeFollowed))
+
+					// Follow repos:
+					for i, repoURL := range toBeFollowed {
+						if stopOnInterrupt(ctx, "follow-by-go-imported-by", toBeFollowed[i:]) {
+							break
+						}
+						envelope, err := follower(repoURL, etac)
+						if err != nil {
+							failed++
+							continue
+						}
+						followed++
+						if envelope != nil {
+							// If the project was NOT already known to lgtm.com,
+							// sleep to avoid triggering too many new builds:
+							isNew := !envelope.IsKnown()
+							if isNew {
+								followedNew++
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					Successf("Followed %v projects (%v new)", followed, followedNew)
+					if failed > 0 {
+						return partialFailuref("failed to follow %v of %v projects", failed, totalToBeFollowed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "follow-by-depnet",
+				Usage: "Follow repositories that depend on a specific repository/package (GitHub Dependency Network).",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of repos to follow.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Type of dependents to select (default=REPOSITORY).",
+					},
+					&cli.StringFlag{
+						Name:  "sub",
+						Usage: "Select a specific subpackage.",
+					},
+					&cli.BoolFlag{
+						Name:  "info",
+						Usage: "Print dependents stats and exit.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "enqueue-only",
+						Usage: "Add the targets to the follow queue (see the queue command) instead of following them.",
+					},
+					&cli.IntFlag{
+						Name:  "priority",
+						Usage: "Priority of the targets added to the follow queue (higher first).",
+					},
+					&cli.IntFlag{
+						Name:  "min-stars",
+						Usage: "Only follow dependents that have at least this number of stars.",
+					},
+					&cli.StringFlag{
+						Name:  "checkpoint",
+						Usage: "Filepath to which save the progress of the traversal of the dependents (default: in the temp dir).",
+					},
+					&cli.StringFlag{
+						Name:  "resume-from",
+						Usage: "Resume the traversal from a checkpoint file saved by a previous run.",
+					},
+					&cli.StringFlag{
+						Name:  "graph-output",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the dependent->dependency edges (json if the extension is .json, DOT otherwise).",
+					},
+					&cli.BoolFlag{
+						Name:  "graph-only",
+						Usage: "Only map the dependency graph (see --graph-output), without following the dependents.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					target := c.Args().First()
+					var checkpoint *DepnetCheckpoint
+					if resumeFrom := c.String("resume-from"); resumeFrom != "" {
+						var err error
+						checkpoint, err = LoadDepnetCheckpoint(resumeFrom)
+						if err != nil {
+							return fmt.Errorf("error while loading checkpoint: %w", err)
+						}
+						if target != "" && !strings.EqualFold(trimGithubPrefix(target), trimGithubPrefix(checkpoint.Target)) {
+							return fmt.Errorf("the checkpoint is for %s, not for %s", checkpoint.Target, target)
+						}
+						target = checkpoint.Target
+					}
+					if target == "" {
+						cli.ShowAppHelp(c)
+						Fataln("Must provide a repo")
+					}
+					limit := c.Int("limit")
+					force := c.Bool("y")
+					infoOnly := c.Bool("info")
+					subPackage := c.String("sub")
+					minStars := c.Int("min-stars")
+					enqueueOnly := c.Bool("enqueue-only")
+					graphOutput := c.String("graph-output")
+					graphOnly := c.Bool("graph-only")
+					if graphOnly && graphOutput == "" {
+						return errors.New("--graph-only requires --graph-output")
+					}
+
+					typ := c.String("type")
+					if typ == "" {
+						typ = depnetloader.TYPE_REPOSITORY
+					}
+					if checkpoint != nil {
+						typ = checkpoint.Type
+						subPackage = checkpoint.SubPackage
+						if !c.IsSet("min-stars") {
+							minStars = checkpoint.MinStars
+						}
+					}
+
+					info, err :=
+						depnetloader.NewLoader(target).
+							Type(typ).
+							GetInfo()
+					if err != nil {
+						panic(err)
+					}
+
+					if infoOnly {
+						JSON(true, info)
+						return nil
+					}
+
+					{
+						if subPackage == "" {
+							Debugf("Getting list of dependents on %s ...", ShakespeareBG(target))
+						} else {
+							Debugf(
+								"Getting list of dependents on %s, subpackage %s ...",
+								ShakespeareBG(target),
+								ShakespeareBG(subPackage),
+							)
+						}
+						cache, err := getFollowedCache(noCache)
+						hasCache := err == nil && cache != nil
+						if !hasCache {
+							if ignoreFollowedErrors {
+								Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+							} else {
+								panic(err)
+							}
+						}
+
+						var totalToBeFollowed int
+						if typ == depnetloader.TYPE_REPOSITORY {
+							totalToBeFollowed = info.Dependents.Counts.Repositories
+						} else {
+							totalToBeFollowed = info.Dependents.Counts.Packages
+						}
+						if checkpoint != nil {
+							totalToBeFollowed -= checkpoint.Processed
+						}
+						if limit == 0 {
+							if graphOnly {
+								Infof("Will map %v dependents...", totalToBeFollowed)
+							} else if minStars > 0 {
+								Infof("Will follow the projects with at least %v stars, out of %v dependents...", minStars, totalToBeFollowed)
+							} else {
+								Infof("Will follow %v projects...", totalToBeFollowed)
+							}
+							if !force && !graphOnly {
+								mustConfirmYes("Do you want to continue?")
+							}
+						} else {
+							totalToBeFollowed = limit
+						}
+
+						writer := writtableTargetListToTempFile(c.String("output"), "follow-by-depnet")
+						defer func() {
+							if err := writer.Close(); err != nil {
+								Errorf("Error while saving list of targets: %s", err)
+							}
+						}()
+						listAdder := mustNewListAdder(client, c.String("add-to-list"))
+
+						var startPage string
+						if checkpoint != nil {
+							startPage = checkpoint.Page
+							if c.IsSet("checkpoint") {
+								checkpoint.path = c.String("checkpoint")
+							}
+							Infof("Resuming from %s (%v dependents already processed)", startPage, checkpoint.Processed)
+						} else {
+							startPage, err = firstDependentsPageURL(target, typ, subPackage)
+							if err != nil {
+								panic(err)
+							}
+							checkpointPath := c.String("checkpoint")
+							if checkpointPath == "" {
+								checkpointPath = defaultDepnetCheckpointFilepath(target)
+							}
+							checkpoint = &DepnetCheckpoint{
+								path:       checkpointPath,
+								Target:     target,
+								Type:       typ,
+								SubPackage: subPackage,
+							}
+						}
+						checkpoint.MinStars = minStars
+						Infof("Saving progress to %s (use --resume-from to resume an interrupted run)", checkpoint.path)
+						{
+							etac := eta.New(int64(totalToBeFollowed))
+							followedNew := 0
+							failed := 0
+							count := 0
+							processed := checkpoint.Processed
+							stopped := false
+							queued := make([]*QueuedTarget, 0)
+							var graph *DepnetGraph
+							if graphOutput != "" {
+								graph = NewDepnetGraph()
+							}
+							// Follow repos:
+							err := walkDependents(
+								startPage,
+								func(pageURL string) error {
+									checkpoint.Page = pageURL
+									checkpoint.Processed = processed
+									return checkpoint.Save()
+								},
+								func(dep *Dependent) bool {
+
+									if ctx.Err() != nil {
+										Warnf("Stopped; the remaining dependents were not followed")
+										stopped = true
+										return false
+									}
+									processed++
+									if dep.Stars < minStars {
+										return true
+									}
+									if graph != nil {
+										graph.Add(dep, target, subPackage)
+									}
+									if graphOnly {
+										count++
+										if limit > 0 && count >= limit {
+											stopped = true
+											return false
+										}
+										return true
+									}
+
+									repoURL := "https://github.com/" + dep.Slug
+
+									if _, isBlacklisted := blacklist.Match(repoURL); isBlacklisted {
+										return true
+									}
+									if cache != nil && cache.HasAny(repoURL) {
+										// Already followed; skip.
+										listAdder.AddProject(cache.GetProject(repoURL))
+										return true
+									}
+									writer.WriteLine(repoURL)
+									if enqueueOnly {
+										queued = append(queued, newQueuedTargets("follow-by-depnet", []string{repoURL}, map[string]int{repoURL: dep.Stars}, c.Int("priority"))...)
+										count++
+										return limit == 0 || count < limit
+									}
+									envelope, err := follower(repoURL, etac)
+									if err != nil {
+										failed++
+									} else {
+										listAdder.AddEnvelope(envelope)
+										if envelope != nil {
+											// If the project was NOT already known to lgtm.com,
+											// sleep to avoid triggering too many new builds:
+											isNew := !envelope.IsKnown()
+											if isNew {
+												followedNew++
+												pacer.Wait(envelope)
+											}
+										}
+									}
+
+									count++
+									if limit > 0 && count >= limit {
+										stopped = true
+										return false
+									}
+
+									return true
+								})
+							if graph != nil {
+								if err := graph.WriteToFile(graphOutput); err != nil {
+									Errorf("Error while saving dependency graph: %s", err)
+								} else {
+									Successf("Saved %v dependency edges to %s", len(graph.Edges), graphOutput)
+								}
+							}
+							if err != nil {
+								Errorf("Traversal stopped; resume it with --resume-from=%s", checkpoint.path)
+								panic(err)
+							}
+							if !stopped {
+								if err := checkpoint.Remove(); err != nil {
+									Warnf("Could not remove checkpoint: %s", err)
+								}
+							} else {
+								Infof("Resume the traversal with --resume-from=%s", checkpoint.path)
+							}
+							if graphOnly {
+								return nil
+							}
+							if enqueueOnly {
+								queue, err := LoadFollowQueue(queueFilepath)
+								if err != nil {
+									panic(err)
+								}
+								added := queue.Add(queued...)
+								if err := queue.Save(); err != nil {
+									panic(err)
+								}
+								Successf("Added %v targets to the queue (%v queued in total)", added, len(queue.Targets))
+								return nil
+							}
+							if err := listAdder.Close(); err != nil {
+								panic(err)
+							}
+							Successf("Followed %v projects (%v new)", count-failed, followedNew)
+							if failed > 0 {
+								return partialFailuref("failed to follow %v of %v projects", failed, count)
+							}
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "query",
+				Usage: "Run a query on one or multiple projects.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "exclude, e",
+						Usage: "Exclude project; example: github/api",
+					},
+					&cli.StringSliceFlag{
+						Name:  "list-key, lk",
+						Usage: "Project list key on which to run the query (can specify multiple).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "list",
+						Usage: "Project list name on which to run the query (can specify multiple).",
+					},
+					&cli.StringFlag{
+						Name:  "lang, l",
+						Usage: "Language of the query project (detected from the query imports or qlpack.yml if not set).",
+					},
+					&cli.StringFlag{
+						Name:  "query, q",
+						Usage: "Filepath to .ql query file, or URL of a raw .ql file, GitHub file or gist.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "var",
+						Usage: "Value of a {{name}} placeholder of the query, as name=value (can use flag multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "validate",
+						Usage: "Validate the query locally before submitting it: a syntax check with the CodeQL CLI (if on the PATH), or else a sanity check (select clause, language import, balanced brackets).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (- for stdin).",
+					},
+					&cli.BoolFlag{
+						Name:  "all-followed, af",
+						Usage: "Query all followed projects.",
+					},
+					&cli.BoolFlag{
+						Name:  "all-lists, al",
+						Usage: "Query all current user's lists.",
+					},
+					&cli.IntFlag{
+						Name:  "max-projects-per-run",
+						Usage: "Max number of projects per query run; above it, the projects are split into temporary lists, and queried with one run per list.",
+						Value: DefaultMaxProjectsPerQueryRun,
+					},
+					&cli.BoolFlag{
+						Name:  "delete-temp-lists",
+						Usage: "Delete the temporary lists created to split the projects, once the query runs have completed.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Query the projects that have this local tag (can use flag multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "save-run",
+						Usage: "Save the metadata of the query runs (see the runs command).",
+					},
+					&cli.BoolFlag{
+						Name:  "run-per-list",
+						Usage: "Start a separate run for each list (and one for the projects), so that each has its own results link.",
+					},
+					&cli.StringFlag{
+						Name:  "aggregate",
+						Usage: "Wait for all the runs to complete, and save to this filepath (or s3://, gs://, https:// URL) the results of all runs merged by project (json).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					queryFilepath := c.String("query")
+					if queryFilepath == "" {
+						panic("--query not set")
+					}
+
+					var queryString string
+					// The filepath used to detect the language (empty for downloaded queries).
+					localQueryFilepath := queryFilepath
+					if isQueryURL(queryFilepath) {
+						Infof("Downloading query from %s ...", queryFilepath)
+						downloaded, err := fetchQuery(queryFilepath)
+						if err != nil {
+							return err
+						}
+						queryString = downloaded
+						localQueryFilepath = ""
+					} else {
+						fileExt := filepath.Ext(queryFilepath)
+						if fileExt != ".ql" {
+							Fatalf("file is not a .ql: %s", queryFilepath)
+						}
+
+						queryBytes, err := ioutil.ReadFile(queryFilepath)
+						if err != nil {
+							return err
+						}
+						queryString = string(queryBytes)
+					}
+
+					vars, err := parseQueryVars(mustStringSliceNotNil(c.StringSlice("var")))
+					if err != nil {
+						return err
+					}
+					// Even without --var, so that a template is not submitted
+					// with its placeholders:
+					queryString, err = substituteQueryVars(queryString, vars)
+					if err != nil {
+						return err
+					}
+
+					lang := ToLower(c.String("lang"))
+					if c.Bool("validate") {
+						check, problems, err := validateQuery(queryString, lang)
+						if err != nil {
+							return fmt.Errorf("error while validating the query: %w", err)
+						}
+						if len(problems) > 0 {
+							for _, problem := range problems {
+								Errorf("%s: %s", queryFilepath, problem)
+							}
+							return fmt.Errorf("the query did not pass the %s (%v problems); not submitted", check, len(problems))
+						}
+						Successf("The query passed the %s", check)
+					}
+					if lang == "" {
+						lang, err = lgtm.DetectQueryLanguage(localQueryFilepath, queryString)
+						if err != nil {
+							return fmt.Errorf("%s; please set --lang", err)
+						}
+						Infof("Detected query language: %s", lang)
+					}
+					if !lgtm.IsSupportedLanguage(lang) {
+						return fmt.Errorf("unsupported language %q; supported: %s", lang, strings.Join(lgtm.Languages, ", "))
+					}
+
+					force := c.Bool("y")
+
+					projectListKeys := mustStringSliceNotNil(c.StringSlice("list-key"))
+					projectListNames := mustStringSliceNotNil(c.StringSlice("list"))
+					doAllLists := c.Bool("all-lists")
+					if len(projectListKeys)+len(projectListNames) > 0 && doAllLists {
+						panic("Cannot set --list-key/--list along with --all-lists")
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					hasRepoListFilepath := c.IsSet("f")
+					if hasRepoListFilepath {
+						repoListFilepaths := mustStringSliceNotNil(c.StringSlice("f"))
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(repoListFilepaths...)...)
+					}
+					repoURLsRaw = append(repoURLsRaw, mustLoadTaggedURLs(tagsFilepath, c.StringSlice("tag"))...)
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+
+					repoURLs := make([]string, 0)
+					for _, raw := range repoURLsRaw {
+						owner, isWholeUser, err := urlparse.IsUserOnly(raw)
+						if err != nil {
+							panic(err)
+						}
+						if isWholeUser {
+							Debugf("Getting list of repos for %s ...", owner)
+							repos, err := GithubGetRepoList(owner)
+							if err != nil {
+								panic(fmt.Errorf("error while getting repo list for user %q: %s", owner, err))
+							}
+							Debugf("%s has %v repos", owner, len(repos))
+							for _, repo := range repos {
+								//repoURLs = append(repoURLs, repo.GetFullName()) // e.g. "kubernetes/dashboard"
+								isFork := repo.GetFork()
+								// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+								if !isFork {
+									repoURLs = append(repoURLs, repo.GetHTMLURL()) // e.g. "https://github.com/kubernetes/dashboard"
+								} else {
+									Warnf("Skipping fork %s", repo.GetFullName())
+								}
+							}
+						} else {
+							parsed, err := urlparse.Parse(raw, false)
+							if err != nil {
+								panic(err)
+							}
+							repoURLs = append(repoURLs, parsed.URL())
+						}
+					}
+
+					projectkeys := make([]string, 0)
+					if len(repoURLs) > 0 {
+						cache, err := getFollowedCacheOf(client, noCache)
+						hasCache := err == nil && cache != nil
+						if !hasCache {
+							if ignoreFollowedErrors {
+								Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+							} else {
+								panic(err)
+							}
+						}
+
+						excluded := mustStringSliceNotNil(c.StringSlice("exclude"))
+
+						if hasCache {
+							// With cache:
+
+							// If no repos specified, and flag --all is true, then query all:
+							if c.Bool("all-followed") {
+								Infof("Gonna query all %v projects", cache.NumProjects())
+								for _, pr := range cache.Projects() {
+									repoURLs = append(repoURLs, pr.ExternalURL.URL)
+								}
+							}
+							repoURLs = Deduplicate(repoURLs)
+							repoURLs = blacklist.Filter(repoURLs)
+
+							for _, repoURL := range repoURLs {
+								isProto := cache.IsProto(repoURL)
+								if isProto {
+									Warnf("%s is proto; skipping", trimGithubPrefix(repoURL))
+									continue
+								}
+
+								pr := cache.GetProject(repoURL)
+								if pr == nil {
+									Warnf("%s is not followed; skipping", trimGithubPrefix(repoURL))
+								} else {
+									isSupportedLanguageForProject := pr.SupportsLanguage(lang)
+									if !isSupportedLanguageForProject {
+										Warnf("%s does not have language %s; skipping", trimGithubPrefix(repoURL), lang)
+									} else {
+										isExcluded := SliceContains(excluded, pr.DisplayName)
+										if isExcluded {
+											Warnf("%s is excluded; skipping", trimGithubPrefix(repoURL))
+										} else {
+											projectkeys = append(projectkeys, pr.Key)
+										}
+									}
+								}
+							}
+						} else {
+							// If no cache available:
+							repoURLs = blacklist.Filter(repoURLs)
+							for _, res := range resolveProjectsBySlug(ctx, client, completeRepoURLs(repoURLs), defaultResolveConcurrency) {
+								repoURL := res.URL
+								pr := res.Project
+								if pr == nil {
+									if !res.NotBuilt {
+										// General error
+										panic(res.Err())
+									}
+									Warnf(
+										"Project %s is not a built project.",
+										trimGithubPrefix(repoURL),
+									)
+									continue
+								}
+								isSupportedLanguageForProject := pr.SupportsLanguage(lang)
+								if !isSupportedLanguageForProject {
+									Warnf("%s does not have language %s; skipping", trimGithubPrefix(repoURL), lang)
+								} else {
+									isExcluded := SliceContains(excluded, pr.DisplayName)
+									if isExcluded {
+										Warnf("%s is excluded; skipping", trimGithubPrefix(repoURL))
+									} else {
+										projectkeys = append(projectkeys, pr.Key)
+									}
+								}
+							}
+						}
+					}
+
+					listNamesByKey := make(map[string]string)
+					if len(projectListNames) > 0 || doAllLists {
+						lists, err := client.ListProjectSelections()
+						if err != nil {
+							panic(err)
+						}
+
+						// Add project lists by name (if any):
+						for _, name := range projectListNames {
+							list := lists.ByName(name)
+							if list == nil {
+								Warnf("List %q not found; skipping", name)
+							} else {
+								projectListKeys = append(projectListKeys, list.Key)
+								listNamesByKey[list.Key] = list.Name
+							}
+						}
+
+						if doAllLists {
+							// Add all created project lists
+							for _, list := range lists {
+								projectListKeys = append(projectListKeys, list.Key)
+								listNamesByKey[list.Key] = list.Name
+							}
+						}
+					}
+
+					if !force {
+						yes, err := askYesNo(Sf(
+							"Do you want to send the query %q to be run on %v projects and %v lists?",
+							queryFilepath,
+							len(projectkeys),
+							len(projectListKeys),
+						))
+						if err != nil {
+							panic(err)
+						}
+						if !yes {
+							Infof("Aborting...")
+							return nil
+						}
+					}
+
+					Infof(
+						"Sending query %q to be run on %v projects and %v lists...",
+						queryFilepath,
+						len(projectkeys),
+						len(projectListKeys),
+					)
+					queryConfig := &lgtm.QueryConfig{
+						Lang:                 lang,
+						ProjectKeys:          projectkeys,
+						QueryString:          queryString,
+						ProjectSelectionKeys: projectListKeys,
+					}
+
+					saveRuns := func(runs ...*lgtm.QueryResponseData) {
+						if !c.Bool("save-run") {
+							return
+						}
+						saved := make([]*SavedQueryRun, 0, len(runs))
+						for _, run := range runs {
+							saved = append(saved, newSavedQueryRun(run, queryFilepath, queryString, repoURLs))
+						}
+						if err := saveQueryRuns(runsFilepath, saved...); err != nil {
+							Errorf("Error while saving query runs: %s", err)
+							return
+						}
+						Infof("Saved %v query runs to %s", len(saved), runsFilepath)
+					}
+					aggregate := func(runs ...*lgtm.QueryResponseData) error {
+						output := c.String("aggregate")
+						if output == "" {
+							return nil
+						}
+						runKeys := make([]string, 0, len(runs))
+						for _, run := range runs {
+							runKeys = append(runKeys, run.Key)
+						}
+						waitQueryRuns(ctx, client, runKeys, 30*time.Second)
+						if ctx.Err() != nil {
+							return ctx.Err()
+						}
+						agg, err := aggregateQueryRuns(client, runKeys)
+						if err != nil {
+							return err
+						}
+						if err := agg.WriteToFile(output); err != nil {
+							return err
+						}
+						Successf("Saved the results of %v runs (%v projects) to %s", len(runKeys), len(agg.Projects), output)
+						return nil
+					}
+
+					maxProjectsPerRun := c.Int("max-projects-per-run")
+					if maxProjectsPerRun > 0 && len(projectkeys) > maxProjectsPerRun {
+						Infof(
+							"%v projects exceed the max of %v projects per run; splitting them into temporary lists...",
+							len(projectkeys),
+							maxProjectsPerRun,
+						)
+						batches, err := runQueryInBatches(ctx, client, queryConfig, maxProjectsPerRun, 3, 3)
+
+						Successf("See query results at:")
+						runs := make([]*lgtm.QueryResponseData, 0, len(batches))
+						for _, batch := range batches {
+							if batch.Run != nil {
+								fmt.Println(batch.Run.GetResultLink())
+								runs = append(runs, batch.Run)
+							}
+						}
+						saveRuns(runs...)
+						if err == nil {
+							err = aggregate(runs...)
+						}
+						if c.Bool("delete-temp-lists") {
+							// The lists are deleted only once the runs are done:
+							waitQueryBatches(ctx, client, batches, 30*time.Second)
+							for _, batch := range batches {
+								if ctx.Err() != nil {
+									Warnf("Stopped; temporary list %q was not deleted", batch.List.Name)
+									continue
+								}
+								Infof("Deleting temporary list %q...", batch.List.Name)
+								if err := client.DeleteProjectSelection(batch.List.Name); err != nil {
+									Errorf("Error while deleting list %q: %s", batch.List.Name, err)
+								}
+							}
+						} else {
+							for _, batch := range batches {
+								Infof("Created temporary list %q; delete it with: lgtm delete-list %s", batch.List.Name, batch.List.Name)
+							}
+						}
+						return err
+					}
+
+					if c.Bool("run-per-list") {
+						labeled, runs, err := runQueryPerList(client, queryConfig, listNamesByKey)
+						if len(labeled) > 0 {
+							Successf("See query results at:")
+							for _, run := range labeled {
+								fmt.Printf("%s: %s\n", run.Label, run.Link)
+							}
+						}
+						saveRuns(runs...)
+						if err != nil {
+							return err
+						}
+						return aggregate(runs...)
+					}
+
+					resp, err := client.Query(queryConfig)
+					if err != nil {
+						return err
+					}
+
+					Successf("See query results at:")
+					fmt.Println(resp.GetResultLink())
+					saveRuns(resp)
+					return aggregate(resp)
+				},
+			},
+			{
+				Name:  "query-diff",
+				Usage: "Compare the results of two query runs.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the diff as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					queryIDA := c.Args().Get(0)
+					queryIDB := c.Args().Get(1)
+					if queryIDA == "" || queryIDB == "" {
+						return errors.New("two query IDs must be provided")
+					}
+
+					took := NewTimer()
+					Infof("Getting results of query %s...", queryIDA)
+					itemsA, err := client.GetAllQueryResults(queryIDA, lgtm.OrderByNumResults)
+					if err != nil {
+						panic(err)
+					}
+					Infof("Getting results of query %s...", queryIDB)
+					itemsB, err := client.GetAllQueryResults(queryIDB, lgtm.OrderByNumResults)
+					if err != nil {
+						panic(err)
+					}
+					Infof("Got %v and %v results; took %s", len(itemsA), len(itemsB), took())
+
+					diff := diffQueryResults(itemsA, itemsB)
+
+					{ // Get the names of the changed projects:
+						deltas := diff.Deltas()
+						projectKeys := make([]string, 0, len(deltas))
+						for _, delta := range deltas {
+							projectKeys = append(projectKeys, delta.ProjectKey)
+						}
+						// The deltas of the projects that could not be looked up
+						// are reported by key.
+						got, err := client.GetProjectsByKeyBulk(ctx, lgtm.DefaultBulkMaxWorkers, projectKeys...)
+						if err != nil {
+							Errorf("error while client.GetProjectsByKeyBulk: %s", err)
+						}
+						for _, delta := range deltas {
+							if pr := got.GetProject(delta.ProjectKey); pr != nil {
+								delta.Project = pr.ExternalURL.URL
+							}
+						}
+					}
+
+					if c.Bool("json") {
+						JSON(true, diff)
+						return nil
+					}
+
+					name := func(delta *QueryResultDelta) string {
+						if delta.Project != "" {
+							return delta.Project
+						}
+						return delta.ProjectKey
+					}
+					for _, delta := range diff.New {
+						Sfln("%s %s (%v results)", Lime("+"), name(delta), delta.ResultsB)
+					}
+					for _, delta := range diff.Gone {
+						Sfln("%s %s (%v results)", RedBG("-"), name(delta), delta.ResultsA)
+					}
+					for _, delta := range diff.Changed {
+						Sfln("%s %s (%v -> %v results; %+d)", OrangeBG("~"), name(delta), delta.ResultsA, delta.ResultsB, delta.Delta)
+					}
+					Successf(
+						"%v projects with new results, %v with no more results, %v changed, %v unchanged",
+						len(diff.New),
+						len(diff.Gone),
+						len(diff.Changed),
+						diff.Unchanged,
+					)
+					if len(diff.Skipped) > 0 {
+						Warnf("%v projects were skipped because they failed or are still running in either run", len(diff.Skipped))
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "query-run-status",
+				Usage:     "Print the status of one or more query runs.",
+				ArgsUsage: "<query-run-key or @N (the Nth most recent saved run)>...",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "watch, w",
+						Usage: "Keep refreshing the status until all runs are completed.",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "Refresh interval (used with --watch).",
+						Value: 30 * time.Second,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the status as json (one object per line).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					queryIDs := make([]string, 0, len(c.Args()))
+					for _, ref := range c.Args() {
+						queryID, err := resolveQueryRunRef(runsFilepath, ref)
+						if err != nil {
+							return err
+						}
+						queryIDs = append(queryIDs, queryID)
+					}
+					queryIDs = Deduplicate(queryIDs)
+					if len(queryIDs) == 0 {
+						return errors.New("query run key not provided")
+					}
+					watch := c.Bool("watch")
+					interval := c.Duration("interval")
+					asJSON := c.Bool("json")
+
+					for {
+						allDone := true
+						if !asJSON {
+							Errorln(Bold("KEY | ALL | FAILED | WITH RESULTS | WITHOUT RESULTS | PENDING"))
+						}
+						for _, queryID := range queryIDs {
+							status, err := client.GetQueryRunStatus(queryID)
+							if err != nil {
+								return err
+							}
+							if !status.IsDone() {
+								allDone = false
+							}
+							if asJSON {
+								JSON(false, status)
+								continue
+							}
+							Sfln(
+								"%s | %v | %v | %v | %v | %v",
+								status.Key,
+								status.Stats.AllRuns,
+								status.Stats.Failed,
+								status.Stats.FinishedWithResults,
+								status.Stats.FinishedWithoutResults,
+								status.Stats.Incomplete+status.Stats.PendingSchedulingTasks,
+							)
+						}
+						if !watch || allDone {
+							return nil
+						}
+						time.Sleep(interval)
+					}
+				},
+			},
+			{
+				Name:  "rebuild-proto",
+				Usage: "(Re)build followed proto-projects.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "exclude, e",
+						Usage: "Exclude project(s) by glob; example: github/api",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y, F",
+						Usage: "Rebuild all proto-projects without asking confirmation for each.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "state",
+						Usage: "Only rebuild proto-projects in this state (e.g. build_failed).",
+					},
+					&cli.BoolFlag{
+						Name:  "never-started",
+						Usage: "Only rebuild proto-projects that never had a build started.",
+					},
+					&cli.StringFlag{
+						Name:  "older-than",
+						Usage: "Only rebuild proto-projects whose last build attempt issued by lgtm-cli is older than this (e.g. 7d, 12h); the ones never rebuilt by lgtm-cli are included.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					rebuildLog, err := LoadProtoRebuildLog(DefaultProtoRebuildsFilepath())
+					if err != nil {
+						return err
+					}
+					filter := &ProtoRebuildFilter{
+						States:       lowerAll(mustStringSliceNotNil(c.StringSlice("state"))),
+						NeverStarted: c.Bool("never-started"),
+						Log:          rebuildLog,
+					}
+					if c.IsSet("older-than") {
+						filter.OlderThan, err = parseAge(c.String("older-than"))
+						if err != nil {
+							return fmt.Errorf("invalid --older-than: %w", err)
+						}
+					}
+
+					took := NewTimer()
+					Infof("Getting list of followed proto-projects...")
+					_, protoProjects, err := client.ListFollowedProjects()
+					if err != nil {
+						panic(err)
+					}
+					Infof("Currently you're following %v proto-projects; took %s", len(protoProjects), took())
+
+					force := c.Bool("force")
+
+					excluded := mustStringSliceNotNil(c.StringSlice("exclude"))
+
+					// With filters, the selected proto-projects are confirmed
+					// all at once, instead of one by one:
+					if filter.IsSet() {
+						selected := make([]*lgtm.ProtoProject, 0)
+						for _, pr := range protoProjects {
+							if ok, reason := filter.Match(pr); !ok {
+								Debugf("%s: %s; skipping", pr.DisplayName, reason)
+								continue
+							}
+							selected = append(selected, pr)
+						}
+						Infof("%v of %v proto-projects match the filters", len(selected), len(protoProjects))
+						if len(selected) == 0 {
+							return nil
+						}
+						protoProjects = selected
+						if !force {
+							mustConfirmYes(Sf("Do you want to rebuild %v proto-projects?", len(selected)))
+							force = true
+						}
+					}
+					defer func() {
+						if err := rebuildLog.Save(); err != nil {
+							Errorf("Error while saving %s: %s", DefaultProtoRebuildsFilepath(), err)
+						}
+					}()
+
+				RebuildLoop:
+					for _, pr := range protoProjects {
+						if ctx.Err() != nil {
+							Warnf("Stopped; the remaining proto-projects were not rebuilt")
+							break RebuildLoop
+						}
+						pattern, isBlacklisted := HasMatch(pr.DisplayName, excluded)
+						if isBlacklisted && pattern != "" {
+							Warnf(
+								"%s is excluded (by pattern %q); skipping",
+								pr.DisplayName,
+								pattern,
+							)
+							continue RebuildLoop
+						}
+						if pattern, isBlacklisted := blacklist.Match(pr.CloneURL); isBlacklisted {
+							Warnf(
+								"%s is blacklisted (by pattern %q); skipping",
+								pr.DisplayName,
+								pattern,
+							)
+							continue RebuildLoop
+						}
+
+						var rebuildOrNot bool
+						if !force {
+							message := Sf(
+								"%s is a proto-project; want to force new build attempt?",
+								pr.DisplayName,
+							)
+
+							if pr.NextBuildStarted {
+								message = Sf(
+									"%s is a proto-project with a build attempt in progress; want to force a new build attempt?",
+									pr.DisplayName,
+								)
+							}
+							rebuildOrNot, err = askYesNo(message)
+							if err != nil {
+								return err
+							}
+						}
+
+						doRebuild := force || rebuildOrNot
+
+						if doRebuild {
+							Infof(
+								"Trying to issue a new build attempt for %s ...",
+								pr.DisplayName,
+							)
+							err := client.RebuildProtoProject(pr.Key)
+							notifier.Record("rebuild-proto", err)
+							if err != nil {
+								Errorf(
+									"Failed to start a new build attemp for %s: %s",
+									pr.DisplayName,
+									err,
+								)
+							} else {
+								rebuildLog.Record(pr.Key)
+								// sleep:
+								time.Sleep(waitDuration)
+							}
+						}
+
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "rebuild",
+				Usage: "Rebuild followed projects.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "exclude, e",
+						Usage: "Exclude project(s) by glob; example: github/api",
+					},
+					&cli.StringFlag{
+						Name:  "lang, l",
+						Usage: "Language to rebuild.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y, F",
+						Usage: "Rebuild without asking for confirmation.",
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Rebuild all projects for specific language.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Only rebuild the projects that have this local tag (can use flag multiple times).",
+					},
+					&cli.StringFlag{
+						Name:  "older-than",
+						Usage: "Only rebuild projects whose latest analyzed commit for the language is older than this (e.g. 30d, 12h).",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent build attempt requests.",
+						Value: 1,
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Filepath (or s3://, gs://, https:// URL) where to save the json report of succeeded/failed build attempts.",
+					},
+					&cli.StringFlag{
+						Name:  "plan",
+						Usage: "Execute the build attempts of a CSV plan file, with rows like repo,language,action(test|new-attempt).",
+					},
+					&cli.StringFlag{
+						Name:  "plan-status",
+						Usage: "Filepath (or s3://, gs://, https:// URL) where to save the plan rows with their status as CSV (default: <plan>.status.csv).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 {
+						panic("--concurrency must be at least 1")
+					}
+
+					if planPath := c.String("plan"); planPath != "" {
+						rows, err := loadRebuildPlan(planPath)
+						if err != nil {
+							return fmt.Errorf("error while loading plan: %w", err)
+						}
+						cache, err := getFollowedCacheOf(client, false)
+						if err != nil {
+							panic(err)
+						}
+						resolveRebuildPlan(rows, cache)
+
+						tasks := make([]*RebuildTask, 0)
+						for _, row := range rows {
+							if row.task != nil {
+								tasks = append(tasks, row.task)
+							} else {
+								Warnf("Skipping %s (%s): %s", row.Repo, row.Lang, row.Error)
+							}
+						}
+						Infof("The plan has %v rows; %v build attempts to be issued", len(rows), len(tasks))
+						if len(tasks) > 0 {
+							if !c.Bool("force") {
+								mustConfirmYes(Sf("Do you want to issue %v build attempts?", len(tasks)))
+							}
+							etac := eta.New(int64(len(tasks)))
+							rebuilder := NewRebuilder(ctx, client, concurrency, waitDuration)
+							for _, task := range tasks {
+								rebuilder.Rebuild(task, etac)
+							}
+							summary := rebuilder.Wait()
+							summary.Print()
+							setRebuildPlanStatus(rows, summary)
+						}
+
+						statusPath := c.String("plan-status")
+						if statusPath == "" {
+							statusPath = strings.TrimSuffix(planPath, filepath.Ext(planPath)) + ".status.csv"
+						}
+						if err := writeRebuildPlanStatus(statusPath, rows); err != nil {
+							panic(err)
+						}
+						Successf("Wrote plan status to %s", statusPath)
+
+						var failed int
+						for _, row := range rows {
+							if row.Status != RebuildStatusSucceeded {
+								failed++
+							}
+						}
+						if failed > 0 {
+							return partialFailuref("%v of %v plan rows did not succeed", failed, len(rows))
+						}
+						return nil
+					}
+
+					lang := c.String("lang")
+					if lang == "" {
+						panic("--lang not set")
+					}
+
+					took := NewTimer()
+					Infof("Getting list of followed projects...")
+					projects, protoProjects, err := client.ListFollowedProjects()
+					if err != nil {
+						panic(err)
+					}
+					Infof("Currently you're following %v projects (and %v proto-projects); took %s", len(projects), len(protoProjects), took())
+
+					var projectsThatSupportTheLanguage int
+					for _, pr := range projects {
+						isSupportedLanguageForProject := pr.SupportsLanguage(lang)
+						if isSupportedLanguageForProject {
+							projectsThatSupportTheLanguage++
+						}
+					}
+					Infof(
+						ShakespeareBG("%v/%v projects support the %s language (%v do not)"),
+						projectsThatSupportTheLanguage,
+						len(projects),
+						lang,
+						len(projects)-projectsThatSupportTheLanguage,
+					)
+
+					force := c.Bool("force")
+					rebuildAll := c.Bool("all")
+
+					var olderThan time.Duration
+					if c.IsSet("older-than") {
+						olderThan, err = parseAge(c.String("older-than"))
+						if err != nil {
+							return fmt.Errorf("invalid --older-than: %w", err)
+						}
+					}
+					// In this mode, projects are selected by the age
+					// of their latest analyzed commit for the language.
+					byAnalysisAge := olderThan > 0
+					if byAnalysisAge && rebuildAll {
+						return errors.New("--all cannot be used with --older-than")
+					}
+
+					excluded := mustStringSliceNotNil(c.StringSlice("exclude"))
+
+					onlyTags := mustStringSliceNotNil(c.StringSlice("tag"))
+					var tags *TagStore
+					if len(onlyTags) > 0 {
+						tags = mustLoadTagStore(tagsFilepath)
+					}
+
+					// Decide (and confirm) what to rebuild first,
+					// then issue the build attempts.
+					tasks := make([]*RebuildTask, 0)
+				RebuildLoop:
+					for _, pr := range projects {
+						pattern, isBlacklisted := HasMatch(pr.DisplayName, excluded)
+						if isBlacklisted && pattern != "" {
+							Warnf(
+								"%s is excluded (by pattern %q); skipping",
+								pr.DisplayName,
+								pattern,
+							)
+							continue RebuildLoop
+						}
+						if pattern, isBlacklisted := blacklist.Match(pr.ExternalURL.URL); isBlacklisted {
+							Warnf(
+								"%s is blacklisted (by pattern %q); skipping",
+								pr.DisplayName,
+								pattern,
+							)
+							continue RebuildLoop
+						}
+						if tags != nil && !tags.HasAnyTag(pr.ExternalURL.URL, onlyTags...) {
+							Debugf("%s is not tagged with %s; skipping", pr.DisplayName, Sq(onlyTags))
+							continue RebuildLoop
+						}
+
+						isSupportedLanguageForProject := pr.SupportsLanguage(lang)
+
+						if byAnalysisAge {
+							if !isSupportedLanguageForProject {
+								Debugf("%s has no %s analysis; skipping", pr.DisplayName, lang)
+								continue RebuildLoop
+							}
+							if ctx.Err() != nil {
+								Warnf("Interrupted; not checking the remaining projects")
+								break RebuildLoop
+							}
+							stats, err := client.GetProjectLatestStateStats(pr.Key)
+							if err != nil {
+								metrics.Inc("errors_total", "op", "latest_state_stats")
+								Errorf("Error while getting the latest state stats of %s: %s", pr.DisplayName, err)
+								continue RebuildLoop
+							}
+							reason := rebuildReason(stats, lang, olderThan, time.Now())
+							if reason == "" {
+								Debugf("%s: no need to rebuild for %s", pr.DisplayName, lang)
+								continue RebuildLoop
+							}
+							Infof(
+								"%s: %s; will start a new build attempt for %s",
+								pr.DisplayName,
+								reason,
+								lang,
+							)
+							tasks = append(tasks, &RebuildTask{
+								Project:     pr,
+								Lang:        lang,
+								IsTestBuild: isSupportedLanguageForProject,
+							})
+							continue RebuildLoop
+						}
+
+						// Rebuild if a project does not support the specified language.
+						if !isSupportedLanguageForProject {
+							Infof(
+								"%s does NOT have language %s; will start a new build attempt",
+								pr.DisplayName,
+								lang,
+							)
+							tasks = append(tasks, &RebuildTask{
+								Project: pr,
+								Lang:    lang,
+							})
+						}
+
+						if isSupportedLanguageForProject && rebuildAll {
+							var rebuildOrNot bool
+							if !force {
+								rebuildOrNot, err = askYesNo(Sf(
+									"%s does already have language %s; Want to force new build attempt?",
+									pr.DisplayName,
+									lang,
+								))
+								if err != nil {
+									return err
+								}
+							}
+
+							doRebuild := force || rebuildOrNot
+
+							if doRebuild {
+								tasks = append(tasks, &RebuildTask{
+									Project:     pr,
+									Lang:        lang,
+									IsTestBuild: true,
+								})
+							}
+						}
+
+					}
+
+					if len(tasks) == 0 {
+						Infof("No projects to rebuild")
+						return nil
+					}
+					if byAnalysisAge && !force {
+						mustConfirmYes(Sf("Do you want to issue %v build attempts?", len(tasks)))
+					}
+
+					Infof("Issuing %v build attempts...", len(tasks))
+					etac := eta.New(int64(len(tasks)))
+					rebuilder := NewRebuilder(ctx, client, concurrency, waitDuration)
+					for _, task := range tasks {
+						rebuilder.Rebuild(task, etac)
+					}
+					summary := rebuilder.Wait()
+					summary.Print()
+
+					if reportPath := c.String("report"); reportPath != "" {
+						if err := summary.WriteToFile(reportPath); err != nil {
+							panic(err)
+						}
+						Errorln(Sf(PurpleBG("Wrote rebuild report to %s"), reportPath))
+					}
+					if len(summary.Failed) > 0 {
+						return partialFailuref("%v build attempts failed", len(summary.Failed))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "diff-followed",
+				Usage: "Compare the followed projects with a list of desired repos (from file), and optionally apply the changes.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "apply",
+						Usage: "Follow the missing repos, and unfollow the projects that are not in the list.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					filepaths := []string(c.Args())
+					if len(filepaths) == 0 {
+						Fataln("Must provide at least one file with the list of desired repos")
+					}
+
+					desired := make([]string, 0)
+					for _, raw := range Deduplicate(mustLoadTargetsFromFilepaths(filepaths...)) {
+						parsed, err := urlparse.Parse(raw, false)
+						if err != nil {
+							panic(err)
+						}
+						if parsed.Repo == "" {
+							Warnf("%s is not a repo; skipping", raw)
+							continue
+						}
+						desired = append(desired, parsed.URL())
+					}
+					desired = Deduplicate(desired)
+
+					cache, err := getFollowedCacheOf(client, false)
+					if err != nil {
+						panic(err)
+					}
+
+					diff := planFollowedDiff(desired, cache)
+					// The blacklisted repos are not followed; but they are still
+					// desired, so they are not unfollowed if already followed:
+					diff.ToFollow = blacklist.Filter(diff.ToFollow)
+
+					for _, repoURL := range diff.ToFollow {
+						Sfln("%s %s", Lime("+"), repoURL)
+					}
+					for _, pr := range diff.ToUnfollow {
+						Sfln("%s %s", RedBG("-"), pr.ExternalURL.URL)
+					}
+					for _, proto := range diff.ToUnfollowProto {
+						Sfln("%s %s (proto)", RedBG("-"), proto.CloneURL)
+					}
+					for _, repoURL := range diff.ProtoOnly {
+						Sfln("%s %s (proto-only)", OrangeBG("~"), repoURL)
+					}
+					Infof(
+						"%v desired repos: %v to follow, %v proto-only; %v projects and %v proto-projects to unfollow",
+						len(desired),
+						len(diff.ToFollow),
+						len(diff.ProtoOnly),
+						len(diff.ToUnfollow),
+						len(diff.ToUnfollowProto),
+					)
+
+					if !c.Bool("apply") {
+						return nil
+					}
+					totalToBeUnfollowed := len(diff.ToUnfollow) + len(diff.ToUnfollowProto)
+					if len(diff.ToFollow) == 0 && totalToBeUnfollowed == 0 {
+						Successf("Followed projects already match the list")
+						return nil
+					}
+					if !c.Bool("y") {
+						mustConfirmYes("Do you want to apply the changes?")
+					}
+
+					failedToFollow := 0
+					if len(diff.ToFollow) > 0 {
+						etac := eta.New(int64(len(diff.ToFollow)))
+						for i, repoURL := range diff.ToFollow {
+							if stopOnInterrupt(ctx, "diff-followed", diff.ToFollow[i:]) {
+								break
+							}
+							envelope, err := follower(repoURL, etac)
+							if err != nil {
+								failedToFollow++
+								continue
+							}
+							if envelope != nil && !envelope.IsKnown() {
+								// Sleep to avoid triggering too many new builds:
+								pacer.Wait(envelope)
+							}
+						}
+					}
+
+					var unfollowErr error
+					if totalToBeUnfollowed > 0 {
+						etac := eta.New(int64(totalToBeUnfollowed))
+						lgtm.RateLimiter = ratelimit.New(3, ratelimit.WithSlack(3))
+						unfollower := NewUnfollower(ctx, client, 6)
+						for _, pr := range diff.ToUnfollow {
+							unfollower.Unfollow(false, pr.Key, pr.ExternalURL.URL, etac)
+						}
+						for _, proto := range diff.ToUnfollowProto {
+							unfollower.Unfollow(true, proto.Key, proto.CloneURL, etac)
+						}
+						unfollowErr = unfollower.Wait()
+					}
+					if failedToFollow > 0 {
+						if unfollowErr != nil {
+							return partialFailuref("failed to follow %v of %v projects; %s", failedToFollow, len(diff.ToFollow), unfollowErr)
+						}
+						return partialFailuref("failed to follow %v of %v projects", failedToFollow, len(diff.ToFollow))
+					}
+					return unfollowErr
+				},
+			},
+			{
+				Name:  "watch",
+				Usage: "Keep the repos of one or more GitHub owners followed (runs continuously).",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "owner",
+						Usage: "GitHub owner (user or org) whose repos to keep followed (can use flag multiple times).",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "Interval between syncs.",
+						Value: 6 * time.Hour,
+					},
+					&cli.BoolFlag{
+						Name:  "unfollow-removed",
+						Usage: "Unfollow projects whose repo does not exist anymore on GitHub.",
+					},
+					&cli.BoolFlag{
+						Name:  "unfollow-archived",
+						Usage: "Unfollow projects whose repo is archived on GitHub.",
+					},
+					&cli.StringFlag{
+						Name:  "add-to-list",
+						Usage: "Name of the list to which add the followed projects (created if it does not exist).",
+					},
+					&cli.BoolFlag{
+						Name:  "once",
+						Usage: "Sync only once and exit.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					owners := mustStringSliceNotNil(c.StringSlice("owner"))
+					if len(owners) == 0 {
+						return errors.New("--owner not set")
+					}
+					interval := c.Duration("interval")
+					unfollowRemoved := c.Bool("unfollow-removed")
+					unfollowArchived := c.Bool("unfollow-archived")
+
+					listAdder := mustNewListAdder(client, c.String("add-to-list"))
+
+					syncOwners := func() error {
+						cache, err := getFollowedCacheOf(client, false)
+						if err != nil {
+							return err
+						}
+
+						failed := 0
+						for _, owner := range owners {
+							if ctx.Err() != nil {
+								return nil
+							}
+							Infof("Syncing repos of %s ...", owner)
+							repos, err := GithubGetRepoList(owner)
+							if err != nil {
+								Errorf("Error while getting repo list for owner %q: %s", owner, err)
+								continue
+							}
+							plan := planOwnerSync(owner, repos, cache, unfollowRemoved, unfollowArchived)
+							plan.ToFollow = blacklist.Filter(plan.ToFollow)
+							Infof(
+								"%s has %v repos; will follow %v projects, and unfollow %v projects and %v proto-projects",
+								owner,
+								len(plan.Current),
+								len(plan.ToFollow),
+								len(plan.ToUnfollow),
+								len(plan.ToUnfollowProto),
+							)
+							listAdder.AddFollowed(cache, plan.Current)
+
+							etac := eta.New(int64(len(plan.ToFollow)))
+							for i, repoURL := range plan.ToFollow {
+								if stopOnInterrupt(ctx, "watch", plan.ToFollow[i:]) {
+									return nil
+								}
+								envelope, err := follower(repoURL, etac)
+								if err != nil {
+									failed++
+									continue
+								}
+								listAdder.AddEnvelope(envelope)
+								if envelope != nil && !envelope.IsKnown() {
+									// Sleep to avoid triggering too many new builds:
+									pacer.Wait(envelope)
+								}
+							}
+
+							totalToBeUnfollowed := len(plan.ToUnfollow) + len(plan.ToUnfollowProto)
+							if totalToBeUnfollowed > 0 {
+								etac := eta.New(int64(totalToBeUnfollowed))
+								unfollower := NewUnfollower(ctx, client, 6)
+								for _, pr := range plan.ToUnfollow {
+									unfollower.Unfollow(false, pr.Key, pr.ExternalURL.URL, etac)
+								}
+								for _, proto := range plan.ToUnfollowProto {
+									unfollower.Unfollow(true, proto.Key, proto.CloneURL, etac)
+								}
+								unfollower.Wait()
+							}
+						}
+						if err := listAdder.Close(); err != nil {
+							return err
+						}
+						if failed > 0 {
+							return partialFailuref("failed to follow %v projects", failed)
+						}
+						return nil
+					}
+
+					for {
+						took := NewTimer()
+						err := syncOwners()
+						if err != nil {
+							Errorf("Error while syncing: %s", err)
+						} else {
+							Successf("Synced %v owners; took %s", len(owners), took())
+						}
+						if c.Bool("once") {
+							return err
+						}
+						if ctx.Err() != nil {
+							return nil
+						}
+						Infof("Next sync at %s", time.Now().Add(interval).Format(time.RFC3339))
+						select {
+						case <-time.After(interval):
+						case <-ctx.Done():
+							return nil
+						}
+					}
+				},
+			},
+			{
+				Name:  "followed",
+				Usage: "List all followed projects.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Go template for each project (e.g. '{{.Slug}} {{join .Languages \",\"}}').",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					tmpl, err := newOutputTemplate(c.String("format"))
+					if err != nil {
+						return err
+					}
+
+					took := NewTimer()
+					Infof("Getting list of followed projects...")
+					projects, protoProjects, err := client.ListFollowedProjects()
+					if err != nil {
+						panic(err)
+					}
+					Successf(
+						"%v projects and %v proto-projects; took %s",
+						len(projects),
+						len(protoProjects),
+						took(),
+					)
+
+					if tmpl != nil {
+						for _, proto := range protoProjects {
+							if err := printWithTemplate(tmpl, newProtoProjectOutput(proto)); err != nil {
+								return err
+							}
+						}
+						for _, pr := range projects {
+							if err := printWithTemplate(tmpl, newProjectOutput(pr)); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+
+					for _, proto := range protoProjects {
+						Sfln("%s", proto.CloneURL)
+					}
+					for _, pr := range projects {
+						Sfln("%s", pr.ExternalURL.URL)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "lists",
+				Usage: "List all lists of projects.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "with-counts",
+						Usage: "Also get the number of projects in each list.",
+					},
+					&cli.BoolFlag{
+						Name:  "with-langs",
+						Usage: "Also get the number of projects per language in each list (implies --with-counts).",
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "Sort lists by: name, count.",
+						Value: "name",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the lists as json.",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Go template for each list (e.g. '{{.Name}} {{.ProjectCount}}').",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					tmpl, err := newOutputTemplate(c.String("format"))
+					if err != nil {
+						return err
+					}
+
+					withLangs := c.Bool("with-langs")
+					withCounts := c.Bool("with-counts") || withLangs
+					sortBy := c.String("sort")
+					if sortBy == "count" && !withCounts {
+						return errors.New("--sort=count requires --with-counts")
+					}
+
+					took := NewTimer()
+					Infof("Getting list of lists...")
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					Successf("%v lists; took %s", len(lists), took())
+
+					stats := make([]*lgtm.ListStats, 0)
+					for listIndex, list := range lists {
+						if !withCounts {
+							stats = append(stats, &lgtm.ListStats{
+								Name: list.Name,
+								Key:  list.Key,
+							})
+							continue
+						}
+						Infof(
+							"Getting stats of %q list (%v/%v)...",
+							list.Name,
+							listIndex+1,
+							len(lists),
+						)
+						listStats, err := client.GetListStats(list, withLangs)
+						if err != nil {
+							panic(err)
+						}
+						stats = append(stats, listStats)
+					}
+					if err := sortListStats(stats, sortBy); err != nil {
+						return err
+					}
+
+					if c.Bool("json") {
+						JSON(true, stats)
+						return nil
+					}
+					if tmpl != nil {
+						for _, list := range stats {
+							if err := printWithTemplate(tmpl, list); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+
+					if !withCounts {
+						Errorln(Bold("NAME | KEY"))
+						for _, list := range stats {
+							Sfln(
+								"%s | %s",
+								list.Name,
+								list.Key,
+							)
+						}
+						return nil
+					}
+
+					totalProjects := 0
+					totalLanguages := make(map[string]int)
+					Errorln(Bold("NAME | KEY | PROJECTS"))
+					for _, list := range stats {
+						Sfln(
+							"%s | %s | %v",
+							list.Name,
+							list.Key,
+							list.ProjectCount,
+						)
+						totalProjects += list.ProjectCount
+						for _, lang := range sortedByCount(list.Languages) {
+							Sfln("    %s: %v", lang, list.Languages[lang])
+							totalLanguages[lang] += list.Languages[lang]
+						}
+					}
+					Successf("Total: %v projects in %v lists", totalProjects, len(stats))
+					for _, lang := range sortedByCount(totalLanguages) {
+						Successf("    %s: %v", lang, totalLanguages[lang])
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "alert-summary",
+				Usage: "Summarize the alerts of the projects of a list (or of all followed projects) by severity and rule.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "list",
+						Usage: "Name of the list of projects to summarize; if not set, all followed projects are summarized.",
+					},
+					&cli.StringFlag{
+						Name:  "lang, l",
+						Usage: "Only summarize alerts of this language.",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "Number of projects and rules to print (0 for all).",
+						Value: 20,
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent stats requests.",
+						Value: 4,
+					},
+					&cli.IntFlag{
+						Name:  "rps",
+						Usage: "Max number of stats requests per second.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries of the stats requests that failed with a transient error.",
+						Value: 3,
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format: table, csv, json.",
+						Value: "table",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					listName := c.String("list")
+					lang := ToLower(c.String("lang"))
+					format := c.String("format")
+					switch format {
+					case "table", "csv", "json":
+					default:
+						return fmt.Errorf("unknown --format: %q", format)
+					}
+					if c.Int64("concurrency") < 1 {
+						return errors.New("--concurrency must be at least 1")
+					}
+
+					took := NewTimer()
+					var projects []*lgtm.Project
+					if listName != "" {
+						Infof("Getting projects of %q list...", listName)
+						got, err := client.GetProjectsInSelection(listName)
+						if err != nil {
+							panic(err)
+						}
+						projects = got
+					} else {
+						Infof("Getting list of followed projects...")
+						got, _, err := client.ListFollowedProjects()
+						if err != nil {
+							panic(err)
+						}
+						projects = got
+					}
+					Infof("Got %v projects; took %s", len(projects), took())
+
+					if lang != "" {
+						withLang := make([]*lgtm.Project, 0)
+						for _, pr := range projects {
+							if pr.SupportsLanguage(lang) {
+								withLang = append(withLang, pr)
+							}
+						}
+						projects = withLang
+					}
+
+					fetcher := NewStatsFetcher(client, "alert-summary", c.Int64("concurrency"), c.Int("rps"), c.Int("retries"))
+					results := fetcher.Fetch(ctx, projects)
+
+					summary := NewAlertSummary()
+					etac := eta.New(int64(len(results)))
+				ProjectLoop:
+					for _, result := range results {
+						if ctx.Err() != nil {
+							Warnf("Stopped; the summary only includes the projects processed so far")
+							break ProjectLoop
+						}
+						etac.Done(1)
+						if result.Err != nil {
+							continue ProjectLoop
+						}
+						pr := result.Project
+						Infof(
+							"[%s](%v/%v) Getting alerts of %s ...",
+							etac.GetFormattedPercentDone(),
+							etac.GetDone(),
+							etac.GetTotal(),
+							pr.DisplayName,
+						)
+						for _, state := range result.Stats.LanguageStates {
+							if lang != "" && state.Lang != lang {
+								continue
+							}
+							if state.TotalAlerts == 0 {
+								summary.Add(pr, state.Lang, nil)
+								continue
+							}
+							exp, err := getCommitSARIF(client, pr, state.Lang, state.RevisionName.Value)
+							var counts []*RuleAlertCount
+							if err == nil {
+								counts, err = countSARIFAlertsByRule(exp.SARIF)
+							}
+							if err != nil {
+								metrics.Inc("errors_total", "op", "alert-summary")
+								Errorf(
+									"error while getting %s alerts of %s: %s",
+									state.Lang,
+									pr.DisplayName,
+									err,
+								)
+								continue
+							}
+							summary.Add(pr, state.Lang, counts)
+						}
+					}
+					summary.Sort()
+					if failed := countStatsErrors(results); failed > 0 && ctx.Err() == nil {
+						Warnf("Could not get the stats of %v of %v projects; they are not included in the summary", failed, len(results))
+					}
+
+					switch format {
+					case "json":
+						JSON(true, summary)
+					case "csv":
+						if err := summary.WriteCSV(os.Stdout); err != nil {
+							Fatalf("Error writing CSV: %s", err)
+						}
+					default:
+						summary.Print(c.Int("top"))
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "create-list",
+				Usage: "Create a new list.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Name of the list to be created.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					name := c.Args().First()
+					if name == "" {
+						return errors.New("name not provided")
+					}
+
+					took := NewTimer()
+					Infof("Creating new list with name %q...", name)
+					err := client.CreateProjectSelection(name)
+					if err != nil {
+						panic(err)
+					}
+					Successf(
+						"Created new list %q; took %s",
+						name,
+						took(),
+					)
+
+					return nil
+				},
+			},
+			{
+				Name:      "delete-list",
+				Usage:     "Delete lists by name, glob of the name (e.g. 'tmp-*'), or key.",
+				ArgsUsage: "[names or globs...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Name of the list to be deleted.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "key",
+						Usage: "Key of a list to be deleted (can use flag multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					patterns := []string(c.Args())
+					if name := c.String("name"); name != "" {
+						patterns = append(patterns, name)
+					}
+					keys := mustStringSliceNotNil(c.StringSlice("key"))
+					if len(patterns) == 0 && len(keys) == 0 {
+						return errors.New("name not provided")
+					}
+
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					selected, unmatched := selectLists(lists, patterns, keys)
+					for _, what := range unmatched {
+						Warnf("No list matches %s", what)
+					}
+					if len(selected) == 0 {
+						return errors.New("no lists to delete")
+					}
+
+					Infof("The following %v lists will be deleted:", len(selected))
+					for _, list := range selected {
+						Sfln("    %s (key %s)", list.Name, list.Key)
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to delete %v lists?", len(selected)))
+					}
+
+					var failed int
+					for _, list := range selected {
+						took := NewTimer()
+						Infof("Deleting list with name %q...", list.Name)
+						if err := client.DeleteProjectSelection(list.Name); err != nil {
+							failed++
+							metrics.Inc("errors_total", "op", "delete-list")
+							Errorf("Error while deleting list %q: %s", list.Name, err)
+							continue
+						}
+						Successf(
+							"Deleted list %q; took %s",
+							list.Name,
+							took(),
+						)
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v lists could not be deleted", failed, len(selected))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "lang-stats",
+				Usage: "Print the number of projects, churn and (optionally) lines of code per language of the followed projects, and the biggest projects.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "list",
+						Usage: "Name of the list of projects; if not set, all followed projects are included.",
+					},
+					&cli.BoolFlag{
+						Name:  "with-lines",
+						Usage: "Also get the lines of code of each project (one request per project).",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "Number of biggest projects to print.",
+						Value: 10,
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent stats requests.",
+						Value: 4,
+					},
+					&cli.IntFlag{
+						Name:  "rps",
+						Usage: "Max number of stats requests per second.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries of the stats requests that failed with a transient error.",
+						Value: 3,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the stats as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					took := NewTimer()
+					var projects []*lgtm.Project
+					if listName := c.String("list"); listName != "" {
+						Infof("Getting projects of %q list...", listName)
+						got, err := client.GetProjectsInSelection(listName)
+						if err != nil {
+							panic(err)
+						}
+						projects = got
+					} else {
+						Infof("Getting list of followed projects...")
+						cache, err := getFollowedCacheOf(client, noCache)
+						if err != nil {
+							panic(err)
+						}
+						projects = cache.Projects()
+					}
+					Infof("Got %v projects; took %s", len(projects), took())
+
+					report := NewLanguageStatsReport()
+					if !c.Bool("with-lines") {
+						for _, pr := range projects {
+							report.Add(pr, nil)
+						}
+					} else {
+						if c.Int64("concurrency") < 1 {
+							return errors.New("--concurrency must be at least 1")
+						}
+						fetcher := NewStatsFetcher(client, "lang-stats", c.Int64("concurrency"), c.Int("rps"), c.Int("retries"))
+						results := fetcher.Fetch(ctx, projects)
+						for _, result := range results {
+							if result.Err == errStatsInterrupted {
+								continue
+							}
+							if result.Err != nil {
+								report.Add(result.Project, nil)
+								continue
+							}
+							lines := make(map[string]int)
+							for _, state := range result.Stats.LanguageStates {
+								lines[state.Lang] += state.TotalLines
+							}
+							report.Add(result.Project, lines)
+						}
+						if ctx.Err() != nil {
+							Warnf("Stopped; the stats only include the projects processed so far")
+						} else if failed := countStatsErrors(results); failed > 0 {
+							Warnf("Could not get the lines of %v of %v projects", failed, len(results))
+						}
+					}
+					report.Finalize(c.Int("top"))
+
+					if c.Bool("json") {
+						JSON(true, report)
+						return nil
+					}
+					report.Print()
+					return nil
+				},
+			},
+			{
+				Name:  "churn",
+				Usage: "Rank the followed projects (or the projects of a list) by code churn per language.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "list",
+						Usage: "Name of the list of projects; if not set, all followed projects are ranked.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "lang, l",
+						Usage: "Only rank the churn of this language (can use flag multiple times).",
+					},
+					&cli.IntFlag{
+						Name:  "min-churn",
+						Usage: "Exclude the projects with less churn than this (per language).",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "Number of projects to print or export (default: all).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the ranking as CSV.",
+					},
+					&cli.BoolFlag{
+						Name:  "csv",
+						Usage: "Print the ranking as CSV.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					took := NewTimer()
+					var projects []*lgtm.Project
+					if listName := c.String("list"); listName != "" {
+						Infof("Getting projects of %q list...", listName)
+						got, err := client.GetProjectsInSelection(listName)
+						if err != nil {
+							panic(err)
+						}
+						projects = got
+					} else {
+						Infof("Getting list of followed projects...")
+						cache, err := getFollowedCacheOf(client, noCache)
+						if err != nil {
+							panic(err)
+						}
+						projects = cache.Projects()
+					}
+					Infof("Got %v projects; took %s", len(projects), took())
+
+					langs := lowerAll(mustStringSliceNotNil(c.StringSlice("lang")))
+					rows := rankProjectsByChurn(projects, langs, c.Int("min-churn"))
+					if top := c.Int("top"); top > 0 && len(rows) > top {
+						rows = rows[:top]
+					}
+
+					if output := c.String("output"); output != "" {
+						var buf bytes.Buffer
+						if err := writeProjectChurnCSV(&buf, rows); err != nil {
+							panic(err)
+						}
+						if err := writeOutputFile(output, buf.Bytes(), "text/csv"); err != nil {
+							panic(err)
+						}
+						Successf("Exported the churn of %v projects to %s", len(rows), output)
+						return nil
+					}
+					if c.Bool("csv") {
+						if err := writeProjectChurnCSV(os.Stdout, rows); err != nil {
+							panic(err)
+						}
+						return nil
+					}
+					printProjectChurn(rows)
+					return nil
+				},
+			},
+			{
+				Name:      "list",
+				Usage:     "List projects inside lists, by name, glob of the name (e.g. 'go-*'), or key.",
+				ArgsUsage: "[names, globs or keys...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Go template for each project (e.g. '{{.Slug}} {{join .Languages \",\"}}').",
+					},
+					&cli.BoolFlag{
+						Name:  "merge",
+						Usage: "When multiple lists are selected, print their projects as a single deduplicated list, without per-list headers.",
+					},
+					&cli.StringFlag{
+						Name:  "diff",
+						Usage: "Name of another list: print the projects that are only in one of the two lists, and the ones in both.",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "With --diff, print the comparison as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					args := []string(c.Args())
+					if len(args) == 0 {
+						return errors.New("name not provided")
+					}
+
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					selected, unmatched := resolveLists(lists, args)
+					for _, arg := range unmatched {
+						Warnf("No list matches %q", arg)
+					}
+					if len(selected) == 0 {
+						return errors.New("no lists found")
+					}
+
+					if other := c.String("diff"); other != "" {
+						if len(selected) != 1 {
+							return fmt.Errorf("--diff requires exactly one list, but %v were selected", len(selected))
+						}
+						otherLists, _ := resolveLists(lists, []string{other})
+						if len(otherLists) != 1 {
+							return fmt.Errorf("--diff: %q must match exactly one list", other)
+						}
+						name, other := selected[0].Name, otherLists[0].Name
+						took := NewTimer()
+						Infof("Comparing %q and %q lists...", name, other)
+						diff, err := diffLists(client, name, other)
+						if err != nil {
+							panic(err)
+						}
+						Infof("took %s", took())
+						if c.Bool("json") {
+							JSON(true, diff)
+						} else {
+							diff.Print()
+						}
+						return nil
+					}
+					tmpl, err := newOutputTemplate(c.String("format"))
+					if err != nil {
+						return err
+					}
+					printProject := func(pr *lgtm.Project) error {
+						if tmpl != nil {
+							return printWithTemplate(tmpl, newProjectOutput(pr))
+						}
+						Sfln(
+							"%s",
+							pr.ExternalURL.URL,
+						)
+						return nil
+					}
+
+					withHeaders := len(selected) > 1 && !c.Bool("merge")
+					printed := make(map[string]bool)
+					for _, list := range selected {
+						took := NewTimer()
+						Infof("Getting projects of %q list...", list.Name)
+						projects, err := client.GetProjectsInSelection(list.Name)
+						if err != nil {
+							panic(err)
+						}
+						Infof(
+							"List %q contains %v projects; took %s",
+							list.Name,
+							len(projects),
+							took(),
+						)
+
+						if withHeaders {
+							Sfln("# %s (%v projects)", list.Name, len(projects))
+						}
+						for _, pr := range projects {
+							if c.Bool("merge") {
+								if printed[pr.Key] {
+									continue
+								}
+								printed[pr.Key] = true
+							}
+							if err := printProject(pr); err != nil {
+								return err
+							}
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "add-to-list",
+				Usage: "Add built projects to a list.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "name",
+						Usage: "Name of the list to which add the projects (can use multiple times).",
+					},
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (- for stdin).",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the list of target repositories.",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of chunks of projects to add to a list at the same time.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries for each chunk of projects that could not be added.",
+						Value: 3,
+					},
+					&cli.StringFlag{
+						Name:  "failed-output",
+						Usage: "Filepath to which save the list of repositories that could not be added.",
+					},
+					&cli.Int64Flag{
+						Name:  "resolve-concurrency",
+						Usage: "Max number of concurrent lookups of repos that are not followed.",
+						Value: 8,
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Filepath (or s3://, gs://, https:// URL) where to save the json report of added/skipped repos.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 {
+						return errors.New("--concurrency must be at least 1")
+					}
+					resolveConcurrency := c.Int64("resolve-concurrency")
+					if resolveConcurrency < 1 {
+						return errors.New("--resolve-concurrency must be at least 1")
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					hasRepoListFilepath := c.IsSet("f")
+					if hasRepoListFilepath {
+						repoListFilepaths := mustStringSliceNotNil(c.StringSlice("f"))
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(repoListFilepaths...)...)
+					}
+					repoURLsRaw = Deduplicate(repoURLsRaw)
+
+					repoURLs := make([]string, 0)
+					for _, raw := range repoURLsRaw {
+						owner, isWholeUser, err := urlparse.IsUserOnly(raw)
+						if err != nil {
+							panic(err)
+						}
+						if isWholeUser {
+							Debugf("Getting list of repos for %s ...", owner)
+							repos, err := GithubGetRepoList(owner)
+							if err != nil {
+								panic(fmt.Errorf("error while getting repo list for user %q: %s", owner, err))
+							}
+							Debugf("%s has %v repos", owner, len(repos))
+							for _, repo := range repos {
+								//repoURLs = append(repoURLs, repo.GetFullName()) // e.g. "kubernetes/dashboard"
+								repoURLs = append(repoURLs, repo.GetHTMLURL()) // e.g. "https://github.com/kubernetes/dashboard"
+							}
+						} else {
+							parsed, err := urlparse.Parse(raw, false)
+							if err != nil {
+								panic(err)
+							}
+							repoURLs = append(repoURLs, parsed.URL())
+						}
+					}
+
+					alreadyFollowedProjectKeys := make(map[string][]string, 0)
+
+					listNames := mustStringSliceNotNil(c.StringSlice("name"))
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+
+					// Check if all lists exist;
+					// if a list does NOT exist, ask if want it to be created:
+					for _, wantedListName := range listNames {
+						exists := lists.ByName(wantedListName) != nil
+						if !exists {
+							Warnf("The %q list does not exist.", wantedListName)
+							yes, err := askYesNo(Sf("Do you want to create %q list?", wantedListName))
+							if err != nil {
+								return err
+							}
+							if yes {
+								// Create the new list:
+								took := NewTimer()
+								err := client.CreateProjectSelection(wantedListName)
+								if err != nil {
+									panic(err)
+								}
+								Infof(
+									"Created new list %q; took %s",
+									wantedListName,
+									took(),
+								)
+							}
+						} else {
+							// Get list of projects inside the list, and cache them:
+							took := NewTimer()
+							Infof("Getting projects of %q list...", wantedListName)
+							resp, err := client.ListProjectsInSelection(wantedListName)
+							if err != nil {
+								panic(err)
+							}
+							Infof("took %s", took())
+							alreadyFollowedProjectKeys[wantedListName] = resp.ProjectKeys
+						}
+					}
+					{ // Refresh list of selections:
+						lists, err = client.ListProjectSelections()
+						if err != nil {
+							panic(err)
+						}
+					}
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					hasCache := err == nil && cache != nil
+					if !hasCache {
+						if ignoreFollowedErrors {
+							Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+						} else {
+							panic(err)
+						}
+					}
+
+					repoURLs = blacklist.Filter(repoURLs)
+					saveTargetListToTempFile(c.String("output"), "add-to-list_urls", repoURLs)
+
+					report := NewAddToListReport()
+					projectKeys := make([]string, 0)
+					projectURLs := make(map[string]string)
+					addProject := func(pr *lgtm.Project, repoURL string) {
+						projectKeys = append(projectKeys, pr.Key)
+						projectURLs[pr.Key] = repoURL
+					}
+
+					// Only built projects can be added to a list.
+					if !hasCache {
+						cache = nil
+					}
+					for _, res := range resolveProjects(ctx, client, cache, repoURLs, resolveConcurrency) {
+						switch {
+						case res.Project != nil:
+							addProject(res.Project, res.URL)
+						case res.Proto != nil:
+							Debugf("%s is a proto-project; cannot be added to list.", trimGithubPrefix(res.URL))
+							report.Proto = append(report.Proto, res.URL)
+						case res.NotBuilt:
+							Debugf("Project %s is not a built project; cannot be added to list.", trimGithubPrefix(res.URL))
+							report.NotBuilt = append(report.NotBuilt, res.URL)
+						default:
+							Debugf("Error while looking up %s: %s", res.URL, res.Error)
+							report.Unresolved = append(report.Unresolved, res)
+						}
+					}
+
+					saveTargetListToTempFile(c.String("output"), "add-to-list_keys", projectKeys)
+
+					{
+						for _, wantedListName := range listNames {
+							// Add to one list at a time:
+							list := lists.ByName(wantedListName)
+							if list == nil {
+								continue
+							}
+
+							notFollowedByThisList := ref.Filter(projectKeys,
+								func(i int, prKey string) bool {
+									notFollowed := !SliceContains(alreadyFollowedProjectKeys[wantedListName], prKey)
+									return notFollowed
+								}).([]string)
+
+							addedCount, failedChunks := addToSelectionInChunks(
+								ctx,
+								client,
+								list,
+								notFollowedByThisList,
+								concurrency,
+								c.Int("retries"),
+							)
+							report.Added[wantedListName] = addedCount
+							for _, chunk := range failedChunks {
+								Errorf(
+									"Chunk %v (%v projects) was not added to %q list: %s",
+									chunk.Index+1,
+									len(chunk.Keys),
+									wantedListName,
+									chunk.Error,
+								)
+								for _, key := range chunk.Keys {
+									report.Failed = append(report.Failed, projectURLs[key])
+								}
+							}
+						}
+					}
+
+					report.Failed = Deduplicate(report.Failed)
+					report.Print()
+					if reportPath := c.String("report"); reportPath != "" {
+						if err := report.WriteToFile(reportPath); err != nil {
+							panic(err)
+						}
+						Errorln(Sf(PurpleBG("Wrote add-to-list report to %s"), reportPath))
+					}
+
+					if len(report.Failed) > 0 {
+						saveTargetListToTempFile(c.String("failed-output"), "add-to-list-failed", report.Failed)
+						return partialFailuref("%v projects could not be added to the lists", len(report.Failed))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "export-lists",
+				Usage: "Export lists (with the URLs of their projects) to a json file.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the lists; if not set, they are printed.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "list",
+						Usage: "Only export the list with this name (can use multiple times).",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					onlyLists := mustStringSliceNotNil(c.StringSlice("list"))
+
+					user, err := client.GetLoggedInUser()
+					if err != nil {
+						panic(err)
+					}
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+
+					export := &ListsExport{
+						ExportedAt: time.Now(),
+						Account:    user.Person.Slug,
+						Lists:      make([]*ExportedList, 0),
+					}
+					for listIndex, list := range lists {
+						if len(onlyLists) > 0 && !SliceContains(onlyLists, list.Name) {
+							continue
+						}
+						Infof(
+							"Getting projects of %q list (%v/%v)...",
+							list.Name,
+							listIndex+1,
+							len(lists),
+						)
+						projects, err := client.GetProjectsInSelection(list.Name)
+						if err != nil {
+							panic(err)
+						}
+						exported := &ExportedList{
+							Name:     list.Name,
+							Projects: make([]string, 0, len(projects)),
+						}
+						for _, pr := range projects {
+							exported.Projects = append(exported.Projects, pr.ExternalURL.URL)
+						}
+						sort.Strings(exported.Projects)
+						export.Lists = append(export.Lists, exported)
+					}
+					for _, name := range onlyLists {
+						if lists.ByName(name) == nil {
+							Warnf("The %q list does not exist.", name)
+						}
+					}
+
+					output := c.String("output")
+					if output == "" {
+						JSON(true, export)
+						return nil
+					}
+					js, err := json.MarshalIndent(export, "", "  ")
+					if err != nil {
+						return err
+					}
+					if err := writeOutputFile(output, js, "application/json"); err != nil {
+						return err
+					}
+					Successf("Exported %v lists to %s", len(export.Lists), output)
+					return nil
+				},
+			},
+			{
+				Name:      "import-lists",
+				Usage:     "Recreate the lists of a file created by export-lists (e.g. on another account).",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "list",
+						Usage: "Only import the list with this name (can use multiple times).",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of chunks of projects to add to a list at the same time.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries for each chunk of projects that could not be added.",
+						Value: 3,
+					},
+					&cli.Int64Flag{
+						Name:  "resolve-concurrency",
+						Usage: "Max number of concurrent lookups of repos that are not followed.",
+						Value: 8,
+					},
+					&cli.StringFlag{
+						Name:  "failed-output",
+						Usage: "Filepath to which save the list of repositories that could not be added.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					exportFilepath := c.Args().First()
+					if exportFilepath == "" {
+						return errors.New("file not provided")
+					}
+					content, err := ioutil.ReadFile(exportFilepath)
+					if err != nil {
+						return err
+					}
+					var export ListsExport
+					if err := json.Unmarshal(content, &export); err != nil {
+						return fmt.Errorf("error while parsing %s: %w", exportFilepath, err)
+					}
+
+					concurrency := c.Int64("concurrency")
+					resolveConcurrency := c.Int64("resolve-concurrency")
+					if concurrency < 1 || resolveConcurrency < 1 {
+						return errors.New("--concurrency and --resolve-concurrency must be at least 1")
+					}
+					onlyLists := mustStringSliceNotNil(c.StringSlice("list"))
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						Warnf("Could not load list of followed projects (%s); all projects will be looked up on lgtm.com.", err)
+						cache = nil
+					}
+
+					failedURLs := make([]string, 0)
+					for _, exported := range export.Lists {
+						if len(onlyLists) > 0 && !SliceContains(onlyLists, exported.Name) {
+							continue
+						}
+						if ctx.Err() != nil {
+							Warnf("Interrupted; not importing the remaining lists")
+							break
+						}
+						Infof("Importing %q list (%v projects)...", exported.Name, len(exported.Projects))
+						imported, err := importList(ctx, client, cache, exported, concurrency, resolveConcurrency, c.Int("retries"))
+						if err != nil {
+							Errorf("Error while importing %q list: %s", exported.Name, err)
+							failedURLs = append(failedURLs, exported.Projects...)
+							continue
+						}
+						Successf(
+							"%q: added %v projects (%v were already in the list)",
+							imported.Name,
+							imported.Added,
+							imported.AlreadyIn,
+						)
+						if len(imported.Skipped) > 0 {
+							Warnf("%q: %v projects were skipped (not built, or not found)", imported.Name, len(imported.Skipped))
+						}
+						failedURLs = append(failedURLs, imported.Failed...)
+					}
+
+					if len(failedURLs) > 0 {
+						saveTargetListToTempFile(c.String("failed-output"), "import-lists-failed", Deduplicate(failedURLs))
+						return partialFailuref("%v projects could not be added to the lists", len(failedURLs))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "lists-sync",
+				Usage:     "Create and update lists to match a YAML manifest that declares them.",
+				ArgsUsage: "<manifest.yaml>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "prune",
+						Usage: "Also remove from the lists the projects that are not declared in the manifest.",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print the plan (and save it to --report).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of chunks of projects to add to a list at the same time.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries for each chunk of projects that could not be added.",
+						Value: 3,
+					},
+					&cli.StringFlag{
+						Name:  "failed-output",
+						Usage: "Filepath to which save the list of repositories that could not be added.",
+					},
+					&cli.Int64Flag{
+						Name:  "resolve-concurrency",
+						Usage: "Max number of concurrent lookups of repos that are not followed.",
+						Value: 8,
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save a json report of the plan.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 {
+						return errors.New("--concurrency must be at least 1")
+					}
+					resolveConcurrency := c.Int64("resolve-concurrency")
+					if resolveConcurrency < 1 {
+						return errors.New("--resolve-concurrency must be at least 1")
+					}
+					manifestPath := c.Args().First()
+					if manifestPath == "" {
+						return errors.New("the manifest filepath is required")
+					}
+					manifest, err := loadListsManifest(manifestPath)
+					if err != nil {
+						return err
+					}
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						panic(err)
+					}
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+
+					plans := make([]*ListSyncPlan, 0)
+					for _, ml := range manifest.Lists {
+						Infof("Resolving members of %q list...", ml.Name)
+						plan, err := planListSync(ctx, client, cache, lists, ml, c.Bool("prune"), resolveConcurrency)
+						if err != nil {
+							return err
+						}
+						plans = append(plans, plan)
+					}
+
+					writeReport := func() {
+						if c.String("report") == "" {
+							return
+						}
+						js, err := json.MarshalIndent(plans, "", "  ")
+						if err != nil {
+							panic(err)
+						}
+						if err := writeOutputFile(c.String("report"), js, "application/json"); err != nil {
+							Errorf("Error while saving report: %s", err)
+						}
+					}
+
+					toApply := make([]*ListSyncPlan, 0)
+					for _, plan := range plans {
+						plan.Print()
+						if !plan.IsNoop() {
+							toApply = append(toApply, plan)
+						}
+					}
+					if len(toApply) == 0 {
+						writeReport()
+						Successf("Nothing to do")
+						return nil
+					}
+					if c.Bool("dry-run") {
+						Infof("Dry run: %v lists would be changed", len(toApply))
+						writeReport()
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to apply the changes to %v lists?", len(toApply)))
+					}
+
+					var failed int
+					failedToAdd := make([]string, 0)
+					for _, plan := range toApply {
+						failedURLs, err := plan.Apply(ctx, client, concurrency, c.Int("retries"))
+						if err != nil {
+							metrics.Inc("errors_total", "op", "lists_sync")
+							Errorf("Error while syncing %q: %s", plan.Name, err)
+							failedToAdd = append(failedToAdd, failedURLs...)
+							failed++
+							continue
+						}
+						Successf(
+							"Synced %q: added %v projects, removed %v",
+							plan.Name,
+							len(plan.ToAdd),
+							len(plan.ToRemove),
+						)
+					}
+					writeReport()
+					if len(failedToAdd) > 0 {
+						saveTargetListToTempFile(c.String("failed-output"), "lists-sync-failed", Deduplicate(failedToAdd))
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v lists could not be synced", failed, len(toApply))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "lists-gc",
+				Usage: "Remove from lists the projects that don't exist anymore on lgtm.com, or that are not followed anymore.",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "list",
+						Usage: "Only clean up the list with this name (can use multiple times).",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-unfollowed",
+						Usage: "Only remove the projects that don't exist anymore (keep the ones that are not followed).",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only print (and save to --report) what would be removed.",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save a json report of the removed projects.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					onlyLists := mustStringSliceNotNil(c.StringSlice("list"))
+					dryRun := c.Bool("dry-run")
+
+					var followedKeys map[string]bool
+					if !c.Bool("keep-unfollowed") {
+						cache, err := getFollowedCacheOf(client, noCache)
+						if err != nil {
+							panic(err)
+						}
+						followedKeys = make(map[string]bool)
+						for _, pr := range cache.Projects() {
+							followedKeys[pr.Key] = true
+						}
+					}
+
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					for _, name := range onlyLists {
+						if lists.ByName(name) == nil {
+							Warnf("The %q list does not exist.", name)
+						}
+					}
+
+					garbages := make([]*ListGarbage, 0)
+					var total int
+					for listIndex, list := range lists {
+						if len(onlyLists) > 0 && !SliceContains(onlyLists, list.Name) {
+							continue
+						}
+						Infof(
+							"Checking projects of %q list (%v/%v)...",
+							list.Name,
+							listIndex+1,
+							len(lists),
+						)
+						garbage, err := findListGarbage(client, list, followedKeys)
+						if err != nil {
+							panic(err)
+						}
+						if len(garbage.Keys()) == 0 {
+							continue
+						}
+						Infof(
+							"%q: %v projects don't exist anymore, %v are not followed",
+							list.Name,
+							len(garbage.Dead),
+							len(garbage.Unfollowed),
+						)
+						for _, repoURL := range garbage.Unfollowed {
+							Sfln("    %s", repoURL)
+						}
+						garbages = append(garbages, garbage)
+						total += len(garbage.Keys())
+					}
+
+					writeReport := func() {
+						if c.String("report") == "" {
+							return
+						}
+						js, err := json.MarshalIndent(garbages, "", "  ")
+						if err != nil {
+							panic(err)
+						}
+						if err := writeOutputFile(c.String("report"), js, "application/json"); err != nil {
+							Errorf("Error while saving report: %s", err)
+						}
+					}
+
+					if total == 0 {
+						Successf("Nothing to remove")
+						return nil
+					}
+					if dryRun {
+						Infof("Dry run: %v projects would be removed from %v lists", total, len(garbages))
+						writeReport()
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to remove %v projects from %v lists?", total, len(garbages)))
+					}
+
+					var failed int
+					for _, garbage := range garbages {
+						if err := garbage.Remove(client); err != nil {
+							Errorf("%s", err)
+							failed++
+							continue
+						}
+						Successf("Removed %v projects from %q", len(garbage.Keys()), garbage.Name)
+					}
+					writeReport()
+					if failed > 0 {
+						return partialFailuref("could not clean up %v lists", failed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "queue",
+				Usage: "Manage the follow queue (stored in --queue-file), filled by the follow-by-* commands with --enqueue-only.",
+				Subcommands: []cli.Command{
+					{
+						Name:  "list",
+						Usage: "List the queued targets, in the order they will be followed.",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Max number of targets to print (0 for all).",
+								Value: 20,
+							},
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the targets as json.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							queue, err := LoadFollowQueue(queueFilepath)
+							if err != nil {
+								return err
+							}
+							targets := queue.Targets
+							if limit := c.Int("limit"); limit > 0 && len(targets) > limit {
+								targets = targets[:limit]
+							}
+							if c.Bool("json") {
+								JSON(true, targets)
+								return nil
+							}
+							Infof("%v targets queued", len(queue.Targets))
+							for _, target := range targets {
+								Sfln(
+									"%s | priority %v | %v stars | %s",
+									trimGithubPrefix(target.URL),
+									target.Priority,
+									target.Stars,
+									target.Source,
+								)
+							}
+							return nil
+						},
+					},
+					{
+						Name:  "run",
+						Usage: "Follow the queued targets (highest priority first), up to the follow limit of the account.",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Max number of targets to follow in this run (0 for all).",
+							},
+							&cli.DurationFlag{
+								Name:  "interval",
+								Usage: "Wait duration between follows (in addition to --wait after new projects).",
+							},
+							&cli.BoolFlag{
+								Name:  "force, y",
+								Usage: "Don't ask for confirmation.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							queue, err := LoadFollowQueue(queueFilepath)
+							if err != nil {
+								return err
+							}
+							if len(queue.Targets) == 0 {
+								Infof("The queue is empty")
+								return nil
+							}
+
+							cache, err := getFollowedCache(noCache)
+							hasCache := err == nil && cache != nil
+							if !hasCache {
+								if ignoreFollowedErrors {
+									Warnf("Could not load list of followed projects. Continuing without list of followed projects.")
+								} else {
+									panic(err)
+								}
+							}
+
+							toBeFollowed := make([]string, 0, len(queue.Targets))
+							for _, target := range append([]*QueuedTarget{}, queue.Targets...) {
+								if hasCache && cache.HasAny(target.URL) {
+									// Already followed:
+									queue.Remove(target.URL)
+									continue
+								}
+								toBeFollowed = append(toBeFollowed, target.URL)
+							}
+							if limit := c.Int("limit"); limit > 0 && len(toBeFollowed) > limit {
+								toBeFollowed = toBeFollowed[:limit]
+							}
+							toBeFollowed = applyFollowQuota(client, cache, toBeFollowed, true, "queue-run")
+							Infof("Will follow %v of the %v queued targets...", len(toBeFollowed), len(queue.Targets))
+							if len(toBeFollowed) > 0 && !c.Bool("force") {
+								mustConfirmYes("Do you want to continue?")
+							}
+
+							interval := c.Duration("interval")
+							followed := 0
+							etac := eta.New(int64(len(toBeFollowed)))
+							for i, repoURL := range toBeFollowed {
+								if ctx.Err() != nil {
+									Warnf("Stopped; %v targets were not followed, and are still queued", len(toBeFollowed)-i)
+									break
+								}
+								envelope, err := follower(repoURL, etac)
+								switch kind := lgtm.ClassifyError(err); {
+								case err == nil:
+									followed++
+									queue.Remove(repoURL)
+								case kind == lgtm.ErrorKindNotFound, kind == lgtm.ErrorKindFork, kind == lgtm.ErrorKindAlreadyFollowed:
+									// Will never be followed (or is already followed):
+									queue.Remove(repoURL)
+								case kind == lgtm.ErrorKindQuotaExceeded, kind == lgtm.ErrorKindStaleSession:
+									// Not the target's fault; it stays queued
+									// without using up an attempt.
+								default:
+									target := queue.Get(repoURL)
+									target.Attempts++
+									target.LastError = err.Error()
+									if target.Attempts >= maxQueueAttempts {
+										Warnf("Removing %s from the queue after %v failed attempts", repoURL, target.Attempts)
+										queue.Remove(repoURL)
+									}
+								}
+								if err := queue.Save(); err != nil {
+									panic(err)
+								}
+								if envelope != nil && !envelope.IsKnown() {
+									// If the project was NOT already known to lgtm.com,
+									// sleep to avoid triggering too many new builds:
+									pacer.Wait(envelope)
+								}
+								time.Sleep(interval)
+							}
+							if err := queue.Save(); err != nil {
+								panic(err)
+							}
+							Successf("Followed %v projects; %v targets still queued", followed, len(queue.Targets))
+							return nil
+						},
+					},
+					{
+						Name:  "clear",
+						Usage: "Remove all the targets from the queue.",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "force, y",
+								Usage: "Don't ask for confirmation.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							queue, err := LoadFollowQueue(queueFilepath)
+							if err != nil {
+								return err
+							}
+							if !c.Bool("force") {
+								mustConfirmYes(Sf("Do you want to remove all the %v queued targets?", len(queue.Targets)))
+							}
+							queue.Targets = make([]*QueuedTarget, 0)
+							if err := queue.Save(); err != nil {
+								return err
+							}
+							Successf("Cleared the queue")
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:      "remove-from-list",
+				Usage:     "Remove projects from a list.",
+				ArgsUsage: "[repo...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Name of the list from which remove the projects.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (- for stdin).",
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					listName := c.String("name")
+					if listName == "" {
+						return errors.New("--name is required")
+					}
+
+					repoURLsRaw := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					repoURLs := make([]string, 0)
+					for _, raw := range Deduplicate(repoURLsRaw) {
+						parsed, err := urlparse.Parse(raw, true)
+						if err != nil {
+							panic(err)
+						}
+						repoURLs = append(repoURLs, parsed.URL())
+					}
+					if len(repoURLs) == 0 {
+						return errors.New("no repos provided")
+					}
+
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					list := lists.ByName(listName)
+					if list == nil {
+						return fmt.Errorf("list %q not found", listName)
+					}
+
+					took := NewTimer()
+					Infof("Getting projects of %q list...", listName)
+					projects, err := client.GetProjectsInSelection(listName)
+					if err != nil {
+						panic(err)
+					}
+					keys, notInList := matchListProjects(projects, repoURLs)
+					for _, repoURL := range notInList {
+						Warnf("%s is not in the %q list; skipping", repoURL, listName)
+					}
+					if len(keys) == 0 {
+						Infof("No projects to remove from %q list", listName)
+						return nil
+					}
+					if !c.Bool("force") {
+						mustConfirmYes(Sf("Do you want to remove %v projects from %q list?", len(keys), listName))
+					}
+
+					if err := removeFromList(client, list, keys); err != nil {
+						panic(err)
+					}
+					Successf(
+						"Removed %v projects from %q list; took %s",
+						len(keys),
+						listName,
+						took(),
+					)
+					return nil
+				},
+			},
+			{
+				Name:      "resolve",
+				Usage:     "Print the lgtm.com project key, languages and build status of one or more repos.",
+				ArgsUsage: "<repos...>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "repos, f",
+						Usage: "Filepath to text file with list of repos (- for stdin).",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent lookups on lgtm.com.",
+						Value: defaultResolveConcurrency,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the results as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					repoURLsRaw := expandStdinArgs(c.Args())
+					if c.IsSet("f") {
+						repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+					}
+					if len(repoURLsRaw) == 0 {
+						return errors.New("no repos provided")
+					}
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 {
+						return errors.New("--concurrency must be at least 1")
+					}
+
+					repoURLs := make([]string, 0, len(repoURLsRaw))
+					for _, raw := range Deduplicate(repoURLsRaw) {
+						parsed, err := urlparse.Parse(raw, true)
+						if err != nil {
+							panic(err)
+						}
+						repoURLs = append(repoURLs, parsed.URL())
+					}
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						Warnf("Could not get the list of followed projects: %s", err)
+						cache = nil
+					}
+
+					resolved := make([]*ResolvedProject, 0, len(repoURLs))
+					failed := 0
+					for _, res := range resolveProjects(ctx, client, cache, repoURLs, concurrency) {
+						if res.Error != "" {
+							failed++
+						}
+						resolved = append(resolved, newResolvedProject(res))
+					}
+
+					if c.Bool("json") {
+						JSON(true, resolved)
+					} else {
+						for _, res := range resolved {
+							switch res.Status {
+							case "built":
+								Sfln("%s\t%s\t%s\t%s", trimGithubPrefix(res.URL), res.Key, Lime(res.Status), strings.Join(res.Languages, ","))
+							case "error":
+								Sfln("%s\t-\t%s\t%s", trimGithubPrefix(res.URL), RedBG(res.Status), res.Error)
+							default:
+								key := res.Key
+								if key == "" {
+									key = "-"
+								}
+								Sfln("%s\t%s\t%s", trimGithubPrefix(res.URL), key, res.Status)
+							}
+						}
+					}
+					if failed > 0 {
+						return partialFailuref("%v of %v repos could not be resolved", failed, len(resolved))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "runs",
+				Usage: "List the query runs saved with query --save-run (most recent first).",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Max number of runs to list.",
+						Value: 20,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the runs as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					reg, err := LoadRunRegistry(runsFilepath)
+					if err != nil {
+						return err
+					}
+					runs := reg.Recent()
+					if limit := c.Int("limit"); limit > 0 && len(runs) > limit {
+						runs = runs[:limit]
+					}
+					if c.Bool("json") {
+						JSON(true, runs)
+						return nil
+					}
+					if len(runs) == 0 {
+						Infof("No saved query runs; use query --save-run to save them")
+						return nil
+					}
+
+					Errorln(Bold("# | DATE | LANG | QUERY | PROJECTS | LISTS | KEY"))
+					for i, run := range runs {
+						Sfln(
+							"%s%v | %s | %s | %s | %v | %v | %s",
+							RunRefPrefix,
+							i+1,
+							run.CreatedAt.Format("2006-01-02 15:04"),
+							run.Lang,
+							run.Query,
+							run.Projects,
+							len(run.ListKeys),
+							run.Key,
+						)
+					}
+					Infof("Get the results of a run with: lgtm x-list-query-results %s1", RunRefPrefix)
+					return nil
+				},
+			},
+			{
+				Name:  "report-card",
+				Usage: "Render a Markdown/HTML report card of the grades and alerts of all the repos of a GitHub org (or user).",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "owner",
+						Usage: "GitHub org (or user) whose repos to report on.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the report card (default: print to stdout).",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Format of the report card: md or html (default: from the extension of --output, or md).",
+					},
+					&cli.BoolFlag{
+						Name:  "no-follow",
+						Usage: "Don't follow the repos that are not followed yet (they are listed as without stats).",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "Number of worst offenders (by alerts) to list.",
+						Value: 10,
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent stats requests.",
+						Value: 4,
+					},
+					&cli.IntFlag{
+						Name:  "rps",
+						Usage: "Max number of stats requests per second.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries of the stats requests that failed with a transient error.",
+						Value: 3,
+					},
+					&cli.BoolFlag{
+						Name:  "force, y",
+						Usage: "Don't ask for confirmation.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					owner := c.String("owner")
+					if owner == "" {
+						return errors.New("--owner is required")
+					}
+					output := c.String("output")
+					format := reportCardFormat(c.String("format"), output)
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 {
+						panic("--concurrency must be at least 1")
+					}
+
+					Infof("Getting list of repos of %s ...", owner)
+					repos, err := GithubGetRepoList(owner)
+					if err != nil {
+						panic(fmt.Errorf("error while getting repo list for %q: %s", owner, err))
+					}
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						panic(err)
+					}
+
+					projects := make([]*lgtm.Project, 0)
+					missing := make([]*ReportCardRepo, 0)
+					notFollowed := make([]string, 0)
+					for _, repo := range repos {
+						repoURL := repo.GetHTMLURL()
+						if repo.GetFork() {
+							Debugf("Skipping fork %s", repo.GetFullName())
+							continue
+						}
+						if pr := cache.GetProject(repoURL); pr != nil {
+							projects = append(projects, pr)
+						} else if cache.IsProto(repoURL) {
+							missing = append(missing, &ReportCardRepo{URL: repoURL, Reason: "not built yet"})
+						} else {
+							notFollowed = append(notFollowed, repoURL)
+						}
+					}
+					Infof(
+						"%s has %v repos (forks excluded): %v followed, %v pending build, %v not followed",
+						owner,
+						len(projects)+len(missing)+len(notFollowed),
+						len(projects),
+						len(missing),
+						len(notFollowed),
+					)
+
+					if len(notFollowed) > 0 && !c.Bool("no-follow") {
+						if !c.Bool("force") {
+							mustConfirmYes(Sf("Do you want to follow %v repos?", len(notFollowed)))
+						}
+						etac := eta.New(int64(len(notFollowed)))
+						for i, repoURL := range notFollowed {
+							if ctx.Err() != nil {
+								Warnf("Stopped; %v repos were not followed", len(notFollowed)-i)
+								for _, rest := range notFollowed[i:] {
+									missing = append(missing, &ReportCardRepo{URL: rest, Reason: "not followed"})
+								}
+								break
+							}
+							envelope, err := follower(repoURL, etac)
+							if err != nil || envelope == nil {
+								reason := "could not be followed"
+								if err != nil {
+									reason = Sf("could not be followed: %s", err)
+								}
+								missing = append(missing, &ReportCardRepo{URL: repoURL, Reason: reason})
+								continue
+							}
+							if pr := envelope.GetProject(); pr != nil {
+								projects = append(projects, pr)
+							} else {
+								missing = append(missing, &ReportCardRepo{URL: repoURL, Reason: "not built yet"})
+							}
+							if !envelope.IsKnown() {
+								pacer.Wait(envelope)
+							}
+						}
+					} else {
+						for _, repoURL := range notFollowed {
+							missing = append(missing, &ReportCardRepo{URL: repoURL, Reason: "not followed"})
+						}
+					}
+
+					Infof("Getting stats of %v projects...", len(projects))
+					fetcher := NewStatsFetcher(client, "report-card", concurrency, c.Int("rps"), c.Int("retries"))
+					rows := getProjectStatsRows(ctx, fetcher, projects)
+					card := newReportCard(owner, rows, missing, c.Int("top"))
+					content, err := card.Render(format)
+					if err != nil {
+						return err
+					}
+					if output == "" {
+						Sfln("%s", string(content))
+						return nil
+					}
+					contentType := "text/markdown"
+					if format == "html" {
+						contentType = "text/html"
+					}
+					if err := writeOutputFile(output, content, contentType); err != nil {
+						panic(err)
+					}
+					Successf("Saved report card of %s to %s", owner, output)
+					return nil
+				},
+			},
+			{
+				Name:  "stats-export",
+				Usage: "Export the stats of the followed projects (or of a list) as CSV, one row per project.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "list",
+						Usage: "Name of the list of projects; if not set, all followed projects are exported.",
+					},
+					&cli.StringFlag{
+						Name:  "output, o",
+						Usage: "Filepath (or s3://, gs://, https:// URL) to which save the CSV (default: stdout).",
+					},
+					&cli.Int64Flag{
+						Name:  "concurrency",
+						Usage: "Max number of concurrent requests.",
+						Value: 4,
+					},
+					&cli.IntFlag{
+						Name:  "rps",
+						Usage: "Max number of requests per second.",
+						Value: 3,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "Max number of retries of the stats requests that failed with a transient error.",
+						Value: 3,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					concurrency := c.Int64("concurrency")
+					if concurrency < 1 || c.Int("rps") < 1 {
+						return errors.New("--concurrency and --rps must be at least 1")
+					}
+
+					took := NewTimer()
+					var projects []*lgtm.Project
+					if listName := c.String("list"); listName != "" {
+						Infof("Getting projects of %q list...", listName)
+						got, err := client.GetProjectsInSelection(listName)
+						if err != nil {
+							panic(err)
+						}
+						projects = got
+					} else {
+						Infof("Getting list of followed projects...")
+						cache, err := getFollowedCacheOf(client, noCache)
+						if err != nil {
+							panic(err)
+						}
+						projects = cache.Projects()
+					}
+					Infof("Got %v projects; took %s", len(projects), took())
+
+					fetcher := NewStatsFetcher(client, "stats-export", concurrency, c.Int("rps"), c.Int("retries"))
+					rows := getProjectStatsRows(ctx, fetcher, projects)
+
+					var buf bytes.Buffer
+					if err := writeProjectStatsCSV(&buf, rows); err != nil {
+						panic(err)
+					}
+					if output := c.String("output"); output != "" {
+						if err := writeOutputFile(output, buf.Bytes(), "text/csv"); err != nil {
+							panic(err)
+						}
+						Successf("Exported the stats of %v projects to %s", len(rows), output)
+					} else {
+						os.Stdout.Write(buf.Bytes())
+					}
+
+					failed := 0
+					for _, row := range rows {
+						if row.Error != "" {
+							failed++
+						}
+					}
+					if failed > 0 {
+						return partialFailuref("could not get the stats of %v of %v projects", failed, len(rows))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "tag",
+				Usage: "Manage local project tags (stored in --tags-file).",
+				Subcommands: []cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Tag one or more projects.",
+						ArgsUsage: "<tag> <repo>...",
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "repos, f",
+								Usage: "Filepath to text file with list of repos (- for stdin).",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							tag := c.Args().First()
+							if tag == "" {
+								return errors.New("tag not provided")
+							}
+							repoURLs, err := loadTagTargets(c)
+							if err != nil {
+								return err
+							}
+							store := mustLoadTagStore(tagsFilepath)
+							added := store.Add(tag, repoURLs...)
+							if err := store.Save(); err != nil {
+								return err
+							}
+							Successf("Tagged %v projects with %q (%v were already tagged)", added, tag, len(repoURLs)-added)
+							return nil
+						},
+					},
+					{
+						Name:      "remove",
+						Usage:     "Untag one or more projects.",
+						ArgsUsage: "<tag> <repo>...",
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "repos, f",
+								Usage: "Filepath to text file with list of repos (- for stdin).",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							tag := c.Args().First()
+							if tag == "" {
+								return errors.New("tag not provided")
+							}
+							repoURLs, err := loadTagTargets(c)
+							if err != nil {
+								return err
+							}
+							store := mustLoadTagStore(tagsFilepath)
+							removed := store.Remove(tag, repoURLs...)
+							if err := store.Save(); err != nil {
+								return err
+							}
+							Successf("Removed tag %q from %v projects", tag, removed)
+							return nil
+						},
+					},
+					{
+						Name:      "list",
+						Usage:     "List tags (with the number of projects), or the projects that have the provided tags.",
+						ArgsUsage: "[<tag>...]",
+						Action: func(c *cli.Context) error {
+							store := mustLoadTagStore(tagsFilepath)
+							tags := []string(c.Args())
+							if len(tags) > 0 {
+								for _, repoURL := range store.URLsWithAnyTag(tags...) {
+									Sfln("%s", repoURL)
+								}
+								return nil
+							}
+							counts := store.Counts()
+							for _, tag := range sortedByCount(counts) {
+								Sfln("%s | %v", tag, counts[tag])
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "whoami",
+				Usage: "Print details about the logged-in lgtm.com account.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the account details as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					took := NewTimer()
+					Infof("Getting logged-in user...")
+					user, err := client.GetLoggedInUser()
+					if err != nil {
+						panic(err)
+					}
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						cache = nil
+					}
+					quota, err := client.GetFollowQuota(cache)
+					if err != nil {
+						panic(err)
+					}
+					lists, err := client.ListProjectSelections()
+					if err != nil {
+						panic(err)
+					}
+					Infof("took %s", took())
+
+					type Identity struct {
+						Provider string `json:"provider"`
+						Username string `json:"username"`
+						URL      string `json:"url,omitempty"`
+					}
+					type Account struct {
+						Profile    string            `json:"profile,omitempty"`
+						Config     string            `json:"config"`
+						Slug       string            `json:"slug"`
+						Name       string            `json:"name"`
+						Identities []*Identity       `json:"identities"`
+						Quota      *lgtm.FollowQuota `json:"quota"`
+						Lists      int               `json:"lists"`
+					}
+					account := &Account{
+						Profile:    fileConf.SelectedProfileName(profileName),
+						Config:     configFilepath,
+						Slug:       user.Person.Slug,
+						Name:       user.Person.Name,
+						Identities: make([]*Identity, 0),
+						Quota:      quota,
+						Lists:      len(lists),
+					}
+					for _, ext := range user.ExternalAccounts {
+						identity := &Identity{
+							Provider: ext.Provider,
+							Username: ext.Username,
+						}
+						if ext.ExternalURL != nil {
+							identity.URL = ext.ExternalURL.URL
+						}
+						account.Identities = append(account.Identities, identity)
+					}
+
+					if c.Bool("json") {
+						JSON(true, account)
+						return nil
+					}
+
+					Sfln("Logged in as %s (%s)", Shakespeare(account.Slug), account.Name)
+					if account.Profile != "" {
+						Sfln("Profile: %s", account.Profile)
+					}
+					Sfln("Config: %s", account.Config)
+					for _, identity := range account.Identities {
+						Sfln("Identity: %s %s %s", identity.Provider, identity.Username, identity.URL)
+					}
+					Sfln("Quota: %s", formatFollowQuota(quota))
+					Sfln("Lists: %v", account.Lists)
+					return nil
+				},
+			},
+			{
+				Name:  "capacity",
+				Usage: "Print how many more projects the account can follow.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the follow quota as json.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					cache, err := getFollowedCacheOf(client, noCache)
+					if err != nil {
+						cache = nil
+					}
+					quota, err := client.GetFollowQuota(cache)
+					if err != nil {
+						panic(err)
+					}
+
+					if c.Bool("json") {
+						type Capacity struct {
+							*lgtm.FollowQuota
+							Remaining int `json:"remaining"`
+						}
+						JSON(true, &Capacity{
+							FollowQuota: quota,
+							Remaining:   quota.Remaining(),
+						})
+						return nil
+					}
+
+					Sfln("Quota: %s", formatFollowQuota(quota))
+					remaining := quota.Remaining()
+					switch {
+					case remaining < 0:
+						Warnf("The follow limit of the account is unknown: set follow_limit in the config (it is saved automatically when lgtm.com reports that the limit has been reached).")
+					case remaining == 0:
+						Errorln(RedBG(Bold("The account cannot follow more projects.")))
+					default:
+						Successf("The account can follow %v more projects.", remaining)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "x-list-query-results",
+				Usage:     "[x] List projects of a query run (json).",
+				ArgsUsage: "<query-run-key or @N (the Nth most recent saved run)>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "min-alerts",
+						Usage: "Min number of alerts; will sort by alert count.",
+					},
+					&cli.IntFlag{
+						Name:  "min-results",
+						Usage: "Min number of results; will sort by result count.",
+					},
+					&cli.BoolFlag{
+						Name:  "github-meta",
+						Usage: "Add the GitHub stars, primary language, archived status and last push date of each project.",
+					},
+					&cli.Int64Flag{
+						Name:  "github-concurrency",
+						Usage: "With --github-meta, max number of concurrent GitHub lookups.",
+						Value: 8,
+					},
+				},
+				Action: func(c *cli.Context) error {
+
+					queryID := c.Args().First()
+					if queryID == "" {
+						return errors.New("query ID not provided")
+					}
+					queryID, err := resolveQueryRunRef(runsFilepath, queryID)
+					if err != nil {
+						return err
+					}
+					minAlerts := c.Int("min-alerts")
+					minResults := c.Int("min-results")
+					if minAlerts > 0 && minResults > 0 {
+						return errors.New("Cannot use both: min-alerts and min-results")
+					}
+					githubMeta := c.Bool("github-meta")
+					if githubMeta && c.Int64("github-concurrency") < 1 {
+						return errors.New("--github-concurrency must be at least 1")
+					}
+
+					var orderBy lgtm.OrderBy
+					if minAlerts > 0 {
+						orderBy = lgtm.OrderByNumAlerts
+					}
+					if minResults > 0 {
+						orderBy = lgtm.OrderByNumResults
+					}
+					if minAlerts == 0 && minResults == 0 {
+						orderBy = lgtm.OrderByNumResults
+					}
+
+					took := NewTimer()
+					Infof("Getting results of query %s...", queryID)
+
+					var startCursor string
+					queryResults := make([]*lgtm.GetQueryResultsResponseItem, 0)
+				GetterLoop:
+					for {
+						resp, err := client.GetQueryResults(queryID, orderBy, startCursor)
+						if err != nil {
+							panic(err)
+						}
+						if resp.Items == nil {
+							break GetterLoop
+						}
+
+						for _, item := range resp.Items {
+							{
+								if minAlerts > 0 && item.Stats == nil {
+									continue
+								}
+								if minAlerts > 0 && item.Stats.NumAlerts < minAlerts {
+									break GetterLoop
+								}
+							}
+							{
+								if minResults > 0 && item.Stats == nil {
+									continue
+								}
+								if minResults > 0 && item.Stats.NumResults < minResults {
+									break GetterLoop
+								}
+							}
+							queryResults = append(queryResults, item)
+						}
+						if resp.Cursor == "" {
+							break GetterLoop
+						}
+						startCursor = resp.Cursor
+					}
+					Successf(
+						"Got %v results; took %s",
+						len(queryResults),
+						took(),
+					)
+
+					projectKeys := ref.MapSlice(queryResults, func(i int) string {
+						return queryResults[i].ProjectKey
+					})
+
+					type Output struct {
+						Project *lgtm.Project
+						Result  *lgtm.GetQueryResultsResponseItem
+						GitHub  *GithubRepoMeta `json:",omitempty"`
+					}
+					output := make([]*Output, 0)
+
+					Infof("Getting meta of %v projects...", len(projectKeys))
+					took = NewTimer()
+					gotProjectResp, err := client.GetProjectsByKeyBulk(ctx, lgtm.DefaultBulkMaxWorkers, projectKeys...)
+					if err != nil {
+						Fatalf(
+							"error while client.GetProjectsByKeyBulk for projects %s: %s",
+							gotProjectResp.FailedKeys,
+							err,
+						)
+					}
+					Infof("took %s", took())
+
+					var repoMeta RepoMetadata
+					if githubMeta {
+						repoURLs := make([]string, 0, len(gotProjectResp.Projects))
+						for _, pr := range gotProjectResp.Projects {
+							repoURLs = append(repoURLs, pr.ExternalURL.URL)
+						}
+						Infof("Getting GitHub meta of %v projects...", len(repoURLs))
+						took = NewTimer()
+						repoMeta = fetchRepoMetadata(ctx, repoURLs, c.Int64("github-concurrency"))
+						Infof("took %s", took())
+					}
+
+					for projectKey, pr := range gotProjectResp.Projects {
+						out := &Output{
+							Project: pr,
+							GitHub:  repoMeta.GetGithubRepoMeta(pr.ExternalURL.URL),
+						}
+
+						{
+							got := ref.FilterSlice(queryResults, func(i int) bool {
+								return queryResults[i].ProjectKey == projectKey
+							}).([]*lgtm.GetQueryResultsResponseItem)
+							out.Result = got[0]
+						}
+						output = append(output, out)
+					}
+
+					js, err := json.Marshal(output)
+					if err != nil {
+						Fatalf("Error marshaling results to json: %s", err)
+					}
+
+					Ln(string(js))
+
+					return nil
+				},
+			},
+		},
+	}
+
+	app.Commands = withCommandGroups(app.Commands)
+	sort.Sort(cli.FlagsByName(app.Flags))
+	sort.Sort(cli.CommandsByName(app.Commands))
+
+	defer exitOnPanic()
+	exitOnError(runTimeBox.Finish(app.Run(os.Args)))
+}
+func GithubListLanguages(owner string, repo string) ([]string, error) {
+	owner = strings.TrimSpace(owner)
+	repo = strings.TrimSpace(repo)
+
+	languagesMap, err := ghClient.ListLanguagesOfRepo(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var languages []string
+
+	for key := range languagesMap {
+		languages = append(languages, ToLower(key))
+	}
+
+	languages = Deduplicate(languages)
+	return languages, nil
+}
+func GithubListReposByLanguage(owner string, lang string) ([]*github.Repository, error) {
+	owner = strings.TrimSpace(owner)
+	lang = strings.TrimSpace(lang)
+
+	repos, err := ghClient.ListReposBylanguage(owner, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+func GithubListAllReposByLanguage(lang string, limit int) ([]*github.Repository, error) {
+	lang = strings.TrimSpace(lang)
+
+	opts := &ghc.ListAllReposByLanguageOpts{
+		Language:     lang,
+		ExcludeForks: true,
+		Limit:        limit,
+	}
+	repos, err := ghClient.ListAllReposByLanguage(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+func GithubListReposByMetaSearch(query string, limit int) ([]*github.Repository, error) {
+	return githubutil.SearchRepos(ghRawClient, query, limit)
+}
+func GithubListReposByCodeSearch(query string, limit int) ([]*github.Repository, error) {
+	codeResults, err := githubutil.SearchCode(ghRawClient, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deduplicate results (for any given repo, there might be more than one code results).
+	ref.DeduplicateSlice2(&codeResults, func(i int) string {
+		return codeResults[i].Repository.GetHTMLURL()
+	})
+
+	var repos []*github.Repository
+	for _, codeResult := range codeResults {
+		repos = append(repos, codeResult.Repository)
+	}
+
+	return repos, nil
+}
+
+// githubOwnerRepoURLs returns the URLs of the repos (forks excluded) of the owner;
+// if lang is not empty, only the repos of that language are returned.
+func githubOwnerRepos(owner string, lang string) ([]*github.Repository, error) {
+	Debugf("Getting list of repos for %s ...", owner)
+
+	var repos []*github.Repository
+	var err error
+	if lang != "" {
+		repos, err = GithubListReposByLanguage(owner, lang)
+	} else {
+		repos, err = GithubGetRepoList(owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while getting repo list for user %q: %s", owner, err)
+	}
+	Debugf("%s has %v repos", owner, len(repos))
+
+	nonForks := make([]*github.Repository, 0)
+	for _, repo := range repos {
+		// "Currently we do not support analysis of forks. Consider adding the parent of the fork instead."
+		if repo.GetFork() {
+			Warnf("Skipping fork %s", repo.GetFullName())
+			continue
+		}
+		nonForks = append(nonForks, repo)
+	}
+	return nonForks, nil
+}
+func GithubGetRepoList(owner string) ([]*github.Repository, error) {
+
+	owner = strings.TrimSpace(owner)
+
+	// determine whether the owner is a user or an org:
+	ownerUser, isUser, err := ghClient.IsOwnerAUser(owner)
+	if err != nil {
+		return nil, bianconiglio.Contextualize(err,
+			"owner", owner,
+		)
+	}
+
+	var ownerOrg *github.Organization
+	var isOrg bool
+	if !isUser {
+		ownerOrg, isOrg, err = ghClient.IsOwnerAnOrg(owner)
+		if err != nil {
+			return nil, bianconiglio.Contextualize(err,
+				"owner", owner,
+			)
+		}
+		if !isOrg {
+			return nil, fmt.Errorf("owner is neither an org nor a common user: %s", owner)
+		}
+	}
+
+	IsUser := func() bool {
+		return ownerUser != nil && ownerUser.GetType() != "Organization"
+	}
+	_ = IsUser
+
+	IsOrg := func() bool {
+		return ownerOrg != nil || (ownerUser != nil && ownerUser.GetType() == "Organization")
+	}
+
+	if ownerUser == nil && ownerOrg == nil {
+		return nil, bianconiglio.Contextualize(
+			errors.New("ownerUser and ownerOrg are both nil"),
+			"owner", owner,
+		)
+	}
+
+	// get list of repos:
+	_ = IsOrg()
+
+	repoList := make([]*github.Repository, 0)
+
+	{ // get list of repos:
+		if isOrg {
+			orgRepos, err := githubutil.ListReposByOwner(ghRawClient, owner, true)
+			if err != nil {
+				return nil, fmt.Errorf("error while ListReposByOrg: %w", err)
+			}
+			repoList = append(repoList, orgRepos...)
+		} else {
+			userRepos, err := githubutil.ListReposByOwner(ghRawClient, owner, false)
+			if err != nil {
+				return nil, fmt.Errorf("error while ListReposByUser: %w", err)
+			}
+			repoList = append(repoList, userRepos...)
+		}
+	}
+
+	return repoList, nil
+}
+
+func HasPrefix(s string, prefix string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+func IsEmptyHostError(err error) bool {
+	if e, ok := err.(*url.Error); ok && e.Err.Error() == "empty host" {
+		return true
+	}
+	return false
+}
+
+func trimGithubPrefix(s string) string {
+	return strings.TrimPrefix(s, "https://github.com/")
+}
+
+type LineWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	// remote is the remote output to which the file
+	// is uploaded on Close (if any).
+	remote string
+}
+
+//
+func (wr *LineWriter) WriteLine(line string) error {
+	_, err := fmt.Fprintln(wr.writer, line)
+	return err
+}
+
+func (wr *LineWriter) Close() error {
+	if err := wr.writer.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := wr.file.Close(); err != nil {
+		return err
+	}
+	if wr.remote == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(wr.file.Name())
+	if err != nil {
+		return err
+	}
+	if err := uploadToSink(wr.remote, content, "text/plain"); err != nil {
+		return err
+	}
+	Errorln(Sf(PurpleBG("Uploaded list of targets to %s"), wr.remote))
+	return nil
+}
+
+func writtableTargetListToTempFile(outputFileName string, cmdName string) *LineWriter {
+	var outputFile *os.File
+	var err error
+
+	var remote string
+	if isRemoteOutput(outputFileName) {
+		// The list is written to a local temp file first,
+		// and uploaded when the writer is closed.
+		remote = outputFileName
+		outputFileName = ""
+	}
+
+	if outputFileName == "" {
+		scanName := Sf(
+			"lgtml-cli-%s-%s",
+			cmdName,
+			time.Now().Format(FilenameTimeFormat),
+		)
+		outputFile, err = ioutil.TempFile("", scanName+".*.txt")
+		outputFileName = outputFile.Name()
+	} else {
+		outputFile, err = os.Create(outputFileName)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	Errorln(Sf(PurpleBG("Writing list of targets to %s"), outputFileName))
+	writer := bufio.NewWriter(outputFile)
+
+	return &LineWriter{
+		writer: writer,
+		file:   outputFile,
+		remote: remote,
+	}
+}
+
+func saveTargetListToTempFile(outputFileName string, cmdName string, targets []string) {
+	if isRemoteOutput(outputFileName) {
+		var content bytes.Buffer
+		for _, target := range targets {
+			content.WriteString(target + "\n")
+		}
+		if err := uploadToSink(outputFileName, content.Bytes(), "text/plain"); err != nil {
+			log.Fatal(err)
+		}
+		Errorln(Sf(PurpleBG("Uploaded compiled list of targets to %s"), outputFileName))
+		return
+	}
+
+	var outputFile *os.File
+	var err error
+
+	if outputFileName == "" {
+		scanName := Sf(
+			"lgtml-cli-%s-%s",
+			cmdName,
+			time.Now().Format(FilenameTimeFormat),
+		)
+		outputFile, err = ioutil.TempFile("", scanName+".*.txt")
+		outputFileName = outputFile.Name()
+	} else {
+		outputFile, err = os.Create(outputFileName)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	writer := bufio.NewWriter(outputFile)
+
+	for _, target := range targets {
+		_, err := writer.WriteString(target + "\n")
+		if err != nil {
+			outputFile.Close()
+			log.Fatal(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	Errorln(Sf(PurpleBG("Wrote compiled list of targets to %s"), outputFileName))
+
+	if err := outputFile.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func trimDotGit(s string) string {
+	return strings.TrimSuffix(s, ".git")
+}
+func mustLoadTargetsFromFilepaths(paths ...string) []string {
+	var res []string
+	for _, path := range paths {
+		if path == StdinTarget {
+			targets, err := readTargetsFromStdin()
+			if err != nil {
+				panic(err)
+			}
+			res = append(res, targets...)
+			continue
+		}
+		err := ReadConfigLinesAsString(path, func(line string) bool {
+			res = append(res, line)
+			return true
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return res
+}
+func mustStringSliceNotNil(sl []string) []string {
+	if sl == nil {
+		return make([]string, 0)
+	}
+	return sl
+}
+func JSON(pretty bool, v interface{}) {
+	if pretty {
+		ToJSONIndentToStdout(v)
+	} else {
+		ToJSONToStdout(v)
+	}
+}
+
+func ToJSONIndentToStdout(v interface{}) {
+	j, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	Ln(string(j))
+}
+
+func ToJSONToStdout(v interface{}) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	Ln(string(j))
+}
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
+// checkProfileSession returns a description of the status
+// of the lgtm.com session of the provided profile config.
+func checkProfileSession(profileConf *lgtm.Config) string {
+	if err := profileConf.Validate(); err != nil {
+		return RedBG(Sf("invalid: %s", err))
+	}
+	profileClient, err := lgtm.NewClient(profileConf)
+	if err != nil {
+		return RedBG(Sf("invalid: %s", err))
+	}
+	user, err := profileClient.GetLoggedInUser()
+	if err != nil {
+		if lgtm.IsStaleSession(err) {
+			return RedBG("stale session")
+		}
+		return RedBG(Sf("error: %s", err))
+	}
+	return Sf("logged in as %s", Shakespeare(user.Person.Slug))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// assumeYes is set by the global --yes flag: all confirmation prompts
+// are answered yes, except the ones of especially destructive operations.
+var assumeYes bool
+
+// mustConfirmYes asks for confirmation (unless --yes is set);
+// it exits if the answer is no.
+func mustConfirmYes(message string) {
+	if assumeYes {
+		Infof("%s %s", message, Bold("yes (--yes)"))
+		return
+	}
+	CLIMustConfirmYes(message)
+}
+
+// askYesNo asks a yes/no question (unless --yes is set,
+// in which case the answer is yes).
+func askYesNo(message string) (bool, error) {
+	if assumeYes {
+		Infof("%s %s", message, Bold("yes (--yes)"))
+		return true, nil
+	}
+	return CLIAskYesNo(message)
+}
+
+// mustConfirmDestructive asks for confirmation of an especially destructive
+// operation, unless the command's own --force flag is set;
+// the global --yes flag is not enough to skip it.
+func mustConfirmDestructive(force bool, message string) {
+	if force {
+		return
+	}
+	if assumeYes {
+		Fatalf("%s: --yes is not enough for this operation; use the --force flag of the command.", message)
+	}
+	CLIMustConfirmYes(message)
+}
+
+// mustConfirmTyped asks the user to type the expected text
+// to confirm a destructive operation; it exits if the text does not match.
+// The global --yes flag is not enough to skip it.
+func mustConfirmTyped(expected string, message string) {
+	if assumeYes {
+		Fatalf("%s: --yes is not enough for this operation; use the --force flag of the command.", message)
+	}
+	Warnf("%s", message)
+	Infof("Type %s to confirm:", Bold(expected))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		Fatalf("Aborted: could not read confirmation: %s", err)
+	}
+	if strings.TrimSpace(line) != expected {
+		Fatalf("Aborted: the typed text does not match %q", expected)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	"github.com/gagliardetto/request"
+	. "github.com/gagliardetto/utilz"
+)
+
+// Dependent is a repo that depends on another repo.
+type Dependent struct {
+	// Slug is the owner/repo slug of the dependent.
+	Slug  string
+	Stars int
+}
+
+// DependentsPage is a page of the dependents of a repo
+// (GitHub Dependency Network).
+type DependentsPage struct {
+	Dependents []*Dependent
+	// NextPage is the URL of the next page (empty for the last page).
+	NextPage string
+}
+
+var nonDigitsRegex = regexp.MustCompile("[^0-9]+")
+
+// firstDependentsPageURL returns the URL of the first page of dependents
+// of the repo, for the provided type and (optional) subpackage.
+func firstDependentsPageURL(target string, typ string, subPackage string) (string, error) {
+	parsed, err := urlparse.Parse(target, true)
+	if err != nil {
+		return "", err
+	}
+	vals := url.Values{}
+	vals.Set("dependent_type", typ)
+	pageURL := Sf("%s/network/dependents?%s", parsed.URL(), vals.Encode())
+	if subPackage == "" {
+		return pageURL, nil
+	}
+
+	doc, err := loadDependentsDoc(pageURL)
+	if err != nil {
+		return "", err
+	}
+	var subURL string
+	doc.Find(`div.select-menu-list`).ChildrenFiltered("a.select-menu-item").Each(func(i int, s *goquery.Selection) {
+		name := strings.TrimSpace(s.ChildrenFiltered("span.select-menu-item-text").Text())
+		if href, ok := s.Attr("href"); ok && name == subPackage {
+			subURL = href
+		}
+	})
+	if subURL == "" {
+		return "", fmt.Errorf("subpackage %q not found", subPackage)
+	}
+	return absoluteGithubURL(subURL), nil
+}
+
+func absoluteGithubURL(href string) string {
+	if strings.HasPrefix(href, "/") {
+		return "https://github.com" + href
+	}
+	return href
+}
+
+func loadDependentsDoc(pageURL string) (*goquery.Document, error) {
+	req := request.NewRequest(webHTTPClient)
+
+	resp, err := req.Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, lgtm.FormatHTTPNotOKStatusCodeError(resp)
+	}
+
+	reader, closer, err := resp.DecompressedReaderFromPool()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting Reader: %s", err)
+	}
+	defer closer()
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error while goquery.NewDocumentFromReader: %s", err)
+	}
+	return doc, nil
+}
+
+// getDependentsPage gets a page of dependents.
+func getDependentsPage(pageURL string) (*DependentsPage, error) {
+	doc, err := loadDependentsDoc(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	page := &DependentsPage{
+		Dependents: make([]*Dependent, 0),
+	}
+	doc.Find("[data-repository-hovercards-enabled]").Each(func(i int, s *goquery.Selection) {
+		// NOTE: only dependents that have a repository are extracted.
+		href, ok := s.ChildrenFiltered("[data-hovercard-type='repository']").Attr("href")
+		if !ok {
+			return
+		}
+		dep := &Dependent{
+			Slug: strings.TrimPrefix(href, "/"),
+		}
+		// The star count is next to the star icon, in the same row:
+		starsText := s.Closest(".Box-row").Find("svg.octicon-star").Parent().Text()
+		if stars, err := strconv.Atoi(nonDigitsRegex.ReplaceAllString(starsText, "")); err == nil {
+			dep.Stars = stars
+		}
+		page.Dependents = append(page.Dependents, dep)
+	})
+
+	last := doc.Find(`[data-test-selector="pagination"]`).ChildrenFiltered("a").Last()
+	if next, ok := last.Attr("href"); ok && !strings.Contains(last.Text(), "Previous") {
+		page.NextPage = absoluteGithubURL(next)
+	}
+	return page, nil
+}
+
+// walkDependents calls the callback for each dependent, starting from the provided page;
+// onPage is called with the URL of each page before its dependents are processed.
+// The walk stops when the callback returns false.
+func walkDependents(pageURL string, onPage func(pageURL string) error, callback func(dep *Dependent) bool) error {
+	for pageURL != "" {
+		if err := onPage(pageURL); err != nil {
+			return err
+		}
+		page, err := getDependentsPage(pageURL)
+		if err != nil {
+			return fmt.Errorf("error while getting %s: %w", pageURL, err)
+		}
+		for _, dep := range page.Dependents {
+			if !callback(dep) {
+				return nil
+			}
+		}
+		pageURL = page.NextPage
+	}
+	return nil
+}
+
+// DepnetCheckpoint is the state of a follow-by-depnet traversal;
+// it is saved before each page of dependents is processed,
+// so that the traversal can be resumed from that page.
+type DepnetCheckpoint struct {
+	path string
+
+	Target     string `json:"target"`
+	Type       string `json:"type"`
+	SubPackage string `json:"subPackage,omitempty"`
+	MinStars   int    `json:"minStars,omitempty"`
+	// Page is the URL of the page of dependents from which to resume.
+	Page string `json:"page"`
+	// Processed is the number of dependents processed before Page.
+	Processed int       `json:"processed"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// defaultDepnetCheckpointFilepath returns the default checkpoint filepath
+// for the provided target.
+func defaultDepnetCheckpointFilepath(target string) string {
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(trimGithubPrefix(target))
+	return filepath.Join(os.TempDir(), Sf("follow-by-depnet-%s.checkpoint.json", name))
+}
+
+// LoadDepnetCheckpoint loads a checkpoint from file.
+func LoadDepnetCheckpoint(path string) (*DepnetCheckpoint, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &DepnetCheckpoint{path: path}
+	if err := json.Unmarshal(content, checkpoint); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	if checkpoint.Target == "" || checkpoint.Page == "" {
+		return nil, errors.New("invalid checkpoint: target or page not set")
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to its file.
+func (cp *DepnetCheckpoint) Save() error {
+	cp.UpdatedAt = time.Now()
+	js, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cp.path, js, 0600)
+}
+
+// Remove deletes the checkpoint file (e.g. once the traversal is complete).
+func (cp *DepnetCheckpoint) Remove() error {
+	err := os.Remove(cp.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// DepnetEdge is a dependent->dependency edge of the dependency network.
+type DepnetEdge struct {
+	// Dependent is the owner/repo slug of the dependent.
+	Dependent string `json:"dependent"`
+	// Dependency is the owner/repo slug of the dependency.
+	Dependency string `json:"dependency"`
+	// Package is the subpackage of the dependency (if any).
+	Package string `json:"package,omitempty"`
+	Stars   int    `json:"stars"`
+}
+
+// DepnetGraph is the dependency graph discovered
+// by a follow-by-depnet traversal.
+type DepnetGraph struct {
+	Edges []*DepnetEdge `json:"edges"`
+}
+
+// NewDepnetGraph returns a new empty graph.
+func NewDepnetGraph() *DepnetGraph {
+	return &DepnetGraph{
+		Edges: make([]*DepnetEdge, 0),
+	}
+}
+
+// Add adds an edge from the dependent to the dependency.
+func (g *DepnetGraph) Add(dep *Dependent, dependency string, subPackage string) {
+	g.Edges = append(g.Edges, &DepnetEdge{
+		Dependent:  dep.Slug,
+		Dependency: strings.TrimSuffix(trimGithubPrefix(dependency), "/"),
+		Package:    subPackage,
+		Stars:      dep.Stars,
+	})
+}
+
+// WriteToFile saves the graph to the provided file (or remote output),
+// as json if the extension is .json, and as DOT otherwise.
+func (g *DepnetGraph) WriteToFile(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		js, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeOutputFile(path, js, "application/json")
+	}
+	return writeOutputFile(path, []byte(g.DOT()), "text/vnd.graphviz")
+}
+
+// DOT returns the graph in the Graphviz DOT format.
+func (g *DepnetGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dependents {\n")
+	for _, edge := range g.Edges {
+		attrs := []string{Sf("stars=%v", edge.Stars)}
+		if edge.Package != "" {
+			attrs = append(attrs, "label="+strconv.Quote(edge.Package))
+		}
+		b.WriteString(Sf(
+			"  %s -> %s [%s];\n",
+			strconv.Quote(edge.Dependent),
+			strconv.Quote(edge.Dependency),
+			strings.Join(attrs, ", "),
+		))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// DuplicateProto is a followed proto-project whose repo
+// is also followed as a built project.
+type DuplicateProto struct {
+	// URL is the URL of the built project.
+	URL        string `json:"url"`
+	ProjectKey string `json:"projectKey"`
+	// CloneURL is the clone URL of the proto-project.
+	CloneURL string `json:"cloneURL"`
+	ProtoKey string `json:"protoKey"`
+}
+
+// normalizeRepoURL returns the URL of the repo in a form that is the same
+// for the different spellings of the URL (.git suffix, case, scp-like clone URLs).
+func normalizeRepoURL(repoURL string) string {
+	if scpLikeCloneURLRegex.MatchString(repoURL) {
+		// e.g. git@github.com:foo/bar.git -> https://github.com/foo/bar.git
+		hostAndPath := repoURL[strings.Index(repoURL, "@")+1:]
+		repoURL = "https://" + strings.Replace(hostAndPath, ":", "/", 1)
+	}
+	if parsed, err := urlparse.Parse(repoURL, true); err == nil {
+		return strings.ToLower(parsed.URL())
+	}
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git"))
+}
+
+// findDuplicateProtos returns the proto-projects whose repo
+// is also followed as a built project.
+func findDuplicateProtos(projects []*lgtm.Project, protoProjects []*lgtm.ProtoProject) []*DuplicateProto {
+	projectsByURL := make(map[string]*lgtm.Project, len(projects))
+	for _, pr := range projects {
+		projectsByURL[normalizeRepoURL(pr.ExternalURL.URL)] = pr
+	}
+	duplicates := make([]*DuplicateProto, 0)
+	for _, proto := range protoProjects {
+		pr, ok := projectsByURL[normalizeRepoURL(proto.CloneURL)]
+		if !ok {
+			continue
+		}
+		duplicates = append(duplicates, &DuplicateProto{
+			URL:        pr.ExternalURL.URL,
+			ProjectKey: pr.Key,
+			CloneURL:   proto.CloneURL,
+			ProtoKey:   proto.Key,
+		})
+	}
+	return duplicates
+}
+
+// writeDuplicateProtos saves the duplicates as json to the provided file
+// (or remote output).
+func writeDuplicateProtos(path string, duplicates []*DuplicateProto) error {
+	js, err := json.MarshalIndent(duplicates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// Exit codes of the CLI; scripts can rely on them
+// to tell apart the different kinds of failures.
+const (
+	ExitCodeOK    = 0
+	ExitCodeError = 1
+	// ExitCodeAuth means that the lgtm.com session (or the GitHub token) is not valid.
+	ExitCodeAuth = 3
+	// ExitCodeRateLimit means that lgtm.com or GitHub rate-limited the requests.
+	ExitCodeRateLimit = 4
+	// ExitCodeNotFound means that a project, list or query run was not found.
+	ExitCodeNotFound = 5
+	// ExitCodePartialFailure means that a batch command completed,
+	// but some of its targets failed.
+	ExitCodePartialFailure = 6
+	// ExitCodeInterrupted means that the command was interrupted.
+	ExitCodeInterrupted = 130
+)
+
+// ExitError is an error with the exit code of the CLI.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// partialFailuref returns an error that makes the CLI exit
+// with ExitCodePartialFailure.
+func partialFailuref(format string, args ...interface{}) error {
+	return &ExitError{
+		Code: ExitCodePartialFailure,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+// exitCodeForError returns the exit code for the error.
+func exitCodeForError(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	switch lgtm.ClassifyError(err) {
+	case lgtm.ErrorKindStaleSession:
+		return ExitCodeAuth
+	case lgtm.ErrorKindRateLimited:
+		return ExitCodeRateLimit
+	case lgtm.ErrorKindNotFound:
+		return ExitCodeNotFound
+	}
+
+	var enriched *lgtm.EnrichedError
+	if errors.As(err, &enriched) {
+		if code := exitCodeForHTTPStatus(enriched.StatusCode()); code != ExitCodeError {
+			return code
+		}
+	}
+
+	var rateLimitErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseErr) {
+		return ExitCodeRateLimit
+	}
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		return exitCodeForHTTPStatus(ghErr.Response.StatusCode)
+	}
+	return ExitCodeError
+}
+
+func exitCodeForHTTPStatus(statusCode int) int {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ExitCodeAuth
+	case http.StatusTooManyRequests:
+		return ExitCodeRateLimit
+	case http.StatusNotFound:
+		return ExitCodeNotFound
+	default:
+		return ExitCodeError
+	}
+}
+
+// exitOnError prints the error (if not nil), and exits with its exit code.
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	Errorf("%s", err)
+	os.Exit(exitCodeForError(err))
+}
+
+// exitOnPanic recovers the panics caused by errors (most commands panic
+// on errors), and exits with the exit code of the error;
+// other panics (i.e. bugs) are re-raised.
+func exitOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, isError := r.(error)
+	if _, isRuntimeError := r.(runtime.Error); !isError || isRuntimeError {
+		panic(r)
+	}
+	exitOnError(err)
+}
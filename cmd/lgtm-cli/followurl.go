@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// scpLikeCloneURLRegex matches scp-like clone URLs (e.g. git@example.com:foo/bar.git).
+var scpLikeCloneURLRegex = regexp.MustCompile(`^[^/@:\s]+@[^/:\s]+:\S+$`)
+
+// validateCloneURL checks that the provided string looks like a clone URL;
+// contrary to urlparse.Parse, the host and path are not interpreted,
+// so that any URL accepted by lgtm.com can be followed.
+func validateCloneURL(u string) error {
+	if strings.Contains(u, "://") || scpLikeCloneURLRegex.MatchString(u) {
+		return nil
+	}
+	return fmt.Errorf("%q is not a clone URL (expected scheme://host/path or user@host:path)", u)
+}
+
+// FollowedURL is the result of following a clone URL.
+type FollowedURL struct {
+	URL string `json:"url"`
+	// Key is the key of the project (or proto-project).
+	Key string `json:"key,omitempty"`
+	// IsProto is true when lgtm.com created a proto-project
+	// (i.e. the repo has not been built yet).
+	IsProto bool   `json:"isProto"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newFollowedURL returns the result of following the URL.
+func newFollowedURL(u string, env *lgtm.Envelope, err error) *FollowedURL {
+	res := &FollowedURL{
+		URL: u,
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if pr := env.GetProject(); pr != nil {
+		res.Key = pr.Key
+	} else if proto := env.GetProtoProject(); proto != nil {
+		res.Key = proto.Key
+		res.IsProto = true
+	}
+	return res
+}
+
+// writeFollowedURLs saves the results as json to the provided file
+// (or remote output).
+func writeFollowedURLs(path string, results []*FollowedURL) error {
+	js, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// FollowedFork is a followed project (or proto-project)
+// whose repo is a fork on GitHub.
+type FollowedFork struct {
+	IsProto bool
+	Key     string
+	URL     string
+	// ParentURL is the URL of the repo it was forked from: the followed one
+	// between its parent and its source (the root of the fork network),
+	// or else its parent.
+	ParentURL string
+	// ParentFollowed is true if its parent (or source) is followed too.
+	ParentFollowed bool
+}
+
+// findFollowedForks looks up on GitHub the followed repos of the cache
+// with at most maxWorkers concurrent requests, and returns the forks
+// (in the order of the followed projects, and then proto-projects).
+// The repos that are not on GitHub are skipped.
+func findFollowedForks(ctx context.Context, cache *lgtm.FollowedProjectCache, maxWorkers int64) []*FollowedFork {
+	type followed struct {
+		isProto bool
+		key     string
+		url     string
+	}
+	repos := make([]*followed, 0, cache.NumProjects()+cache.NumProto())
+	for _, pr := range cache.Projects() {
+		repos = append(repos, &followed{key: pr.Key, url: pr.ExternalURL.URL})
+	}
+	for _, proto := range cache.ProtoProjects() {
+		repos = append(repos, &followed{isProto: true, key: proto.Key, url: trimDotGit(proto.CloneURL)})
+	}
+
+	repoURLs := make([]string, 0, len(repos))
+	for _, followedRepo := range repos {
+		repoURLs = append(repoURLs, followedRepo.url)
+	}
+	meta := fetchRepoMetadata(ctx, repoURLs, maxWorkers)
+
+	forks := make([]*FollowedFork, len(repos))
+	for i, followedRepo := range repos {
+		repo := meta.Get(followedRepo.url)
+		if repo == nil || !repo.GetFork() || repo.GetParent() == nil {
+			continue
+		}
+		fork := &FollowedFork{
+			IsProto:   followedRepo.isProto,
+			Key:       followedRepo.key,
+			URL:       followedRepo.url,
+			ParentURL: repo.GetParent().GetHTMLURL(),
+		}
+		for _, upstream := range []string{repo.GetParent().GetHTMLURL(), repo.GetSource().GetHTMLURL()} {
+			if upstream != "" && cache.IsFollowed(upstream) {
+				fork.ParentURL = upstream
+				fork.ParentFollowed = true
+				break
+			}
+		}
+		forks[i] = fork
+	}
+
+	res := make([]*FollowedFork, 0)
+	for _, fork := range forks {
+		if fork != nil {
+			res = append(res, fork)
+		}
+	}
+	return res
+}
+
+// countForksOfParents returns the number of forks of the parents.
+func countForksOfParents(forksByParent map[string][]*FollowedFork, parentURLs []string) int {
+	var count int
+	for _, parentURL := range parentURLs {
+		count += len(forksByParent[parentURL])
+	}
+	return count
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// ProjectOutput is what the --format templates of project listings
+// get for each project (or proto-project).
+type ProjectOutput struct {
+	Key       string
+	Slug      string
+	Name      string
+	URL       string
+	Languages []string
+	IsProto   bool
+	// State is only set for proto-projects.
+	State string
+}
+
+func newProjectOutput(pr *lgtm.Project) *ProjectOutput {
+	return &ProjectOutput{
+		Key:       pr.Key,
+		Slug:      pr.Slug,
+		Name:      pr.DisplayName,
+		URL:       pr.ExternalURL.URL,
+		Languages: pr.Languages,
+	}
+}
+
+func newProtoProjectOutput(proto *lgtm.ProtoProject) *ProjectOutput {
+	return &ProjectOutput{
+		Key:       proto.Key,
+		Name:      proto.DisplayName,
+		URL:       proto.CloneURL,
+		Languages: make([]string, 0),
+		IsProto:   true,
+		State:     proto.State,
+	}
+}
+
+var outputTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v interface{}) (string, error) {
+		js, err := json.Marshal(v)
+		return string(js), err
+	},
+}
+
+// newOutputTemplate parses the Go template of a --format flag;
+// it returns nil if the format is empty.
+func newOutputTemplate(format string) (*template.Template, error) {
+	if format == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("format").Funcs(outputTemplateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printWithTemplate prints the item formatted with the template, on its own line.
+func printWithTemplate(tmpl *template.Template, v interface{}) error {
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return fmt.Errorf("error while executing --format template: %w", err)
+	}
+	Sfln("%s", buf.String())
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/githubutil"
+	. "github.com/gagliardetto/utilz"
+	"golang.org/x/sync/semaphore"
+)
+
+// GithubRepoMeta is the GitHub metadata of the repo of a project,
+// used to triage the projects of query results.
+type GithubRepoMeta struct {
+	Stars    int       `json:"stars"`
+	Language string    `json:"language"`
+	Archived bool      `json:"archived"`
+	PushedAt time.Time `json:"pushedAt"`
+}
+
+// GetGithubRepoMeta returns the GitHub metadata of the repo (or nil).
+func (meta RepoMetadata) GetGithubRepoMeta(repoURL string) *GithubRepoMeta {
+	repo := meta.Get(repoURL)
+	if repo == nil {
+		return nil
+	}
+	return &GithubRepoMeta{
+		Stars:    repo.GetStargazersCount(),
+		Language: repo.GetLanguage(),
+		Archived: repo.GetArchived(),
+		PushedAt: repo.GetPushedAt().Time,
+	}
+}
+
+// forEachConcurrently calls fn for each index in [0, count),
+// with at most maxWorkers concurrent calls; it stops starting new calls
+// when ctx is done, and returns when all the started calls have returned.
+func forEachConcurrently(ctx context.Context, count int, maxWorkers int64, fn func(i int)) {
+	wg := &sync.WaitGroup{}
+	sem := semaphore.NewWeighted(maxWorkers)
+	for i := 0; i < count; i++ {
+		// Acquire succeeds on a done context if a worker is free:
+		if ctx.Err() != nil || sem.Acquire(ctx, 1) != nil {
+			break
+		}
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			defer sem.Release(1)
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// fetchRepoMetadata gets the GitHub metadata of the repos,
+// with at most maxWorkers concurrent requests; the repos
+// that are not on GitHub, or that cannot be looked up, are skipped.
+func fetchRepoMetadata(ctx context.Context, repoURLs []string, maxWorkers int64) RepoMetadata {
+	meta := make(RepoMetadata)
+	mu := &sync.Mutex{}
+	forEachConcurrently(ctx, len(repoURLs), maxWorkers, func(i int) {
+		repoURL := repoURLs[i]
+		parsed, err := urlparse.Parse(repoURL, true)
+		if err != nil || parsed.Hostname != "github.com" {
+			return
+		}
+		repo, err := githubutil.GetRepo(ghRawClient, parsed.User, parsed.Repo)
+		if err != nil {
+			metrics.Inc("errors_total", "op", "get_repo")
+			Warnf("Could not get GitHub metadata of %s: %s", repoURL, err)
+			return
+		}
+		if repo == nil {
+			Debugf("%s not found on GitHub", repoURL)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// By the requested URL, which differs from
+		// the HTML URL of the repo if it was renamed:
+		meta[ToLower(repoURL)] = repo
+	})
+	return meta
+}
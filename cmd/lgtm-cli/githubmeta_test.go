@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	const count = 20
+	var running, maxRunning int32
+	mu := &sync.Mutex{}
+	visited := make(map[int]int)
+	forEachConcurrently(context.Background(), count, 3, func(i int) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if now > maxRunning {
+			maxRunning = now
+		}
+		visited[i]++
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+	})
+	if len(visited) != count {
+		t.Errorf("visited %v indexes, want %v", len(visited), count)
+	}
+	for i, times := range visited {
+		if times != 1 {
+			t.Errorf("index %v visited %v times", i, times)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("%v concurrent calls, want at most 3", maxRunning)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	forEachConcurrently(ctx, count, 3, func(i int) {
+		called = true
+	})
+	if called {
+		t.Error("no calls should start after the context is done")
+	}
+}
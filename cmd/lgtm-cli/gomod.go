@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GoModRequire is a require directive of a go.mod file.
+type GoModRequire struct {
+	Path     string
+	Version  string
+	Indirect bool
+}
+
+// GoModFile contains the parts of a go.mod file
+// that are needed to follow the dependencies of a module.
+type GoModFile struct {
+	Module   string
+	Requires []*GoModRequire
+}
+
+// parseGoModFile parses the module and require directives of a go.mod file.
+func parseGoModFile(reader io.Reader) (*GoModFile, error) {
+	mod := &GoModFile{
+		Requires: make([]*GoModRequire, 0),
+	}
+	scanner := bufio.NewScanner(reader)
+	inRequireBlock := false
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		var comment string
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if inRequireBlock {
+			if line == ")" {
+				inRequireBlock = false
+				continue
+			}
+			req, err := parseGoModRequire(line, comment)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", lineNum, err)
+			}
+			mod.Requires = append(mod.Requires, req)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %v: invalid module directive", lineNum)
+			}
+			mod.Module = strings.Trim(fields[1], `"`)
+		case "require":
+			rest := strings.TrimSpace(strings.TrimPrefix(line, "require"))
+			if rest == "(" {
+				inRequireBlock = true
+				continue
+			}
+			req, err := parseGoModRequire(rest, comment)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", lineNum, err)
+			}
+			mod.Requires = append(mod.Requires, req)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mod, nil
+}
+
+func parseGoModRequire(line string, comment string) (*GoModRequire, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid require: %q", line)
+	}
+	return &GoModRequire{
+		Path:     strings.Trim(fields[0], `"`),
+		Version:  fields[1],
+		Indirect: comment == "indirect",
+	}, nil
+}
+
+// loadGoModFile parses the go.mod file at the provided path.
+func loadGoModFile(path string) (*GoModFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	mod, err := parseGoModFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	return mod, nil
+}
+
+// findGoModFiles returns the go.mod files in the provided directory
+// and its subdirectories (vendor and testdata directories are skipped).
+func findGoModFiles(dir string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case "vendor", "testdata", ".git":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == "go.mod" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// goModDependencies returns the module paths required by the go.mod files
+// (the modules defined by the files themselves are excluded).
+func goModDependencies(mods []*GoModFile, withIndirect bool) []string {
+	local := make(map[string]bool)
+	for _, mod := range mods {
+		local[mod.Module] = true
+	}
+	deps := make([]string, 0)
+	for _, mod := range mods {
+		for _, req := range mod.Requires {
+			if local[req.Path] || (req.Indirect && !withIndirect) {
+				continue
+			}
+			deps = append(deps, req.Path)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	"github.com/gagliardetto/request"
+)
+
+// ErrUnsupportedGoRepoHost is returned when a Go import path
+// resolves to a repository that is not hosted on a supported host.
+var ErrUnsupportedGoRepoHost = errors.New("repository host not supported")
+
+// goKnownRepoHosts are the hosts where the repo root
+// is made of the first three parts of an import path.
+var goKnownRepoHosts = []string{
+	"github.com",
+	"gitlab.com",
+	"bitbucket.org",
+}
+
+// GoRepoResolver resolves Go import paths (packages or modules)
+// to the URLs of the repositories that contain them;
+// vanity import paths are resolved via their go-import meta tags
+// (or go-source meta tags when the go-import repo root is not on a supported host,
+// e.g. golang.org/x/* and gopkg.in/*).
+type GoRepoResolver struct {
+	mu *sync.Mutex
+	// prefixes maps the import path prefixes (repo roots)
+	// to the repository URLs.
+	prefixes map[string]string
+}
+
+func NewGoRepoResolver() *GoRepoResolver {
+	return &GoRepoResolver{
+		mu:       &sync.Mutex{},
+		prefixes: make(map[string]string),
+	}
+}
+
+// Resolve returns the URL of the repository that contains the provided import path.
+func (res *GoRepoResolver) Resolve(importPath string) (string, error) {
+	importPath = normalizeGoImportPath(importPath)
+	if importPath == "" {
+		return "", errors.New("import path is empty")
+	}
+	if host := strings.Split(importPath, "/")[0]; !strings.Contains(host, ".") {
+		return "", fmt.Errorf("%q is not a remote import path", importPath)
+	}
+
+	if repoURL, ok := goRepoURLFromKnownHost(importPath); ok {
+		return repoURL, nil
+	}
+
+	if repoURL, ok := res.fromCache(importPath); ok {
+		return repoURL, nil
+	}
+
+	prefix, repoURL, err := resolveGoImportMeta(importPath)
+	if err != nil {
+		return "", fmt.Errorf("error while resolving %q: %w", importPath, err)
+	}
+
+	res.mu.Lock()
+	res.prefixes[prefix] = repoURL
+	res.mu.Unlock()
+
+	return repoURL, nil
+}
+
+func (res *GoRepoResolver) fromCache(importPath string) (string, bool) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	for prefix, repoURL := range res.prefixes {
+		if isGoImportPathPrefix(prefix, importPath) {
+			return repoURL, true
+		}
+	}
+	return "", false
+}
+
+// normalizeGoImportPath removes schemes, slashes, and versions
+// from the provided import path.
+func normalizeGoImportPath(importPath string) string {
+	importPath = strings.TrimSpace(importPath)
+	// Remove the version (e.g. `example.com/foo v1.2.3` or `example.com/foo@v1.2.3`):
+	if fields := strings.Fields(importPath); len(fields) > 0 {
+		importPath = fields[0]
+	}
+	if i := strings.Index(importPath, "@"); i > -1 {
+		importPath = importPath[:i]
+	}
+	importPath = strings.TrimPrefix(importPath, "https://")
+	importPath = strings.TrimPrefix(importPath, "http://")
+	importPath = strings.Trim(importPath, "/")
+	return importPath
+}
+
+// isGoImportPathPrefix returns true if the import path is (or is inside) the prefix.
+func isGoImportPathPrefix(prefix string, importPath string) bool {
+	return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
+
+// isGoKnownRepoHost returns true if the repos of the provided host
+// can be followed on lgtm.com.
+func isGoKnownRepoHost(host string) bool {
+	for _, known := range goKnownRepoHosts {
+		if host == known {
+			return true
+		}
+	}
+	return false
+}
+
+// goRepoURLFromKnownHost returns the repo URL for import paths on known hosts.
+func goRepoURLFromKnownHost(importPath string) (string, bool) {
+	parts := strings.Split(importPath, "/")
+	if !isGoKnownRepoHost(parts[0]) || len(parts) < 3 {
+		return "", false
+	}
+	return "https://" + strings.Join(parts[:3], "/"), true
+}
+
+// resolveGoImportMeta gets the go-import (and go-source) meta tags for the import path
+// (as `go get` does), and returns the matching import path prefix
+// and the URL of the repository.
+func resolveGoImportMeta(importPath string) (string, string, error) {
+	req := request.NewRequest(webHTTPClient)
+	resp, err := req.Get("https://" + importPath + "?go-get=1")
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", lgtm.FormatHTTPNotOKStatusCodeError(resp)
+	}
+
+	reader, closer, err := resp.DecompressedReaderFromPool()
+	if err != nil {
+		return "", "", fmt.Errorf("error while getting Reader: %s", err)
+	}
+	defer closer()
+	defer resp.Body.Close()
+
+	meta, err := parseGoImportMeta(reader, importPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	repoURL, err := goRepoRootToRepoURL(meta.RepoRoot)
+	if err != nil && meta.SourceHome != "" {
+		// e.g. golang.org/x/net is on go.googlesource.com,
+		// but its go-source home is on github.com/golang/net
+		var sourceErr error
+		repoURL, sourceErr = goRepoRootToRepoURL(meta.SourceHome)
+		if sourceErr == nil {
+			err = nil
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return meta.Prefix, repoURL, nil
+}
+
+// goImportMeta contains the values of the go-import and go-source meta tags.
+type goImportMeta struct {
+	Prefix     string
+	RepoRoot   string
+	SourceHome string
+}
+
+// parseGoImportMeta returns the import path prefix and the repo root
+// of the go-import meta tag that matches the import path,
+// along with the home of the matching go-source meta tag (if any).
+func parseGoImportMeta(reader io.Reader, importPath string) (*goImportMeta, error) {
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error while goquery.NewDocumentFromReader: %s", err)
+	}
+
+	meta := &goImportMeta{}
+	doc.Find(`meta[name="go-import"]`).Each(func(i int, s *goquery.Selection) {
+		content, ok := s.Attr("content")
+		if !ok {
+			return
+		}
+		// Format: "import-prefix vcs repo-root"
+		fields := strings.Fields(content)
+		if len(fields) != 3 || fields[1] == "mod" {
+			return
+		}
+		if !isGoImportPathPrefix(fields[0], importPath) {
+			return
+		}
+		// Use the longest matching prefix:
+		if len(fields[0]) > len(meta.Prefix) {
+			meta.Prefix = fields[0]
+			meta.RepoRoot = fields[2]
+		}
+	})
+	if meta.RepoRoot == "" {
+		return nil, errors.New("go-import meta tag not found")
+	}
+
+	doc.Find(`meta[name="go-source"]`).Each(func(i int, s *goquery.Selection) {
+		content, ok := s.Attr("content")
+		if !ok {
+			return
+		}
+		// Format: "import-prefix home directory file"
+		fields := strings.Fields(content)
+		if len(fields) < 2 || fields[0] != meta.Prefix {
+			return
+		}
+		meta.SourceHome = fields[1]
+	})
+	return meta, nil
+}
+
+// goRepoRootToRepoURL converts the repo root of a go-import meta tag
+// to a repo URL on a supported host.
+func goRepoRootToRepoURL(repoRoot string) (string, error) {
+	repoURL, ok := goRepoURLFromKnownHost(normalizeGoImportPath(strings.TrimSuffix(repoRoot, ".git")))
+	if !ok {
+		return "", fmt.Errorf("%s: %w", repoRoot, ErrUnsupportedGoRepoHost)
+	}
+	return repoURL, nil
+}
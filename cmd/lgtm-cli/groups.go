@@ -0,0 +1,118 @@
+package main
+
+import (
+	. "github.com/gagliardetto/utilz"
+	"github.com/urfave/cli"
+)
+
+// CommandGroup groups existing commands as subcommands of one command
+// (e.g. "lgtm project follow" runs "lgtm follow"); the grouped commands
+// are still available under their old names.
+type CommandGroup struct {
+	Name string
+	// Aliases are other names of the group (e.g. "list" for "lists");
+	// each of them must be the name of an existing command.
+	Aliases []string
+	Usage   string
+	// Subcommands maps the names of the subcommands
+	// to the names of the commands they run.
+	Subcommands []GroupedCommand
+}
+
+// GroupedCommand is a subcommand of a CommandGroup.
+type GroupedCommand struct {
+	Name    string
+	Command string
+}
+
+var commandGroups = []CommandGroup{
+	{
+		Name:  "project",
+		Usage: "Follow, unfollow and get the stats of projects.",
+		Subcommands: []GroupedCommand{
+			{Name: "follow", Command: "follow"},
+			{Name: "unfollow", Command: "unfollow"},
+			{Name: "stats", Command: "stats-export"},
+		},
+	},
+	{
+		Name:    "lists",
+		Aliases: []string{"list"},
+		Usage:   "Create and delete lists, and add and remove their projects.",
+		Subcommands: []GroupedCommand{
+			{Name: "create", Command: "create-list"},
+			{Name: "delete", Command: "delete-list"},
+			{Name: "add", Command: "add-to-list"},
+			{Name: "remove", Command: "remove-from-list"},
+		},
+	},
+	{
+		Name:    "queries",
+		Aliases: []string{"query"},
+		Usage:   "Run queries and get their results and status.",
+		Subcommands: []GroupedCommand{
+			{Name: "run", Command: "query"},
+			{Name: "results", Command: "x-list-query-results"},
+			{Name: "status", Command: "query-run-status"},
+		},
+	},
+}
+
+// withCommandGroups adds the command groups to the commands;
+// if a command with the name (or an alias) of a group already exists
+// (e.g. "lists"), the subcommands are added to it, and it keeps working
+// as before when its first argument is not the name of a subcommand.
+func withCommandGroups(commands []cli.Command) []cli.Command {
+	byName := make(map[string]cli.Command, len(commands))
+	for _, cmd := range commands {
+		byName[cmd.Name] = cmd
+	}
+
+	for _, group := range commandGroups {
+		subcommands := make([]cli.Command, 0, len(group.Subcommands))
+		for _, sub := range group.Subcommands {
+			cmd, ok := byName[sub.Command]
+			if !ok {
+				panic(Sf("command %q of group %q not found", sub.Command, group.Name))
+			}
+			cmd.Name = sub.Name
+			cmd.Usage = Sf("%s (same as %q)", cmd.Usage, sub.Command)
+			subcommands = append(subcommands, cmd)
+		}
+
+		for _, alias := range group.Aliases {
+			if _, ok := byName[alias]; !ok {
+				panic(Sf("alias %q of group %q is not a command", alias, group.Name))
+			}
+		}
+		for i := range commands {
+			if commands[i].Name == group.Name || SliceContains(group.Aliases, commands[i].Name) {
+				commands[i] = extendCommand(commands[i], group.Usage, subcommands)
+			}
+		}
+		if _, ok := byName[group.Name]; ok {
+			continue
+		}
+		commands = append(commands, cli.Command{
+			Name:        group.Name,
+			Usage:       group.Usage,
+			Subcommands: subcommands,
+		})
+	}
+	return commands
+}
+
+// extendCommand adds the subcommands of a group to an existing command.
+// As a command with subcommands, its arguments are matched with the names
+// of the subcommands, and the flags after them are not parsed; so when
+// the first argument is not the name of a subcommand, the original command
+// is run again on the whole command line.
+func extendCommand(cmd cli.Command, groupUsage string, subcommands []cli.Command) cli.Command {
+	original := cmd
+	cmd.Description = Sf("%s %s", cmd.Usage, groupUsage)
+	cmd.Subcommands = subcommands
+	cmd.Action = func(c *cli.Context) error {
+		return original.Run(c.Parent())
+	}
+	return cmd
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// newTestGroupsApp returns an app with a stub for each command used by the
+// command groups; each stub records its name, arguments and --flag value.
+func newTestGroupsApp(ran *[]string) *cli.App {
+	stub := func(name string) cli.Command {
+		return cli.Command{
+			Name: name,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "flag"},
+			},
+			Action: func(c *cli.Context) error {
+				*ran = append(*ran, name+" "+strings.Join(c.Args(), ",")+" flag="+c.String("flag"))
+				return nil
+			},
+		}
+	}
+	var commands []cli.Command
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			commands = append(commands, stub(name))
+		}
+	}
+	add("list")
+	add("lists")
+	add("query")
+	for _, group := range commandGroups {
+		for _, sub := range group.Subcommands {
+			add(sub.Command)
+		}
+	}
+
+	app := cli.NewApp()
+	app.Commands = withCommandGroups(commands)
+	return app
+}
+
+func TestCommandGroups(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		// The old names:
+		{args: []string{"create-list", "--flag=x", "foo"}, want: "create-list foo flag=x"},
+		{args: []string{"list", "foo", "bar"}, want: "list foo,bar flag="},
+		// The flags after the arguments of an extended command are parsed:
+		{args: []string{"list", "foo", "--flag=x"}, want: "list foo flag=x"},
+		{args: []string{"list", "--flag=x", "foo"}, want: "list foo flag=x"},
+		{args: []string{"lists"}, want: "lists  flag="},
+		{args: []string{"query", "--flag=x", "github.com/foo/bar"}, want: "query github.com/foo/bar flag=x"},
+		// The groups and their aliases:
+		{args: []string{"project", "stats", "--flag=x"}, want: "stats-export  flag=x"},
+		{args: []string{"lists", "create", "foo", "--flag=x"}, want: "create-list foo flag=x"},
+		{args: []string{"list", "create", "--flag=x", "foo"}, want: "create-list foo flag=x"},
+		{args: []string{"list", "remove", "foo"}, want: "remove-from-list foo flag="},
+		{args: []string{"queries", "status", "@1"}, want: "query-run-status @1 flag="},
+		{args: []string{"query", "results", "@1", "--flag=x"}, want: "x-list-query-results @1 flag=x"},
+	}
+	for _, tt := range tests {
+		var ran []string
+		app := newTestGroupsApp(&ran)
+		if err := app.Run(append([]string{"lgtm"}, tt.args...)); err != nil {
+			t.Errorf("%q: %s", tt.args, err)
+			continue
+		}
+		if len(ran) != 1 || ran[0] != tt.want {
+			t.Errorf("%q ran %q, want %q", tt.args, ran, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// HTTPRecording is a request to lgtm.com and its response,
+// as saved by --record-http (one json file per request).
+// The credentials (cookies, nonce and all the other request headers,
+// and the cookies set by the response) are not saved, and the profile
+// of the logged-in user is redacted.
+type HTTPRecording struct {
+	Time     time.Time              `json:"time"`
+	Request  *HTTPRecordingRequest  `json:"request"`
+	Response *HTTPRecordingResponse `json:"response"`
+}
+
+type HTTPRecordingRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type HTTPRecordingResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	// Body is the raw body (e.g. still gzipped).
+	Body []byte `json:"body,omitempty"`
+}
+
+// key returns the key by which the recording is matched with the replayed
+// requests: the method, path, query and body, without the apiVersion
+// (so that a recording can be replayed with any config).
+func (rec *HTTPRecordingRequest) key() (string, error) {
+	parsed, err := url.Parse(rec.URL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	query.Del("apiVersion")
+	body := rec.Body
+	if form, err := url.ParseQuery(body); err == nil {
+		form.Del("apiVersion")
+		body = form.Encode()
+	}
+	return rec.Method + " " + parsed.Path + "?" + query.Encode() + " " + body, nil
+}
+
+// readRequestBody reads the body of the request,
+// and replaces it so that it can be sent.
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return string(body), nil
+}
+
+// recordingTransport saves each request and its response to a directory.
+type recordingTransport struct {
+	transport http.RoundTripper
+	dir       string
+	mu        *sync.Mutex
+	seq       int
+}
+
+// newRecordingTransport returns a transport that records to dir
+// (created if it does not exist); the numbering of the recordings
+// continues after the ones already in dir.
+func newRecordingTransport(transport http.RoundTripper, dir string) (*recordingTransport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTransport{
+		transport: transport,
+		dir:       dir,
+		mu:        &sync.Mutex{},
+		seq:       len(existing),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		// Network errors are not recorded.
+		return resp, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	header, respBody, err = redactResponse(req, header, respBody)
+	if err != nil {
+		// Never record a response that could not be redacted:
+		metrics.Inc("errors_total", "op", "record_http")
+		Warnf("Could not redact the response of %s %s (not recorded): %s", req.Method, req.URL.Path, err)
+		return resp, nil
+	}
+	rec := &HTTPRecording{
+		Time: time.Now().UTC(),
+		Request: &HTTPRecordingRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Body:   reqBody,
+		},
+		Response: &HTTPRecordingResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       respBody,
+		},
+	}
+	if err := t.save(rec); err != nil {
+		// Never fail the request because of the recording:
+		metrics.Inc("errors_total", "op", "record_http")
+		Warnf("Could not record the response of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// redactedPerson replaces the profile of the logged-in user in the recordings.
+var redactedPerson = map[string]interface{}{
+	"key":  "redacted",
+	"slug": "redacted",
+	"name": "redacted",
+}
+
+// redactResponse returns the header and the body to record for the response
+// of the request: the profile and the external accounts of the logged-in user
+// (returned by getLoggedInUser) are replaced, and the body is saved uncompressed.
+// The other responses are returned as they are.
+func redactResponse(req *http.Request, header http.Header, body []byte) (http.Header, []byte, error) {
+	if !strings.HasSuffix(req.URL.Path, "/getLoggedInUser") {
+		return header, body, nil
+	}
+	decoded := body
+	if strings.EqualFold(strings.TrimSpace(header.Get("Content-Encoding")), "gzip") {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		decoded, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(decoded, &response); err != nil {
+		return nil, nil, err
+	}
+	if data, ok := response["data"].([]interface{}); ok {
+		for _, item := range data {
+			if user, ok := item.(map[string]interface{}); ok {
+				user["person"] = redactedPerson
+				delete(user, "externalAccounts")
+			}
+		}
+	}
+	redacted, err := json.Marshal(response)
+	if err != nil {
+		return nil, nil, err
+	}
+	header = header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return header, redacted, nil
+}
+
+func (t *recordingTransport) save(rec *HTTPRecording) error {
+	js, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	return ioutil.WriteFile(filepath.Join(t.dir, Sf("%06d.json", t.seq)), js, 0600)
+}
+
+// replayTransport serves the responses recorded with --record-http
+// instead of sending the requests. Identical requests get the recorded
+// responses in the order they were recorded (the last one is repeated
+// once they are exhausted); a request that was not recorded fails.
+type replayTransport struct {
+	mu         *sync.Mutex
+	recordings map[string][]*HTTPRecording
+	served     map[string]int
+}
+
+// newReplayTransport loads the recordings of the directory.
+func newReplayTransport(dir string) (*replayTransport, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+	sort.Strings(paths)
+	t := &replayTransport{
+		mu:         &sync.Mutex{},
+		recordings: make(map[string][]*HTTPRecording),
+		served:     make(map[string]int),
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var rec HTTPRecording
+		if err := json.Unmarshal(content, &rec); err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+		}
+		if rec.Request == nil || rec.Response == nil {
+			return nil, fmt.Errorf("invalid recording %s: missing request or response", path)
+		}
+		key, err := rec.Request.key()
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+		}
+		t.recordings[key] = append(t.recordings[key], &rec)
+	}
+	return t, nil
+}
+
+// Len returns the number of recordings.
+func (t *replayTransport) Len() int {
+	var count int
+	for _, recs := range t.recordings {
+		count += len(recs)
+	}
+	return count
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key, err := (&HTTPRecordingRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Body:   reqBody,
+	}).key()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	recs := t.recordings[key]
+	index := t.served[key]
+	if index >= len(recs) {
+		index = len(recs) - 1
+	} else {
+		t.served[key]++
+	}
+	t.mu.Unlock()
+
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+	}
+	rec := recs[index].Response
+	return &http.Response{
+		Status:        Sf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// newReplayConfig returns the config used to replay recordings without
+// a config: the credentials are placeholders, since no request is sent.
+func newReplayConfig() *lgtm.Config {
+	return &lgtm.Config{
+		APIVersion: "replay",
+		Session: &lgtm.LGTMSession{
+			Nonce:        "replay",
+			ShortSession: "replay",
+			LongSession:  "replay",
+		},
+		GitHub: &lgtm.GithubConfig{
+			Token: "replay",
+		},
+	}
+}
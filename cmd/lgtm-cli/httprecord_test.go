@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLoggedInUser = `{"status":"success","data":[{"person":{"key":"123","slug":"Alice","name":"Alice Smith","avatarUrl":"https://example.com/a.png"},"externalAccounts":[{"provider":"github","username":"alice"}],"hasAdminPanelAccess":true}]}`
+
+func newTestLGTMServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/internal_api/v0.2/getLoggedInUser":
+			http.SetCookie(w, &http.Cookie{Name: "lgtm_short_session", Value: "secret-session"})
+			buf := &bytes.Buffer{}
+			gz := gzip.NewWriter(buf)
+			gz.Write([]byte(testLoggedInUser))
+			gz.Close()
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(buf.Bytes())
+		case "/internal_api/v0.2/getProject":
+			w.Write([]byte(`{"status":"success","data":{"key":"1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func getBody(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	// No transparent decompression, as with the lgtm client:
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err = ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return string(body)
+}
+
+func TestRecordAndReplayHTTP(t *testing.T) {
+	server := newTestLGTMServer()
+	defer server.Close()
+	dir := t.TempDir()
+
+	recorder, err := newRecordingTransport(http.DefaultTransport, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordingClient := &http.Client{Transport: recorder}
+	if got := getBody(t, recordingClient, server.URL+"/internal_api/v0.2/getLoggedInUser?apiVersion=1"); got != testLoggedInUser {
+		t.Fatalf("the recorded response was altered: %q", got)
+	}
+	projectBody := getBody(t, recordingClient, server.URL+"/internal_api/v0.2/getProject?apiVersion=1&key=1")
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %v recordings, want 2", len(paths))
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"secret-session", "Alice", "alice"} {
+			if bytes.Contains(content, []byte(secret)) {
+				t.Errorf("%s contains %q", filepath.Base(path), secret)
+			}
+		}
+	}
+
+	replayer, err := newReplayTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replay with another apiVersion, which is ignored:
+	replayClient := &http.Client{Transport: replayer}
+	user := getBody(t, replayClient, "https://lgtm.com/internal_api/v0.2/getLoggedInUser?apiVersion=2")
+	if !strings.Contains(user, `"slug":"redacted"`) || !strings.Contains(user, `"hasAdminPanelAccess":true`) {
+		t.Errorf("unexpected replayed user: %q", user)
+	}
+	if got := getBody(t, replayClient, "https://lgtm.com/internal_api/v0.2/getProject?apiVersion=2&key=1"); got != projectBody {
+		t.Errorf("replayed getProject = %q, want %q", got, projectBody)
+	}
+	if _, err := replayClient.Get("https://lgtm.com/internal_api/v0.2/getProject?apiVersion=2&key=2"); err == nil {
+		t.Errorf("replaying a request that was not recorded should fail")
+	}
+}
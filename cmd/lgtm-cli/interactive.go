@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/githubutil"
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// curateFollowTargets asks, for each repo, whether to follow it,
+// and returns the approved repos (in their original order).
+// The answers are y(es), n(o), a(ll: this and all the remaining repos)
+// and q(uit: none of the remaining repos).
+// The GitHub metadata of the repos not in meta (which can be nil)
+// is looked up before asking.
+func curateFollowTargets(repoURLs []string, meta RepoMetadata) []string {
+	if len(repoURLs) == 0 {
+		return repoURLs
+	}
+	input, closer := openPromptInput()
+	defer closer()
+	reader := bufio.NewReader(input)
+
+	approved := make([]string, 0, len(repoURLs))
+RepoLoop:
+	for i, repoURL := range repoURLs {
+		Ln()
+		Sfln("[%v/%v] %s", i+1, len(repoURLs), Bold(repoURL))
+		printRepoSummary(repoURL, meta)
+
+		for {
+			fmt.Print("Follow? [y]es/[n]o/[a]ll/[q]uit: ")
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				Warnf("Could not read answer: %s; not asking about the remaining %v repos.", err, len(repoURLs)-i)
+				break RepoLoop
+			}
+			switch ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				approved = append(approved, repoURL)
+				continue RepoLoop
+			case "n", "no":
+				continue RepoLoop
+			case "a", "all":
+				approved = append(approved, repoURLs[i:]...)
+				break RepoLoop
+			case "q", "quit":
+				break RepoLoop
+			}
+		}
+	}
+	Ln()
+	Infof("Approved %v of %v projects.", len(approved), len(repoURLs))
+	return approved
+}
+
+// printRepoSummary prints the stars, language and description of the repo.
+func printRepoSummary(repoURL string, meta RepoMetadata) {
+	repo := meta.Get(repoURL)
+	if repo == nil {
+		repo = lookupGithubRepo(repoURL)
+		if repo != nil && meta != nil {
+			meta.Add(repo)
+		}
+	}
+	if repo == nil {
+		Sfln("    (no GitHub metadata)")
+		return
+	}
+	lang := repo.GetLanguage()
+	if lang == "" {
+		lang = "-"
+	}
+	Sfln("    stars: %v, language: %s", repo.GetStargazersCount(), lang)
+	if desc := repo.GetDescription(); desc != "" {
+		Sfln("    %s", desc)
+	}
+}
+
+// lookupGithubRepo gets the metadata of the repo
+// (or nil, if it is not a GitHub repo or the lookup fails).
+func lookupGithubRepo(repoURL string) *github.Repository {
+	parsed, err := urlparse.Parse(repoURL, true)
+	if err != nil || parsed.Hostname != "github.com" {
+		return nil
+	}
+	repo, err := githubutil.GetRepo(ghRawClient, parsed.User, parsed.Repo)
+	if err != nil {
+		Debugf("Could not get metadata of %s: %s", repoURL, err)
+		return nil
+	}
+	return repo
+}
+
+// openPromptInput returns the terminal to read answers from,
+// so that prompts work even when the targets are piped through stdin;
+// it falls back to stdin when there is no terminal.
+func openPromptInput() (io.Reader, func()) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return os.Stdin, func() {}
+	}
+	return tty, func() { tty.Close() }
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// newInterruptContext returns a context that is canceled on the first
+// SIGINT/SIGTERM, so that the running command can finish the in-flight
+// requests and exit cleanly; a second signal exits immediately.
+func newInterruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		Warnf("Interrupted; finishing in-flight requests (interrupt again to exit immediately) ...")
+		cancel()
+
+		<-signals
+		Errorln(RedBG("Interrupted again; exiting"))
+		os.Exit(ExitCodeInterrupted)
+	}()
+
+	return ctx
+}
+
+// stopOnInterrupt returns true if the context has been canceled;
+// the targets that were not processed yet are saved to a file,
+// so that the run can be resumed.
+func stopOnInterrupt(ctx context.Context, cmdName string, remaining []string) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	Warnf("Stopped; %v targets were not processed", len(remaining))
+	if len(remaining) > 0 {
+		saveTargetListToTempFile("", cmdName+"-remaining", remaining)
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gagliardetto/lgtm-cli/internal/cache"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// keyCache is the project key cache shared by all the commands
+// (nil if --no-key-cache is set).
+var keyCache *cache.ProjectKeyCache
+
+// getProjectBySlug returns the project of the slug: by its cached key
+// if any, else by slug (and then its key is cached).
+// The cached key is invalidated only if it is not found; on other
+// errors (e.g. a timeout) it is kept, and the slug is looked up.
+func getProjectBySlug(cl *lgtm.Client, slug string) (*lgtm.Project, error) {
+	if key, ok := keyCache.Get(cl, slug); ok {
+		got, err := cl.GetProjectsByKey(key)
+		switch {
+		case err == nil:
+			if pr := got.GetProject(key); pr != nil {
+				return pr, nil
+			}
+			Debugf("The cached key %s of %s was not found; looking it up by slug", key, slug)
+			keyCache.Invalidate(cl, slug)
+		case lgtm.IsNotFound(err):
+			Debugf("The cached key %s of %s was not found; looking it up by slug", key, slug)
+			keyCache.Invalidate(cl, slug)
+		default:
+			Debugf("Could not get %s by its cached key %s: %s; looking it up by slug", slug, key, err)
+		}
+	}
+	pr, err := cl.GetProjectBySlug(slug)
+	if err != nil {
+		if lgtm.IsNotFound(err) {
+			keyCache.Invalidate(cl, slug)
+		}
+		return nil, err
+	}
+	keyCache.Set(cl, slug, pr.Key)
+	return pr, nil
+}
+
+// getProjectsByCachedKeys looks up in bulk the projects of the slugs
+// whose key is cached; the result maps the slugs to the projects found.
+// The slugs whose cached key was not found are invalidated; the
+// slugs that are not in the result must be looked up by slug.
+func getProjectsByCachedKeys(ctx context.Context, cl *lgtm.Client, slugs []string) map[string]*lgtm.Project {
+	found := make(map[string]*lgtm.Project)
+	keysBySlug := make(map[string]string)
+	keys := make([]string, 0)
+	for _, slug := range slugs {
+		if key, ok := keyCache.Get(cl, slug); ok {
+			keysBySlug[slug] = key
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return found
+	}
+	got, err := cl.GetProjectsByKeyBulk(ctx, lgtm.DefaultBulkMaxWorkers, keys...)
+	if err != nil {
+		// The slugs of the failed chunks are looked up by slug.
+		Warnf("Could not look up some projects by their cached keys: %s", err)
+	}
+	for slug, key := range keysBySlug {
+		if pr := got.GetProject(key); pr != nil {
+			found[slug] = pr
+			continue
+		}
+		if !SliceContains(got.FailedKeys, key) {
+			keyCache.Invalidate(cl, slug)
+		}
+	}
+	return found
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/lgtm-cli/internal/cache"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	"go.uber.org/ratelimit"
+)
+
+func TestGetProjectBySlugInvalidation(t *testing.T) {
+	tests := []struct {
+		name string
+		// byKey is the response of getProjectsByKey (empty for a 502),
+		// bySlug the one of getProjectBySlug.
+		byKey       string
+		bySlug      string
+		wantErr     bool
+		wantProject string
+		wantKey     string
+		wantCached  bool
+	}{
+		{
+			name:        "cached key found",
+			byKey:       `{"status":"success","data":{"fullProjects":{"1":{"key":"1","slug":"g/foo/bar"}}}}`,
+			wantProject: "1",
+			wantKey:     "1",
+			wantCached:  true,
+		},
+		{
+			name:        "cached key not in the response",
+			byKey:       `{"status":"success","data":{"fullProjects":{}}}`,
+			bySlug:      `{"status":"success","data":{"left":{"key":"2","slug":"g/foo/bar"}}}`,
+			wantProject: "2",
+			wantKey:     "2",
+			wantCached:  true,
+		},
+		{
+			name:    "cached key not found, and slug not found",
+			byKey:   `{"status":"error","error":"not found"}`,
+			bySlug:  `{"status":"error","error":"not found"}`,
+			wantErr: true,
+		},
+		{
+			name:       "failed lookups keep the cached key",
+			wantErr:    true,
+			wantKey:    "1",
+			wantCached: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body string
+				switch r.URL.Path {
+				case "/internal_api/v0.2/getProjectsByKey":
+					body = tt.byKey
+				case "/internal_api/v0.2/getProjectBySlug":
+					body = tt.bySlug
+				}
+				if body == "" {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+			cl, err := lgtm.NewClient(&lgtm.Config{
+				BaseURL:    server.URL,
+				APIVersion: "1",
+				Session:    &lgtm.LGTMSession{Nonce: "nonce", ShortSession: "short", LongSession: "long"},
+				GitHub:     &lgtm.GithubConfig{Token: "token"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cl = cl.WithRateLimiter(ratelimit.NewUnlimited())
+
+			previous := keyCache
+			defer func() { keyCache = previous }()
+			keyCache, err = cache.LoadProjectKeyCache(filepath.Join(t.TempDir(), "keys.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyCache.Set(cl, "g/foo/bar", "1")
+
+			pr, err := getProjectBySlug(cl, "g/foo/bar")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getProjectBySlug() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && pr.Key != tt.wantProject {
+				t.Errorf("got project %s, want %s", pr.Key, tt.wantProject)
+			}
+			if key, ok := keyCache.Get(cl, "g/foo/bar"); key != tt.wantKey || ok != tt.wantCached {
+				t.Errorf("cached key = %q, %v; want %q, %v", key, ok, tt.wantKey, tt.wantCached)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	. "github.com/gagliardetto/utilz"
+)
+
+// githubLanguageNames maps the lgtm.com languages to the (lowercased)
+// GitHub languages whose code they analyze; the other lgtm.com languages
+// have the same name on GitHub.
+var githubLanguageNames = map[string][]string{
+	"cpp":        {"c", "c++"},
+	"csharp":     {"c#"},
+	"javascript": {"javascript", "typescript"},
+}
+
+// languageBytes returns the bytes of code of the lgtm.com language,
+// from the bytes by (lowercased) GitHub language.
+func languageBytes(bytesByLang map[string]int, lang string) int {
+	names, ok := githubLanguageNames[lang]
+	if !ok {
+		names = []string{lang}
+	}
+	var total int
+	for _, name := range names {
+		total += bytesByLang[name]
+	}
+	return total
+}
+
+// GithubLanguageBytes returns the bytes of code of the repo
+// by (lowercased) GitHub language.
+func GithubLanguageBytes(owner string, repo string) (map[string]int, error) {
+	languagesMap, err := ghClient.ListLanguagesOfRepo(strings.TrimSpace(owner), strings.TrimSpace(repo))
+	if err != nil {
+		return nil, err
+	}
+	bytesByLang := make(map[string]int, len(languagesMap))
+	for name, bytes := range languagesMap {
+		bytesByLang[ToLower(name)] += bytes
+	}
+	return bytesByLang, nil
+}
+
+// filterReposByLanguageBytes returns the repos that have at least minBytes
+// of code of the lgtm.com language (in the same order), looking up the languages
+// of the repos with at most maxWorkers concurrent requests.
+// The repos whose languages cannot be looked up are skipped.
+func filterReposByLanguageBytes(ctx context.Context, repoURLs []string, lang string, minBytes int, maxWorkers int64) []string {
+	keep := make([]bool, len(repoURLs))
+	forEachConcurrently(ctx, len(repoURLs), maxWorkers, func(i int) {
+		repoURL := repoURLs[i]
+		parsed, err := urlparse.Parse(repoURL, true)
+		if err != nil {
+			Warnf("Skipping %s: %s", repoURL, err)
+			return
+		}
+		bytesByLang, err := GithubLanguageBytes(parsed.User, parsed.Repo)
+		if err != nil {
+			metrics.Inc("errors_total", "op", "languages")
+			Warnf("Skipping %s: could not get its languages: %s", repoURL, err)
+			return
+		}
+		bytes := languageBytes(bytesByLang, lang)
+		if bytes < minBytes {
+			Debugf("Skipping %s: has %v bytes of %s", repoURL, bytes, lang)
+			return
+		}
+		keep[i] = true
+	})
+
+	filtered := make([]string, 0)
+	for i, repoURL := range repoURLs {
+		if keep[i] {
+			filtered = append(filtered, repoURL)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// LanguageStats contains the aggregated stats of a language
+// over a set of projects.
+type LanguageStats struct {
+	Lang     string `json:"lang"`
+	Projects int    `json:"projects"`
+	Churn    int    `json:"churn"`
+	// Lines is only set when the lines of code were requested.
+	Lines int `json:"lines,omitempty"`
+}
+
+// ProjectSize is the size of a project (over all its languages).
+type ProjectSize struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Churn int    `json:"churn"`
+	Lines int    `json:"lines,omitempty"`
+}
+
+// LanguageStatsReport contains the language stats of a set of projects.
+type LanguageStatsReport struct {
+	Projects  int              `json:"projects"`
+	Languages []*LanguageStats `json:"languages"`
+	// Biggest are the biggest projects (by lines if available, otherwise by churn).
+	Biggest []*ProjectSize `json:"biggest"`
+
+	byLang   map[string]*LanguageStats
+	sizes    []*ProjectSize
+	hasLines bool
+}
+
+// NewLanguageStatsReport returns a new empty report.
+func NewLanguageStatsReport() *LanguageStatsReport {
+	return &LanguageStatsReport{
+		Languages: make([]*LanguageStats, 0),
+		Biggest:   make([]*ProjectSize, 0),
+		byLang:    make(map[string]*LanguageStats),
+		sizes:     make([]*ProjectSize, 0),
+	}
+}
+
+func (rep *LanguageStatsReport) lang(lang string) *LanguageStats {
+	stats, ok := rep.byLang[lang]
+	if !ok {
+		stats = &LanguageStats{Lang: lang}
+		rep.byLang[lang] = stats
+		rep.Languages = append(rep.Languages, stats)
+	}
+	return stats
+}
+
+// Add adds the project to the report; the lines of code per language
+// are optional (nil if they were not requested).
+func (rep *LanguageStatsReport) Add(pr *lgtm.Project, lines map[string]int) {
+	rep.Projects++
+	size := &ProjectSize{
+		Name: pr.DisplayName,
+		URL:  pr.ExternalURL.URL,
+	}
+	for _, lang := range pr.Languages {
+		rep.lang(lang).Projects++
+	}
+	for _, churn := range pr.TotalLanguageChurn {
+		rep.lang(churn.Lang).Churn += churn.Churn
+		size.Churn += churn.Churn
+	}
+	if lines != nil {
+		rep.hasLines = true
+		for lang, count := range lines {
+			rep.lang(lang).Lines += count
+			size.Lines += count
+		}
+	}
+	rep.sizes = append(rep.sizes, size)
+}
+
+// Finalize sorts the languages by number of projects, and selects
+// the top biggest projects.
+func (rep *LanguageStatsReport) Finalize(top int) {
+	sort.SliceStable(rep.Languages, func(i, j int) bool {
+		a, b := rep.Languages[i], rep.Languages[j]
+		if a.Projects != b.Projects {
+			return a.Projects > b.Projects
+		}
+		return a.Lang < b.Lang
+	})
+	sort.SliceStable(rep.sizes, func(i, j int) bool {
+		a, b := rep.sizes[i], rep.sizes[j]
+		if rep.hasLines && a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Name < b.Name
+	})
+	rep.Biggest = rep.sizes
+	if top > 0 && len(rep.Biggest) > top {
+		rep.Biggest = rep.Biggest[:top]
+	}
+}
+
+// Print prints the breakdown by language and the biggest projects.
+func (rep *LanguageStatsReport) Print() {
+	if rep.hasLines {
+		Errorln(Bold("LANG | PROJECTS | LINES | CHURN"))
+	} else {
+		Errorln(Bold("LANG | PROJECTS | CHURN"))
+	}
+	for _, stats := range rep.Languages {
+		if rep.hasLines {
+			Sfln("%s | %v | %v | %v", stats.Lang, stats.Projects, stats.Lines, stats.Churn)
+		} else {
+			Sfln("%s | %v | %v", stats.Lang, stats.Projects, stats.Churn)
+		}
+	}
+	Ln()
+	if rep.hasLines {
+		Errorln(Bold("PROJECT | URL | LINES | CHURN"))
+	} else {
+		Errorln(Bold("PROJECT | URL | CHURN"))
+	}
+	for _, size := range rep.Biggest {
+		if rep.hasLines {
+			Sfln("%s | %s | %v | %v", size.Name, size.URL, size.Lines, size.Churn)
+		} else {
+			Sfln("%s | %s | %v", size.Name, size.URL, size.Churn)
+		}
+	}
+	Ln()
+	Successf("Total: %v projects, %v languages", rep.Projects, len(rep.Languages))
+}
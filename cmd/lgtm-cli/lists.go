@@ -0,0 +1,667 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"golang.org/x/sync/semaphore"
+)
+
+// sortListStats sorts the provided stats by the provided key (name or count).
+func sortListStats(stats []*lgtm.ListStats, by string) error {
+	switch by {
+	case "", "name":
+		sort.Slice(stats, func(i, j int) bool {
+			return stats[i].Name < stats[j].Name
+		})
+	case "count":
+		sort.SliceStable(stats, func(i, j int) bool {
+			return stats[i].ProjectCount > stats[j].ProjectCount
+		})
+	default:
+		return fmt.Errorf("unknown sort key: %q", by)
+	}
+	return nil
+}
+
+// sortedByCount returns the keys of the provided counts
+// (e.g. a language breakdown), sorted by count (descending) and then by name.
+func sortedByCount(counts map[string]int) []string {
+	res := make([]string, 0, len(counts))
+	for key := range counts {
+		res = append(res, key)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if counts[res[i]] == counts[res[j]] {
+			return res[i] < res[j]
+		}
+		return counts[res[i]] > counts[res[j]]
+	})
+	return res
+}
+
+// ListAdder adds built projects to a list, in chunks, as they get followed.
+// A nil *ListAdder is valid and does nothing.
+type ListAdder struct {
+	client   *lgtm.Client
+	list     *lgtm.ProjectSelectionBare
+	inList   map[string]bool
+	pending  []string
+	added    int
+	notBuilt int
+}
+
+// getOrCreateList returns the list with the provided name,
+// creating it if it does not exist.
+func getOrCreateList(cl *lgtm.Client, name string) (*lgtm.ProjectSelectionBare, error) {
+	list, created, err := cl.GetOrCreateProjectSelection(name)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		Infof("Created new list %q", name)
+	}
+	return list, nil
+}
+
+// NewListAdder returns a new ListAdder for the list with the provided name;
+// the list is created if it does not exist.
+func NewListAdder(cl *lgtm.Client, name string) (*ListAdder, error) {
+	list, err := getOrCreateList(cl, name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cl.ListProjectsInSelection(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", name, err)
+	}
+	inList := make(map[string]bool)
+	for _, key := range resp.ProjectKeys {
+		inList[key] = true
+	}
+	return &ListAdder{
+		// The projects followed before an interrupt
+		// are still added to the list on Close:
+		client:  cl.WithContext(context.Background()),
+		list:    list,
+		inList:  inList,
+		pending: make([]string, 0),
+	}, nil
+}
+
+// mustNewListAdder returns a new ListAdder, or nil if the name is empty.
+func mustNewListAdder(cl *lgtm.Client, name string) *ListAdder {
+	if name == "" {
+		return nil
+	}
+	adder, err := NewListAdder(cl, name)
+	if err != nil {
+		panic(err)
+	}
+	return adder
+}
+
+// AddEnvelope adds the project of the envelope (if it is a built project).
+func (la *ListAdder) AddEnvelope(env *lgtm.Envelope) {
+	if la == nil || env == nil {
+		return
+	}
+	pr := env.GetProject()
+	if pr == nil {
+		// Proto-projects cannot be added to a list.
+		la.notBuilt++
+		return
+	}
+	la.AddProject(pr)
+}
+
+// AddProject adds the project to the list.
+func (la *ListAdder) AddProject(pr *lgtm.Project) {
+	if la == nil || pr == nil {
+		return
+	}
+	if la.inList[pr.Key] {
+		return
+	}
+	la.inList[pr.Key] = true
+	la.pending = append(la.pending, pr.Key)
+	if len(la.pending) >= 100 {
+		if err := la.flush(); err != nil {
+			Errorf("Error while adding projects to %q list: %s", la.list.Name, err)
+		}
+	}
+}
+
+// AddFollowed adds the already-followed projects among the provided repo URLs.
+func (la *ListAdder) AddFollowed(cache *lgtm.FollowedProjectCache, repoURLs []string) {
+	if la == nil || cache == nil {
+		return
+	}
+	for _, repoURL := range repoURLs {
+		la.AddProject(cache.GetProject(repoURL))
+	}
+}
+
+func (la *ListAdder) flush() error {
+	if len(la.pending) == 0 {
+		return nil
+	}
+	err := la.client.AddProjectToSelection(la.list.Key, la.pending...)
+	if err != nil {
+		return err
+	}
+	la.added += len(la.pending)
+	la.pending = make([]string, 0)
+	return nil
+}
+
+// Close adds the remaining projects to the list.
+func (la *ListAdder) Close() error {
+	if la == nil {
+		return nil
+	}
+	if err := la.flush(); err != nil {
+		return fmt.Errorf("error while adding projects to %q list: %w", la.list.Name, err)
+	}
+	Successf("Added %v new projects to %q list.", la.added, la.list.Name)
+	if la.notBuilt > 0 {
+		Warnf("%v followed projects are not built yet, and were not added to %q list.", la.notBuilt, la.list.Name)
+	}
+	return nil
+}
+
+// SelectionChunk is a chunk of project keys to be added to a list.
+type SelectionChunk struct {
+	Index int      `json:"index"`
+	Keys  []string `json:"keys"`
+	Error string   `json:"error,omitempty"`
+}
+
+// addToSelectionInChunks adds the project keys to the list in chunks of 100,
+// with at most maxWorkers concurrent requests; a chunk that fails is retried
+// with backoff up to maxRetries times. It returns the number of added projects
+// and the chunks that could not be added.
+func addToSelectionInChunks(
+	ctx context.Context,
+	cl *lgtm.Client,
+	list *lgtm.ProjectSelectionBare,
+	projectKeys []string,
+	maxWorkers int64,
+	maxRetries int,
+) (int, []*SelectionChunk) {
+	chunks := make([]*SelectionChunk, 0)
+	partsNumber := lgtm.CalcChunkCount(len(projectKeys), 100)
+	for _, keys := range SplitStringSlice(partsNumber, projectKeys) {
+		if len(keys) == 0 {
+			continue
+		}
+		chunks = append(chunks, &SelectionChunk{
+			Index: len(chunks),
+			Keys:  keys,
+		})
+	}
+
+	var added int
+	failed := make([]*SelectionChunk, 0)
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+	sem := semaphore.NewWeighted(maxWorkers)
+	for _, chunk := range chunks {
+		if ctx.Err() != nil || sem.Acquire(ctx, 1) != nil {
+			chunk.Error = "interrupted"
+			mu.Lock()
+			failed = append(failed, chunk)
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+
+		go func(chunk *SelectionChunk) {
+			defer wg.Done()
+			defer sem.Release(1)
+
+			Infof(
+				"Adding projects to %q list; chunk %v/%v...",
+				list.Name,
+				chunk.Index+1,
+				len(chunks),
+			)
+			err := addChunkWithRetry(ctx, cl, list, chunk, maxRetries)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				metrics.Inc("errors_total", "op", "add-to-list")
+				Errorf(
+					"Failed to add chunk %v/%v to %q list: %s",
+					chunk.Index+1,
+					len(chunks),
+					list.Name,
+					err,
+				)
+				chunk.Error = err.Error()
+				failed = append(failed, chunk)
+				return
+			}
+			added += len(chunk.Keys)
+		}(chunk)
+	}
+	wg.Wait()
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].Index < failed[j].Index
+	})
+	return added, failed
+}
+
+func addChunkWithRetry(ctx context.Context, cl *lgtm.Client, list *lgtm.ProjectSelectionBare, chunk *SelectionChunk, maxRetries int) error {
+	for attempt := 1; ; attempt++ {
+		err := cl.AddProjectToSelection(list.Key, chunk.Keys...)
+		if err == nil || attempt > maxRetries || ctx.Err() != nil {
+			return err
+		}
+		backoff := lgtm.RetryBackoff(attempt)
+		Warnf(
+			"Error while adding chunk %v to %q list (%s); retrying in %s (attempt %v/%v) ...",
+			chunk.Index+1,
+			list.Name,
+			err,
+			backoff,
+			attempt,
+			maxRetries,
+		)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// AddToListReport contains the repos that could not be added to the lists, and why.
+type AddToListReport struct {
+	Added map[string]int `json:"added"`
+	// Proto are followed proto-projects (not built yet).
+	Proto []string `json:"proto"`
+	// NotBuilt are repos that lgtm.com has no built project for.
+	NotBuilt   []string          `json:"notBuilt"`
+	Unresolved []*SlugResolution `json:"unresolved"`
+	Failed     []string          `json:"failed"`
+}
+
+func NewAddToListReport() *AddToListReport {
+	return &AddToListReport{
+		Added:      make(map[string]int),
+		Proto:      make([]string, 0),
+		NotBuilt:   make([]string, 0),
+		Unresolved: make([]*SlugResolution, 0),
+		Failed:     make([]string, 0),
+	}
+}
+
+// Print prints the number of added and skipped repos.
+func (rep *AddToListReport) Print() {
+	for _, name := range sortedByCount(rep.Added) {
+		Successf("Added %v new projects to %q list.", rep.Added[name], name)
+	}
+	if len(rep.Proto) > 0 {
+		Warnf("%v repos were skipped because they are proto-projects (not built yet)", len(rep.Proto))
+	}
+	if len(rep.NotBuilt) > 0 {
+		Warnf("%v repos were skipped because they are not built projects", len(rep.NotBuilt))
+	}
+	if len(rep.Unresolved) > 0 {
+		Errorf("%v repos were skipped because they could not be looked up on lgtm.com", len(rep.Unresolved))
+	}
+	if len(rep.Failed) > 0 {
+		Errorf("%v projects could not be added to the lists", len(rep.Failed))
+	}
+}
+
+// WriteToFile saves the report as json to the provided file (or remote output).
+func (rep *AddToListReport) WriteToFile(path string) error {
+	js, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
+
+// ListsExport is a backup of lists, with the URLs of their projects
+// (project keys are not portable across accounts).
+type ListsExport struct {
+	ExportedAt time.Time       `json:"exportedAt"`
+	Account    string          `json:"account,omitempty"`
+	Lists      []*ExportedList `json:"lists"`
+}
+
+// ExportedList is a list and the URLs of its projects.
+type ExportedList struct {
+	Name     string   `json:"name"`
+	Projects []string `json:"projects"`
+}
+
+// ImportedList contains the results of the import of a list.
+type ImportedList struct {
+	Name      string `json:"name"`
+	Added     int    `json:"added"`
+	AlreadyIn int    `json:"alreadyIn"`
+	// Skipped are the projects that could not be resolved to a built project.
+	Skipped []string `json:"skipped"`
+	Failed  []string `json:"failed"`
+}
+
+// importList recreates the list (if it does not exist) and adds
+// the projects to it: the followed projects are found in the cache (if not nil),
+// the others are looked up on lgtm.com.
+func importList(
+	ctx context.Context,
+	cl *lgtm.Client,
+	cache *lgtm.FollowedProjectCache,
+	exported *ExportedList,
+	maxWorkers int64,
+	maxResolveWorkers int64,
+	maxRetries int,
+) (*ImportedList, error) {
+	res := &ImportedList{
+		Name:    exported.Name,
+		Skipped: make([]string, 0),
+		Failed:  make([]string, 0),
+	}
+	list, err := getOrCreateList(cl, exported.Name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := cl.ListProjectsInSelection(exported.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", exported.Name, err)
+	}
+
+	projectKeys := make([]string, 0)
+	projectURLs := make(map[string]string)
+	addProject := func(pr *lgtm.Project, repoURL string) {
+		if SliceContains(current.ProjectKeys, pr.Key) {
+			res.AlreadyIn++
+			return
+		}
+		if _, ok := projectURLs[pr.Key]; ok {
+			return
+		}
+		projectKeys = append(projectKeys, pr.Key)
+		projectURLs[pr.Key] = repoURL
+	}
+
+	for _, resolved := range resolveProjects(ctx, cl, cache, Deduplicate(exported.Projects), maxResolveWorkers) {
+		if resolved.Project == nil {
+			res.Skipped = append(res.Skipped, resolved.URL)
+			continue
+		}
+		addProject(resolved.Project, resolved.URL)
+	}
+
+	added, failedChunks := addToSelectionInChunks(ctx, cl, list, projectKeys, maxWorkers, maxRetries)
+	res.Added = added
+	for _, chunk := range failedChunks {
+		for _, key := range chunk.Keys {
+			res.Failed = append(res.Failed, projectURLs[key])
+		}
+	}
+	return res, nil
+}
+
+// ListGarbage contains the projects of a list that should be removed from it.
+type ListGarbage struct {
+	Name string `json:"name"`
+	// Dead are the keys that don't resolve to a project anymore.
+	Dead []string `json:"dead"`
+	// Unfollowed are the URLs of the projects that are not followed anymore.
+	Unfollowed []string `json:"unfollowed"`
+	Removed    bool     `json:"removed"`
+
+	list           *lgtm.ProjectSelectionBare
+	unfollowedKeys []string
+}
+
+// Keys returns the keys of all the projects to be removed from the list.
+func (lg *ListGarbage) Keys() []string {
+	return append(append([]string{}, lg.Dead...), lg.unfollowedKeys...)
+}
+
+// findListGarbage resolves the keys of the list, and returns the ones
+// that don't resolve to a project anymore; if followedKeys is not nil,
+// the projects that are not followed anymore are returned too.
+func findListGarbage(cl *lgtm.Client, list *lgtm.ProjectSelectionBare, followedKeys map[string]bool) (*ListGarbage, error) {
+	resp, err := cl.ListProjectsInSelection(list.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", list.Name, err)
+	}
+	garbage := &ListGarbage{
+		Name:           list.Name,
+		Dead:           make([]string, 0),
+		Unfollowed:     make([]string, 0),
+		list:           list,
+		unfollowedKeys: make([]string, 0),
+	}
+	if len(resp.ProjectKeys) == 0 {
+		return garbage, nil
+	}
+
+	got, err := cl.GetProjectsByKeyBulk(cl.Context(), lgtm.DefaultBulkMaxWorkers, resp.ProjectKeys...)
+	if err != nil {
+		// Don't report as dead the keys that could not be looked up.
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", list.Name, err)
+	}
+	for _, key := range Deduplicate(resp.ProjectKeys) {
+		pr := got.GetProject(key)
+		if pr == nil {
+			garbage.Dead = append(garbage.Dead, key)
+			continue
+		}
+		if followedKeys != nil && !followedKeys[key] {
+			garbage.Unfollowed = append(garbage.Unfollowed, pr.ExternalURL.URL)
+			garbage.unfollowedKeys = append(garbage.unfollowedKeys, key)
+		}
+	}
+	return garbage, nil
+}
+
+// Remove removes the garbage projects from the list.
+func (lg *ListGarbage) Remove(cl *lgtm.Client) error {
+	if err := removeFromList(cl, lg.list, lg.Keys()); err != nil {
+		return err
+	}
+	lg.Removed = true
+	return nil
+}
+
+// removeFromList removes the projects with the provided keys
+// from the list, in chunks.
+func removeFromList(cl *lgtm.Client, list *lgtm.ProjectSelectionBare, keys []string) error {
+	partsNumber := lgtm.CalcChunkCount(len(keys), 100)
+	chunks := SplitStringSlice(partsNumber, keys)
+	for _, chunk := range chunks {
+		if len(chunk) == 0 {
+			continue
+		}
+		if err := cl.RemoveProjectsFromSelection(list.Key, chunk...); err != nil {
+			return fmt.Errorf("error while removing projects from list %q: %w", list.Name, err)
+		}
+	}
+	return nil
+}
+
+// matchListProjects returns the keys of the projects of the list
+// that have one of the provided URLs, and the URLs that are not in the list.
+func matchListProjects(projects []*lgtm.Project, repoURLs []string) ([]string, []string) {
+	keysByURL := make(map[string]string, len(projects))
+	for _, pr := range projects {
+		keysByURL[ToLower(pr.ExternalURL.URL)] = pr.Key
+	}
+	keys := make([]string, 0)
+	notInList := make([]string, 0)
+	for _, repoURL := range repoURLs {
+		key, ok := keysByURL[ToLower(repoURL)]
+		if !ok {
+			notInList = append(notInList, repoURL)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return Deduplicate(keys), notInList
+}
+
+// ListDiff is the comparison of the projects of two lists.
+type ListDiff struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	// The projects are identified by URL (or by key,
+	// if the key does not resolve to a project anymore).
+	OnlyInA []string `json:"onlyInA"`
+	OnlyInB []string `json:"onlyInB"`
+	InBoth  []string `json:"inBoth"`
+}
+
+// diffLists compares the projects of the two lists.
+func diffLists(cl *lgtm.Client, a string, b string) (*ListDiff, error) {
+	respA, err := cl.ListProjectsInSelection(a)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", a, err)
+	}
+	respB, err := cl.ListProjectsInSelection(b)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", b, err)
+	}
+	inB := make(map[string]bool, len(respB.ProjectKeys))
+	for _, key := range respB.ProjectKeys {
+		inB[key] = true
+	}
+	inA := make(map[string]bool, len(respA.ProjectKeys))
+	for _, key := range respA.ProjectKeys {
+		inA[key] = true
+	}
+
+	urls, err := projectURLsByKey(cl, Deduplicate(append(append([]string{}, respA.ProjectKeys...), respB.ProjectKeys...)))
+	if err != nil {
+		return nil, err
+	}
+	diff := &ListDiff{
+		A:       a,
+		B:       b,
+		OnlyInA: make([]string, 0),
+		OnlyInB: make([]string, 0),
+		InBoth:  make([]string, 0),
+	}
+	for _, key := range Deduplicate(respA.ProjectKeys) {
+		if inB[key] {
+			diff.InBoth = append(diff.InBoth, urls[key])
+		} else {
+			diff.OnlyInA = append(diff.OnlyInA, urls[key])
+		}
+	}
+	for _, key := range Deduplicate(respB.ProjectKeys) {
+		if !inA[key] {
+			diff.OnlyInB = append(diff.OnlyInB, urls[key])
+		}
+	}
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+	sort.Strings(diff.InBoth)
+	return diff, nil
+}
+
+// projectURLsByKey resolves the project keys to the URLs of the projects;
+// the keys that don't resolve to a project are mapped to themselves.
+func projectURLsByKey(cl *lgtm.Client, keys []string) (map[string]string, error) {
+	got, err := cl.GetProjectsByKeyBulk(cl.Context(), lgtm.DefaultBulkMaxWorkers, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects by key: %w", err)
+	}
+	urls := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if pr := got.GetProject(key); pr != nil {
+			urls[key] = pr.ExternalURL.URL
+		} else {
+			urls[key] = key
+		}
+	}
+	return urls, nil
+}
+
+// Print prints the projects that are only in one of the lists
+// and the number of projects in both.
+func (diff *ListDiff) Print() {
+	Infof("Only in %q (%v):", diff.A, len(diff.OnlyInA))
+	for _, u := range diff.OnlyInA {
+		Sfln("- %s", u)
+	}
+	Infof("Only in %q (%v):", diff.B, len(diff.OnlyInB))
+	for _, u := range diff.OnlyInB {
+		Sfln("+ %s", u)
+	}
+	Infof("In both (%v):", len(diff.InBoth))
+	for _, u := range diff.InBoth {
+		Sfln("  %s", u)
+	}
+}
+
+// selectLists returns the lists whose name matches any of the patterns
+// (exact names or globs, e.g. tmp-*) or whose key is one of the keys,
+// and the patterns and keys that matched no list.
+func selectLists(lists lgtm.ProjectSelectionBareSlice, patterns []string, keys []string) (lgtm.ProjectSelectionBareSlice, []string) {
+	selected := make(lgtm.ProjectSelectionBareSlice, 0)
+	isSelected := make(map[string]bool)
+	add := func(list *lgtm.ProjectSelectionBare) {
+		if !isSelected[list.Key] {
+			isSelected[list.Key] = true
+			selected = append(selected, list)
+		}
+	}
+	unmatched := make([]string, 0)
+	for _, pattern := range patterns {
+		var found bool
+		for _, list := range lists {
+			if _, ok := HasMatch(list.Name, []string{pattern}); ok {
+				found = true
+				add(list)
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	for _, key := range keys {
+		list := lists.ByKey(key)
+		if list == nil {
+			unmatched = append(unmatched, "key "+key)
+			continue
+		}
+		add(list)
+	}
+	return selected, unmatched
+}
+
+// resolveLists returns the lists that the provided args refer to:
+// each arg is matched against the list names (exact name or glob, e.g. go-*),
+// and, if it matches no name, is looked up as a list key.
+// The args that match no list are returned too.
+func resolveLists(lists lgtm.ProjectSelectionBareSlice, args []string) (lgtm.ProjectSelectionBareSlice, []string) {
+	selected, notNames := selectLists(lists, args, nil)
+	unmatched := make([]string, 0)
+	for _, arg := range notNames {
+		list := lists.ByKey(arg)
+		if list == nil {
+			unmatched = append(unmatched, arg)
+			continue
+		}
+		if selected.ByKey(list.Key) == nil {
+			selected = append(selected, list)
+		}
+	}
+	return selected, unmatched
+}
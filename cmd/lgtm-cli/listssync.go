@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"gopkg.in/yaml.v2"
+)
+
+// ListsManifest declares lists and their members,
+// so that the lists can be kept under version control (see lists-sync).
+type ListsManifest struct {
+	Lists []*ManifestList `yaml:"lists"`
+}
+
+// ManifestList is a list declared in a manifest.
+type ManifestList struct {
+	Name string `yaml:"name"`
+	// Members are repos, owners and globs (e.g. kubernetes/*);
+	// owners and globs are matched against the followed projects.
+	Members []string `yaml:"members"`
+	// Prune, if set, overrides the --prune flag for this list.
+	Prune *bool `yaml:"prune"`
+}
+
+// loadListsManifest loads and validates the manifest
+// (YAML; JSON files are valid YAML too).
+func loadListsManifest(path string) (*ListsManifest, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ListsManifest
+	if err := yaml.UnmarshalStrict(content, &manifest); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	if len(manifest.Lists) == 0 {
+		return nil, fmt.Errorf("%s does not declare any list", path)
+	}
+	seen := make(map[string]bool)
+	for i, ml := range manifest.Lists {
+		if ml == nil || ml.Name == "" {
+			return nil, fmt.Errorf("%s: list #%v has no name", path, i+1)
+		}
+		if seen[ml.Name] {
+			return nil, fmt.Errorf("%s: list %q is declared more than once", path, ml.Name)
+		}
+		seen[ml.Name] = true
+		if _, err := urlparse.CompileRepoURLPatterns(ml.Members); err != nil {
+			return nil, fmt.Errorf("%s: list %q: %w", path, ml.Name, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// ListSyncPlan contains the changes needed to make a list
+// match its declaration in the manifest.
+type ListSyncPlan struct {
+	Name   string `json:"name"`
+	Create bool   `json:"create"`
+	// ToAdd and ToRemove are the URLs of the projects
+	// to be added to (and removed from) the list.
+	ToAdd    []string `json:"toAdd"`
+	ToRemove []string `json:"toRemove"`
+	// Unresolved are the members that are not built projects.
+	Unresolved []string `json:"unresolved"`
+	// Unmatched are the owners and globs that don't match any followed project.
+	Unmatched []string `json:"unmatched"`
+
+	addKeys    []string
+	removeKeys []string
+}
+
+// IsNoop returns true if there is nothing to apply to the list;
+// some members of the manifest might still be unresolved or unmatched.
+func (plan *ListSyncPlan) IsNoop() bool {
+	return !plan.Create && len(plan.addKeys) == 0 && len(plan.removeKeys) == 0
+}
+
+// planListSync compares the list declared in the manifest with the list
+// on lgtm.com (if it exists): the repos are resolved to projects
+// (looking them up on lgtm.com if not followed), and the owners and globs
+// are matched against the followed projects of the cache.
+func planListSync(
+	ctx context.Context,
+	cl *lgtm.Client,
+	cache *lgtm.FollowedProjectCache,
+	lists lgtm.ProjectSelectionBareSlice,
+	ml *ManifestList,
+	prune bool,
+	maxResolveWorkers int64,
+) (*ListSyncPlan, error) {
+	if ml.Prune != nil {
+		prune = *ml.Prune
+	}
+	plan := &ListSyncPlan{
+		Name:       ml.Name,
+		Create:     lists.ByName(ml.Name) == nil,
+		ToAdd:      make([]string, 0),
+		ToRemove:   make([]string, 0),
+		Unresolved: make([]string, 0),
+		Unmatched:  make([]string, 0),
+		addKeys:    make([]string, 0),
+		removeKeys: make([]string, 0),
+	}
+
+	// desired contains the URLs of the projects that should be in the list, by key:
+	desired := make(map[string]string)
+	desiredKeys := make([]string, 0)
+	addDesired := func(pr *lgtm.Project) {
+		if _, ok := desired[pr.Key]; ok {
+			return
+		}
+		desired[pr.Key] = pr.ExternalURL.URL
+		desiredKeys = append(desiredKeys, pr.Key)
+	}
+
+	repoURLs := make([]string, 0)
+	for _, member := range Deduplicate(ml.Members) {
+		patterns, err := urlparse.CompileRepoURLPatterns([]string{member})
+		if err != nil {
+			return nil, err
+		}
+		if !urlparse.IsGlob(patterns[0]) {
+			repoURLs = append(repoURLs, patterns[0])
+			continue
+		}
+		// An owner or a glob:
+		var matched bool
+		for _, pr := range cache.Projects() {
+			if _, ok := HasMatch(pr.ExternalURL.URL, patterns); ok {
+				addDesired(pr)
+				matched = true
+			}
+		}
+		if !matched {
+			plan.Unmatched = append(plan.Unmatched, member)
+		}
+	}
+	for _, resolved := range resolveProjects(ctx, cl, cache, repoURLs, maxResolveWorkers) {
+		if resolved.Project == nil {
+			plan.Unresolved = append(plan.Unresolved, resolved.URL)
+			continue
+		}
+		addDesired(resolved.Project)
+	}
+
+	current := make(map[string]bool)
+	if !plan.Create {
+		projects, err := cl.GetProjectsInSelection(ml.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range projects {
+			current[pr.Key] = true
+			if _, ok := desired[pr.Key]; !ok && prune {
+				plan.ToRemove = append(plan.ToRemove, pr.ExternalURL.URL)
+				plan.removeKeys = append(plan.removeKeys, pr.Key)
+			}
+		}
+	}
+	for _, key := range desiredKeys {
+		if !current[key] {
+			plan.ToAdd = append(plan.ToAdd, desired[key])
+			plan.addKeys = append(plan.addKeys, key)
+		}
+	}
+	return plan, nil
+}
+
+// Print prints the changes of the plan, and the members
+// that were skipped (also when there is nothing to apply).
+func (plan *ListSyncPlan) Print() {
+	skipped := len(plan.Unresolved) + len(plan.Unmatched)
+	switch {
+	case plan.IsNoop() && skipped == 0:
+		Successf("%q is up to date", plan.Name)
+	case plan.IsNoop():
+		Infof("%q: nothing to change, but %v members were skipped", plan.Name, skipped)
+	default:
+		var action string
+		if plan.Create {
+			action = " (will be created)"
+		}
+		Infof(
+			"%q%s: %v to add, %v to remove",
+			plan.Name,
+			action,
+			len(plan.ToAdd),
+			len(plan.ToRemove),
+		)
+	}
+	for _, repoURL := range plan.ToAdd {
+		Sfln("+ %s", repoURL)
+	}
+	for _, repoURL := range plan.ToRemove {
+		Sfln("- %s", repoURL)
+	}
+	for _, repoURL := range plan.Unresolved {
+		Warnf("%q: %s is not a built project; skipping", plan.Name, repoURL)
+	}
+	for _, member := range plan.Unmatched {
+		Warnf("%q: %s does not match any followed project", plan.Name, member)
+	}
+}
+
+// Apply creates the list (if needed), and adds and removes its projects;
+// it returns the URLs of the projects that could not be added.
+func (plan *ListSyncPlan) Apply(ctx context.Context, cl *lgtm.Client, maxWorkers int64, maxRetries int) ([]string, error) {
+	list, err := getOrCreateList(cl, plan.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.removeKeys) > 0 {
+		if err := removeFromList(cl, list, plan.removeKeys); err != nil {
+			return nil, err
+		}
+	}
+	failedURLs := make([]string, 0)
+	if len(plan.addKeys) == 0 {
+		return failedURLs, nil
+	}
+	urlsByKey := make(map[string]string, len(plan.addKeys))
+	for i, key := range plan.addKeys {
+		urlsByKey[key] = plan.ToAdd[i]
+	}
+	_, failedChunks := addToSelectionInChunks(ctx, cl, list, plan.addKeys, maxWorkers, maxRetries)
+	for _, chunk := range failedChunks {
+		for _, key := range chunk.Keys {
+			failedURLs = append(failedURLs, urlsByKey[key])
+		}
+	}
+	if len(failedURLs) > 0 {
+		return failedURLs, errors.New("some projects could not be added")
+	}
+	return failedURLs, nil
+}
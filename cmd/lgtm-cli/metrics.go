@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// metrics contains the counters of the current run.
+var metrics = NewMetrics()
+
+const metricsNamespace = "lgtm_cli"
+
+// Metrics is a minimal collection of counters and gauges
+// that can be exposed in the Prometheus text format.
+type Metrics struct {
+	mu *sync.Mutex
+	// values maps the metric names to the values by labels.
+	values map[string]map[string]float64
+	types  map[string]string
+	help   map[string]string
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		mu:     &sync.Mutex{},
+		values: make(map[string]map[string]float64),
+		types:  make(map[string]string),
+		help:   make(map[string]string),
+	}
+}
+
+// Describe sets the type ("counter" or "gauge") and the help text of a metric.
+func (m *Metrics) Describe(name string, typ string, help string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.types[name] = typ
+	m.help[name] = help
+}
+
+// Add adds the value to the metric with the provided labels
+// (as key/value pairs).
+func (m *Metrics) Add(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.valuesOf(name)[formatMetricLabels(labels)] += value
+}
+
+// Inc increments by one the metric with the provided labels.
+func (m *Metrics) Inc(name string, labels ...string) {
+	m.Add(name, 1, labels...)
+}
+
+// Set sets the value of the metric with the provided labels.
+func (m *Metrics) Set(name string, value float64, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.valuesOf(name)[formatMetricLabels(labels)] = value
+}
+
+func (m *Metrics) valuesOf(name string) map[string]float64 {
+	values, ok := m.values[name]
+	if !ok {
+		values = make(map[string]float64)
+		m.values[name] = values
+	}
+	return values
+}
+
+func formatMetricLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	names := make([]string, 0, len(m.values))
+	for name := range m.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fullName := metricsNamespace + "_" + name
+		if help, ok := m.help[name]; ok {
+			fmt.Fprintf(w, "# HELP %s %s\n", fullName, help)
+		}
+		if typ, ok := m.types[name]; ok {
+			fmt.Fprintf(w, "# TYPE %s %s\n", fullName, typ)
+		}
+		labels := make([]string, 0, len(m.values[name]))
+		for label := range m.values[name] {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			fmt.Fprintf(w, "%s%s %v\n", fullName, label, m.values[name][label])
+		}
+	}
+}
+
+func init() {
+	metrics.Describe("follows_total", "counter", "Number of followed projects.")
+	metrics.Describe("unfollows_total", "counter", "Number of unfollowed projects.")
+	metrics.Describe("build_attempts_total", "counter", "Number of started build attempts.")
+	metrics.Describe("errors_total", "counter", "Number of failed operations.")
+	metrics.Describe("api_requests_total", "counter", "Number of HTTP requests by endpoint.")
+	metrics.Describe("api_request_duration_seconds_sum", "counter", "Total duration of HTTP requests by endpoint.")
+	metrics.Describe("github_rate_remaining", "gauge", "Remaining GitHub API rate.")
+}
+
+// ServeMetrics starts an HTTP server that exposes
+// the metrics at /metrics on the provided address.
+func ServeMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	return http.ListenAndServe(addr, mux)
+}
+
+// metricsTransport is an http.RoundTripper that records
+// the number and duration of the requests.
+type metricsTransport struct {
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointLabel(req.URL, lgtmAPIHost)
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	metrics.Add("api_request_duration_seconds_sum", time.Since(start).Seconds(), "endpoint", endpoint)
+	metrics.Inc("api_requests_total", "endpoint", endpoint)
+	if err != nil {
+		metrics.Inc("errors_total", "op", "http")
+	}
+	return resp, err
+}
+
+// lgtmAPIHost is the host of the base URL of the selected profile
+// (lgtm.com by default), whose API requests are labeled by endpoint.
+var lgtmAPIHost = (&lgtm.Config{}).GetBaseHost()
+
+// endpointLabel returns the name of the lgtm API endpoint
+// (if the request is to apiHost), or the host for other requests.
+func endpointLabel(u *url.URL, apiHost string) string {
+	if u.Host == apiHost && strings.HasPrefix(u.Path, "/internal_api/") {
+		return path.Base(u.Path)
+	}
+	return u.Host
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		rawURL  string
+		apiHost string
+		want    string
+	}{
+		{
+			rawURL:  "https://lgtm.com/internal_api/v0.2/getProjectBySlug?slug=g/foo/bar",
+			apiHost: lgtmAPIHost,
+			want:    "getProjectBySlug",
+		},
+		{
+			rawURL:  "https://lgtm.example.com:8443/internal_api/v0.2/followProject",
+			apiHost: "lgtm.example.com:8443",
+			want:    "followProject",
+		},
+		{
+			rawURL:  "https://lgtm.com/internal_api/v0.2/followProject",
+			apiHost: "lgtm.example.com:8443",
+			want:    "lgtm.com",
+		},
+		{
+			rawURL:  "https://api.github.com/repos/foo/bar",
+			apiHost: lgtmAPIHost,
+			want:    "api.github.com",
+		},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := endpointLabel(u, tt.apiHost); got != tt.want {
+			t.Errorf("endpointLabel(%s, %s) = %q, want %q", tt.rawURL, tt.apiHost, got, tt.want)
+		}
+	}
+}
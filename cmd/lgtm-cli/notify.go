@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// notifier sends the notifications of the current run
+// (nil unless --notify-webhook or --notify-cmd is set).
+var notifier *Notifier
+
+const (
+	// NotifyEventCompleted is sent at the end of the run,
+	// for each operation that was attempted.
+	NotifyEventCompleted = "completed"
+	// NotifyEventFailureRate is sent (once per operation) as soon as
+	// the failure rate of an operation exceeds the threshold.
+	NotifyEventFailureRate = "failure_rate_exceeded"
+)
+
+// Notification is the payload of a notification; it is sent as json
+// (Slack-compatible thanks to the text field) to the webhook,
+// and on stdin to the command.
+type Notification struct {
+	Text        string    `json:"text"`
+	Event       string    `json:"event"`
+	Operation   string    `json:"operation"`
+	Succeeded   int       `json:"succeeded"`
+	Failed      int       `json:"failed"`
+	FailureRate float64   `json:"failureRate"`
+	Time        time.Time `json:"time"`
+}
+
+type notifyCounts struct {
+	succeeded int
+	failed    int
+	alerted   bool
+}
+
+// Notifier counts the outcomes of the follows and build attempts,
+// and reports out-of-band (webhook and/or command) when the failure rate
+// gets too high, and when the run completes.
+type Notifier struct {
+	webhookURL  string
+	cmd         string
+	threshold   float64
+	minAttempts int
+	mu          *sync.Mutex
+	counts      map[string]*notifyCounts
+}
+
+// NewNotifier returns a new Notifier; the failure rate notification is sent
+// when more than threshold (0-1) of the attempts of an operation failed,
+// after at least minAttempts attempts.
+func NewNotifier(webhookURL string, cmd string, threshold float64, minAttempts int) (*Notifier, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("invalid failure rate threshold %v: must be greater than 0 and at most 1", threshold)
+	}
+	if minAttempts < 1 {
+		minAttempts = 1
+	}
+	return &Notifier{
+		webhookURL:  webhookURL,
+		cmd:         cmd,
+		threshold:   threshold,
+		minAttempts: minAttempts,
+		mu:          &sync.Mutex{},
+		counts:      make(map[string]*notifyCounts),
+	}, nil
+}
+
+// Record records the outcome of an attempt of the operation
+// (e.g. follow); a nil err is a success.
+// It is a no-op on a nil notifier.
+func (n *Notifier) Record(op string, err error) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	counts, ok := n.counts[op]
+	if !ok {
+		counts = &notifyCounts{}
+		n.counts[op] = counts
+	}
+	if err != nil {
+		counts.failed++
+	} else {
+		counts.succeeded++
+	}
+	var notification *Notification
+	if !counts.alerted && counts.failed+counts.succeeded >= n.minAttempts {
+		if rate := failureRate(counts); rate > n.threshold {
+			counts.alerted = true
+			notification = newNotification(NotifyEventFailureRate, op, counts)
+			notification.Text = Sf(
+				"lgtm: %.0f%% of %s attempts failed (%v of %v) on %s",
+				rate*100,
+				op,
+				counts.failed,
+				counts.failed+counts.succeeded,
+				hostname(),
+			)
+		}
+	}
+	n.mu.Unlock()
+
+	if notification != nil {
+		n.send(notification)
+	}
+}
+
+// Completed sends the completion notification of each operation
+// that was attempted during the run.
+// It is a no-op on a nil notifier.
+func (n *Notifier) Completed() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	ops := make([]string, 0, len(n.counts))
+	for op := range n.counts {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	notifications := make([]*Notification, 0, len(ops))
+	for _, op := range ops {
+		counts := n.counts[op]
+		notification := newNotification(NotifyEventCompleted, op, counts)
+		notification.Text = Sf(
+			"lgtm: %s completed on %s: %v succeeded, %v failed",
+			op,
+			hostname(),
+			counts.succeeded,
+			counts.failed,
+		)
+		notifications = append(notifications, notification)
+	}
+	n.mu.Unlock()
+
+	for _, notification := range notifications {
+		n.send(notification)
+	}
+}
+
+func newNotification(event string, op string, counts *notifyCounts) *Notification {
+	return &Notification{
+		Event:       event,
+		Operation:   op,
+		Succeeded:   counts.succeeded,
+		Failed:      counts.failed,
+		FailureRate: failureRate(counts),
+		Time:        time.Now().UTC(),
+	}
+}
+
+func failureRate(counts *notifyCounts) float64 {
+	total := counts.failed + counts.succeeded
+	if total == 0 {
+		return 0
+	}
+	return float64(counts.failed) / float64(total)
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown host"
+	}
+	return name
+}
+
+// send sends the notification to the webhook and to the command;
+// failures are only logged, so that they never interrupt the run.
+func (n *Notifier) send(notification *Notification) {
+	js, err := json.Marshal(notification)
+	if err != nil {
+		return
+	}
+	if n.webhookURL != "" {
+		if err := postWebhook(n.webhookURL, js); err != nil {
+			metrics.Inc("errors_total", "op", "notify")
+			Warnf("Could not send notification to webhook: %s", err)
+		}
+	}
+	if n.cmd != "" {
+		if err := runNotifyCmd(n.cmd, notification, js); err != nil {
+			metrics.Inc("errors_total", "op", "notify")
+			Warnf("Notification command failed: %s", err)
+		}
+	}
+}
+
+func postWebhook(webhookURL string, payload []byte) error {
+	resp, err := webHTTPClient.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("status %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// runNotifyCmd runs the command with the shell; the notification is passed
+// as json on stdin, and in the LGTM_NOTIFY_* env vars.
+func runNotifyCmd(command string, notification *Notification, payload []byte) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(
+		os.Environ(),
+		"LGTM_NOTIFY_EVENT="+notification.Event,
+		"LGTM_NOTIFY_OPERATION="+notification.Operation,
+		"LGTM_NOTIFY_TEXT="+notification.Text,
+		Sf("LGTM_NOTIFY_SUCCEEDED=%v", notification.Succeeded),
+		Sf("LGTM_NOTIFY_FAILED=%v", notification.Failed),
+	)
+	return cmd.Run()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// followOrders are the orders in which the targets of a follow can be sorted.
+var followOrders = []string{"stars", "size", "pushed", "alpha", "random"}
+
+func validateFollowOrder(order string) error {
+	if order == "" {
+		return nil
+	}
+	for _, valid := range followOrders {
+		if order == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --order %q (must be one of %s)", order, strings.Join(followOrders, ", "))
+}
+
+// RepoMetadata contains the GitHub metadata of repos, by lowercased repo URL.
+type RepoMetadata map[string]*github.Repository
+
+// Add adds the repos, and returns their URLs.
+func (meta RepoMetadata) Add(repos ...*github.Repository) []string {
+	repoURLs := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		repoURL := repo.GetHTMLURL() // e.g. "https://github.com/kubernetes/dashboard"
+		meta[strings.ToLower(repoURL)] = repo
+		repoURLs = append(repoURLs, repoURL)
+	}
+	return repoURLs
+}
+
+// Get returns the metadata of the repo (or nil).
+func (meta RepoMetadata) Get(repoURL string) *github.Repository {
+	return meta[strings.ToLower(repoURL)]
+}
+
+// Sort returns the repo URLs sorted in the provided order:
+// stars, size and pushed are descending, and the repos
+// without metadata come last (in their original order).
+func (meta RepoMetadata) Sort(repoURLs []string, order string) []string {
+	sorted := make([]string, len(repoURLs))
+	copy(sorted, repoURLs)
+
+	switch order {
+	case "alpha":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i]) < strings.ToLower(sorted[j])
+		})
+		return sorted
+	case "random":
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rnd.Shuffle(len(sorted), func(i, j int) {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		})
+		return sorted
+	}
+
+	less := func(a *github.Repository, b *github.Repository) bool {
+		switch order {
+		case "stars":
+			return a.GetStargazersCount() > b.GetStargazersCount()
+		case "size":
+			return a.GetSize() > b.GetSize()
+		case "pushed":
+			return a.GetPushedAt().Time.After(b.GetPushedAt().Time)
+		}
+		return false
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := meta.Get(sorted[i]), meta.Get(sorted[j])
+		if a == nil || b == nil {
+			// The repos with metadata come first:
+			return a != nil && b == nil
+		}
+		return less(a, b)
+	})
+	return sorted
+}
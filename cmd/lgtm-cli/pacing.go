@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// FollowPacer paces the follows of projects that are new to lgtm.com
+// (each of which triggers a new build).
+//
+// By default, it waits for a fixed duration after each new follow;
+// in adaptive mode, the wait duration is doubled when the build backlog
+// is above the target, and halved when it is below half the target,
+// within min and max. The backlog is estimated from the follow responses
+// (so that no requests are needed): it is the number of the recent
+// new follows whose build was queued instead of started.
+type FollowPacer struct {
+	ctx   context.Context
+	fixed time.Duration
+
+	adaptive      bool
+	min           time.Duration
+	max           time.Duration
+	targetBacklog int
+	checkInterval time.Duration
+
+	delay     time.Duration
+	lastCheck time.Time
+	// queued tells, for each of the recent new follows (at most
+	// 2*targetBacklog, oldest first), whether its build was queued.
+	queued []bool
+}
+
+// NewFixedFollowPacer returns a FollowPacer that always waits
+// for the provided duration after a new follow (or until ctx is done).
+func NewFixedFollowPacer(ctx context.Context, wait time.Duration) *FollowPacer {
+	return &FollowPacer{
+		ctx:   ctx,
+		fixed: wait,
+	}
+}
+
+// NewAdaptiveFollowPacer returns a FollowPacer that adapts the wait duration
+// (between min and max) to keep the build backlog around targetBacklog;
+// the wait duration is adjusted at most once every checkInterval.
+func NewAdaptiveFollowPacer(ctx context.Context, min time.Duration, max time.Duration, targetBacklog int, checkInterval time.Duration) *FollowPacer {
+	return &FollowPacer{
+		ctx:           ctx,
+		adaptive:      true,
+		min:           min,
+		max:           max,
+		targetBacklog: targetBacklog,
+		checkInterval: checkInterval,
+		delay:         min,
+		lastCheck:     time.Now(),
+		queued:        make([]bool, 0, 2*targetBacklog),
+	}
+}
+
+// Wait waits after the follow of a project that was new to lgtm.com;
+// it returns early if the context is done.
+func (p *FollowPacer) Wait(envelope *lgtm.Envelope) {
+	if !p.adaptive {
+		p.sleep(p.fixed)
+		return
+	}
+	proto := envelope.GetProtoProject()
+	p.record(proto != nil && !proto.NextBuildStarted)
+	if time.Since(p.lastCheck) >= p.checkInterval {
+		p.adjust()
+	}
+	p.sleep(p.delay)
+}
+
+func (p *FollowPacer) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// record adds a new follow to the recent ones.
+func (p *FollowPacer) record(queued bool) {
+	if len(p.queued) >= 2*p.targetBacklog && len(p.queued) > 0 {
+		p.queued = p.queued[1:]
+	}
+	p.queued = append(p.queued, queued)
+}
+
+// backlog returns the number of the recent new follows
+// whose build was queued.
+func (p *FollowPacer) backlog() int {
+	backlog := 0
+	for _, queued := range p.queued {
+		if queued {
+			backlog++
+		}
+	}
+	return backlog
+}
+
+// adjust updates the wait duration according to the build backlog.
+func (p *FollowPacer) adjust() {
+	p.lastCheck = time.Now()
+	backlog := p.backlog()
+
+	previous := p.delay
+	if backlog > p.targetBacklog {
+		p.delay *= 2
+		if p.delay > p.max {
+			p.delay = p.max
+		}
+	} else if backlog < p.targetBacklog/2 {
+		p.delay /= 2
+		if p.delay < p.min {
+			p.delay = p.min
+		}
+	}
+	metrics.Set("follow_build_backlog", float64(backlog))
+	metrics.Set("follow_pacing_delay_seconds", p.delay.Seconds())
+	if p.delay != previous {
+		Infof("Build backlog is %v (target %v); waiting %s between new follows", backlog, p.targetBacklog, p.delay)
+	}
+}
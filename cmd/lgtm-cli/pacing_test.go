@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFollowPacerAdjust(t *testing.T) {
+	p := NewAdaptiveFollowPacer(context.Background(), time.Second, 4*time.Second, 2, 0)
+
+	// 3 of the last 4 follows queued: above the target.
+	for _, queued := range []bool{true, true, false, true} {
+		p.record(queued)
+	}
+	p.adjust()
+	if p.delay != 2*time.Second {
+		t.Fatalf("delay = %s, want 2s", p.delay)
+	}
+	p.adjust()
+	p.adjust()
+	if p.delay != 4*time.Second {
+		t.Fatalf("delay = %s, want the max 4s", p.delay)
+	}
+
+	// Only the last 4 follows count:
+	for i := 0; i < 4; i++ {
+		p.record(false)
+	}
+	if got := p.backlog(); got != 0 {
+		t.Fatalf("backlog = %v, want 0", got)
+	}
+	p.adjust()
+	if p.delay != 2*time.Second {
+		t.Fatalf("delay = %s, want 2s", p.delay)
+	}
+	p.adjust()
+	p.adjust()
+	if p.delay != time.Second {
+		t.Fatalf("delay = %s, want the min 1s", p.delay)
+	}
+}
+
+func TestFollowPacerWaitStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewFixedFollowPacer(ctx, time.Hour)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	done := make(chan struct{})
+	go func() {
+		p.Wait(nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after the context was canceled")
+	}
+}
@@ -7,26 +7,30 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
 	"github.com/gagliardetto/request"
 	. "github.com/gagliardetto/utilz"
 )
 
 // GetImportersOfGolangPackage gets a list of importers of a Golang package
-// from pkg.go.dev.
+// from the importedby tab of pkg.go.dev (which only lists part of the importers
+// of the most imported packages); if limit is greater than zero,
+// at most limit importers are returned.
 func GetImportersOfGolangPackage(pkgPath string, limit int) ([]string, error) {
-	req := request.NewRequest(httpClient)
-
 	pkgPath = strings.TrimSpace(pkgPath)
 	pkgPath = strings.TrimPrefix(pkgPath, "https://")
 	pkgPath = strings.TrimPrefix(pkgPath, "http://")
 	pkgPath = strings.TrimPrefix(pkgPath, "/")
 	pkgPath = strings.TrimSuffix(pkgPath, "/")
+
+	req := request.NewRequest(webHTTPClient)
+
 	resp, err := req.Get("https://pkg.go.dev/" + pkgPath + "?tab=importedby")
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, lgtm.FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -34,20 +38,17 @@ func GetImportersOfGolangPackage(pkgPath string, limit int) ([]string, error) {
 		return nil, fmt.Errorf("error while getting Reader: %s", err)
 	}
 	defer closer()
+	defer resp.Body.Close()
 
-	deps, err := getImportersOfGolangPackage(reader)
+	rawDependants, err := parseImportedByPage(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	if limit > 0 && len(deps) > limit {
-		deps = deps[:limit-1]
-	}
-
-	return deps, nil
+	return resolveGoImporters(NewGoRepoResolver(), rawDependants, limit), nil
 }
 
-func getImportersOfGolangPackage(reader io.Reader) ([]string, error) {
+func parseImportedByPage(reader io.Reader) ([]string, error) {
 	// Load the HTML document
 	doc, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
@@ -67,26 +68,33 @@ func getImportersOfGolangPackage(reader io.Reader) ([]string, error) {
 		}
 	})
 
-	rawDependants = Deduplicate(rawDependants)
+	return Deduplicate(rawDependants), nil
+}
 
-	// rootDependants are the package paths of the importers:
+// resolveGoImporters resolves the package paths of the importers
+// to the URLs of their repos; if limit is greater than zero,
+// it stops once limit repos have been collected.
+func resolveGoImporters(resolver *GoRepoResolver, rawDependants []string, limit int) []string {
+	// rootDependants are the repo URLs of the importers:
 	var rootDependants []string
 
 	for _, dependant := range rawDependants {
-		isSupported := strings.HasPrefix(dependant, "github.com/") || strings.HasPrefix(dependant, "gitlab.org/") || strings.HasPrefix(dependant, "bitbucket.org/")
+		// Don't resolve (possibly over the network) more importers than needed:
+		if limit > 0 && len(rootDependants) >= limit {
+			break
+		}
+		// Vanity import paths (e.g. k8s.io/client-go) are resolved
+		// to their underlying repos.
 		// NOTE: we are skipping anything that is not on github, gitlab, or bitbucket.
-		if isSupported {
-			parts := strings.Split(dependant, "/")
-			if len(parts) < 3 {
-				continue
-			}
-			root := "https://" + strings.Join(parts[:3], "/")
-
+		root, err := resolver.Resolve(dependant)
+		if err != nil {
+			Debugf("Skipping importer %s: %s", dependant, err)
+			continue
+		}
+		if !SliceContains(rootDependants, root) {
 			rootDependants = append(rootDependants, root)
 		}
 	}
 
-	rootDependants = Deduplicate(rootDependants)
-
-	return rootDependants, nil
+	return rootDependants
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveGoImportersLimit(t *testing.T) {
+	rawDependants := []string{
+		"github.com/foo/bar/cmd",
+		"github.com/foo/bar/pkg",
+		"localpkg/notremote",
+		"github.com/foo/baz",
+		"github.com/foo/qux",
+	}
+
+	got := resolveGoImporters(NewGoRepoResolver(), rawDependants, 2)
+	want := []string{"https://github.com/foo/bar", "https://github.com/foo/baz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveGoImporters(limit=2) = %q, want %q", got, want)
+	}
+
+	got = resolveGoImporters(NewGoRepoResolver(), rawDependants, 0)
+	want = append(want, "https://github.com/foo/qux")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveGoImporters(limit=0) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/eta"
+)
+
+// progress emits the progress events of the current run
+// (nil unless --progress-json is set).
+var progress *ProgressEmitter
+
+const (
+	ProgressOutcomeSuccess = "success"
+	ProgressOutcomeError   = "error"
+)
+
+// ProgressEvent is emitted each time an item of a long operation
+// (follow, unfollow, rebuild, etc.) is processed.
+type ProgressEvent struct {
+	// Type is the operation (e.g. follow).
+	Type   string `json:"type"`
+	Target string `json:"target"`
+	Done   int64  `json:"done"`
+	Total  int64  `json:"total"`
+	// ETA is the estimated number of seconds to completion.
+	ETA     float64   `json:"eta"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// ProgressEmitter writes progress events as newline-delimited JSON.
+type ProgressEmitter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// NewProgressEmitter returns an emitter that writes to the provided file
+// (e.g. a named pipe), or to stderr if path is empty.
+func NewProgressEmitter(path string) (*ProgressEmitter, error) {
+	var w io.Writer = os.Stderr
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = file
+	}
+	return &ProgressEmitter{
+		mu: &sync.Mutex{},
+		w:  w,
+	}, nil
+}
+
+// Emit emits an event for the target, with the progress of etac
+// (which must already count the target as done); a nil err is a success.
+// It is a no-op on a nil emitter.
+func (p *ProgressEmitter) Emit(typ string, target string, etac *eta.ETA, err error) {
+	if p == nil {
+		return
+	}
+	event := &ProgressEvent{
+		Type:    typ,
+		Target:  target,
+		Done:    etac.GetDone(),
+		Total:   etac.GetTotal(),
+		ETA:     etac.GetETA().Seconds(),
+		Outcome: ProgressOutcomeSuccess,
+		Time:    time.Now().UTC(),
+	}
+	if err != nil {
+		event.Outcome = ProgressOutcomeError
+		event.Error = err.Error()
+	}
+	js, jsErr := json.Marshal(event)
+	if jsErr != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// One write per event, so that readers of a pipe
+	// never get interleaved lines.
+	p.w.Write(append(js, '\n'))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"github.com/hako/durafmt"
+)
+
+// DefaultProtoRebuildsFilepath returns the default filepath of the log
+// of the build attempts of proto-projects.
+func DefaultProtoRebuildsFilepath() string {
+	return filepath.Join(filepath.Dir(DefaultRunsFilepath()), "proto-rebuilds.json")
+}
+
+// ProtoRebuildLog contains the time of the last build attempt issued
+// (by this CLI) for each proto-project; lgtm.com does not expose
+// when the build attempts of a proto-project were started.
+type ProtoRebuildLog struct {
+	path string
+	// LastAttempts are the times of the last build attempts, by proto-project key.
+	LastAttempts map[string]time.Time `json:"lastAttempts"`
+}
+
+// LoadProtoRebuildLog loads the log from the provided file;
+// if the file does not exist, an empty log is returned.
+func LoadProtoRebuildLog(path string) (*ProtoRebuildLog, error) {
+	rl := &ProtoRebuildLog{
+		path:         path,
+		LastAttempts: make(map[string]time.Time),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rl, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, rl); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	if rl.LastAttempts == nil {
+		rl.LastAttempts = make(map[string]time.Time)
+	}
+	return rl, nil
+}
+
+// Save writes the log to the file it was loaded from.
+func (rl *ProtoRebuildLog) Save() error {
+	js, err := json.MarshalIndent(rl, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rl.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(rl.path, js, 0600)
+}
+
+// Record sets the time of the last build attempt of the proto-project to now.
+func (rl *ProtoRebuildLog) Record(key string) {
+	rl.LastAttempts[key] = time.Now()
+}
+
+// ProtoRebuildFilter selects the proto-projects to be rebuilt.
+type ProtoRebuildFilter struct {
+	// States are the accepted states (e.g. build_failed); any state if empty.
+	States []string
+	// NeverStarted selects only the proto-projects that never had a build started.
+	NeverStarted bool
+	// OlderThan selects only the proto-projects whose last build attempt
+	// (as recorded in Log) is older than this; the proto-projects
+	// without a recorded build attempt are selected too.
+	OlderThan time.Duration
+	Log       *ProtoRebuildLog
+}
+
+// IsSet returns true if any filter is set.
+func (f *ProtoRebuildFilter) IsSet() bool {
+	return len(f.States) > 0 || f.NeverStarted || f.OlderThan > 0
+}
+
+// Match returns whether the proto-project should be rebuilt;
+// if not, the reason is returned.
+func (f *ProtoRebuildFilter) Match(pr *lgtm.ProtoProject) (bool, string) {
+	if len(f.States) > 0 && !SliceContains(f.States, ToLower(pr.State)) {
+		return false, Sf("state is %q", pr.State)
+	}
+	if f.NeverStarted && (pr.NextBuildStarted || pr.BuildAttemptKey != "") {
+		return false, "a build was already started"
+	}
+	if f.OlderThan > 0 && f.Log != nil {
+		if last, ok := f.Log.LastAttempts[pr.Key]; ok {
+			if age := time.Since(last); age <= f.OlderThan {
+				return false, Sf("last build attempt is %s old", durafmt.Parse(age).LimitFirstN(1))
+			}
+		}
+	}
+	return true, ""
+}
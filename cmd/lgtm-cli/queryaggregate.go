@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// LabeledQueryRun is a query run, with a label that tells
+// what it was run on (e.g. the name of a list).
+type LabeledQueryRun struct {
+	Label string `json:"label"`
+	Key   string `json:"key"`
+	Link  string `json:"link"`
+}
+
+// runQueryPerList starts one run of the query for each list, plus one run
+// for the project keys (if any), so that each list has its own results link.
+func runQueryPerList(cl *lgtm.Client, conf *lgtm.QueryConfig, listNamesByKey map[string]string) ([]*LabeledQueryRun, []*lgtm.QueryResponseData, error) {
+	labeled := make([]*LabeledQueryRun, 0)
+	runs := make([]*lgtm.QueryResponseData, 0)
+	start := func(label string, projectKeys []string, listKeys []string) error {
+		run, err := cl.Query(&lgtm.QueryConfig{
+			Lang:                 conf.Lang,
+			ProjectKeys:          projectKeys,
+			QueryString:          conf.QueryString,
+			ProjectSelectionKeys: listKeys,
+		})
+		if err != nil {
+			return err
+		}
+		labeled = append(labeled, &LabeledQueryRun{
+			Label: label,
+			Key:   run.Key,
+			Link:  run.GetResultLink(),
+		})
+		runs = append(runs, run)
+		return nil
+	}
+
+	if len(conf.ProjectKeys) > 0 {
+		if err := start(Sf("%v projects", len(conf.ProjectKeys)), conf.ProjectKeys, nil); err != nil {
+			return labeled, runs, err
+		}
+	}
+	for _, listKey := range conf.ProjectSelectionKeys {
+		label := "list " + listKey
+		if name, ok := listNamesByKey[listKey]; ok {
+			label = Sf("list %q", name)
+		}
+		if err := start(label, nil, []string{listKey}); err != nil {
+			return labeled, runs, err
+		}
+	}
+	return labeled, runs, nil
+}
+
+// waitQueryRuns waits until all the query runs are done,
+// or the context is canceled.
+func waitQueryRuns(ctx context.Context, cl *lgtm.Client, runKeys []string, interval time.Duration) {
+	pending := runKeys
+	for len(pending) > 0 {
+		stillPending := make([]string, 0)
+		for _, key := range pending {
+			status, err := cl.GetQueryRunStatus(key)
+			if err != nil {
+				Errorf("Error while getting status of run %s: %s", key, err)
+				stillPending = append(stillPending, key)
+				continue
+			}
+			if !status.IsDone() {
+				stillPending = append(stillPending, key)
+			}
+		}
+		pending = stillPending
+		if len(pending) == 0 {
+			return
+		}
+		Infof("Waiting for %v query runs to complete...", len(pending))
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// AggregatedProjectResult contains the results of a query
+// for a project, across all runs.
+type AggregatedProjectResult struct {
+	ProjectKey string `json:"projectKey"`
+	// Runs are the keys of the runs that included the project.
+	Runs       []string `json:"runs"`
+	NumResults int      `json:"numResults"`
+	// Done is true if the query completed on the project in all runs.
+	Done   bool     `json:"done"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// QueryAggregate contains the results of multiple runs of a query,
+// by project key.
+type QueryAggregate struct {
+	Runs     []string                            `json:"runs"`
+	Projects map[string]*AggregatedProjectResult `json:"projects"`
+}
+
+// aggregateQueryRuns merges the results of the query runs by project.
+func aggregateQueryRuns(cl *lgtm.Client, runKeys []string) (*QueryAggregate, error) {
+	agg := &QueryAggregate{
+		Runs:     runKeys,
+		Projects: make(map[string]*AggregatedProjectResult),
+	}
+	for _, runKey := range runKeys {
+		items, err := cl.GetAllQueryResults(runKey, lgtm.OrderByNumResults)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			res, ok := agg.Projects[item.ProjectKey]
+			if !ok {
+				res = &AggregatedProjectResult{
+					ProjectKey: item.ProjectKey,
+					Runs:       make([]string, 0),
+					Done:       true,
+				}
+				agg.Projects[item.ProjectKey] = res
+			}
+			res.Runs = append(res.Runs, runKey)
+			res.Done = res.Done && item.Done
+			if item.Error != "" {
+				res.Errors = append(res.Errors, item.Error)
+			}
+			if item.Stats != nil {
+				res.NumResults += item.Stats.NumResults
+			}
+		}
+	}
+	return agg, nil
+}
+
+// WriteToFile saves the aggregate as json to the provided file
+// (or remote output).
+func (agg *QueryAggregate) WriteToFile(path string) error {
+	js, err := json.MarshalIndent(agg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
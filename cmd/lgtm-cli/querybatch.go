@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// DefaultMaxProjectsPerQueryRun is the max number of projects
+// that lgtm.com accepts in a single query run.
+const DefaultMaxProjectsPerQueryRun = 1000
+
+// QueryBatch is a query run on a part of the projects,
+// that were added to a temporary list.
+type QueryBatch struct {
+	List *lgtm.ProjectSelectionBare
+	Run  *lgtm.QueryResponseData
+}
+
+// runQueryInBatches splits the project keys of the query config into temporary lists
+// of at most maxProjects projects each, and runs the query on each list;
+// the lists of the query config are queried in the first run.
+func runQueryInBatches(
+	ctx context.Context,
+	cl *lgtm.Client,
+	queryConfig *lgtm.QueryConfig,
+	maxProjects int,
+	concurrency int64,
+	retries int,
+) ([]*QueryBatch, error) {
+	batches := make([]*QueryBatch, 0)
+
+	partsNumber := lgtm.CalcChunkCount(len(queryConfig.ProjectKeys), maxProjects)
+	chunks := SplitStringSlice(partsNumber, queryConfig.ProjectKeys)
+	prefix := Sf("lgtm-cli-tmp-%s", time.Now().Format(FilenameTimeFormat))
+	for chunkIndex, chunk := range chunks {
+		if len(chunk) == 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			return batches, ctx.Err()
+		}
+
+		name := Sf("%s-%v", prefix, chunkIndex+1)
+		Infof(
+			"Adding %v projects to temporary list %q (%v/%v)...",
+			len(chunk),
+			name,
+			chunkIndex+1,
+			len(chunks),
+		)
+		list, err := getOrCreateList(cl, name)
+		if err != nil {
+			return batches, err
+		}
+		batch := &QueryBatch{
+			List: list,
+		}
+		batches = append(batches, batch)
+
+		_, failed := addToSelectionInChunks(ctx, cl, list, chunk, concurrency, retries)
+		for _, failedChunk := range failed {
+			Warnf(
+				"%v projects could not be added to %q list, and won't be queried: %s",
+				len(failedChunk.Keys),
+				name,
+				failedChunk.Error,
+			)
+		}
+
+		selectionKeys := []string{list.Key}
+		if len(batches) == 1 {
+			selectionKeys = append(selectionKeys, queryConfig.ProjectSelectionKeys...)
+		}
+		batch.Run, err = cl.Query(&lgtm.QueryConfig{
+			Lang:                 queryConfig.Lang,
+			QueryString:          queryConfig.QueryString,
+			ProjectSelectionKeys: selectionKeys,
+		})
+		if err != nil {
+			return batches, fmt.Errorf("error while running query on %q list: %w", name, err)
+		}
+		Successf("Started run %v/%v: %s", chunkIndex+1, len(chunks), batch.Run.GetResultLink())
+	}
+	return batches, nil
+}
+
+// waitQueryBatches waits until all the runs of the batches are done,
+// or the context is canceled.
+func waitQueryBatches(ctx context.Context, cl *lgtm.Client, batches []*QueryBatch, interval time.Duration) {
+	runKeys := make([]string, 0)
+	for _, batch := range batches {
+		if batch.Run != nil {
+			runKeys = append(runKeys, batch.Run.Key)
+		}
+	}
+	waitQueryRuns(ctx, cl, runKeys, interval)
+}
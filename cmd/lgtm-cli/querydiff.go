@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// QueryResultDelta is the change in the number of results
+// of a project between two query runs.
+type QueryResultDelta struct {
+	ProjectKey string `json:"projectKey"`
+	Project    string `json:"project,omitempty"`
+	ResultsA   int    `json:"resultsA"`
+	ResultsB   int    `json:"resultsB"`
+	Delta      int    `json:"delta"`
+}
+
+// QueryRunDiff contains the differences between the results of two query runs.
+type QueryRunDiff struct {
+	// New contains the projects that have results only in run B.
+	New []*QueryResultDelta `json:"new"`
+	// Gone contains the projects that have results only in run A.
+	Gone []*QueryResultDelta `json:"gone"`
+	// Changed contains the projects that have results in both runs,
+	// but a different number of them.
+	Changed   []*QueryResultDelta `json:"changed"`
+	Unchanged int                 `json:"unchanged"`
+	// Skipped contains the keys of the projects that failed
+	// or are still running in either run.
+	Skipped []string `json:"skipped"`
+}
+
+// Deltas returns all the changes.
+func (diff *QueryRunDiff) Deltas() []*QueryResultDelta {
+	res := make([]*QueryResultDelta, 0, len(diff.New)+len(diff.Gone)+len(diff.Changed))
+	res = append(res, diff.New...)
+	res = append(res, diff.Gone...)
+	res = append(res, diff.Changed...)
+	return res
+}
+
+// diffQueryResults compares the result items of run A with the ones of run B.
+func diffQueryResults(itemsA []*lgtm.GetQueryResultsResponseItem, itemsB []*lgtm.GetQueryResultsResponseItem) *QueryRunDiff {
+	diff := &QueryRunDiff{
+		New:     make([]*QueryResultDelta, 0),
+		Gone:    make([]*QueryResultDelta, 0),
+		Changed: make([]*QueryResultDelta, 0),
+		Skipped: make([]string, 0),
+	}
+
+	isComparable := func(item *lgtm.GetQueryResultsResponseItem) bool {
+		return item.Error == "" && item.Done
+	}
+	numResults := func(item *lgtm.GetQueryResultsResponseItem) int {
+		if item == nil || item.Stats == nil {
+			return 0
+		}
+		return item.Stats.NumResults
+	}
+
+	byKeyA := make(map[string]*lgtm.GetQueryResultsResponseItem)
+	for _, item := range itemsA {
+		byKeyA[item.ProjectKey] = item
+	}
+	byKeyB := make(map[string]*lgtm.GetQueryResultsResponseItem)
+	for _, item := range itemsB {
+		byKeyB[item.ProjectKey] = item
+	}
+
+	keys := make([]string, 0)
+	for key := range byKeyA {
+		keys = append(keys, key)
+	}
+	for key := range byKeyB {
+		if _, ok := byKeyA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		itemA, itemB := byKeyA[key], byKeyB[key]
+		if (itemA != nil && !isComparable(itemA)) || (itemB != nil && !isComparable(itemB)) {
+			diff.Skipped = append(diff.Skipped, key)
+			continue
+		}
+		delta := &QueryResultDelta{
+			ProjectKey: key,
+			ResultsA:   numResults(itemA),
+			ResultsB:   numResults(itemB),
+		}
+		delta.Delta = delta.ResultsB - delta.ResultsA
+		switch {
+		case delta.Delta == 0:
+			diff.Unchanged++
+		case delta.ResultsA == 0:
+			diff.New = append(diff.New, delta)
+		case delta.ResultsB == 0:
+			diff.Gone = append(diff.Gone, delta)
+		default:
+			diff.Changed = append(diff.Changed, delta)
+		}
+	}
+
+	for _, deltas := range [][]*QueryResultDelta{diff.New, diff.Gone, diff.Changed} {
+		sortDeltas(deltas)
+	}
+	return diff
+}
+
+// sortDeltas sorts the deltas by absolute change (descending).
+func sortDeltas(deltas []*QueryResultDelta) {
+	abs := func(n int) int {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return abs(deltas[i].Delta) > abs(deltas[j].Delta)
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// queryVarRegex matches the placeholders of query templates (e.g. {{sink}}).
+var queryVarRegex = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// parseQueryVars parses name=value pairs (as provided with --var).
+func parseQueryVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, pair := range pairs {
+		eq := strings.Index(pair, "=")
+		if eq < 1 {
+			return nil, fmt.Errorf("invalid variable %q: expected name=value", pair)
+		}
+		name := pair[:eq]
+		if !queryVarRegex.MatchString("{{" + name + "}}") {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		if _, ok := vars[name]; ok {
+			return nil, fmt.Errorf("variable %q set more than once", name)
+		}
+		vars[name] = pair[eq+1:]
+	}
+	return vars, nil
+}
+
+// substituteQueryVars replaces the placeholders of the query with the values
+// of the variables; it is an error if a placeholder has no value,
+// or if a variable is not used by the query.
+func substituteQueryVars(query string, vars map[string]string) (string, error) {
+	missing := make([]string, 0)
+	used := make(map[string]bool)
+	res := queryVarRegex.ReplaceAllStringFunc(query, func(placeholder string) string {
+		name := queryVarRegex.FindStringSubmatch(placeholder)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+		used[name] = true
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for the query variables %s (use --var name=value)", strings.Join(Deduplicate(missing), ", "))
+	}
+	unused := make([]string, 0)
+	for name := range vars {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", fmt.Errorf("the query does not use the variables %s", strings.Join(unused, ", "))
+	}
+	return res, nil
+}
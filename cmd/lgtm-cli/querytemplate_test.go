@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSubstituteQueryVars(t *testing.T) {
+	const query = `import go
+from CallExpr call
+where call.getTarget().getName() = "{{sink}}" or call.getTarget().getName() = "{{ sink }}"
+select call, "{{message}}"`
+
+	got, err := substituteQueryVars(query, map[string]string{"sink": "Exec", "message": "call to Exec"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `import go
+from CallExpr call
+where call.getTarget().getName() = "Exec" or call.getTarget().getName() = "Exec"
+select call, "call to Exec"`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := substituteQueryVars(query, map[string]string{}); err == nil {
+		t.Errorf("a template without variables should be rejected")
+	}
+	if _, err := substituteQueryVars(query, map[string]string{"sink": "Exec"}); err == nil {
+		t.Errorf("a missing variable should be rejected")
+	}
+	if _, err := substituteQueryVars(query, map[string]string{"sink": "Exec", "message": "m", "other": "x"}); err == nil {
+		t.Errorf("an unused variable should be rejected")
+	}
+	if got, err := substituteQueryVars("import go\nselect 1", map[string]string{}); err != nil || got != "import go\nselect 1" {
+		t.Errorf("a query without placeholders should be kept as is: %q, %v", got, err)
+	}
+}
+
+func TestParseQueryVars(t *testing.T) {
+	vars, err := parseQueryVars([]string{"sink=Exec", "expr=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["sink"] != "Exec" || vars["expr"] != "a=b" {
+		t.Errorf("unexpected vars: %v", vars)
+	}
+	for _, invalid := range [][]string{{"sink"}, {"=Exec"}, {"1sink=Exec"}, {"sink=a", "sink=b"}} {
+		if _, err := parseQueryVars(invalid); err == nil {
+			t.Errorf("parseQueryVars(%q) should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// isQueryURL returns true if the query is to be downloaded.
+func isQueryURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// rawQueryURL returns the URL of the raw content of GitHub blob URLs
+// (e.g. https://github.com/owner/repo/blob/main/query.ql);
+// other URLs are returned as-is.
+func rawQueryURL(parsed *url.URL) string {
+	if parsed.Host != "github.com" {
+		return parsed.String()
+	}
+	// owner/repo/blob/ref/path...
+	parts := strings.SplitN(strings.Trim(parsed.Path, "/"), "/", 4)
+	if len(parts) != 4 || parts[2] != "blob" {
+		return parsed.String()
+	}
+	return Sf(
+		"https://raw.githubusercontent.com/%s/%s/%s",
+		parts[0],
+		parts[1],
+		parts[3],
+	)
+}
+
+// gistID returns the ID of a gist page URL (e.g. https://gist.github.com/user/0123abcd).
+func gistID(parsed *url.URL) (string, bool) {
+	if parsed.Host != "gist.github.com" {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) == 0 || parts[len(parts)-1] == "" || strings.Contains(parsed.Path, "/raw") {
+		return "", false
+	}
+	return parts[len(parts)-1], true
+}
+
+var qlImportLineRegex = regexp.MustCompile(`(?m)^\s*import\s+\S+`)
+
+// looksLikeQL returns true if the content looks like a QL query.
+func looksLikeQL(content string) bool {
+	hasImport := qlImportLineRegex.MatchString(content)
+	hasSelect := strings.Contains(content, "select ")
+	return hasImport && hasSelect
+}
+
+// fetchQueryFromGist returns the content of the only .ql file of the gist.
+func fetchQueryFromGist(id string) (string, error) {
+	gist, _, err := ghRawClient.Gists.Get(context.Background(), id)
+	if err != nil {
+		return "", fmt.Errorf("error while getting gist %s: %w", id, err)
+	}
+	var found []string
+	var content string
+	for name, file := range gist.Files {
+		if path.Ext(string(name)) == ".ql" {
+			found = append(found, string(name))
+			content = file.GetContent()
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("gist %s does not contain a .ql file", id)
+	case 1:
+		return content, nil
+	default:
+		return "", fmt.Errorf("gist %s contains more than one .ql file: %s", id, strings.Join(found, ", "))
+	}
+}
+
+// fetchQuery downloads the query from the provided URL (a raw file,
+// a GitHub blob URL, or a gist with exactly one .ql file),
+// and checks that it is a .ql file (or that its content looks like a query).
+func fetchQuery(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid query URL %q: %w", rawURL, err)
+	}
+	if id, ok := gistID(parsed); ok {
+		return fetchQueryFromGist(id)
+	}
+
+	resp, err := webHTTPClient.Get(rawQueryURL(parsed))
+	if err != nil {
+		return "", fmt.Errorf("error while downloading query: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error while downloading query: status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error while downloading query: %w", err)
+	}
+	content := string(body)
+
+	if path.Ext(parsed.Path) != ".ql" && !looksLikeQL(content) {
+		return "", errors.New("the downloaded file is not a .ql file, and does not look like a query")
+	}
+	return content, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// QueryProblem is a problem found in a query by the local validation.
+type QueryProblem struct {
+	// Line and Column are one-indexed (zero if unknown).
+	Line    int
+	Column  int
+	Message string
+}
+
+func (problem *QueryProblem) String() string {
+	switch {
+	case problem.Line > 0 && problem.Column > 0:
+		return Sf("line %v, column %v: %s", problem.Line, problem.Column, problem.Message)
+	case problem.Line > 0:
+		return Sf("line %v: %s", problem.Line, problem.Message)
+	}
+	return problem.Message
+}
+
+// validateQuery checks the query before it is submitted to lgtm.com:
+// if the CodeQL CLI is on the PATH, the query is parsed with
+// `codeql query format` (a syntax check that does not need the libraries);
+// otherwise a lightweight sanity check is done (see sanityCheckQuery).
+// The returned string is the name of the check that was done.
+func validateQuery(queryString string, lang string) (string, []*QueryProblem, error) {
+	codeqlPath, err := exec.LookPath("codeql")
+	if err != nil {
+		return "sanity check", sanityCheckQuery(queryString, lang), nil
+	}
+	problems, err := codeqlSyntaxCheck(codeqlPath, queryString)
+	return "codeql query format", problems, err
+}
+
+// codeqlErrorRegex matches the errors of the CodeQL CLI, e.g.
+// "ERROR: mismatched input 'where' expecting ... (/tmp/query.ql:5,1-6)".
+var codeqlErrorRegex = regexp.MustCompile(`^ERROR: (.+) \([^()]*:(\d+),(\d+)(?:-\d+)?\)$`)
+
+// codeqlSyntaxCheck parses the query with the CodeQL CLI.
+func codeqlSyntaxCheck(codeqlPath string, queryString string) ([]*QueryProblem, error) {
+	dir, err := ioutil.TempDir("", "lgtm-cli-query")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	queryFilepath := filepath.Join(dir, "query.ql")
+	if err := ioutil.WriteFile(queryFilepath, []byte(queryString), 0644); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(codeqlPath, "query", "format", queryFilepath)
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err == nil {
+		return nil, nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		return nil, fmt.Errorf("error while running %s: %w", codeqlPath, err)
+	}
+
+	problems := make([]*QueryProblem, 0)
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		line = strings.TrimSpace(line)
+		matches := codeqlErrorRegex.FindStringSubmatch(line)
+		if len(matches) != 4 {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(matches[2])
+		column, _ := strconv.Atoi(matches[3])
+		problems = append(problems, &QueryProblem{
+			Line:    lineNumber,
+			Column:  column,
+			Message: matches[1],
+		})
+	}
+	if len(problems) == 0 {
+		// Not a syntax error we can locate; report the output as is:
+		problems = append(problems, &QueryProblem{
+			Message: Sf("codeql query format failed: %s", strings.TrimSpace(stderr.String())),
+		})
+	}
+	return problems, nil
+}
+
+var (
+	qlSelectRegex         = regexp.MustCompile(`\bselect\b`)
+	qlQueryPredicateRegex = regexp.MustCompile(`\bquery\s+predicate\b`)
+)
+
+// sanityCheckQuery checks that the query has a select clause (or a query predicate),
+// that it imports the library of a language (the one of lang, if set),
+// and that its parentheses, brackets and braces are balanced.
+func sanityCheckQuery(queryString string, lang string) []*QueryProblem {
+	problems := make([]*QueryProblem, 0)
+	code := stripQLComments(queryString)
+
+	if !qlSelectRegex.MatchString(code) && !qlQueryPredicateRegex.MatchString(code) {
+		problems = append(problems, &QueryProblem{
+			Message: "no select clause (or query predicate) found",
+		})
+	}
+
+	detected, err := lgtm.DetectQueryLanguage("", code)
+	switch {
+	case err != nil:
+		problems = append(problems, &QueryProblem{
+			Message: Sf("%s (e.g. import %s)", err, importExample(lang)),
+		})
+	case lang != "" && detected != lang:
+		problems = append(problems, &QueryProblem{
+			Message: Sf("the query imports the %s library, but the language is %s", detected, lang),
+		})
+	}
+
+	return append(problems, checkQLBrackets(code)...)
+}
+
+func importExample(lang string) string {
+	if lang != "" {
+		return lang
+	}
+	return lgtm.LangGo
+}
+
+// stripQLComments replaces the comments of the query with spaces
+// (keeping the newlines, so that line and column numbers don't change),
+// and ignores the comment markers inside strings.
+func stripQLComments(queryString string) string {
+	src := []byte(queryString)
+	out := make([]byte, len(src))
+	copy(out, src)
+	inString := false
+	for i := 0; i < len(src); i++ {
+		switch {
+		case inString:
+			if src[i] == '\\' {
+				i++
+			} else if src[i] == '"' || src[i] == '\n' {
+				inString = false
+			}
+		case src[i] == '"':
+			inString = true
+		case src[i] == '/' && i+1 < len(src) && src[i+1] == '/':
+			for ; i < len(src) && src[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case src[i] == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			stop := len(src)
+			if end >= 0 {
+				stop = i + 2 + end + 2
+			}
+			for ; i < stop; i++ {
+				if src[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		}
+	}
+	return string(out)
+}
+
+// checkQLBrackets returns a problem for each unbalanced
+// parenthesis, bracket or brace (strings are ignored).
+func checkQLBrackets(code string) []*QueryProblem {
+	type opening struct {
+		char   byte
+		line   int
+		column int
+	}
+	pairs := map[byte]byte{')': '(', ']': '[', '}': '{'}
+	problems := make([]*QueryProblem, 0)
+	stack := make([]opening, 0)
+	line, column := 1, 0
+	inString := false
+	for i := 0; i < len(code); i++ {
+		char := code[i]
+		column++
+		if char == '\n' {
+			line++
+			column = 0
+			inString = false
+			continue
+		}
+		if inString {
+			if char == '\\' {
+				i++
+				column++
+			} else if char == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch char {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			stack = append(stack, opening{char: char, line: line, column: column})
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1].char != pairs[char] {
+				problems = append(problems, &QueryProblem{
+					Line:    line,
+					Column:  column,
+					Message: Sf("unexpected %q", string(char)),
+				})
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	for _, open := range stack {
+		problems = append(problems, &QueryProblem{
+			Line:    open.line,
+			Column:  open.column,
+			Message: Sf("%q is never closed", string(open.char)),
+		})
+	}
+	return problems
+}
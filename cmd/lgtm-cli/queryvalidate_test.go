@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func problemStrings(problems []*QueryProblem) []string {
+	out := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		out = append(out, problem.String())
+	}
+	return out
+}
+
+func TestStripQLComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "line comment",
+			query: "import go // the Go library\nselect 1",
+			want:  "import go " + strings.Repeat(" ", len("// the Go library")) + "\nselect 1",
+		},
+		{
+			name:  "block comment keeps the newlines",
+			query: "/**\n * @kind problem\n */\nimport go",
+			want:  "   \n" + strings.Repeat(" ", len(" * @kind problem")) + "\n   \nimport go",
+		},
+		{
+			name:  "unterminated block comment",
+			query: "select 1 /* (",
+			want:  "select 1     ",
+		},
+		{
+			name:  "comment markers inside strings",
+			query: `select "http://example.com", "/* no comment */" // (`,
+			want:  `select "http://example.com", "/* no comment */"     `,
+		},
+		{
+			name:  "escaped quote inside a string",
+			query: `select "a \" // b" // c`,
+			want:  `select "a \" // b"     `,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripQLComments(tt.query)
+			if got != tt.want {
+				t.Errorf("stripQLComments() = %q, want %q", got, tt.want)
+			}
+			if len(got) != len(tt.query) {
+				t.Errorf("stripQLComments() changed the length from %v to %v", len(tt.query), len(got))
+			}
+		})
+	}
+}
+
+func TestCheckQLBrackets(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "balanced",
+			code: "from Call c\nwhere c.getArgument(0) = [1, 2]\nselect c, \"ok\"",
+			want: []string{},
+		},
+		{
+			name: "brackets inside strings",
+			code: `select "(", "\")", "]}"`,
+			want: []string{},
+		},
+		{
+			name: "unexpected closing",
+			code: "from int i\nwhere i = 1)\nselect i",
+			want: []string{`line 2, column 12: unexpected ")"`},
+		},
+		{
+			name: "never closed",
+			code: "predicate p(int i) {\n  i = [1, 2\n}",
+			want: []string{
+				`line 3, column 1: unexpected "}"`,
+				`line 1, column 20: "{" is never closed`,
+				`line 2, column 7: "[" is never closed`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := problemStrings(checkQLBrackets(tt.code))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkQLBrackets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeQLErrorRegex(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{
+			line: "ERROR: mismatched input 'where' expecting {'(', '.'} (/tmp/lgtm-cli-query123/query.ql:5,1-6)",
+			want: []string{"mismatched input 'where' expecting {'(', '.'}", "5", "1"},
+		},
+		{
+			line: "ERROR: extraneous input ')' (C:\\Temp\\query.ql:12,30)",
+			want: []string{"extraneous input ')'", "12", "30"},
+		},
+		{
+			line: "A fatal error occurred: no such file",
+		},
+		{
+			line: "ERROR: could not resolve module go",
+		},
+	}
+	for _, tt := range tests {
+		matches := codeqlErrorRegex.FindStringSubmatch(tt.line)
+		if tt.want == nil {
+			if matches != nil {
+				t.Errorf("codeqlErrorRegex matched %q: %q", tt.line, matches)
+			}
+			continue
+		}
+		if len(matches) != 4 || strings.Join(matches[1:], "|") != strings.Join(tt.want, "|") {
+			t.Errorf("codeqlErrorRegex on %q = %q, want %q", tt.line, matches, tt.want)
+		}
+	}
+}
+
+func TestSanityCheckQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		lang  string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			query: "import semmle.code.java.dataflow.DataFlow\n\nfrom DataFlow::Node n\nselect n",
+			lang:  "java",
+			want:  []string{},
+		},
+		{
+			name:  "query predicate",
+			query: "import cpp\n\nquery predicate p(int i) { i = 1 }",
+			lang:  "",
+			want:  []string{},
+		},
+		{
+			name:  "select only in a comment",
+			query: "import go\n// select 1\nfrom int i where i = 1",
+			lang:  "go",
+			want:  []string{"no select clause (or query predicate) found"},
+		},
+		{
+			name:  "language mismatch",
+			query: "import semmle.code.cpp.dataflow.DataFlow\nselect 1",
+			lang:  "java",
+			want:  []string{"the query imports the cpp library, but the language is java"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := problemStrings(sanityCheckQuery(tt.query, tt.lang))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("sanityCheckQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
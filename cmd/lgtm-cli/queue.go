@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// maxQueueAttempts is the max number of times the follow
+// of a queued target is attempted before it is dropped from the queue.
+const maxQueueAttempts = 3
+
+// DefaultQueueFilepath returns the default filepath of the follow queue.
+func DefaultQueueFilepath() string {
+	return filepath.Join(filepath.Dir(DefaultRunsFilepath()), "queue.json")
+}
+
+// QueuedTarget is a repo waiting to be followed.
+type QueuedTarget struct {
+	URL      string `json:"url"`
+	Priority int    `json:"priority"`
+	Stars    int    `json:"stars,omitempty"`
+	// Source is the command that discovered the target.
+	Source    string    `json:"source"`
+	AddedAt   time.Time `json:"addedAt"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// FollowQueue contains the targets to be followed by the queue run command;
+// the discovery commands add targets to it with --enqueue-only.
+type FollowQueue struct {
+	path    string
+	Targets []*QueuedTarget `json:"targets"`
+}
+
+// LoadFollowQueue loads the queue from the provided file;
+// if the file does not exist, an empty queue is returned.
+func LoadFollowQueue(path string) (*FollowQueue, error) {
+	queue := &FollowQueue{
+		path:    path,
+		Targets: make([]*QueuedTarget, 0),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return queue, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, queue); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// Save writes the queue to the file it was loaded from.
+func (queue *FollowQueue) Save() error {
+	js, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(queue.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(queue.path, js, 0600)
+}
+
+// Add adds the targets to the queue, and returns the number of new targets;
+// for the targets that are already queued, the highest priority
+// and the latest star count are kept.
+func (queue *FollowQueue) Add(targets ...*QueuedTarget) int {
+	byURL := make(map[string]*QueuedTarget, len(queue.Targets))
+	for _, target := range queue.Targets {
+		byURL[strings.ToLower(target.URL)] = target
+	}
+	added := 0
+	for _, target := range targets {
+		if queued, ok := byURL[strings.ToLower(target.URL)]; ok {
+			if target.Priority > queued.Priority {
+				queued.Priority = target.Priority
+			}
+			if target.Stars > 0 {
+				queued.Stars = target.Stars
+			}
+			continue
+		}
+		byURL[strings.ToLower(target.URL)] = target
+		queue.Targets = append(queue.Targets, target)
+		added++
+	}
+	queue.Sort()
+	return added
+}
+
+// Sort sorts the targets by priority, then by stars (highest first),
+// then by the time they were added (oldest first).
+func (queue *FollowQueue) Sort() {
+	sort.SliceStable(queue.Targets, func(i, j int) bool {
+		a, b := queue.Targets[i], queue.Targets[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.Stars != b.Stars {
+			return a.Stars > b.Stars
+		}
+		return a.AddedAt.Before(b.AddedAt)
+	})
+}
+
+// Get returns the queued target with the provided URL (nil if not queued).
+func (queue *FollowQueue) Get(repoURL string) *QueuedTarget {
+	for _, target := range queue.Targets {
+		if strings.EqualFold(target.URL, repoURL) {
+			return target
+		}
+	}
+	return nil
+}
+
+// Remove removes the target with the provided URL from the queue.
+func (queue *FollowQueue) Remove(repoURL string) {
+	for i, target := range queue.Targets {
+		if strings.EqualFold(target.URL, repoURL) {
+			queue.Targets = append(queue.Targets[:i], queue.Targets[i+1:]...)
+			return
+		}
+	}
+}
+
+// newQueuedTargets returns the targets for the repo URLs;
+// stars can be nil.
+func newQueuedTargets(source string, repoURLs []string, stars map[string]int, priority int) []*QueuedTarget {
+	now := time.Now()
+	targets := make([]*QueuedTarget, 0, len(repoURLs))
+	for _, repoURL := range repoURLs {
+		targets = append(targets, &QueuedTarget{
+			URL:      repoURL,
+			Priority: priority,
+			Stars:    stars[repoURL],
+			Source:   source,
+			AddedAt:  now,
+		})
+	}
+	return targets
+}
+
+// enqueueFollowTargets adds the repos to the follow queue
+// (instead of following them).
+func enqueueFollowTargets(path string, source string, repoURLs []string, stars map[string]int, priority int) error {
+	queue, err := LoadFollowQueue(path)
+	if err != nil {
+		return err
+	}
+	added := queue.Add(newQueuedTargets(source, repoURLs, stars, priority)...)
+	if err := queue.Save(); err != nil {
+		return err
+	}
+	Successf(
+		"Added %v targets to the queue (%v were already queued; %v queued in total); follow them with the queue run command",
+		added,
+		len(repoURLs)-added,
+		len(queue.Targets),
+	)
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// applyFollowQuota warns when following the targets would exceed
+// the follow limit of the account; if stopAtLimit is true, only the targets
+// up to the limit are returned, and the others are saved to a file
+// (to be followed later).
+func applyFollowQuota(
+	cl *lgtm.Client,
+	cache *lgtm.FollowedProjectCache,
+	toBeFollowed []string,
+	stopAtLimit bool,
+	cmdName string,
+) []string {
+	if len(toBeFollowed) == 0 {
+		return toBeFollowed
+	}
+	quota, err := cl.GetFollowQuota(cache)
+	if err != nil {
+		Warnf("Could not get the follow quota of the account: %s", err)
+		return toBeFollowed
+	}
+	remaining := quota.Remaining()
+	if remaining < 0 {
+		Debugf("The follow limit of the account is unknown (set follow_limit in the config)")
+		return toBeFollowed
+	}
+	if len(toBeFollowed) <= remaining {
+		return toBeFollowed
+	}
+
+	Warnf(
+		"Following %v projects would exceed the follow limit of the account: %v/%v followed (%v remaining)",
+		len(toBeFollowed),
+		quota.Total(),
+		quota.Max,
+		remaining,
+	)
+	if !stopAtLimit {
+		Warnf("Use --stop-at-limit to only follow projects up to the limit.")
+		return toBeFollowed
+	}
+	Warnf("Will stop at the limit, after %v projects", remaining)
+	saveTargetListToTempFile("", cmdName+"-over-limit", toBeFollowed[remaining:])
+	return toBeFollowed[:remaining]
+}
+
+// formatFollowQuota returns a description of the follow quota.
+func formatFollowQuota(quota *lgtm.FollowQuota) string {
+	if quota.Max == 0 {
+		return Sf(
+			"following %v projects and %v proto-projects (limit unknown)",
+			quota.Followed,
+			quota.FollowedProto,
+		)
+	}
+	return Sf(
+		"following %v projects and %v proto-projects; limit %v (%v remaining)",
+		quota.Followed,
+		quota.FollowedProto,
+		quota.Max,
+		quota.Remaining(),
+	)
+}
+
+// projectLimitGuard stops the run the first time lgtm.com reports
+// that the account has reached the max number of projects it can follow
+// (instead of failing the follow of every remaining target).
+type projectLimitGuard struct {
+	once  sync.Once
+	abort context.CancelFunc
+}
+
+// Reached reports the error, saves the limit stated in the error message
+// (if any, and if different from the known one) to the config file,
+// and stops the run.
+func (g *projectLimitGuard) Reached(status *lgtm.StatusResponse, configFilepath string, profileName string, knownLimit int) {
+	g.once.Do(func() {
+		Errorln(RedBG(Bold("The account has reached the max number of projects it can follow; stopping.")))
+		Errorf("lgtm.com said: %s", status.Message)
+
+		if limit := status.ProjectLimit(); limit > 0 && limit != knownLimit {
+			if err := lgtm.SaveFollowLimitToFile(configFilepath, profileName, limit); err != nil {
+				Warnf("Could not save the follow limit (%v) to the config: %s", limit, err)
+			} else {
+				Infof("Saved follow_limit %v to %s", limit, configFilepath)
+			}
+		}
+		Infof("Unfollow some projects before following more (see the capacity command).")
+		g.abort()
+	})
+}
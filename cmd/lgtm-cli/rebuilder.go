@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/eta"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"github.com/hako/durafmt"
+	"golang.org/x/sync/semaphore"
+)
+
+// RebuildTask is a build attempt for a project and language.
+type RebuildTask struct {
+	Project *lgtm.Project `json:"-"`
+	Lang    string        `json:"lang"`
+	// IsTestBuild is true when the project already has the language,
+	// and a new test build is requested.
+	IsTestBuild bool `json:"isTestBuild"`
+
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+// parseAge parses a duration that can also be expressed in days (e.g. "30d").
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// rebuildReason returns why the project should be rebuilt for the language,
+// given its latest state stats: the latest analyzed commit is older than olderThan.
+// An empty string is returned if the project should not be rebuilt
+// (or if it has no analysis for the language).
+func rebuildReason(stats *lgtm.LatestStateStatsData, lang string, olderThan time.Duration, now time.Time) string {
+	for _, state := range stats.LanguageStates {
+		if state.Lang != lang {
+			continue
+		}
+		age := now.Sub(time.Unix(0, state.SnapshotDate*int64(time.Millisecond)))
+		if age > olderThan {
+			return Sf("latest analyzed commit is %s old", durafmt.Parse(age).LimitFirstN(1))
+		}
+		return ""
+	}
+	return ""
+}
+
+type Rebuilder struct {
+	ctx       context.Context
+	client    *lgtm.Client
+	wg        *sync.WaitGroup
+	sem       *semaphore.Weighted
+	mu        *sync.Mutex
+	wait      time.Duration
+	succeeded []*RebuildTask
+	failed    []*RebuildTask
+	skipped   []*RebuildTask
+}
+
+// NewRebuilder returns a new Rebuilder that issues build attempts
+// with at most maxWorkers concurrent requests; each worker waits
+// for the wait duration after a successful build attempt.
+// Once the context is canceled, no new build attempts are started.
+func NewRebuilder(ctx context.Context, client *lgtm.Client, maxWorkers int64, wait time.Duration) *Rebuilder {
+	return &Rebuilder{
+		ctx:       ctx,
+		client:    client,
+		wg:        &sync.WaitGroup{},
+		sem:       semaphore.NewWeighted(maxWorkers),
+		mu:        &sync.Mutex{},
+		wait:      wait,
+		succeeded: make([]*RebuildTask, 0),
+		failed:    make([]*RebuildTask, 0),
+		skipped:   make([]*RebuildTask, 0),
+	}
+}
+
+//
+func (rb *Rebuilder) Rebuild(task *RebuildTask, etac *eta.ETA) {
+	if rb.ctx.Err() != nil || rb.sem.Acquire(rb.ctx, 1) != nil {
+		task.URL = task.Project.ExternalURL.URL
+		rb.mu.Lock()
+		rb.skipped = append(rb.skipped, task)
+		rb.mu.Unlock()
+		return
+	}
+	rb.wg.Add(1)
+
+	go rb.rebuilder(task, etac)
+}
+
+//
+func (rb *Rebuilder) rebuilder(task *RebuildTask, etac *eta.ETA) {
+	var err error
+	defer func() {
+		progress.Emit("rebuild", task.URL, etac, err)
+		notifier.Record("rebuild", err)
+	}()
+	defer etac.Done(1)
+	defer rb.wg.Done()
+	defer rb.sem.Release(1)
+
+	averagedETA := etac.GetETA()
+	thisETA := durafmt.Parse(averagedETA.Round(time.Second)).String()
+
+	pr := task.Project
+	task.URL = pr.ExternalURL.URL
+
+	if task.IsTestBuild {
+		Infof(
+			"[%s](%v/%v) Requesting a new test build for %s for %s language ... ETA %s",
+			etac.GetFormattedPercentDone(),
+			etac.GetDone()+1,
+			etac.GetTotal(),
+			pr.DisplayName,
+			task.Lang,
+			thisETA,
+		)
+		err = rb.client.RequestTestBuild(pr.Slug, task.Lang)
+	} else {
+		Infof(
+			"[%s](%v/%v) Issuing a new build attempt for %s for %s language ... ETA %s",
+			etac.GetFormattedPercentDone(),
+			etac.GetDone()+1,
+			etac.GetTotal(),
+			pr.DisplayName,
+			task.Lang,
+			thisETA,
+		)
+		err = rb.client.NewBuildAttempt(pr.Key, task.Lang)
+	}
+
+	if err != nil {
+		metrics.Inc("errors_total", "op", "rebuild")
+	} else {
+		metrics.Inc("build_attempts_total")
+	}
+
+	rb.mu.Lock()
+	if err != nil {
+		task.Error = err.Error()
+		rb.failed = append(rb.failed, task)
+	} else {
+		rb.succeeded = append(rb.succeeded, task)
+	}
+	rb.mu.Unlock()
+
+	if err != nil {
+		Errorf(
+			"Failed to start a new build attempt for %s for %s language: %s",
+			pr.DisplayName,
+			task.Lang,
+			err,
+		)
+		return
+	}
+	Successf(
+		"Started a new build attempt for %s for %s language",
+		pr.DisplayName,
+		task.Lang,
+	)
+	// sleep:
+	time.Sleep(rb.wait)
+}
+
+// RebuildSummary contains the results of the build attempts.
+type RebuildSummary struct {
+	Succeeded []*RebuildTask `json:"succeeded"`
+	Failed    []*RebuildTask `json:"failed"`
+	// Skipped are the build attempts that were not started
+	// because the run was interrupted.
+	Skipped []*RebuildTask `json:"skipped,omitempty"`
+}
+
+// Wait waits for all build attempts to complete, and returns a summary.
+func (rb *Rebuilder) Wait() *RebuildSummary {
+	rb.wg.Wait()
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return &RebuildSummary{
+		Succeeded: rb.succeeded,
+		Failed:    rb.failed,
+		Skipped:   rb.skipped,
+	}
+}
+
+// Print prints the summary.
+func (sum *RebuildSummary) Print() {
+	Successf("%v build attempts succeeded", len(sum.Succeeded))
+	if len(sum.Failed) > 0 {
+		Errorf("%v build attempts failed:", len(sum.Failed))
+		for _, task := range sum.Failed {
+			Errorf("    %s (%s): %s", task.URL, task.Lang, task.Error)
+		}
+	}
+	if len(sum.Skipped) > 0 {
+		Warnf("%v build attempts were not started (interrupted)", len(sum.Skipped))
+	}
+}
+
+// WriteToFile saves the summary as json to the provided file
+// (or remote output).
+func (sum *RebuildSummary) WriteToFile(path string) error {
+	js, err := json.MarshalIndent(sum, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
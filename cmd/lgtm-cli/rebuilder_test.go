@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+func TestRebuildReason(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int64 {
+		return now.Add(-time.Duration(days)*24*time.Hour).UnixNano() / int64(time.Millisecond)
+	}
+	stats := &lgtm.LatestStateStatsData{
+		LanguageStates: []lgtm.LanguageStates{
+			{Lang: lgtm.LangGo, SnapshotDate: daysAgo(45)},
+			{Lang: lgtm.LangJavaScript, SnapshotDate: daysAgo(3)},
+		},
+	}
+	olderThan, err := parseAge("30d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		lgtm.LangGo:         "latest analyzed commit is 6 weeks old",
+		lgtm.LangJavaScript: "",
+		lgtm.LangPython:     "",
+	}
+	for lang, want := range tests {
+		if got := rebuildReason(stats, lang, olderThan, now); got != want {
+			t.Errorf("rebuildReason(%s) = %q, want %q", lang, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+const (
+	// RebuildActionTest requests a new test build
+	// (for a language the project already has).
+	RebuildActionTest = "test"
+	// RebuildActionNewAttempt issues a new build attempt
+	// (for a language the project does not have yet).
+	RebuildActionNewAttempt = "new-attempt"
+)
+
+const (
+	RebuildStatusSucceeded = "succeeded"
+	RebuildStatusFailed    = "failed"
+	// RebuildStatusSkipped is the status of the rows that were not executed
+	// because the run was interrupted.
+	RebuildStatusSkipped = "skipped"
+	// RebuildStatusInvalid is the status of the rows that could not be executed
+	// (e.g. invalid action, or project not followed).
+	RebuildStatusInvalid = "invalid"
+)
+
+// RebuildPlanRow is a row of a rebuild plan: repo,language,action(test|new-attempt).
+type RebuildPlanRow struct {
+	Repo   string
+	Lang   string
+	Action string
+
+	Status string
+	Error  string
+
+	task *RebuildTask
+}
+
+// loadRebuildPlan reads a rebuild plan from a CSV file; the first line
+// is skipped if it is a header (i.e. its first column is "repo").
+func loadRebuildPlan(path string) ([]*RebuildPlanRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseRebuildPlan(file)
+}
+
+func parseRebuildPlan(r io.Reader) ([]*RebuildPlanRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*RebuildPlanRow, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "repo") {
+			continue
+		}
+		rows = append(rows, &RebuildPlanRow{
+			Repo:   strings.TrimSpace(record[0]),
+			Lang:   strings.ToLower(strings.TrimSpace(record[1])),
+			Action: strings.ToLower(strings.TrimSpace(record[2])),
+		})
+	}
+	return rows, nil
+}
+
+// resolveRebuildPlan sets the build attempt of each row of the plan,
+// or marks the row as invalid.
+func resolveRebuildPlan(rows []*RebuildPlanRow, cache *lgtm.FollowedProjectCache) {
+	for _, row := range rows {
+		if row.Action != RebuildActionTest && row.Action != RebuildActionNewAttempt {
+			row.setInvalid(fmt.Errorf("invalid action %q (must be %s or %s)", row.Action, RebuildActionTest, RebuildActionNewAttempt))
+			continue
+		}
+		if row.Lang == "" {
+			row.setInvalid(fmt.Errorf("language not set"))
+			continue
+		}
+		parsed, err := urlparse.Parse(row.Repo, true)
+		if err != nil {
+			row.setInvalid(err)
+			continue
+		}
+		pr := cache.GetProject(parsed.URL())
+		if pr == nil {
+			row.setInvalid(fmt.Errorf("not followed as a built project"))
+			continue
+		}
+		row.task = &RebuildTask{
+			Project:     pr,
+			Lang:        row.Lang,
+			IsTestBuild: row.Action == RebuildActionTest,
+		}
+	}
+}
+
+func (row *RebuildPlanRow) setInvalid(err error) {
+	row.Status = RebuildStatusInvalid
+	row.Error = err.Error()
+}
+
+// setRebuildPlanStatus sets the status of each row of the plan
+// from the summary of the executed build attempts.
+func setRebuildPlanStatus(rows []*RebuildPlanRow, summary *RebuildSummary) {
+	statuses := make(map[*RebuildTask]string)
+	for _, task := range summary.Succeeded {
+		statuses[task] = RebuildStatusSucceeded
+	}
+	for _, task := range summary.Failed {
+		statuses[task] = RebuildStatusFailed
+	}
+	for _, task := range summary.Skipped {
+		statuses[task] = RebuildStatusSkipped
+	}
+	for _, row := range rows {
+		if row.task == nil {
+			continue
+		}
+		row.Status = statuses[row.task]
+		row.Error = row.task.Error
+	}
+}
+
+// writeRebuildPlanStatus saves the rows of the plan, with their status,
+// as CSV to the provided file (or remote output).
+func writeRebuildPlanStatus(path string, rows []*RebuildPlanRow) error {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+	err := writer.Write([]string{"repo", "language", "action", "status", "error"})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := writer.Write([]string{
+			row.Repo,
+			row.Lang,
+			row.Action,
+			row.Status,
+			row.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return writeOutputFile(path, buf.Bytes(), "text/csv")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/githubutil"
+)
+
+// RenamedRepo is a followed project whose GitHub repo
+// was renamed, transferred, or deleted.
+type RenamedRepo struct {
+	URL     string `json:"url"`
+	Key     string `json:"key"`
+	IsProto bool   `json:"isProto"`
+	// NewURL is the current URL of the repo (empty if the repo is gone).
+	NewURL string `json:"newURL,omitempty"`
+	// Gone is true if the repo does not exist anymore (404).
+	Gone bool `json:"gone,omitempty"`
+}
+
+// checkRenamed checks the followed repo against the GitHub API
+// (which redirects renamed and transferred repos to their new location);
+// it returns nil if the repo is still at the same URL,
+// or if it is not a GitHub repo.
+func checkRenamed(isProto bool, key string, repoURL string) (*RenamedRepo, error) {
+	parsed, err := urlparse.Parse(repoURL, true)
+	if err != nil || parsed.Hostname != "github.com" {
+		// Only GitHub repos can be checked.
+		return nil, nil
+	}
+	repo, err := githubutil.GetRepo(ghRawClient, parsed.User, parsed.Repo)
+	if err != nil {
+		return nil, err
+	}
+	renamed := &RenamedRepo{
+		URL:     repoURL,
+		Key:     key,
+		IsProto: isProto,
+	}
+	if repo == nil {
+		renamed.Gone = true
+		return renamed, nil
+	}
+	if strings.EqualFold(repo.GetFullName(), parsed.User+"/"+parsed.Repo) {
+		return nil, nil
+	}
+	renamed.NewURL = repo.GetHTMLURL()
+	return renamed, nil
+}
+
+// writeRenamedRepos saves the renamed repos as json to the provided file
+// (or remote output).
+func writeRenamedRepos(path string, renamed []*RenamedRepo) error {
+	js, err := json.MarshalIndent(renamed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(path, js, "application/json")
+}
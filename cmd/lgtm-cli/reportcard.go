@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// gradeRanks are the LGTM grades, from best to worst.
+var gradeRanks = map[string]int{
+	"A+": 0,
+	"A":  1,
+	"B":  2,
+	"C":  3,
+	"D":  4,
+	"E":  5,
+}
+
+// gradeRank returns the rank of the grade (higher is worse);
+// projects without a grade rank after all the graded ones.
+func gradeRank(grade string) int {
+	if rank, ok := gradeRanks[grade]; ok {
+		return rank
+	}
+	return len(gradeRanks)
+}
+
+// ReportCardRow is a project in the table of a language.
+type ReportCardRow struct {
+	Slug   string
+	URL    string
+	Grade  string
+	Alerts int
+	Lines  int
+}
+
+// ReportCardLanguage is the table of the projects of a language,
+// from the worst grade to the best.
+type ReportCardLanguage struct {
+	Lang   string
+	Alerts int
+	Rows   []*ReportCardRow
+}
+
+// ReportCardRepo is a repo of the owner that has no stats
+// (not built yet, or not followed).
+type ReportCardRepo struct {
+	URL    string
+	Reason string
+}
+
+// ReportCard is a point-in-time snapshot of the grades
+// and alerts of the repos of an owner.
+type ReportCard struct {
+	Owner       string
+	GeneratedAt time.Time
+
+	NumRepos    int
+	NumAnalyzed int
+	TotalAlerts int
+	TotalLines  int
+	// GradeCounts are the number of (project, language) pairs by grade.
+	GradeCounts []*GradeCount
+
+	Languages []*ReportCardLanguage
+	// WorstOffenders are the projects with the most alerts.
+	WorstOffenders []*ReportCardOffender
+	// Missing are the repos that have no stats.
+	Missing []*ReportCardRepo
+}
+
+// ReportCardOffender is a project with many alerts.
+type ReportCardOffender struct {
+	Slug   string
+	URL    string
+	Alerts int
+	// Grades are the lang:grade pairs of the project.
+	Grades string
+}
+
+// GradeCount is the number of (project, language) pairs with a grade.
+type GradeCount struct {
+	Grade string
+	Count int
+}
+
+// newReportCard builds the report card from the stats of the analyzed projects
+// and the repos that have no stats; at most maxOffenders worst offenders are listed.
+func newReportCard(owner string, rows []*ProjectStatsRow, missing []*ReportCardRepo, maxOffenders int) *ReportCard {
+	card := &ReportCard{
+		Owner:       owner,
+		GeneratedAt: time.Now().UTC(),
+		NumRepos:    len(rows) + len(missing),
+		Missing:     missing,
+	}
+
+	analyzed := make([]*ProjectStatsRow, 0, len(rows))
+	byLang := make(map[string]*ReportCardLanguage)
+	gradeCounts := make(map[string]int)
+	for _, row := range rows {
+		if row.Error != "" {
+			card.Missing = append(card.Missing, &ReportCardRepo{URL: row.URL, Reason: "error: " + row.Error})
+			continue
+		}
+		analyzed = append(analyzed, row)
+		card.TotalAlerts += row.Alerts
+		card.TotalLines += row.Lines
+		for lang, lines := range row.LinesByLang {
+			table, ok := byLang[lang]
+			if !ok {
+				table = &ReportCardLanguage{Lang: lang}
+				byLang[lang] = table
+			}
+			grade := row.Grades[lang]
+			table.Alerts += row.AlertsByLang[lang]
+			table.Rows = append(table.Rows, &ReportCardRow{
+				Slug:   row.Slug,
+				URL:    row.URL,
+				Grade:  grade,
+				Alerts: row.AlertsByLang[lang],
+				Lines:  lines,
+			})
+			if grade != "" {
+				gradeCounts[grade]++
+			}
+		}
+	}
+	card.NumAnalyzed = len(analyzed)
+
+	for _, table := range byLang {
+		sort.SliceStable(table.Rows, func(i, j int) bool {
+			ri, rj := gradeRank(table.Rows[i].Grade), gradeRank(table.Rows[j].Grade)
+			if ri != rj {
+				return ri > rj
+			}
+			return table.Rows[i].Alerts > table.Rows[j].Alerts
+		})
+		card.Languages = append(card.Languages, table)
+	}
+	sort.Slice(card.Languages, func(i, j int) bool {
+		return card.Languages[i].Lang < card.Languages[j].Lang
+	})
+
+	for grade, count := range gradeCounts {
+		card.GradeCounts = append(card.GradeCounts, &GradeCount{Grade: grade, Count: count})
+	}
+	sort.Slice(card.GradeCounts, func(i, j int) bool {
+		return gradeRank(card.GradeCounts[i].Grade) < gradeRank(card.GradeCounts[j].Grade)
+	})
+
+	sort.SliceStable(analyzed, func(i, j int) bool {
+		return analyzed[i].Alerts > analyzed[j].Alerts
+	})
+	for _, row := range analyzed {
+		if len(card.WorstOffenders) >= maxOffenders || row.Alerts == 0 {
+			break
+		}
+		card.WorstOffenders = append(card.WorstOffenders, &ReportCardOffender{
+			Slug:   row.Slug,
+			URL:    row.URL,
+			Alerts: row.Alerts,
+			Grades: row.formatGrades(),
+		})
+	}
+	sort.Slice(card.Missing, func(i, j int) bool {
+		return card.Missing[i].URL < card.Missing[j].URL
+	})
+	return card
+}
+
+const reportCardMarkdownTemplate = `# LGTM report card: {{ .Owner }}
+
+Snapshot of {{ .GeneratedAt.Format "2006-01-02 15:04 MST" }} (no trends).
+
+- Repos: {{ .NumRepos }} ({{ .NumAnalyzed }} analyzed, {{ len .Missing }} without stats)
+- Alerts: {{ .TotalAlerts }}
+- Lines of code: {{ .TotalLines }}
+{{- if .GradeCounts }}
+- Grades: {{ range $i, $gc := .GradeCounts }}{{ if $i }}, {{ end }}{{ $gc.Grade }} × {{ $gc.Count }}{{ end }}
+{{- end }}
+{{ if .WorstOffenders }}
+## Worst offenders
+
+| Project | Alerts | Grades |
+|---|---:|---|
+{{- range .WorstOffenders }}
+| [{{ cell .Slug }}]({{ .URL }}) | {{ .Alerts }} | {{ cell .Grades }} |
+{{- end }}
+{{ end }}
+{{- range .Languages }}
+## {{ .Lang }} ({{ .Alerts }} alerts)
+
+| Project | Grade | Alerts | Lines |
+|---|---|---:|---:|
+{{- range .Rows }}
+| [{{ cell .Slug }}]({{ .URL }}) | {{ or .Grade "-" | cell }} | {{ .Alerts }} | {{ .Lines }} |
+{{- end }}
+{{ end }}
+{{- if .Missing }}
+## Repos without stats
+
+| Repo | Reason |
+|---|---|
+{{- range .Missing }}
+| {{ cell .URL }} | {{ cell .Reason }} |
+{{- end }}
+{{ end -}}
+`
+
+const reportCardHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>LGTM report card: {{ .Owner }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>LGTM report card: {{ .Owner }}</h1>
+<p>Snapshot of {{ .GeneratedAt.Format "2006-01-02 15:04 MST" }} (no trends).</p>
+<ul>
+<li>Repos: {{ .NumRepos }} ({{ .NumAnalyzed }} analyzed, {{ len .Missing }} without stats)</li>
+<li>Alerts: {{ .TotalAlerts }}</li>
+<li>Lines of code: {{ .TotalLines }}</li>
+{{- if .GradeCounts }}
+<li>Grades: {{ range $i, $gc := .GradeCounts }}{{ if $i }}, {{ end }}{{ $gc.Grade }} × {{ $gc.Count }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- if .WorstOffenders }}
+<h2>Worst offenders</h2>
+<table>
+<tr><th>Project</th><th>Alerts</th><th>Grades</th></tr>
+{{- range .WorstOffenders }}
+<tr><td><a href="{{ .URL }}">{{ .Slug }}</a></td><td class="num">{{ .Alerts }}</td><td>{{ .Grades }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- range .Languages }}
+<h2>{{ .Lang }} ({{ .Alerts }} alerts)</h2>
+<table>
+<tr><th>Project</th><th>Grade</th><th>Alerts</th><th>Lines</th></tr>
+{{- range .Rows }}
+<tr><td><a href="{{ .URL }}">{{ .Slug }}</a></td><td>{{ or .Grade "-" }}</td><td class="num">{{ .Alerts }}</td><td class="num">{{ .Lines }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Missing }}
+<h2>Repos without stats</h2>
+<table>
+<tr><th>Repo</th><th>Reason</th></tr>
+{{- range .Missing }}
+<tr><td>{{ .URL }}</td><td>{{ .Reason }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`
+
+// markdownCellReplacer escapes the pipes, which would end the cell,
+// and replaces the newlines, which would end the row.
+var markdownCellReplacer = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// markdownCell escapes the text of a Markdown table cell.
+func markdownCell(text string) string {
+	return markdownCellReplacer.Replace(text)
+}
+
+// reportCardFormat returns the format of the report card (md or html):
+// the provided one, or the one of the extension of the output file.
+func reportCardFormat(format string, output string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".html", ".htm":
+		return "html"
+	}
+	return "md"
+}
+
+// Render renders the report card in the provided format (md or html).
+func (card *ReportCard) Render(format string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	switch format {
+	case "html":
+		tmpl, err := htmltemplate.New("report-card").Parse(reportCardHTMLTemplate)
+		if err != nil {
+			return nil, err
+		}
+		err = tmpl.Execute(buf, card)
+		return buf.Bytes(), err
+	case "md", "markdown":
+		tmpl, err := template.New("report-card").
+			Funcs(template.FuncMap{"cell": markdownCell}).
+			Parse(reportCardMarkdownTemplate)
+		if err != nil {
+			return nil, err
+		}
+		err = tmpl.Execute(buf, card)
+		return buf.Bytes(), err
+	}
+	return nil, fmt.Errorf("unknown report card format %q (must be md or html)", format)
+}
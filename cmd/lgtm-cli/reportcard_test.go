@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownCell(t *testing.T) {
+	tests := map[string]string{
+		"g/foo/bar":                      "g/foo/bar",
+		"go:A | python:C":                `go:A \| python:C`,
+		"not built:\nerror 500\r\nretry": "not built: error 500 retry",
+	}
+	for text, want := range tests {
+		if got := markdownCell(text); got != want {
+			t.Errorf("markdownCell(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestRenderReportCardMarkdownEscapesCells(t *testing.T) {
+	card := &ReportCard{
+		Owner: "foo",
+		Missing: []*ReportCardRepo{
+			{URL: "https://github.com/foo/bar", Reason: "error | status 502:\nbad gateway"},
+		},
+	}
+	out, err := card.Render("md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `| https://github.com/foo/bar | error \| status 502: bad gateway |`
+	if !strings.Contains(string(out), want+"\n") {
+		t.Errorf("the report card does not contain %q:\n%s", want, out)
+	}
+}
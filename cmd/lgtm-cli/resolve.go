@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"golang.org/x/sync/semaphore"
+)
+
+// defaultResolveConcurrency is the default max number
+// of concurrent lookups of repos on lgtm.com.
+const defaultResolveConcurrency = 8
+
+// completeRepoURLs returns the URLs of the provided repos, skipping
+// globs and whole owners (that cannot be looked up by slug).
+func completeRepoURLs(repoURLs []string) []string {
+	res := make([]string, 0, len(repoURLs))
+	for _, repoURL := range repoURLs {
+		if urlparse.IsGlob(repoURL) {
+			// Skip because not a complete URL.
+			Infof("Skipping %s", repoURL)
+			continue
+		}
+		parsed, err := urlparse.Parse(repoURL, true)
+		if err != nil {
+			panic(err)
+		}
+		isWholeUser := parsed.Repo == ""
+		if isWholeUser {
+			// Skip because not a complete URL.
+			Infof("Skipping %s", repoURL)
+			continue
+		}
+		res = append(res, repoURL)
+	}
+	return res
+}
+
+// SlugResolution is the result of looking up a repo on lgtm.com.
+type SlugResolution struct {
+	URL     string        `json:"url"`
+	Project *lgtm.Project `json:"-"`
+	// Proto is set if the repo is a followed proto-project.
+	Proto *lgtm.ProtoProject `json:"-"`
+	// Followed is true if the repo was found among the followed projects.
+	Followed bool `json:"followed,omitempty"`
+	// NotBuilt is true if lgtm.com does not have a built project for the repo.
+	NotBuilt bool   `json:"notBuilt,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	err error
+}
+
+// Err returns the error that occurred while looking up the repo (if any).
+func (res *SlugResolution) Err() error {
+	return res.err
+}
+
+// Status returns a short description of the state of the repo on lgtm.com.
+func (res *SlugResolution) Status() string {
+	switch {
+	case res.Project != nil:
+		return "built"
+	case res.Proto != nil:
+		return "proto"
+	case res.NotBuilt:
+		return "not built"
+	default:
+		return "error"
+	}
+}
+
+// Key returns the key of the project or proto-project (if any).
+func (res *SlugResolution) Key() string {
+	switch {
+	case res.Project != nil:
+		return res.Project.Key
+	case res.Proto != nil:
+		return res.Proto.Key
+	default:
+		return ""
+	}
+}
+
+// resolveProjects looks up the repos among the followed projects
+// of the cache (if not nil) first, and then on lgtm.com
+// with at most maxWorkers concurrent requests;
+// the results are in the same order as the repo URLs.
+func resolveProjects(ctx context.Context, cl *lgtm.Client, cache *lgtm.FollowedProjectCache, repoURLs []string, maxWorkers int64) []*SlugResolution {
+	results := make([]*SlugResolution, len(repoURLs))
+	toResolve := repoURLs
+	if cache != nil {
+		followed, proto, unknown := cache.Lookup(repoURLs)
+		for i, repoURL := range repoURLs {
+			if pr, ok := followed[repoURL]; ok {
+				results[i] = &SlugResolution{URL: repoURL, Project: pr, Followed: true}
+			} else if pr, ok := proto[repoURL]; ok {
+				results[i] = &SlugResolution{URL: repoURL, Proto: pr, Followed: true}
+			}
+		}
+		// NOTE: Even if it is not a followed project, it still could be a built project.
+		toResolve = unknown
+	}
+	if len(toResolve) == 0 {
+		return results
+	}
+
+	took := NewTimer()
+	Infof("Looking up %v repos on lgtm.com...", len(toResolve))
+	resolved := resolveProjectsBySlug(ctx, cl, toResolve, maxWorkers)
+	Infof("took %s", took())
+
+	// The unknown repos are in the same order as in repoURLs:
+	next := 0
+	for i := range results {
+		if results[i] == nil {
+			results[i] = resolved[next]
+			next++
+		}
+	}
+	return results
+}
+
+// resolveProjectsBySlug looks up the repos on lgtm.com: the ones whose key
+// is in the key cache in bulk, and the others by slug
+// with at most maxWorkers concurrent requests;
+// the results are in the same order as the repo URLs.
+func resolveProjectsBySlug(ctx context.Context, cl *lgtm.Client, repoURLs []string, maxWorkers int64) []*SlugResolution {
+	results := make([]*SlugResolution, len(repoURLs))
+	slugs := make([]string, len(repoURLs))
+	for i, repoURL := range repoURLs {
+		results[i] = &SlugResolution{URL: repoURL}
+		parsed, err := urlparse.Parse(repoURL, true)
+		if err != nil {
+			results[i].err = err
+			results[i].Error = err.Error()
+			continue
+		}
+		slugs[i], err = parsed.Slug()
+		if err != nil {
+			results[i].err = err
+			results[i].Error = err.Error()
+		}
+	}
+	cached := getProjectsByCachedKeys(ctx, cl, slugs)
+
+	wg := &sync.WaitGroup{}
+	sem := semaphore.NewWeighted(maxWorkers)
+	for i, res := range results {
+		if res.err != nil {
+			continue
+		}
+		if pr, ok := cached[slugs[i]]; ok {
+			res.Project = pr
+			continue
+		}
+		if err := ctx.Err(); err != nil || sem.Acquire(ctx, 1) != nil {
+			res.err = ctx.Err()
+			res.Error = "interrupted"
+			continue
+		}
+		wg.Add(1)
+
+		go func(res *SlugResolution, slug string) {
+			defer wg.Done()
+			defer sem.Release(1)
+
+			pr, err := getProjectBySlug(cl, slug)
+			if err != nil {
+				if lgtm.IsNotFound(err) {
+					res.NotBuilt = true
+					return
+				}
+				metrics.Inc("errors_total", "op", "get-project")
+				res.err = err
+				res.Error = err.Error()
+				return
+			}
+			res.Project = pr
+		}(res, slugs[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// ResolvedProject is the output of the resolve command for a repo.
+type ResolvedProject struct {
+	URL       string   `json:"url"`
+	Key       string   `json:"key,omitempty"`
+	Languages []string `json:"languages"`
+	Status    string   `json:"status"`
+	Followed  bool     `json:"followed"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func newResolvedProject(res *SlugResolution) *ResolvedProject {
+	out := &ResolvedProject{
+		URL:       res.URL,
+		Key:       res.Key(),
+		Languages: make([]string, 0),
+		Status:    res.Status(),
+		Followed:  res.Followed,
+		Error:     res.Error,
+	}
+	if res.Project != nil {
+		out.Languages = res.Project.Languages
+	}
+	return out
+}
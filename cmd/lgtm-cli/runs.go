@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// RunRefPrefix is the prefix of the references to saved query runs
+// by index (e.g. @1 is the most recent run).
+const RunRefPrefix = "@"
+
+// DefaultRunsFilepath returns the default filepath of the saved query runs.
+func DefaultRunsFilepath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "lgtm-cli", "runs.json")
+}
+
+// SavedQueryRun contains the metadata of a query run.
+type SavedQueryRun struct {
+	Key        string    `json:"key"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Lang       string    `json:"lang"`
+	Query      string    `json:"query"`
+	QueryHash  string    `json:"queryHash"`
+	Targets    []string  `json:"targets,omitempty"`
+	Projects   int       `json:"projects"`
+	ListKeys   []string  `json:"listKeys,omitempty"`
+	ResultLink string    `json:"resultLink"`
+}
+
+// RunRegistry contains the saved query runs, oldest first.
+type RunRegistry struct {
+	path string
+	Runs []*SavedQueryRun `json:"runs"`
+}
+
+// LoadRunRegistry loads the saved query runs from the provided file;
+// if the file does not exist, an empty registry is returned.
+func LoadRunRegistry(path string) (*RunRegistry, error) {
+	reg := &RunRegistry{
+		path: path,
+		Runs: make([]*SavedQueryRun, 0),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return reg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, reg); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	return reg, nil
+}
+
+// Save writes the runs to the file they were loaded from.
+func (reg *RunRegistry) Save() error {
+	js, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(reg.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(reg.path, js, 0600)
+}
+
+// Recent returns the runs, most recent first.
+func (reg *RunRegistry) Recent() []*SavedQueryRun {
+	res := make([]*SavedQueryRun, 0, len(reg.Runs))
+	for i := len(reg.Runs) - 1; i >= 0; i-- {
+		res = append(res, reg.Runs[i])
+	}
+	return res
+}
+
+// ByIndex returns the run with the provided one-indexed position
+// among the most recent runs (1 is the most recent run).
+func (reg *RunRegistry) ByIndex(index int) (*SavedQueryRun, error) {
+	if index < 1 || index > len(reg.Runs) {
+		return nil, fmt.Errorf("run %s%v not found (%v runs saved)", RunRefPrefix, index, len(reg.Runs))
+	}
+	return reg.Runs[len(reg.Runs)-index], nil
+}
+
+// newSavedQueryRun returns the metadata of the query run.
+func newSavedQueryRun(run *lgtm.QueryResponseData, query string, queryString string, targets []string) *SavedQueryRun {
+	return &SavedQueryRun{
+		Key:        run.Key,
+		CreatedAt:  time.Now(),
+		Lang:       run.LanguageKey,
+		Query:      query,
+		QueryHash:  sha256Hex([]byte(queryString)),
+		Targets:    targets,
+		Projects:   len(run.ProjectKeys),
+		ListKeys:   run.ProjectSelectionKeys,
+		ResultLink: run.GetResultLink(),
+	}
+}
+
+// saveQueryRuns adds the runs to the registry file.
+func saveQueryRuns(path string, runs ...*SavedQueryRun) error {
+	reg, err := LoadRunRegistry(path)
+	if err != nil {
+		return err
+	}
+	reg.Runs = append(reg.Runs, runs...)
+	return reg.Save()
+}
+
+// resolveQueryRunRef returns the key of the query run; references
+// to saved runs by index (e.g. @1 for the most recent run) are resolved
+// using the registry file, and other values are returned as-is.
+func resolveQueryRunRef(path string, ref string) (string, error) {
+	if !strings.HasPrefix(ref, RunRefPrefix) {
+		return ref, nil
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(ref, RunRefPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid run reference %q", ref)
+	}
+	reg, err := LoadRunRegistry(path)
+	if err != nil {
+		return "", err
+	}
+	run, err := reg.ByIndex(index)
+	if err != nil {
+		return "", err
+	}
+	return run.Key, nil
+}
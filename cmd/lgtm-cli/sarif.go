@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	"github.com/gagliardetto/lgtm-cli/pkg/githubutil"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// SARIFExport is the SARIF log of the alerts of the latest analysis
+// of a project for a language.
+type SARIFExport struct {
+	Project  *lgtm.Project
+	Lang     string
+	CommitID string
+	SARIF    []byte
+}
+
+// getProjectSARIF gets the alerts of the latest analysis
+// of the project for the language, as a SARIF 2.1.0 log
+// (the alerts of the other languages of the analysis are removed).
+func getProjectSARIF(cl *lgtm.Client, pr *lgtm.Project, lang string) (*SARIFExport, error) {
+	stats, err := cl.GetProjectLatestStateStats(pr.Key)
+	if err != nil {
+		return nil, err
+	}
+	for _, state := range stats.LanguageStates {
+		if state.Lang == lang {
+			return getCommitSARIF(cl, pr, lang, state.RevisionName.Value)
+		}
+	}
+	return nil, errors.New("no analyzed commit found")
+}
+
+// getCommitSARIF gets the alerts of the analysis of the commit
+// of the project for the language, as a SARIF 2.1.0 log
+// (the alerts of the other languages of the analysis are removed).
+func getCommitSARIF(cl *lgtm.Client, pr *lgtm.Project, lang string, commitID string) (*SARIFExport, error) {
+	if commitID == "" {
+		return nil, errors.New("no analyzed commit found")
+	}
+	analysis, err := cl.GetAnalysisForCommit(pr.Key, commitID)
+	if err != nil {
+		return nil, err
+	}
+	sarif, err := cl.GetAnalysisSARIF(analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+	sarif, err = filterSARIFByLanguage(sarif, lang)
+	if err != nil {
+		return nil, err
+	}
+	return &SARIFExport{
+		Project:  pr,
+		Lang:     lang,
+		CommitID: commitID,
+		SARIF:    sarif,
+	}, nil
+}
+
+// sarifRuleLanguages maps the prefixes of the rule IDs
+// (e.g. js in js/xss) to the languages.
+var sarifRuleLanguages = map[string]string{
+	"cpp":  lgtm.LangCPP,
+	"cs":   lgtm.LangCSharp,
+	"go":   lgtm.LangGo,
+	"java": lgtm.LangJava,
+	"js":   lgtm.LangJavaScript,
+	"py":   lgtm.LangPython,
+}
+
+// sarifRuleLanguage returns the language of the rule ID
+// (e.g. js/xss, or com.lgtm/javascript-queries:js/xss);
+// it returns an empty string if the language is unknown.
+func sarifRuleLanguage(ruleID string) string {
+	if i := strings.LastIndex(ruleID, ":"); i >= 0 {
+		ruleID = ruleID[i+1:]
+	}
+	i := strings.Index(ruleID, "/")
+	if i < 0 {
+		return ""
+	}
+	return sarifRuleLanguages[ruleID[:i]]
+}
+
+// filterSARIFByLanguage removes from the SARIF log of an analysis
+// (which contains the alerts of all the languages of the commit)
+// the results whose rule is not of the language, and sets the automation
+// ID of the runs to lgtm/<lang>/, so that the logs of the languages
+// of a commit can be uploaded to GitHub code scanning side by side.
+func filterSARIFByLanguage(sarif []byte, lang string) ([]byte, error) {
+	var log map[string]json.RawMessage
+	if err := json.Unmarshal(sarif, &log); err != nil {
+		return nil, fmt.Errorf("error while parsing SARIF: %w", err)
+	}
+	rawRuns, ok := log["runs"]
+	if !ok {
+		return sarif, nil
+	}
+	var runs []map[string]json.RawMessage
+	if err := json.Unmarshal(rawRuns, &runs); err != nil {
+		return nil, fmt.Errorf("error while parsing SARIF runs: %w", err)
+	}
+	automationDetails, err := json.Marshal(map[string]string{"id": "lgtm/" + lang + "/"})
+	if err != nil {
+		return nil, err
+	}
+	var dropped int
+	for _, run := range runs {
+		var results []json.RawMessage
+		if raw, ok := run["results"]; ok {
+			if err := json.Unmarshal(raw, &results); err != nil {
+				return nil, fmt.Errorf("error while parsing SARIF results: %w", err)
+			}
+		}
+		kept := make([]json.RawMessage, 0, len(results))
+		for _, raw := range results {
+			var res struct {
+				RuleID string `json:"ruleId"`
+			}
+			if err := json.Unmarshal(raw, &res); err != nil {
+				return nil, fmt.Errorf("error while parsing SARIF result: %w", err)
+			}
+			switch sarifRuleLanguage(res.RuleID) {
+			case lang:
+				kept = append(kept, raw)
+			case "":
+				dropped++
+			}
+		}
+		if run["results"], err = json.Marshal(kept); err != nil {
+			return nil, err
+		}
+		if _, ok := run["automationDetails"]; !ok {
+			run["automationDetails"] = automationDetails
+		}
+	}
+	if dropped > 0 {
+		Debugf("Dropped %v SARIF results whose rule has no known language", dropped)
+	}
+	if log["runs"], err = json.Marshal(runs); err != nil {
+		return nil, err
+	}
+	return json.Marshal(log)
+}
+
+// Filename returns the name of the SARIF file of the export
+// (e.g. g_kubernetes_kubernetes.go.sarif).
+func (exp *SARIFExport) Filename() string {
+	slug := strings.NewReplacer("/", "_", ":", "_").Replace(exp.Project.Slug)
+	return Sf("%s.%s.sarif", slug, exp.Lang)
+}
+
+// WriteToDir writes the SARIF log to its file in the provided directory,
+// and returns the filepath.
+func (exp *SARIFExport) WriteToDir(dir string) (string, error) {
+	path := filepath.Join(dir, exp.Filename())
+	return path, ioutil.WriteFile(path, exp.SARIF, 0644)
+}
+
+// UploadToGitHub uploads the SARIF log to the code scanning
+// of the GitHub repo of the project (for its default branch).
+func (exp *SARIFExport) UploadToGitHub() (string, error) {
+	parsed, err := urlparse.Parse(exp.Project.ExternalURL.URL, true)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Hostname != "github.com" {
+		return "", errors.New("not a GitHub repo")
+	}
+	repo, err := githubutil.GetRepo(ghRawClient, parsed.User, parsed.Repo)
+	if err != nil {
+		return "", err
+	}
+	if repo == nil {
+		return "", errors.New("repo not found")
+	}
+	ref := "refs/heads/" + repo.GetDefaultBranch()
+	return githubutil.UploadSARIF(ghRawClient, parsed.User, parsed.Repo, exp.CommitID, ref, exp.SARIF)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testAnalysisSARIF = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "LGTM.com"}},
+    "results": [
+      {"ruleId": "com.lgtm/javascript-queries:js/xss", "message": {"text": "XSS"}},
+      {"ruleId": "com.lgtm/python-queries:py/sql-injection", "message": {"text": "SQL injection"}},
+      {"ruleId": "js/unused-local-variable", "message": {"text": "Unused variable"}},
+      {"ruleId": "java/path-injection", "message": {"text": "Path injection"}},
+      {"ruleId": "custom-rule", "message": {"text": "No language"}}
+    ]
+  }]
+}`
+
+func TestSARIFRuleLanguage(t *testing.T) {
+	tests := map[string]string{
+		"js/xss":                                   "javascript",
+		"com.lgtm/javascript-queries:js/xss":       "javascript",
+		"java/path-injection":                      "java",
+		"com.lgtm/cpp-queries:cpp/overflow":        "cpp",
+		"cs/sql-injection":                         "csharp",
+		"go/incomplete-hostname-regexp":            "go",
+		"com.lgtm/python-queries:py/unused-import": "python",
+		"custom-rule":                              "",
+		"rb/unknown-language":                      "",
+	}
+	for ruleID, want := range tests {
+		if got := sarifRuleLanguage(ruleID); got != want {
+			t.Errorf("sarifRuleLanguage(%q) = %q, want %q", ruleID, got, want)
+		}
+	}
+}
+
+func TestFilterSARIFByLanguage(t *testing.T) {
+	filtered, err := filterSARIFByLanguage([]byte(testAnalysisSARIF), "javascript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			AutomationDetails struct {
+				ID string `json:"id"`
+			} `json:"automationDetails"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(filtered, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "LGTM.com" {
+		t.Fatalf("the rest of the log was not kept: %s", filtered)
+	}
+	if got := log.Runs[0].AutomationDetails.ID; got != "lgtm/javascript/" {
+		t.Errorf("automationDetails.id = %q, want %q", got, "lgtm/javascript/")
+	}
+	var ruleIDs []string
+	for _, res := range log.Runs[0].Results {
+		ruleIDs = append(ruleIDs, res.RuleID)
+	}
+	want := []string{"com.lgtm/javascript-queries:js/xss", "js/unused-local-variable"}
+	if len(ruleIDs) != len(want) || ruleIDs[0] != want[0] || ruleIDs[1] != want[1] {
+		t.Errorf("results = %v, want %v", ruleIDs, want)
+	}
+}
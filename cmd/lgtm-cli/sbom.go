@@ -0,0 +1,572 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	. "github.com/gagliardetto/utilz"
+	"golang.org/x/sync/semaphore"
+)
+
+// SBOMPackage is a package referenced by an SBOM.
+type SBOMPackage struct {
+	Name string `json:"name"`
+	// PURL is the package URL (e.g. pkg:npm/lodash@4.17.21), if any.
+	PURL string `json:"purl,omitempty"`
+	// Locations are the URLs of the package that can point to its repo
+	// (VCS references, download locations, homepages), most specific first.
+	Locations []string `json:"locations,omitempty"`
+}
+
+// ID returns the purl of the package, or else its name.
+func (pkg *SBOMPackage) ID() string {
+	if pkg.PURL != "" {
+		return pkg.PURL
+	}
+	return pkg.Name
+}
+
+// parseSBOM parses an SPDX (json or tag-value) or CycloneDX (json or xml) SBOM,
+// and returns the packages it references, and the name of its format.
+func parseSBOM(content []byte) ([]*SBOMPackage, string, error) {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			SPDXVersion string `json:"spdxVersion"`
+			BOMFormat   string `json:"bomFormat"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, "", fmt.Errorf("invalid json SBOM: %w", err)
+		}
+		switch {
+		case probe.SPDXVersion != "":
+			packages, err := parseSPDXJSON(trimmed)
+			return packages, "SPDX (json)", err
+		case strings.EqualFold(probe.BOMFormat, "CycloneDX"):
+			packages, err := parseCycloneDXJSON(trimmed)
+			return packages, "CycloneDX (json)", err
+		}
+		return nil, "", errors.New("unknown json SBOM format (neither SPDX nor CycloneDX)")
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		packages, err := parseCycloneDXXML(trimmed)
+		return packages, "CycloneDX (xml)", err
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		packages, err := parseSPDXTagValue(trimmed)
+		return packages, "SPDX (tag-value)", err
+	}
+	return nil, "", errors.New("unknown SBOM format: must be SPDX (json or tag-value) or CycloneDX (json or xml)")
+}
+
+// parseSPDXJSON parses an SPDX json document; the packages
+// the document describes (i.e. the product itself) are excluded.
+func parseSPDXJSON(content []byte) ([]*SBOMPackage, error) {
+	var doc struct {
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID           string `json:"SPDXID"`
+			Name             string `json:"name"`
+			DownloadLocation string `json:"downloadLocation"`
+			Homepage         string `json:"homepage"`
+			ExternalRefs     []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %w", err)
+	}
+	packages := make([]*SBOMPackage, 0)
+	for _, spdxPkg := range doc.Packages {
+		if SliceContains(doc.DocumentDescribes, spdxPkg.SPDXID) {
+			continue
+		}
+		pkg := &SBOMPackage{
+			Name:      spdxPkg.Name,
+			Locations: []string{spdxPkg.DownloadLocation, spdxPkg.Homepage},
+		}
+		for _, ref := range spdxPkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				pkg.PURL = ref.ReferenceLocator
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// parseSPDXTagValue parses an SPDX tag-value document.
+func parseSPDXTagValue(content []byte) ([]*SBOMPackage, error) {
+	packages := make([]*SBOMPackage, 0)
+	var pkg *SBOMPackage
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		tag, value, ok := splitSPDXTag(scanner.Text())
+		if !ok {
+			continue
+		}
+		if tag == "PackageName" {
+			pkg = &SBOMPackage{Name: value}
+			packages = append(packages, pkg)
+			continue
+		}
+		if pkg == nil {
+			continue
+		}
+		switch tag {
+		case "PackageDownloadLocation", "PackageHomePage":
+			pkg.Locations = append(pkg.Locations, value)
+		case "ExternalRef":
+			// e.g. "PACKAGE-MANAGER purl pkg:npm/lodash@4.17.21"
+			if fields := strings.Fields(value); len(fields) == 3 && fields[1] == "purl" {
+				pkg.PURL = fields[2]
+			}
+		}
+	}
+	return packages, scanner.Err()
+}
+
+func splitSPDXTag(line string) (string, string, bool) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), true
+}
+
+type cycloneDXComponent struct {
+	Name               string `json:"name"`
+	PURL               string `json:"purl"`
+	ExternalReferences []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"externalReferences"`
+	Components []*cycloneDXComponent `json:"components"`
+}
+
+// parseCycloneDXJSON parses a CycloneDX json BOM
+// (including the nested components).
+func parseCycloneDXJSON(content []byte) ([]*SBOMPackage, error) {
+	var bom struct {
+		Components []*cycloneDXComponent `json:"components"`
+	}
+	if err := json.Unmarshal(content, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX BOM: %w", err)
+	}
+	packages := make([]*SBOMPackage, 0)
+	var walk func(components []*cycloneDXComponent)
+	walk = func(components []*cycloneDXComponent) {
+		for _, component := range components {
+			pkg := &SBOMPackage{
+				Name: component.Name,
+				PURL: component.PURL,
+			}
+			for _, ref := range component.ExternalReferences {
+				pkg.addCycloneDXReference(ref.Type, ref.URL)
+			}
+			packages = append(packages, pkg)
+			walk(component.Components)
+		}
+	}
+	walk(bom.Components)
+	return packages, nil
+}
+
+type cycloneDXXMLComponent struct {
+	Name               string `xml:"name"`
+	PURL               string `xml:"purl"`
+	ExternalReferences []struct {
+		Type string `xml:"type,attr"`
+		URL  string `xml:"url"`
+	} `xml:"externalReferences>reference"`
+	Components []*cycloneDXXMLComponent `xml:"components>component"`
+}
+
+// parseCycloneDXXML parses a CycloneDX xml BOM
+// (including the nested components).
+func parseCycloneDXXML(content []byte) ([]*SBOMPackage, error) {
+	var bom struct {
+		XMLName    xml.Name                 `xml:"bom"`
+		Components []*cycloneDXXMLComponent `xml:"components>component"`
+	}
+	if err := xml.Unmarshal(content, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX BOM: %w", err)
+	}
+	packages := make([]*SBOMPackage, 0)
+	var walk func(components []*cycloneDXXMLComponent)
+	walk = func(components []*cycloneDXXMLComponent) {
+		for _, component := range components {
+			pkg := &SBOMPackage{
+				Name: strings.TrimSpace(component.Name),
+				PURL: strings.TrimSpace(component.PURL),
+			}
+			for _, ref := range component.ExternalReferences {
+				pkg.addCycloneDXReference(ref.Type, strings.TrimSpace(ref.URL))
+			}
+			packages = append(packages, pkg)
+			walk(component.Components)
+		}
+	}
+	walk(bom.Components)
+	return packages, nil
+}
+
+// addCycloneDXReference adds the URL of an external reference
+// to the locations: VCS references first, then websites.
+func (pkg *SBOMPackage) addCycloneDXReference(typ string, refURL string) {
+	switch typ {
+	case "vcs":
+		pkg.Locations = append([]string{refURL}, pkg.Locations...)
+	case "website", "distribution":
+		pkg.Locations = append(pkg.Locations, refURL)
+	}
+}
+
+// sbomRepoHosts are the hosts of the repos that can be followed on lgtm.com.
+var sbomRepoHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// sbomReservedOwners are, by host, the first path elements of the pages
+// that are not owners of repos (e.g. https://github.com/sponsors/foo,
+// a common homepage).
+var sbomReservedOwners = map[string]map[string]bool{
+	"github.com": githubReservedOwners,
+	"gitlab.com": {
+		"-":         true,
+		"dashboard": true,
+		"explore":   true,
+		"groups":    true,
+		"help":      true,
+		"users":     true,
+	},
+	"bitbucket.org": {
+		"account":   true,
+		"dashboard": true,
+		"product":   true,
+	},
+}
+
+// repoURLFromVCSURL returns the URL of the repo of a VCS URL, download location or homepage
+// (e.g. git+https://github.com/foo/bar.git@v1.0.0, git@github.com:foo/bar.git,
+// https://github.com/foo/bar/tree/main/pkg, github:foo/bar),
+// if it is on a host that lgtm.com supports.
+func repoURLFromVCSURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	switch strings.ToUpper(raw) {
+	case "", "NOASSERTION", "NONE":
+		return "", false
+	}
+	// Maven SCM URLs (e.g. scm:git:git@github.com:foo/bar.git):
+	raw = strings.TrimPrefix(raw, "scm:")
+	if strings.HasPrefix(raw, "git:") && !strings.HasPrefix(raw, "git://") {
+		raw = strings.TrimPrefix(raw, "git:")
+	}
+	raw = strings.TrimPrefix(raw, "git+")
+	if strings.HasPrefix(raw, "github:") {
+		// npm shorthand:
+		raw = "https://github.com/" + strings.TrimPrefix(raw, "github:")
+	}
+	if strings.HasPrefix(raw, "git@") {
+		// scp-like syntax (git@github.com:foo/bar.git):
+		raw = "https://" + strings.Replace(strings.TrimPrefix(raw, "git@"), ":", "/", 1)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	host := strings.TrimPrefix(ToLower(parsed.Hostname()), "www.")
+	if !SliceContains(sbomRepoHosts, host) {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 2 {
+		return "", false
+	}
+	owner := segments[0]
+	repo := segments[1]
+	if sbomReservedOwners[host][ToLower(owner)] {
+		return "", false
+	}
+	// Remove the revision (e.g. bar.git@v1.0.0):
+	if i := strings.Index(repo, "@"); i > -1 {
+		repo = repo[:i]
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	if owner == "" || repo == "" {
+		return "", false
+	}
+	return "https://" + host + "/" + owner + "/" + repo, true
+}
+
+// PackageURL is a parsed package URL (purl),
+// e.g. pkg:npm/%40angular/core@12.0.0.
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Qualifiers url.Values
+}
+
+// parsePackageURL parses a package URL (the version and subpath are discarded).
+func parsePackageURL(raw string) (*PackageURL, error) {
+	rest := strings.TrimSpace(raw)
+	if !strings.HasPrefix(rest, "pkg:") {
+		return nil, fmt.Errorf("invalid purl %q: must start with pkg:", raw)
+	}
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "pkg:"), "/")
+	if i := strings.Index(rest, "#"); i > -1 {
+		rest = rest[:i]
+	}
+	purl := &PackageURL{}
+	if i := strings.Index(rest, "?"); i > -1 {
+		qualifiers, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid purl %q: %w", raw, err)
+		}
+		purl.Qualifiers = qualifiers
+		rest = rest[:i]
+	}
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("invalid purl %q: missing name", raw)
+	}
+	purl.Type = ToLower(segments[0])
+	name := segments[len(segments)-1]
+	// Remove the version:
+	if i := strings.Index(name, "@"); i > 0 {
+		name = name[:i]
+	}
+	unescaped := make([]string, 0, len(segments)-1)
+	for _, segment := range append(segments[1:len(segments)-1], name) {
+		value, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid purl %q: %w", raw, err)
+		}
+		unescaped = append(unescaped, value)
+	}
+	purl.Name = unescaped[len(unescaped)-1]
+	purl.Namespace = strings.Join(unescaped[:len(unescaped)-1], "/")
+	return purl, nil
+}
+
+// FullName returns the namespace and name (e.g. @angular/core).
+func (purl *PackageURL) FullName() string {
+	if purl.Namespace == "" {
+		return purl.Name
+	}
+	return purl.Namespace + "/" + purl.Name
+}
+
+// errSBOMPackageNotFound is returned when the package
+// does not exist in its registry.
+var errSBOMPackageNotFound = errors.New("package not found in its registry")
+
+// SBOMRepoResolver resolves the packages of an SBOM to the URLs of their repos:
+// from their VCS references, download locations and homepages, from the purl
+// (for github, gitlab, bitbucket and golang packages), or else from the metadata
+// of their registry (npm, pypi, cargo).
+type SBOMRepoResolver struct {
+	goResolver *GoRepoResolver
+}
+
+func NewSBOMRepoResolver() *SBOMRepoResolver {
+	return &SBOMRepoResolver{
+		goResolver: NewGoRepoResolver(),
+	}
+}
+
+// Resolve returns the URL of the repo of the package.
+func (res *SBOMRepoResolver) Resolve(pkg *SBOMPackage) (string, error) {
+	var purl *PackageURL
+	locations := pkg.Locations
+	if pkg.PURL != "" {
+		var err error
+		purl, err = parsePackageURL(pkg.PURL)
+		if err != nil {
+			return "", err
+		}
+		if vcsURL := purl.Qualifiers.Get("vcs_url"); vcsURL != "" {
+			locations = append([]string{vcsURL}, locations...)
+		}
+	}
+	for _, location := range locations {
+		if repoURL, ok := repoURLFromVCSURL(location); ok {
+			return repoURL, nil
+		}
+	}
+	if purl == nil {
+		return "", errors.New("no purl, and no repo URL among its locations")
+	}
+
+	switch purl.Type {
+	case "github", "gitlab", "bitbucket":
+		host := map[string]string{"github": "github.com", "gitlab": "gitlab.com", "bitbucket": "bitbucket.org"}[purl.Type]
+		parsed, err := urlparse.Parse(host+"/"+purl.FullName(), true)
+		if err != nil {
+			return "", err
+		}
+		return parsed.URL(), nil
+	case "golang":
+		return res.goResolver.Resolve(purl.FullName())
+	case "npm":
+		return resolveNpmRepo(purl.FullName())
+	case "pypi":
+		return resolvePypiRepo(purl.Name)
+	case "cargo":
+		return resolveCargoRepo(purl.Name)
+	}
+	return "", fmt.Errorf("unsupported package type %q", purl.Type)
+}
+
+// getRegistryJSON gets the json metadata of a package from its registry.
+func getRegistryJSON(metaURL string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, metaURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	// crates.io rejects requests without a user-agent:
+	req.Header.Set("User-Agent", "lgtm-cli (https://github.com/gagliardetto/lgtm-cli)")
+	resp, err := webHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errSBOMPackageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error while getting %s: status %s", metaURL, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func resolveNpmRepo(name string) (string, error) {
+	var meta struct {
+		Repository json.RawMessage `json:"repository"`
+		Homepage   string          `json:"homepage"`
+	}
+	// Scoped packages: @scope/name -> @scope%2fname
+	err := getRegistryJSON("https://registry.npmjs.org/"+strings.Replace(name, "/", "%2f", 1), &meta)
+	if err != nil {
+		return "", err
+	}
+	// The repository is either a string, or an object with a url:
+	var repository struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(meta.Repository, &repository); err != nil {
+		json.Unmarshal(meta.Repository, &repository.URL)
+	}
+	for _, location := range []string{repository.URL, meta.Homepage} {
+		if repoURL, ok := repoURLFromVCSURL(location); ok {
+			return repoURL, nil
+		}
+	}
+	return "", errors.New("the npm package has no repo URL")
+}
+
+// pypiRepoURLKeys are the keys of the project_urls of a pypi package
+// that usually point to its repo, most likely first.
+var pypiRepoURLKeys = []string{"Source", "Source Code", "Repository", "Code", "GitHub", "Homepage"}
+
+func resolvePypiRepo(name string) (string, error) {
+	var meta struct {
+		Info struct {
+			HomePage    string            `json:"home_page"`
+			ProjectURLs map[string]string `json:"project_urls"`
+		} `json:"info"`
+	}
+	if err := getRegistryJSON("https://pypi.org/pypi/"+url.PathEscape(name)+"/json", &meta); err != nil {
+		return "", err
+	}
+	locations := make([]string, 0)
+	for _, key := range pypiRepoURLKeys {
+		locations = append(locations, meta.Info.ProjectURLs[key])
+	}
+	locations = append(locations, meta.Info.HomePage)
+	for _, location := range meta.Info.ProjectURLs {
+		locations = append(locations, location)
+	}
+	for _, location := range locations {
+		if repoURL, ok := repoURLFromVCSURL(location); ok {
+			return repoURL, nil
+		}
+	}
+	return "", errors.New("the pypi package has no repo URL")
+}
+
+func resolveCargoRepo(name string) (string, error) {
+	var meta struct {
+		Crate struct {
+			Repository string `json:"repository"`
+			Homepage   string `json:"homepage"`
+		} `json:"crate"`
+	}
+	if err := getRegistryJSON("https://crates.io/api/v1/crates/"+url.PathEscape(name), &meta); err != nil {
+		return "", err
+	}
+	for _, location := range []string{meta.Crate.Repository, meta.Crate.Homepage} {
+		if repoURL, ok := repoURLFromVCSURL(location); ok {
+			return repoURL, nil
+		}
+	}
+	return "", errors.New("the crate has no repo URL")
+}
+
+// SBOMResolution is the repo of a package of an SBOM
+// (or the error while resolving it).
+type SBOMResolution struct {
+	Package *SBOMPackage `json:"package"`
+	RepoURL string       `json:"repoURL,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// resolveSBOMPackages resolves the packages to their repos,
+// with at most maxWorkers concurrent lookups; the resolutions
+// are in the same order as the packages.
+func resolveSBOMPackages(ctx context.Context, packages []*SBOMPackage, maxWorkers int64) []*SBOMResolution {
+	resolver := NewSBOMRepoResolver()
+	resolutions := make([]*SBOMResolution, len(packages))
+	wg := &sync.WaitGroup{}
+	sem := semaphore.NewWeighted(maxWorkers)
+	for i, pkg := range packages {
+		resolution := &SBOMResolution{Package: pkg}
+		resolutions[i] = resolution
+		if sem.Acquire(ctx, 1) != nil {
+			resolution.Error = "interrupted"
+			continue
+		}
+		wg.Add(1)
+
+		go func(resolution *SBOMResolution) {
+			defer wg.Done()
+			defer sem.Release(1)
+
+			repoURL, err := resolver.Resolve(resolution.Package)
+			if err != nil {
+				resolution.Error = err.Error()
+				Debugf("Could not resolve the repo of %s: %s", resolution.Package.ID(), err)
+				return
+			}
+			Debugf("%s is in %s", resolution.Package.ID(), repoURL)
+			resolution.RepoURL = repoURL
+		}(resolution)
+	}
+	wg.Wait()
+	return resolutions
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSBOM(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantFormat   string
+		wantPackages []*SBOMPackage
+	}{
+		{
+			name: "SPDX json",
+			content: `{
+  "spdxVersion": "SPDX-2.2",
+  "documentDescribes": ["SPDXRef-Product"],
+  "packages": [
+    {"SPDXID": "SPDXRef-Product", "name": "product", "downloadLocation": "https://github.com/acme/product"},
+    {
+      "SPDXID": "SPDXRef-lodash",
+      "name": "lodash",
+      "downloadLocation": "NOASSERTION",
+      "homepage": "https://lodash.com/",
+      "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:npm/lodash@4.17.21"}]
+    }
+  ]
+}`,
+			wantFormat: "SPDX (json)",
+			wantPackages: []*SBOMPackage{
+				{Name: "lodash", PURL: "pkg:npm/lodash@4.17.21", Locations: []string{"NOASSERTION", "https://lodash.com/"}},
+			},
+		},
+		{
+			name: "SPDX tag-value",
+			content: `SPDXVersion: SPDX-2.2
+DocumentName: product
+
+PackageName: zap
+PackageDownloadLocation: git+https://github.com/uber-go/zap.git@v1.19.0
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/go.uber.org/zap@v1.19.0
+
+PackageName: requests
+PackageHomePage: https://requests.readthedocs.io
+ExternalRef: PACKAGE-MANAGER purl pkg:pypi/requests@2.26.0
+`,
+			wantFormat: "SPDX (tag-value)",
+			wantPackages: []*SBOMPackage{
+				{Name: "zap", PURL: "pkg:golang/go.uber.org/zap@v1.19.0", Locations: []string{"git+https://github.com/uber-go/zap.git@v1.19.0"}},
+				{Name: "requests", PURL: "pkg:pypi/requests@2.26.0", Locations: []string{"https://requests.readthedocs.io"}},
+			},
+		},
+		{
+			name: "CycloneDX json",
+			content: `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.3",
+  "components": [
+    {
+      "name": "serde",
+      "purl": "pkg:cargo/serde@1.0.130",
+      "externalReferences": [
+        {"type": "website", "url": "https://serde.rs"},
+        {"type": "vcs", "url": "https://github.com/serde-rs/serde"},
+        {"type": "issue-tracker", "url": "https://github.com/serde-rs/serde/issues"}
+      ],
+      "components": [{"name": "serde_derive", "purl": "pkg:cargo/serde_derive@1.0.130"}]
+    }
+  ]
+}`,
+			wantFormat: "CycloneDX (json)",
+			wantPackages: []*SBOMPackage{
+				{Name: "serde", PURL: "pkg:cargo/serde@1.0.130", Locations: []string{"https://github.com/serde-rs/serde", "https://serde.rs"}},
+				{Name: "serde_derive", PURL: "pkg:cargo/serde_derive@1.0.130"},
+			},
+		},
+		{
+			name: "CycloneDX xml",
+			content: `<?xml version="1.0"?>
+<bom xmlns="http://cyclonedx.org/schema/bom/1.3">
+  <components>
+    <component type="library">
+      <name>guava</name>
+      <purl>pkg:maven/com.google.guava/guava@30.1-jre</purl>
+      <externalReferences>
+        <reference type="vcs"><url>scm:git:git@github.com:google/guava.git</url></reference>
+      </externalReferences>
+      <components>
+        <component type="library">
+          <name>failureaccess</name>
+          <purl>pkg:maven/com.google.guava/failureaccess@1.0.1</purl>
+        </component>
+      </components>
+    </component>
+  </components>
+</bom>`,
+			wantFormat: "CycloneDX (xml)",
+			wantPackages: []*SBOMPackage{
+				{Name: "guava", PURL: "pkg:maven/com.google.guava/guava@30.1-jre", Locations: []string{"scm:git:git@github.com:google/guava.git"}},
+				{Name: "failureaccess", PURL: "pkg:maven/com.google.guava/failureaccess@1.0.1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, format, err := parseSBOM([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(packages, tt.wantPackages) {
+				t.Errorf("got packages:\n%s\nwant:\n%s", packagesString(packages), packagesString(tt.wantPackages))
+			}
+		})
+	}
+
+	for _, invalid := range []string{``, `{"foo": "bar"}`, `{`, `name,version`} {
+		if _, _, err := parseSBOM([]byte(invalid)); err == nil {
+			t.Errorf("parseSBOM(%q) should fail", invalid)
+		}
+	}
+}
+
+func packagesString(packages []*SBOMPackage) string {
+	js, _ := json.Marshal(packages)
+	return string(js)
+}
+
+func TestRepoURLFromVCSURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/foo/bar":                   "https://github.com/foo/bar",
+		"git+https://github.com/foo/bar.git@v1.0.0":    "https://github.com/foo/bar",
+		"git@github.com:foo/bar.git":                   "https://github.com/foo/bar",
+		"scm:git:git@github.com:foo/bar.git":           "https://github.com/foo/bar",
+		"git://github.com/foo/bar.git":                 "https://github.com/foo/bar",
+		"github:foo/bar":                               "https://github.com/foo/bar",
+		"https://www.github.com/foo/bar/tree/main/pkg": "https://github.com/foo/bar",
+		"github.com/foo/bar#readme":                    "https://github.com/foo/bar",
+		"https://gitlab.com/foo/bar/-/tree/main":       "https://gitlab.com/foo/bar",
+		"https://bitbucket.org/foo/bar/src/master":     "https://bitbucket.org/foo/bar",
+		// Not repos:
+		"https://github.com/sponsors/foo":       "",
+		"https://github.com/orgs/foo/people":    "",
+		"https://github.com/topics/security":    "",
+		"https://github.com/foo":                "",
+		"https://gitlab.com/explore/projects":   "",
+		"https://bitbucket.org/product/pricing": "",
+		"https://foo.github.io/bar":             "",
+		"https://example.com/foo/bar":           "",
+		"NOASSERTION":                           "",
+		"":                                      "",
+	}
+	for raw, want := range tests {
+		got, ok := repoURLFromVCSURL(raw)
+		if got != want || ok != (want != "") {
+			t.Errorf("repoURLFromVCSURL(%q) = %q, %v; want %q", raw, got, ok, want)
+		}
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want *PackageURL
+	}{
+		{raw: "pkg:npm/lodash@4.17.21", want: &PackageURL{Type: "npm", Name: "lodash"}},
+		{raw: "pkg:npm/%40angular/core@12.0.0", want: &PackageURL{Type: "npm", Namespace: "@angular", Name: "core"}},
+		{raw: "pkg:golang/go.uber.org/zap@v1.19.0#subpath", want: &PackageURL{Type: "golang", Namespace: "go.uber.org", Name: "zap"}},
+		{raw: "pkg:GitHub/foo/bar", want: &PackageURL{Type: "github", Namespace: "foo", Name: "bar"}},
+	}
+	for _, tt := range tests {
+		got, err := parsePackageURL(tt.raw)
+		if err != nil {
+			t.Errorf("parsePackageURL(%q): %s", tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePackageURL(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+
+	purl, err := parsePackageURL("pkg:maven/org.foo/bar@1.0?vcs_url=git%2Bhttps://github.com/foo/bar.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := purl.Qualifiers.Get("vcs_url"); got != "git+https://github.com/foo/bar.git" {
+		t.Errorf("vcs_url = %q", got)
+	}
+
+	for _, invalid := range []string{"npm/lodash", "pkg:npm", "pkg:npm/%zz"} {
+		if _, err := parsePackageURL(invalid); err == nil {
+			t.Errorf("parsePackageURL(%q) should fail", invalid)
+		}
+	}
+}
+
+// testRegistryTransport serves the canned responses by URL
+// (without the query); the other URLs are not found.
+type testRegistryTransport map[string]string
+
+func (tr testRegistryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.RawQuery = ""
+	body, ok := tr[u.String()]
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusNotFound
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestSBOMRepoResolver(t *testing.T) {
+	previous := webHTTPClient
+	defer func() { webHTTPClient = previous }()
+	webHTTPClient = &http.Client{Transport: testRegistryTransport{
+		"https://registry.npmjs.org/lodash":          `{"repository": {"type": "git", "url": "git+https://github.com/lodash/lodash.git"}}`,
+		"https://registry.npmjs.org/@angular%2fcore": `{"repository": "github:angular/angular", "homepage": "https://github.com/angular/angular#readme"}`,
+		"https://registry.npmjs.org/funding":         `{"homepage": "https://github.com/sponsors/someone"}`,
+		"https://pypi.org/pypi/requests/json":        `{"info": {"home_page": "https://requests.readthedocs.io", "project_urls": {"Documentation": "https://requests.readthedocs.io", "Source": "https://github.com/psf/requests"}}}`,
+		"https://crates.io/api/v1/crates/serde":      `{"crate": {"repository": "https://github.com/serde-rs/serde", "homepage": "https://serde.rs"}}`,
+		"https://go.uber.org/zap":                    `<html><head><meta name="go-import" content="go.uber.org/zap git https://github.com/uber-go/zap"></head></html>`,
+	}}
+
+	tests := []struct {
+		name    string
+		pkg     *SBOMPackage
+		want    string
+		wantErr bool
+	}{
+		{name: "github purl", pkg: &SBOMPackage{PURL: "pkg:github/foo/bar@v1.0.0"}, want: "https://github.com/foo/bar"},
+		{name: "gitlab purl", pkg: &SBOMPackage{PURL: "pkg:gitlab/foo/bar"}, want: "https://gitlab.com/foo/bar"},
+		{name: "bitbucket purl", pkg: &SBOMPackage{PURL: "pkg:bitbucket/foo/bar"}, want: "https://bitbucket.org/foo/bar"},
+		{name: "golang purl on a known host", pkg: &SBOMPackage{PURL: "pkg:golang/github.com/foo/bar/v2@v2.0.0"}, want: "https://github.com/foo/bar"},
+		{name: "golang purl with a vanity import path", pkg: &SBOMPackage{PURL: "pkg:golang/go.uber.org/zap@v1.19.0"}, want: "https://github.com/uber-go/zap"},
+		{name: "npm purl", pkg: &SBOMPackage{PURL: "pkg:npm/lodash@4.17.21"}, want: "https://github.com/lodash/lodash"},
+		{name: "scoped npm purl", pkg: &SBOMPackage{PURL: "pkg:npm/%40angular/core@12.0.0"}, want: "https://github.com/angular/angular"},
+		{name: "npm purl with a sponsors homepage", pkg: &SBOMPackage{PURL: "pkg:npm/funding@1.0.0"}, wantErr: true},
+		{name: "npm purl not found", pkg: &SBOMPackage{PURL: "pkg:npm/not-found@1.0.0"}, wantErr: true},
+		{name: "pypi purl", pkg: &SBOMPackage{PURL: "pkg:pypi/requests@2.26.0"}, want: "https://github.com/psf/requests"},
+		{name: "cargo purl", pkg: &SBOMPackage{PURL: "pkg:cargo/serde@1.0.130"}, want: "https://github.com/serde-rs/serde"},
+		{name: "maven purl", pkg: &SBOMPackage{PURL: "pkg:maven/com.google.guava/guava@30.1-jre"}, wantErr: true},
+		{
+			name: "vcs_url qualifier",
+			pkg:  &SBOMPackage{PURL: "pkg:maven/org.foo/bar@1.0?vcs_url=git%2Bhttps://github.com/foo/bar.git"},
+			want: "https://github.com/foo/bar",
+		},
+		{
+			name: "locations before the purl",
+			pkg:  &SBOMPackage{PURL: "pkg:npm/lodash@4.17.21", Locations: []string{"https://github.com/sponsors/foo", "https://gitlab.com/foo/lodash"}},
+			want: "https://gitlab.com/foo/lodash",
+		},
+		{name: "no purl", pkg: &SBOMPackage{Name: "foo", Locations: []string{"https://example.com"}}, wantErr: true},
+	}
+	resolver := NewSBOMRepoResolver()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tt.pkg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Resolve() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"go.uber.org/ratelimit"
+)
+
+// PooledSession is a session (profile) of a SessionPool.
+type PooledSession struct {
+	Name   string
+	Weight int
+	Client *lgtm.Client
+	Conf   *lgtm.Config
+
+	// current is the current weight of the smooth weighted round-robin.
+	current  int
+	disabled bool
+
+	Follows int
+	Errors  int
+}
+
+// SessionPool distributes the follows across the sessions
+// of several profiles (lgtm.com accounts), with weighted round-robin;
+// each session has its own rate limiter.
+type SessionPool struct {
+	mu       *sync.Mutex
+	sessions []*PooledSession
+}
+
+// parseProfileWeights parses the comma-separated profiles
+// (name, or name:weight) of the --profiles flag.
+func parseProfileWeights(raw string) ([]string, map[string]int, error) {
+	names := make([]string, 0)
+	weights := make(map[string]int)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, weight := item, 1
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			name = item[:i]
+			parsed, err := strconv.Atoi(item[i+1:])
+			if err != nil || parsed < 1 {
+				return nil, nil, fmt.Errorf("invalid weight of profile %q: %q", name, item[i+1:])
+			}
+			weight = parsed
+		}
+		if _, ok := weights[name]; ok {
+			return nil, nil, fmt.Errorf("profile %q is repeated", name)
+		}
+		names = append(names, name)
+		weights[name] = weight
+	}
+	return names, weights, nil
+}
+
+// NewSessionPool returns a pool with the sessions of the provided profiles
+// of the config, after checking that they are valid; the session of the
+// selected profile (if in the pool) reuses its client and config
+// (which can include credentials from env vars).
+func NewSessionPool(fileConf *lgtm.Config, names []string, weights map[string]int, selected string, selectedClient *lgtm.Client, selectedConf *lgtm.Config) (*SessionPool, error) {
+	if len(names) < 2 {
+		return nil, errors.New("at least two profiles are needed to distribute the follows")
+	}
+	pool := &SessionPool{
+		mu:       &sync.Mutex{},
+		sessions: make([]*PooledSession, 0, len(names)),
+	}
+	for _, name := range names {
+		cl, conf := selectedClient, selectedConf
+		if name != selected {
+			var err error
+			conf, err = fileConf.GetProfile(name)
+			if err != nil {
+				return nil, err
+			}
+			if err := conf.Validate(); err != nil {
+				return nil, fmt.Errorf("config of profile %q is not valid: %w", name, err)
+			}
+			cl, err = lgtm.NewClient(conf)
+			if err != nil {
+				return nil, err
+			}
+			cl.SetRateLimiter(ratelimit.New(1, ratelimit.WithSlack(3)))
+			cl = cl.WithContext(selectedClient.Context())
+		}
+		user, err := cl.GetLoggedInUser()
+		if err != nil {
+			if lgtm.IsStaleSession(err) {
+				return nil, fmt.Errorf("the session of profile %q is stale (refresh it with: lgtm --profile=%s login)", name, name)
+			}
+			return nil, fmt.Errorf("error while checking the session of profile %q: %w", name, err)
+		}
+		Infof("Profile %q is logged in as %s (weight %v)", name, user.Person.Slug, weights[name])
+		pool.sessions = append(pool.sessions, &PooledSession{
+			Name:   name,
+			Weight: weights[name],
+			Client: cl,
+			Conf:   conf,
+		})
+	}
+	return pool, nil
+}
+
+// Len returns the number of sessions in the pool.
+func (pool *SessionPool) Len() int {
+	return len(pool.sessions)
+}
+
+// Next returns the session that should do the next follow
+// (smooth weighted round-robin among the enabled sessions),
+// or nil if all sessions are disabled.
+func (pool *SessionPool) Next() *PooledSession {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var best *PooledSession
+	var total int
+	for _, sess := range pool.sessions {
+		if sess.disabled {
+			continue
+		}
+		sess.current += sess.Weight
+		total += sess.Weight
+		if best == nil || sess.current > best.current {
+			best = sess
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// Record records the outcome of a follow done with the session.
+func (pool *SessionPool) Record(sess *PooledSession, err error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if err != nil {
+		sess.Errors++
+	} else {
+		sess.Follows++
+	}
+}
+
+// Disable removes the session from the rotation (e.g. when its account
+// has reached the follow limit), and returns the number of enabled sessions left.
+func (pool *SessionPool) Disable(sess *PooledSession) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	sess.disabled = true
+	var enabled int
+	for _, other := range pool.sessions {
+		if !other.disabled {
+			enabled++
+		}
+	}
+	return enabled
+}
+
+// GetFollowedCache returns the projects followed by any of the sessions,
+// so that projects followed by another account are not followed again.
+func (pool *SessionPool) GetFollowedCache(dont bool) (*lgtm.FollowedProjectCache, error) {
+	if dont {
+		return nil, errors.New("decided to not fetch the cache")
+	}
+	caches := make([]*lgtm.FollowedProjectCache, 0, len(pool.sessions))
+	for _, sess := range pool.sessions {
+		Infof("Getting followed projects of profile %q...", sess.Name)
+		cache, err := getFollowedCacheOf(sess.Client, false)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", sess.Name, err)
+		}
+		caches = append(caches, cache)
+	}
+	return lgtm.MergeFollowedCaches(caches...), nil
+}
+
+// PrintReport prints the number of follows and errors of each session.
+func (pool *SessionPool) PrintReport() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	Infof("Follows per profile:")
+	for _, sess := range pool.sessions {
+		var note string
+		if sess.disabled {
+			note = " (stopped: follow limit reached)"
+		}
+		Sfln("    %s: %v followed, %v errors%s", sess.Name, sess.Follows, sess.Errors, note)
+	}
+}
+
+// isFollowCommand returns true if the args run a command
+// whose follows can be distributed across profiles.
+func isFollowCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch {
+	case args[0] == "project":
+		return len(args) > 1 && args[1] == "follow"
+	case args[0] == "queue":
+		return len(args) > 1 && args[1] == "run"
+	default:
+		return strings.HasPrefix(args[0], "follow")
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// Output sinks: besides local files, target lists and reports can be
+// written to S3 (s3://bucket/key), to Google Cloud Storage (gs://bucket/key),
+// or PUT to an HTTP endpoint (https://...).
+//
+// S3 credentials are taken from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN (optional) and AWS_REGION env vars (AWS_S3_ENDPOINT can be used
+// for S3-compatible storages); the GCS access token is taken from the
+// GOOGLE_OAUTH_ACCESS_TOKEN env var.
+
+// isRemoteOutput returns true if the output is not a local file.
+func isRemoteOutput(output string) bool {
+	for _, scheme := range []string{"s3://", "gs://", "https://", "http://"} {
+		if strings.HasPrefix(output, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutputFile writes the content to the provided file or remote output.
+func writeOutputFile(output string, content []byte, contentType string) error {
+	if isRemoteOutput(output) {
+		return uploadToSink(output, content, contentType)
+	}
+	return ioutil.WriteFile(output, content, 0644)
+}
+
+// uploadToSink writes the content to the remote output.
+func uploadToSink(output string, content []byte, contentType string) error {
+	parsed, err := url.Parse(output)
+	if err != nil {
+		return fmt.Errorf("invalid output %q: %w", output, err)
+	}
+
+	var req *http.Request
+	switch parsed.Scheme {
+	case "s3":
+		req, err = newS3PutRequest(parsed.Host, strings.TrimPrefix(parsed.Path, "/"), content, contentType, time.Now())
+	case "gs":
+		req, err = newGCSUploadRequest(parsed.Host, strings.TrimPrefix(parsed.Path, "/"), content, contentType)
+	case "http", "https":
+		req, err = http.NewRequest(http.MethodPut, output, bytes.NewReader(content))
+		if err == nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+	default:
+		return fmt.Errorf("unsupported output scheme: %q", parsed.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := webHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while uploading to %s: %w", output, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("error while uploading to %s: status %s: %s", output, resp.Status, string(body))
+	}
+	return nil
+}
+
+func newGCSUploadRequest(bucket string, key string, content []byte, contentType string) (*http.Request, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		return nil, errors.New("GOOGLE_OAUTH_ACCESS_TOKEN is not set")
+	}
+	vals := url.Values{}
+	vals.Set("uploadType", "media")
+	vals.Set("name", key)
+	dst := "https://storage.googleapis.com/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o?" + vals.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, dst, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+// newS3PutRequest returns a PutObject request signed with AWS Signature Version 4
+// at the provided time.
+func newS3PutRequest(bucket string, key string, content []byte, contentType string, now time.Time) (*http.Request, error) {
+	accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	escapedKey := make([]string, 0)
+	for _, segment := range strings.Split(key, "/") {
+		escapedKey = append(escapedKey, awsURIEncode(segment))
+	}
+	var dst string
+	if endpoint := os.Getenv("AWS_S3_ENDPOINT"); endpoint != "" {
+		// Path-style, for S3-compatible storages:
+		dst = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + strings.Join(escapedKey, "/")
+	} else {
+		dst = Sf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, strings.Join(escapedKey, "/"))
+	}
+
+	req, err := http.NewRequest(http.MethodPut, dst, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256Hex(content)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signS3Request(req, payloadHash, accessKeyID, secretAccessKey, region, now)
+	return req, nil
+}
+
+// signS3Request sets the x-amz-date and Authorization headers of the request,
+// signing the host and all the headers already set on the request.
+// The path of the request must already be URI-encoded as S3 expects (see awsURIEncode),
+// and the request must not have a query string.
+func signS3Request(req *http.Request, payloadHash string, accessKeyID string, secretAccessKey string, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name, values := range req.Header {
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.TrimSpace(value))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", Sf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+// awsURIEncode encodes all the characters of a path segment
+// except the unreserved ones (A-Z, a-z, 0-9, '-', '.', '_' and '~'),
+// as the AWS signature expects; url.PathEscape keeps e.g. '$' and '='.
+func awsURIEncode(segment string) string {
+	var out strings.Builder
+	for i := 0; i < len(segment); i++ {
+		char := segment[i]
+		switch {
+		case 'A' <= char && char <= 'Z', 'a' <= char && char <= 'z', '0' <= char && char <= '9',
+			char == '-', char == '.', char == '_', char == '~':
+			out.WriteByte(char)
+		default:
+			fmt.Fprintf(&out, "%%%02X", char)
+		}
+	}
+	return out.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
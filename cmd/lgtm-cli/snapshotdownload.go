@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// snapshotFilename returns the name of the file of the CodeQL database
+// of the project for the language (e.g. g_kubernetes_kubernetes.go.zip).
+func snapshotFilename(pr *lgtm.Project, lang string) string {
+	slug := strings.NewReplacer("/", "_", ":", "_").Replace(pr.Slug)
+	return Sf("%s.%s.zip", slug, lang)
+}
+
+// downloadSnapshot downloads the CodeQL database of the latest snapshot
+// of the project for the language to its file in the provided directory,
+// and returns the filepath and size; the file is only created
+// once the download has completed.
+func downloadSnapshot(cl *lgtm.Client, pr *lgtm.Project, lang string, dir string) (string, int64, error) {
+	path := filepath.Join(dir, snapshotFilename(pr, lang))
+	tmp, err := os.Create(path + ".part")
+	if err != nil {
+		return "", 0, err
+	}
+	size, err := cl.DownloadSnapshot(pr.Key, lang, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("error while downloading snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, size, nil
+}
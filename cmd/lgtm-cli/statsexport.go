@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+// ProjectStatsRow contains the stats of a project, as exported by stats-export.
+type ProjectStatsRow struct {
+	Key       string
+	Slug      string
+	URL       string
+	Languages []string
+	Lines     int
+	Alerts    int
+	// Grades are the grades of the project by language.
+	Grades map[string]string
+	// AlertsByLang and LinesByLang are the alerts and lines
+	// of the project by language.
+	AlertsByLang map[string]int
+	LinesByLang  map[string]int
+	Contributors int
+	Error        string
+}
+
+func newProjectStatsRow(pr *lgtm.Project) *ProjectStatsRow {
+	return &ProjectStatsRow{
+		Key:       pr.Key,
+		Slug:      pr.Slug,
+		URL:       pr.ExternalURL.URL,
+		Languages: pr.Languages,
+		Grades:    make(map[string]string),
+
+		AlertsByLang: make(map[string]int),
+		LinesByLang:  make(map[string]int),
+	}
+}
+
+// setStats adds the latest stats of the project to the row.
+func (row *ProjectStatsRow) setStats(stats *lgtm.LatestStateStatsData) {
+	row.Contributors = stats.NumContributors
+	for _, state := range stats.LanguageStates {
+		row.Lines += state.TotalLines
+		row.Alerts += state.TotalAlerts
+		row.LinesByLang[state.Lang] += state.TotalLines
+		row.AlertsByLang[state.Lang] += state.TotalAlerts
+		if state.Rating.Grade != "" {
+			row.Grades[state.Lang] = state.Rating.Grade
+		}
+	}
+}
+
+// formatGrades returns the grades as lang:grade pairs, sorted by language.
+func (row *ProjectStatsRow) formatGrades() string {
+	langs := make([]string, 0, len(row.Grades))
+	for lang := range row.Grades {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	pairs := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		pairs = append(pairs, lang+":"+row.Grades[lang])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// getProjectStatsRows gets the latest stats of the projects with the fetcher;
+// the rows are in the same order as the projects.
+func getProjectStatsRows(ctx context.Context, fetcher *StatsFetcher, projects []*lgtm.Project) []*ProjectStatsRow {
+	rows := make([]*ProjectStatsRow, 0, len(projects))
+	for _, result := range fetcher.Fetch(ctx, projects) {
+		row := newProjectStatsRow(result.Project)
+		if result.Err != nil {
+			row.Error = result.Err.Error()
+		} else {
+			row.setStats(result.Stats)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// writeProjectStatsCSV writes the rows as CSV, with a header.
+func writeProjectStatsCSV(w io.Writer, rows []*ProjectStatsRow) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"key", "slug", "url", "languages", "lines", "alerts", "grades", "contributors", "error"})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := writer.Write([]string{
+			row.Key,
+			row.Slug,
+			row.URL,
+			strings.Join(row.Languages, ";"),
+			strconv.Itoa(row.Lines),
+			strconv.Itoa(row.Alerts),
+			row.formatGrades(),
+			strconv.Itoa(row.Contributors),
+			row.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/eta"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"go.uber.org/ratelimit"
+	"golang.org/x/sync/semaphore"
+)
+
+// errStatsInterrupted is the error of the projects whose stats
+// were not fetched because the run was interrupted.
+var errStatsInterrupted = errors.New("interrupted")
+
+// ProjectStats contains the latest stats of a project
+// (or the error while getting them).
+type ProjectStats struct {
+	Project *lgtm.Project
+	Stats   *lgtm.LatestStateStatsData
+	Err     error
+}
+
+// StatsFetcher gets the latest stats of many projects for the stats-oriented
+// commands (stats-export, lang-stats, report-card, alert-summary):
+// with at most maxWorkers concurrent requests, at most rps requests
+// per second (if not zero), and retrying the transient errors.
+type StatsFetcher struct {
+	cl         *lgtm.Client
+	op         string
+	maxWorkers int64
+	maxRetries int
+}
+
+// NewStatsFetcher returns a new StatsFetcher; op is the name
+// of the command (used in the metrics).
+func NewStatsFetcher(cl *lgtm.Client, op string, maxWorkers int64, rps int, maxRetries int) *StatsFetcher {
+	// The stats requests are limited by rps only
+	// (not by the shared rate limiter of the lgtm package):
+	limiter := ratelimit.NewUnlimited()
+	if rps > 0 {
+		limiter = ratelimit.New(rps, ratelimit.WithSlack(3))
+	}
+	return &StatsFetcher{
+		cl:         cl.WithRateLimiter(limiter),
+		op:         op,
+		maxWorkers: maxWorkers,
+		maxRetries: maxRetries,
+	}
+}
+
+// Fetch gets the latest stats of the projects; the results are
+// in the same order as the projects, and the projects whose stats
+// could not be fetched have an Err (partial results).
+// Once the context is canceled, the remaining projects are not processed.
+func (fetcher *StatsFetcher) Fetch(ctx context.Context, projects []*lgtm.Project) []*ProjectStats {
+	results := make([]*ProjectStats, len(projects))
+	wg := &sync.WaitGroup{}
+	sem := semaphore.NewWeighted(fetcher.maxWorkers)
+	etac := eta.New(int64(len(projects)))
+	for i, pr := range projects {
+		result := &ProjectStats{Project: pr}
+		results[i] = result
+		if ctx.Err() != nil || sem.Acquire(ctx, 1) != nil {
+			result.Err = errStatsInterrupted
+			continue
+		}
+		wg.Add(1)
+
+		go func(result *ProjectStats) {
+			defer func() {
+				progress.Emit("stats", result.Project.ExternalURL.URL, etac, result.Err)
+			}()
+			defer etac.Done(1)
+			defer wg.Done()
+			defer sem.Release(1)
+
+			Infof(
+				"[%s](%v/%v) Getting stats of %s ...",
+				etac.GetFormattedPercentDone(),
+				etac.GetDone()+1,
+				etac.GetTotal(),
+				result.Project.DisplayName,
+			)
+			result.Stats, result.Err = fetcher.fetchWithRetry(ctx, result.Project)
+			if result.Err != nil {
+				metrics.Inc("errors_total", "op", fetcher.op)
+				Errorf(
+					"error while getting stats of %s: %s",
+					result.Project.DisplayName,
+					result.Err,
+				)
+			}
+		}(result)
+	}
+	wg.Wait()
+	return results
+}
+
+func (fetcher *StatsFetcher) fetchWithRetry(ctx context.Context, pr *lgtm.Project) (*lgtm.LatestStateStatsData, error) {
+	for attempt := 1; ; attempt++ {
+		stats, err := fetcher.cl.GetProjectLatestStateStats(pr.Key)
+		if err == nil || !lgtm.ClassifyError(err).IsRetryable() || attempt > fetcher.maxRetries || ctx.Err() != nil {
+			return stats, err
+		}
+		backoff := lgtm.RetryBackoff(attempt)
+		Warnf(
+			"Error while getting stats of %s (%s); retrying in %s (attempt %v/%v) ...",
+			pr.DisplayName,
+			err,
+			backoff,
+			attempt,
+			fetcher.maxRetries,
+		)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// countStatsErrors returns the number of projects whose stats
+// could not be fetched.
+func countStatsErrors(results []*ProjectStats) int {
+	var failed int
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
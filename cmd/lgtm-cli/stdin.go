@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// StdinTarget is the target (or target list filepath) that stands for stdin.
+const StdinTarget = "-"
+
+var (
+	stdinOnce    sync.Once
+	stdinTargets []string
+	stdinErr     error
+)
+
+// readTargetsFromStdin reads the targets from stdin, one per line
+// (empty lines and lines starting with # are ignored, as in target files);
+// stdin is read only once, so "-" can be used more than once.
+func readTargetsFromStdin() ([]string, error) {
+	stdinOnce.Do(func() {
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			Infof("Reading targets from stdin (one per line; end with Ctrl-D)...")
+		}
+		content, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			stdinErr = err
+			return
+		}
+		stdinTargets = make([]string, 0)
+		stdinErr = ReadStringLineByLine(string(content), func(line string) bool {
+			line = strings.TrimSpace(line)
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				return true
+			}
+			stdinTargets = append(stdinTargets, line)
+			return true
+		})
+	})
+	return stdinTargets, stdinErr
+}
+
+// expandStdinArgs replaces the "-" args with the targets read from stdin.
+func expandStdinArgs(args []string) []string {
+	res := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != StdinTarget {
+			res = append(res, arg)
+			continue
+		}
+		targets, err := readTargetsFromStdin()
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, targets...)
+	}
+	return res
+}
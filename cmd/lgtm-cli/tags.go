@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/internal/urlparse"
+	. "github.com/gagliardetto/utilz"
+	"github.com/urfave/cli"
+)
+
+// DefaultTagsFilepath returns the default filepath of the local project tags.
+func DefaultTagsFilepath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "lgtm-cli", "tags.json")
+}
+
+// TagStore contains the local tags of projects, keyed by project URL;
+// tags are a grouping mechanism independent of lgtm.com lists.
+type TagStore struct {
+	path string
+	// Projects maps project URLs to their tags.
+	Projects map[string][]string `json:"projects"`
+}
+
+// key returns the key of the project URL in the store
+// (URLs are compared case-insensitively).
+func (ts *TagStore) key(repoURL string) string {
+	if _, ok := ts.Projects[repoURL]; ok {
+		return repoURL
+	}
+	for key := range ts.Projects {
+		if strings.EqualFold(key, repoURL) {
+			return key
+		}
+	}
+	return repoURL
+}
+
+// LoadTagStore loads the tags from the provided file;
+// if the file does not exist, an empty store is returned.
+func LoadTagStore(path string) (*TagStore, error) {
+	store := &TagStore{
+		path:     path,
+		Projects: make(map[string][]string),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, store); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	if store.Projects == nil {
+		store.Projects = make(map[string][]string)
+	}
+	return store, nil
+}
+
+// Save writes the tags to the file they were loaded from.
+func (ts *TagStore) Save() error {
+	js, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ts.path, js, 0600)
+}
+
+// Add tags the projects; it returns the number of projects
+// that were not already tagged.
+func (ts *TagStore) Add(tag string, repoURLs ...string) int {
+	added := 0
+	for _, repoURL := range repoURLs {
+		key := ts.key(repoURL)
+		if SliceContains(ts.Projects[key], tag) {
+			continue
+		}
+		ts.Projects[key] = append(ts.Projects[key], tag)
+		sort.Strings(ts.Projects[key])
+		added++
+	}
+	return added
+}
+
+// Remove untags the projects; it returns the number of projects
+// that were tagged.
+func (ts *TagStore) Remove(tag string, repoURLs ...string) int {
+	removed := 0
+	for _, repoURL := range repoURLs {
+		key := ts.key(repoURL)
+		tags := make([]string, 0, len(ts.Projects[key]))
+		for _, t := range ts.Projects[key] {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		if len(tags) == len(ts.Projects[key]) {
+			continue
+		}
+		removed++
+		if len(tags) == 0 {
+			delete(ts.Projects, key)
+		} else {
+			ts.Projects[key] = tags
+		}
+	}
+	return removed
+}
+
+// HasAnyTag returns true if the project has at least one of the tags.
+func (ts *TagStore) HasAnyTag(repoURL string, tags ...string) bool {
+	for _, tag := range ts.Projects[ts.key(repoURL)] {
+		if SliceContains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// URLsWithAnyTag returns the sorted URLs of the projects
+// that have at least one of the tags.
+func (ts *TagStore) URLsWithAnyTag(tags ...string) []string {
+	res := make([]string, 0)
+	for repoURL := range ts.Projects {
+		if ts.HasAnyTag(repoURL, tags...) {
+			res = append(res, repoURL)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Counts returns the number of projects per tag.
+func (ts *TagStore) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, tags := range ts.Projects {
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// mustLoadTagStore loads the tags from the provided file, or exits.
+func mustLoadTagStore(path string) *TagStore {
+	store, err := LoadTagStore(path)
+	if err != nil {
+		Fatalf("Error while loading tags: %s", err)
+	}
+	return store
+}
+
+// mustLoadTaggedURLs returns the URLs of the projects
+// that have at least one of the tags.
+func mustLoadTaggedURLs(path string, tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	urls := mustLoadTagStore(path).URLsWithAnyTag(tags...)
+	if len(urls) == 0 {
+		Warnf("No projects are tagged with %s", Sq(tags))
+	}
+	return urls
+}
+
+// loadTagTargets returns the normalized URLs of the repos provided
+// to a tag subcommand (after the tag), as args or in files.
+func loadTagTargets(c *cli.Context) ([]string, error) {
+	repoURLsRaw := expandStdinArgs(c.Args().Tail())
+	if c.IsSet("f") {
+		repoURLsRaw = append(repoURLsRaw, mustLoadTargetsFromFilepaths(mustStringSliceNotNil(c.StringSlice("f"))...)...)
+	}
+	if len(repoURLsRaw) == 0 {
+		return nil, errors.New("no repos provided")
+	}
+	repoURLs := make([]string, 0, len(repoURLsRaw))
+	for _, raw := range Deduplicate(repoURLsRaw) {
+		parsed, err := urlparse.Parse(raw, true)
+		if err != nil {
+			return nil, err
+		}
+		repoURLs = append(repoURLs, parsed.URL())
+	}
+	return repoURLs, nil
+}
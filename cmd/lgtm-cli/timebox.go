@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// maxTimeBoxMargin is the max time left to the in-flight operations
+// to complete once a time-boxed run stops dispatching new ones.
+const maxTimeBoxMargin = time.Minute
+
+// timeBox stops a run (i.e. the dispatching of new operations)
+// when its max duration approaches.
+type timeBox struct {
+	maxDuration time.Duration
+	reached     int32
+}
+
+// startTimeBox calls abort shortly before the max duration elapses,
+// leaving some time to the in-flight operations to complete
+// (5% of the max duration, up to a minute).
+func startTimeBox(maxDuration time.Duration, abort context.CancelFunc) *timeBox {
+	tb := &timeBox{
+		maxDuration: maxDuration,
+	}
+	margin := maxDuration / 20
+	if margin > maxTimeBoxMargin {
+		margin = maxTimeBoxMargin
+	}
+	time.AfterFunc(maxDuration-margin, func() {
+		atomic.StoreInt32(&tb.reached, 1)
+		Warnf("The --max-duration (%s) is about to elapse; finishing in-flight requests ...", maxDuration)
+		abort()
+	})
+	return tb
+}
+
+// Reached returns true if the run was stopped because of the max duration.
+func (tb *timeBox) Reached() bool {
+	return tb != nil && atomic.LoadInt32(&tb.reached) == 1
+}
+
+// Finish returns the error with which the run should exit: a run
+// stopped because of the max duration is successful (i.e. it can be resumed),
+// unless it failed for other reasons.
+func (tb *timeBox) Finish(err error) error {
+	if !tb.Reached() {
+		return err
+	}
+	var exitErr *ExitError
+	isPartial := errors.As(err, &exitErr) && exitErr.Code == ExitCodePartialFailure
+	if err != nil && !isPartial && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if err != nil {
+		Warnf("%s", err)
+	}
+	Warnf("Stopped after --max-duration (%s); the run can be resumed (see the files of remaining targets and reports above).", tb.maxDuration)
+	return nil
+}
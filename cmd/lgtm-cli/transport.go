@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// slowRequestTransport is an http.RoundTripper that warns about
+// requests that take longer than the threshold, naming the endpoint
+// that is stalling.
+type slowRequestTransport struct {
+	transport http.RoundTripper
+	threshold time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *slowRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.threshold <= 0 {
+		return t.transport.RoundTrip(req)
+	}
+	endpoint := endpointLabel(req.URL, lgtmAPIHost)
+	start := time.Now()
+	timer := time.AfterFunc(t.threshold, func() {
+		Warnf("Request to %s is taking more than %s ...", endpoint, t.threshold)
+	})
+	resp, err := t.transport.RoundTrip(req)
+	if !timer.Stop() {
+		Warnf("Slow request to %s took %s", endpoint, time.Since(start).Round(time.Millisecond))
+	}
+	return resp, err
+}
+
+// pickTimeout returns the timeout set with a flag, or the one set
+// in the config, or the fallback.
+func pickTimeout(flagValue time.Duration, confValue string, fallback time.Duration) time.Duration {
+	if flagValue > 0 {
+		return flagValue
+	}
+	if confValue != "" {
+		if parsed, err := time.ParseDuration(confValue); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// webHTTPClient is the http client of the requests to GitHub (API and web pages),
+// pkg.go.dev, and all the other websites that are not lgtm.com;
+// it can use a different proxy than the lgtm.com client.
+var webHTTPClient = http.DefaultClient
+
+// pickProxy returns the proxy set with a flag, or the one set in the config.
+func pickProxy(flagValue string, confValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return confValue
+}
+
+// setDefaultTransportProxy sets the proxy of http.DefaultTransport,
+// which is used by the libraries that don't accept a custom transport.
+func setDefaultTransportProxy(proxy func(*http.Request) (*url.URL, error)) {
+	if tr, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr.Proxy = proxy
+	}
+}
+
+// parseHeaderOverrides returns the header overrides of the lgtm.com requests
+// set with flags (or nil if none is set).
+func parseHeaderOverrides(userAgent string, headers []string, cookies []string) (*lgtm.HeadersConfig, error) {
+	if userAgent == "" && len(headers) == 0 && len(cookies) == 0 {
+		return nil, nil
+	}
+	overrides := &lgtm.HeadersConfig{
+		UserAgent: userAgent,
+		Extra:     make(map[string]string),
+		Cookies:   make(map[string]string),
+	}
+	for _, raw := range headers {
+		name, value, err := lgtm.ParseHeader(raw)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Extra[name] = value
+	}
+	for _, raw := range cookies {
+		name, value, err := lgtm.ParseCookie(raw)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Cookies[name] = value
+	}
+	return overrides, overrides.Validate()
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/eta"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	"github.com/gagliardetto/ref"
+	. "github.com/gagliardetto/utilz"
+	"github.com/hako/durafmt"
+	"golang.org/x/sync/semaphore"
+)
+
+type Unfollower struct {
+	ctx     context.Context
+	client  *lgtm.Client
+	wg      *sync.WaitGroup
+	sem     *semaphore.Weighted
+	mu      *sync.Mutex
+	skipped int
+	failed  int
+}
+
+// NewUnfollower returns a new Unfollower; once the context is canceled,
+// no new unfollows are started.
+func NewUnfollower(ctx context.Context, client *lgtm.Client, maxWorkers int64) *Unfollower {
+	return &Unfollower{
+		ctx:    ctx,
+		client: client,
+		wg:     &sync.WaitGroup{},
+		sem:    semaphore.NewWeighted(maxWorkers),
+		mu:     &sync.Mutex{},
+	}
+}
+
+//
+func (un *Unfollower) Unfollow(isProto bool, key string, name string, etac *eta.ETA) {
+	if un.ctx.Err() != nil || un.sem.Acquire(un.ctx, 1) != nil {
+		un.mu.Lock()
+		un.skipped++
+		un.mu.Unlock()
+		return
+	}
+	un.wg.Add(1)
+
+	go un.unfollower(isProto, key, name, etac)
+}
+
+//
+func (un *Unfollower) unfollower(isProto bool, key string, name string, etac *eta.ETA) {
+	var err error
+	defer func() {
+		progress.Emit("unfollow", name, etac, err)
+	}()
+	defer etac.Done(1)
+	defer un.wg.Done()
+	defer un.sem.Release(1)
+
+	averagedETA := etac.GetETA()
+	thisETA := durafmt.Parse(averagedETA.Round(time.Second)).String()
+
+	Infof(
+		"[%s](%v/%v) Unfollowing %s ... ETA %s",
+		etac.GetFormattedPercentDone(),
+		etac.GetDone()+1,
+		etac.GetTotal(),
+		name,
+		thisETA,
+	)
+
+	unfollowFunc := un.client.UnfollowProject
+	if isProto {
+		unfollowFunc = un.client.UnfollowProtoProject
+	}
+
+	err = unfollowFunc(key)
+	if err != nil {
+		un.mu.Lock()
+		un.failed++
+		un.mu.Unlock()
+		metrics.Inc("errors_total", "op", "unfollow")
+		Errorf(
+			"error while unfollowing project %s: %s",
+			name,
+			err,
+		)
+	} else {
+		metrics.Inc("unfollows_total")
+		Successf(
+			"[%s](%v/%v) Unfollowed %s; ETA %s",
+			etac.GetFormattedPercentDone(),
+			etac.GetDone()+1,
+			etac.GetTotal(),
+			name,
+			thisETA,
+		)
+	}
+}
+
+// Wait waits for all the unfollows to complete;
+// it returns an error if some unfollows failed.
+func (un *Unfollower) Wait() error {
+	un.wg.Wait()
+	if un.skipped > 0 {
+		Warnf("Stopped; %v projects were not unfollowed", un.skipped)
+	} else {
+		Errorln(LimeBG(">>> Completed. <<<"))
+	}
+	if un.failed > 0 {
+		return partialFailuref("failed to unfollow %v projects", un.failed)
+	}
+	return nil
+}
+
+// filterExceptProjects returns the projects that don't match any of the except-patterns.
+func filterExceptProjects(projects []*lgtm.Project, exceptPatterns []string) []*lgtm.Project {
+	if len(exceptPatterns) == 0 {
+		return projects
+	}
+	return ref.Filter(projects,
+		func(i int, pr *lgtm.Project) bool {
+			_, isExcepted := HasMatch(pr.ExternalURL.URL, exceptPatterns)
+			return !isExcepted
+		}).([]*lgtm.Project)
+}
+
+// filterExceptProto returns the proto-projects that don't match any of the except-patterns.
+func filterExceptProto(protoProjects []*lgtm.ProtoProject, exceptPatterns []string) []*lgtm.ProtoProject {
+	if len(exceptPatterns) == 0 {
+		return protoProjects
+	}
+	return ref.Filter(protoProjects,
+		func(i int, pr *lgtm.ProtoProject) bool {
+			_, isExcepted := HasMatch(trimDotGit(pr.CloneURL), exceptPatterns)
+			return !isExcepted
+		}).([]*lgtm.ProtoProject)
+}
+
+// filterProjectsByLanguages returns the projects whose analyzed languages
+// are all among onlyLangs (if not empty), and that don't have
+// any of the withoutLangs (if not empty).
+func filterProjectsByLanguages(projects []*lgtm.Project, onlyLangs []string, withoutLangs []string) []*lgtm.Project {
+	return ref.Filter(projects,
+		func(i int, pr *lgtm.Project) bool {
+			if len(onlyLangs) > 0 {
+				if len(pr.Languages) == 0 {
+					return false
+				}
+				for _, lang := range pr.Languages {
+					if !SliceContains(onlyLangs, lang) {
+						return false
+					}
+				}
+			}
+			for _, lang := range withoutLangs {
+				if pr.SupportsLanguage(lang) {
+					return false
+				}
+			}
+			return true
+		}).([]*lgtm.Project)
+}
+
+// filterArchived returns the projects and proto-projects whose repo
+// is archived on GitHub, looking up the repos with at most maxWorkers
+// concurrent requests; it also returns the GitHub metadata of the repos.
+// The repos that are not on GitHub, or that cannot be looked up, are skipped.
+func filterArchived(ctx context.Context, projects []*lgtm.Project, protoProjects []*lgtm.ProtoProject, maxWorkers int64) ([]*lgtm.Project, []*lgtm.ProtoProject, RepoMetadata) {
+	repoURLs := make([]string, 0, len(projects)+len(protoProjects))
+	for _, pr := range projects {
+		repoURLs = append(repoURLs, pr.ExternalURL.URL)
+	}
+	for _, pr := range protoProjects {
+		repoURLs = append(repoURLs, trimDotGit(pr.CloneURL))
+	}
+	meta := fetchRepoMetadata(ctx, repoURLs, maxWorkers)
+
+	archivedProjects := ref.Filter(projects,
+		func(i int, pr *lgtm.Project) bool {
+			return meta.Get(pr.ExternalURL.URL).GetArchived()
+		}).([]*lgtm.Project)
+	archivedProto := ref.Filter(protoProjects,
+		func(i int, pr *lgtm.ProtoProject) bool {
+			return meta.Get(trimDotGit(pr.CloneURL)).GetArchived()
+		}).([]*lgtm.ProtoProject)
+	return archivedProjects, archivedProto, meta
+}
+
+// lowerAll returns the lowercased strings.
+func lowerAll(sl []string) []string {
+	res := make([]string, 0, len(sl))
+	for _, s := range sl {
+		res = append(res, ToLower(s))
+	}
+	return res
+}
+
+// validateProjectKeys checks that the keys look like lgtm.com
+// project keys (i.e. numbers), and not like URLs or slugs.
+func validateProjectKeys(keys []string) error {
+	for _, key := range keys {
+		if _, err := strconv.ParseUint(key, 10, 64); err != nil {
+			return fmt.Errorf("%q is not a project key", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gagliardetto/eta"
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// getFollowedCacheOf gets the list of followed projects of the client,
+// reporting how many there are.
+func getFollowedCacheOf(cl *lgtm.Client, dont bool) (*lgtm.FollowedProjectCache, error) {
+	if dont {
+		return cl.GetFollowedCache(dont)
+	}
+	took := NewTimer()
+	Infof("Getting list of followed projects...")
+	cache, err := cl.GetFollowedCache(dont)
+	if err != nil {
+		return nil, err
+	}
+	Successf("Currently %v projects (and %v proto) are followed; took %s", cache.NumProjects(), cache.NumProto(), took())
+	return cache, nil
+}
+
+// findNotFollowed gets the current list of followed projects from lgtm.com,
+// and returns the repos that are neither among the followed projects
+// nor among the followed proto-projects.
+func findNotFollowed(cl *lgtm.Client, repoURLs []string) ([]string, error) {
+	cache, err := getFollowedCacheOf(cl, false)
+	if err != nil {
+		return nil, err
+	}
+	_, _, unknown := cache.Lookup(repoURLs)
+	return unknown, nil
+}
+
+// verifyFollows checks that the followed repos actually show up
+// among the followed projects, following again the missing ones
+// (up to maxRetries times); it returns the repos that are still missing.
+func verifyFollows(
+	ctx context.Context,
+	cl *lgtm.Client,
+	repoURLs []string,
+	maxRetries int,
+	follow func(repoURL string, etac *eta.ETA) (*lgtm.Envelope, error),
+) ([]string, error) {
+	Infof("Verifying that %v projects are followed ...", len(repoURLs))
+	for attempt := 1; ; attempt++ {
+		missing, err := findNotFollowed(cl, repoURLs)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) == 0 || attempt > maxRetries || ctx.Err() != nil {
+			return missing, nil
+		}
+
+		backoff := lgtm.RetryBackoff(attempt)
+		Warnf(
+			"%v projects are not followed; following them again in %s (attempt %v/%v) ...",
+			len(missing),
+			backoff,
+			attempt,
+			maxRetries,
+		)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return missing, nil
+		}
+		etac := eta.New(int64(len(missing)))
+		for _, repoURL := range missing {
+			if ctx.Err() != nil {
+				break
+			}
+			follow(repoURL, etac)
+		}
+		repoURLs = missing
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// OwnerSyncPlan contains the changes needed to keep the followed projects
+// in sync with the repos of a GitHub owner.
+type OwnerSyncPlan struct {
+	// Current contains the URLs of the current non-fork repos of the owner.
+	Current         []string
+	ToFollow        []string
+	ToUnfollow      []*lgtm.Project
+	ToUnfollowProto []*lgtm.ProtoProject
+}
+
+// planOwnerSync compares the current repos of an owner with the followed projects.
+func planOwnerSync(
+	owner string,
+	repos []*github.Repository,
+	cache *lgtm.FollowedProjectCache,
+	unfollowRemoved bool,
+	unfollowArchived bool,
+) *OwnerSyncPlan {
+	plan := &OwnerSyncPlan{
+		Current:         make([]string, 0),
+		ToFollow:        make([]string, 0),
+		ToUnfollow:      make([]*lgtm.Project, 0),
+		ToUnfollowProto: make([]*lgtm.ProtoProject, 0),
+	}
+
+	// existing and archived contain the (lowercase) URLs
+	// of the repos that currently exist/are archived.
+	existing := make(map[string]bool)
+	archived := make(map[string]bool)
+	for _, repo := range repos {
+		repoURL := repo.GetHTMLURL()
+		existing[ToLower(repoURL)] = true
+		if repo.GetFork() {
+			continue
+		}
+		if repo.GetArchived() {
+			archived[ToLower(repoURL)] = true
+			if unfollowArchived {
+				continue
+			}
+		}
+		plan.Current = append(plan.Current, repoURL)
+		if !cache.HasAny(repoURL) {
+			plan.ToFollow = append(plan.ToFollow, repoURL)
+		}
+	}
+
+	if !unfollowRemoved && !unfollowArchived {
+		return plan
+	}
+
+	isToBeUnfollowed := func(repoURL string) bool {
+		if archived[repoURL] {
+			return unfollowArchived
+		}
+		return !existing[repoURL] && unfollowRemoved
+	}
+
+	ownerPrefix := ToLower(githubHost + "/" + owner + "/")
+	for _, pr := range cache.Projects() {
+		repoURL := ToLower(pr.ExternalURL.URL)
+		if strings.HasPrefix(repoURL, ownerPrefix) && isToBeUnfollowed(repoURL) {
+			plan.ToUnfollow = append(plan.ToUnfollow, pr)
+		}
+	}
+	for _, proto := range cache.ProtoProjects() {
+		repoURL := ToLower(trimDotGit(proto.CloneURL))
+		if strings.HasPrefix(repoURL, ownerPrefix) && isToBeUnfollowed(repoURL) {
+			plan.ToUnfollowProto = append(plan.ToUnfollowProto, proto)
+		}
+	}
+	return plan
+}
+
+// FollowedDiff contains the changes needed to make the followed projects
+// match a desired list of repos.
+type FollowedDiff struct {
+	// ToFollow contains the desired repos that are not followed.
+	ToFollow []string
+	// ProtoOnly contains the desired repos that are followed
+	// only as proto-projects (i.e. they have not been built yet).
+	ProtoOnly []string
+	// ToUnfollow contains the followed projects that are not desired.
+	ToUnfollow      []*lgtm.Project
+	ToUnfollowProto []*lgtm.ProtoProject
+}
+
+// planFollowedDiff compares the desired repos with the followed projects.
+func planFollowedDiff(desired []string, cache *lgtm.FollowedProjectCache) *FollowedDiff {
+	diff := &FollowedDiff{
+		ToFollow:        make([]string, 0),
+		ProtoOnly:       make([]string, 0),
+		ToUnfollow:      make([]*lgtm.Project, 0),
+		ToUnfollowProto: make([]*lgtm.ProtoProject, 0),
+	}
+
+	// isDesired contains the (lowercase) URLs of the desired repos.
+	isDesired := make(map[string]bool)
+	for _, repoURL := range desired {
+		isDesired[ToLower(trimDotGit(repoURL))] = true
+		switch {
+		case cache.GetProject(repoURL) != nil:
+			// Already followed.
+		case cache.IsProto(repoURL):
+			diff.ProtoOnly = append(diff.ProtoOnly, repoURL)
+		default:
+			diff.ToFollow = append(diff.ToFollow, repoURL)
+		}
+	}
+
+	for _, pr := range cache.Projects() {
+		if !isDesired[ToLower(trimDotGit(pr.ExternalURL.URL))] {
+			diff.ToUnfollow = append(diff.ToUnfollow, pr)
+		}
+	}
+	for _, proto := range cache.ProtoProjects() {
+		if !isDesired[ToLower(trimDotGit(proto.CloneURL))] {
+			diff.ToUnfollowProto = append(diff.ToUnfollowProto, proto)
+		}
+	}
+	return diff
+}
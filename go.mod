@@ -3,6 +3,7 @@ module github.com/gagliardetto/lgtm-cli
 go 1.15
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/PuerkitoBio/goquery v1.6.1
 	github.com/gagliardetto/bianconiglio v0.0.0-20190606172837-89e88fc437e7
 	github.com/gagliardetto/depnet v0.0.0-20210307170001-ce77129da456
@@ -18,4 +19,5 @@ require (
 	github.com/urfave/cli v1.22.5
 	go.uber.org/ratelimit v0.2.0
 	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,144 @@
+// Package cache contains the caches that are persisted across runs.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+	. "github.com/gagliardetto/utilz"
+)
+
+// DefaultKeyCacheFilepath returns the default path of the project key cache.
+func DefaultKeyCacheFilepath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "lgtm-cli", "keys.json")
+}
+
+// ProjectKeyCache maps the slugs of the projects (e.g. g/foo/bar)
+// to their keys, so that the repos that were already resolved once
+// are looked up by key (in bulk) instead of one getProjectBySlug request each.
+// The methods are safe for concurrent use, and are no-ops on a nil cache.
+type ProjectKeyCache struct {
+	path string
+	mu   *sync.Mutex
+	// Keys maps the normalized instance URL and slug
+	// (e.g. https://lgtm.com/g/foo/bar) to the key of the project.
+	Keys  map[string]string `json:"keys"`
+	dirty bool
+}
+
+// LoadProjectKeyCache loads the cache from the provided file;
+// if the file does not exist, an empty cache is returned.
+func LoadProjectKeyCache(path string) (*ProjectKeyCache, error) {
+	kc := &ProjectKeyCache{
+		path: path,
+		mu:   &sync.Mutex{},
+		Keys: make(map[string]string),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return kc, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, kc); err != nil {
+		return nil, fmt.Errorf("error while parsing %s: %w", path, err)
+	}
+	if kc.Keys == nil {
+		kc.Keys = make(map[string]string)
+	}
+	return kc, nil
+}
+
+// cacheKey returns the key of the slug in the cache; the keys are scoped
+// by instance, since the same slug has different keys on lgtm.com
+// and on an LGTM Enterprise instance.
+func (kc *ProjectKeyCache) cacheKey(cl *lgtm.Client, slug string) string {
+	return ToLower(cl.BaseURL() + "/" + slug)
+}
+
+// Get returns the cached key of the slug.
+func (kc *ProjectKeyCache) Get(cl *lgtm.Client, slug string) (string, bool) {
+	if kc == nil {
+		return "", false
+	}
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	key, ok := kc.Keys[kc.cacheKey(cl, slug)]
+	return key, ok
+}
+
+// Set caches the key of the slug.
+func (kc *ProjectKeyCache) Set(cl *lgtm.Client, slug string, key string) {
+	if kc == nil || key == "" {
+		return
+	}
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if kc.Keys[kc.cacheKey(cl, slug)] != key {
+		kc.Keys[kc.cacheKey(cl, slug)] = key
+		kc.dirty = true
+	}
+}
+
+// Invalidate removes the slug from the cache
+// (e.g. because its project was not found).
+func (kc *ProjectKeyCache) Invalidate(cl *lgtm.Client, slug string) {
+	if kc == nil {
+		return
+	}
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if _, ok := kc.Keys[kc.cacheKey(cl, slug)]; ok {
+		delete(kc.Keys, kc.cacheKey(cl, slug))
+		kc.dirty = true
+	}
+}
+
+// Save writes the cache to the file it was loaded from,
+// if it changed since it was loaded.
+func (kc *ProjectKeyCache) Save() error {
+	if kc == nil {
+		return nil
+	}
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if !kc.dirty {
+		return nil
+	}
+	js, err := json.Marshal(kc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(kc.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temp file and rename it, so that concurrent runs
+	// never read a partially written cache:
+	tmp, err := ioutil.TempFile(filepath.Dir(kc.path), ".keys-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(js); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), kc.path); err != nil {
+		return err
+	}
+	kc.dirty = false
+	return nil
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/lgtm-cli/pkg/lgtm"
+)
+
+func newTestClient(t *testing.T, baseURL string) *lgtm.Client {
+	t.Helper()
+	cl, err := lgtm.NewClient(&lgtm.Config{
+		BaseURL:    baseURL,
+		APIVersion: "1",
+		Session: &lgtm.LGTMSession{
+			Nonce:        "nonce",
+			ShortSession: "short",
+			LongSession:  "long",
+		},
+		GitHub: &lgtm.GithubConfig{Token: "token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cl
+}
+
+func TestProjectKeyCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lgtm-cli", "keys.json")
+	kc, err := LoadProjectKeyCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lgtmCom := newTestClient(t, "")
+	enterprise := newTestClient(t, "https://lgtm.example.com")
+
+	kc.Set(lgtmCom, "g/foo/bar", "1")
+	kc.Set(enterprise, "g/foo/bar", "2")
+	kc.Set(lgtmCom, "g/foo/baz", "")
+	tests := []struct {
+		cl      *lgtm.Client
+		slug    string
+		wantKey string
+		wantOK  bool
+	}{
+		{cl: lgtmCom, slug: "g/foo/bar", wantKey: "1", wantOK: true},
+		{cl: lgtmCom, slug: "g/Foo/Bar", wantKey: "1", wantOK: true},
+		{cl: enterprise, slug: "g/foo/bar", wantKey: "2", wantOK: true},
+		{cl: lgtmCom, slug: "g/foo/baz"},
+	}
+	for _, tt := range tests {
+		key, ok := kc.Get(tt.cl, tt.slug)
+		if key != tt.wantKey || ok != tt.wantOK {
+			t.Errorf("Get(%s, %s) = %q, %v; want %q, %v", tt.cl.BaseURL(), tt.slug, key, ok, tt.wantKey, tt.wantOK)
+		}
+	}
+
+	if err := kc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	kc.Invalidate(lgtmCom, "g/foo/bar")
+	if _, ok := kc.Get(lgtmCom, "g/foo/bar"); ok {
+		t.Error("the invalidated key is still cached")
+	}
+
+	// The invalidation was not saved:
+	loaded, err := LoadProjectKeyCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := loaded.Get(lgtmCom, "g/foo/bar"); key != "1" {
+		t.Errorf("loaded key = %q, want 1", key)
+	}
+	if err := kc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadProjectKeyCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Get(lgtmCom, "g/foo/bar"); ok {
+		t.Error("the invalidation was not saved")
+	}
+	if key, _ := loaded.Get(enterprise, "g/foo/bar"); key != "2" {
+		t.Errorf("loaded key = %q, want 2", key)
+	}
+}
+
+func TestNilProjectKeyCache(t *testing.T) {
+	var kc *ProjectKeyCache
+	cl := newTestClient(t, "")
+	kc.Set(cl, "g/foo/bar", "1")
+	kc.Invalidate(cl, "g/foo/bar")
+	if _, ok := kc.Get(cl, "g/foo/bar"); ok {
+		t.Error("a nil cache has no keys")
+	}
+	if err := kc.Save(); err != nil {
+		t.Errorf("Save() = %v", err)
+	}
+}
+
+func TestLoadProjectKeyCacheInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProjectKeyCache(path); err == nil {
+		t.Error("loading an invalid cache should fail")
+	}
+}
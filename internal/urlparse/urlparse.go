@@ -0,0 +1,196 @@
+// Package urlparse parses the repo URLs, owners and globs
+// that the CLI accepts as targets.
+package urlparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/gagliardetto/utilz"
+	"github.com/goware/urlx"
+)
+
+// DefaultHost is the host of the targets that don't specify one
+// (e.g. kubernetes/kubernetes).
+const DefaultHost = "https://github.com"
+
+// GitURL is a parsed repo URL (or owner URL, if Repo is empty).
+type GitURL struct {
+	Scheme   string
+	Hostname string
+	Port     string
+
+	User string
+	Repo string
+}
+
+// slugPrefixes are the lgtm.com slug prefixes by hostname.
+var slugPrefixes = map[string]string{
+	"github.com":    "g",
+	"gitlab.com":    "gl",
+	"bitbucket.org": "b",
+}
+
+// Slug returns the lgtm.com slug of the repo (e.g. g/kubernetes/kubernetes);
+// an error is returned for hosts that lgtm.com has no slug prefix for.
+func (grl *GitURL) Slug() (string, error) {
+	prefix, ok := slugPrefixes[grl.Hostname]
+	if !ok {
+		return "", fmt.Errorf("no known slug prefix for %s", grl.Hostname)
+	}
+	return Sf("%s/%s/%s", prefix, grl.User, grl.Repo), nil
+}
+
+// URL returns the URL of the repo (or of the owner, if Repo is empty).
+func (grl *GitURL) URL() string {
+	if grl.Port != "" {
+		if grl.Repo != "" {
+			return grl.Scheme + "://" + grl.Hostname + ":" + grl.Port + "/" + grl.User + "/" + grl.Repo
+		}
+		return grl.Scheme + "://" + grl.Hostname + ":" + grl.Port + "/" + grl.User
+	} else {
+		if grl.Repo != "" {
+			return grl.Scheme + "://" + grl.Hostname + "/" + grl.User + "/" + grl.Repo
+		}
+		return grl.Scheme + "://" + grl.Hostname + "/" + grl.User
+	}
+}
+
+// Parse verifies and splits a URL into the git repo info (hostname, user account name, repo name);
+// URLs without a host (e.g. kubernetes/kubernetes, or kubernetes) are on DefaultHost.
+func Parse(rawURL string, mustHaveRepoName bool) (*GitURL, error) {
+	rawURL = strings.TrimSuffix(rawURL, ".git")
+	{
+		if countSlashes(rawURL) == 1 || countSlashes(rawURL) == 0 {
+			rawURL = trimSlashes(DefaultHost) + "/" + trimSlashes(rawURL)
+		}
+	}
+	parsedURL, err := urlx.ParseWithDefaultScheme(rawURL, "https")
+	if err != nil {
+		return nil, err
+	}
+
+	final := &GitURL{}
+
+	final.Scheme = parsedURL.Scheme
+	final.Hostname = SanitizeFileNamePart(parsedURL.Hostname())
+	final.Port = parsedURL.Port()
+
+	path := trimSlashes(parsedURL.Path)
+
+	slashCount := strings.Count(path, "/")
+
+	if !mustHaveRepoName {
+		if slashCount > 1 {
+			return nil, fmt.Errorf("invalid URL: %s contains a wrong number of slashes", path)
+		}
+
+		if slashCount > 0 {
+			slice := strings.Split(path, "/")
+			if len(slice) < 1 {
+				return nil, fmt.Errorf("invalid URL: %s contains a wrong number of slashes", path)
+			}
+			final.User = SanitizeFileNamePart(strings.TrimSpace(slice[0]))
+			if len(slice) > 1 {
+				final.Repo = SanitizeFileNamePart(strings.TrimSpace(slice[1]))
+			}
+		}
+
+		if slashCount == 0 {
+			final.User = SanitizeFileNamePart(path)
+		}
+
+	} else {
+		if slashCount != 1 {
+			return nil, fmt.Errorf("invalid URL: %s contains a wrong number of slashes", path)
+		}
+
+		slice := strings.Split(path, "/")
+		if len(slice) != 2 {
+			return nil, fmt.Errorf("invalid URL: %s contains a wrong number of slashes", path)
+		}
+		final.User = SanitizeFileNamePart(strings.TrimSpace(slice[0]))
+		final.Repo = SanitizeFileNamePart(strings.TrimSpace(slice[1]))
+	}
+
+	if len(final.User) == 0 {
+		return nil, errors.New("user not specified")
+	}
+	if len(final.Repo) == 0 && mustHaveRepoName {
+		return nil, errors.New("repo not specified")
+	}
+
+	return final, nil
+}
+
+// IsUserOnly returns a bool telling whether only the user is specified (i.e. whole account, without a particular repo name).
+func IsUserOnly(rawURL string) (string, bool, error) {
+	grl, err := Parse(rawURL, false)
+	if err != nil {
+		return "", false, err
+	}
+
+	isWholeUser := grl.Repo == ""
+	if isWholeUser {
+		return grl.User, isWholeUser, nil
+	}
+	return "", false, nil
+}
+
+// IsGlob returns whether the target is a glob (e.g. kubernetes/*).
+func IsGlob(s string) bool {
+	return strings.Contains(s, "*")
+}
+
+// CompileRepoURLPatterns converts the provided raw targets (repos, owners, globs)
+// to glob patterns that match repo URLs.
+func CompileRepoURLPatterns(raws []string) ([]string, error) {
+	repoURLPatterns := make([]string, 0)
+	for _, raw := range raws {
+		parsed, err := Parse(raw, false)
+		if err != nil {
+			return nil, err
+		}
+		if IsGlob(raw) {
+			repoURLPatterns = append(repoURLPatterns, parsed.URL())
+		} else {
+			_, isWholeUser, err := IsUserOnly(raw)
+			if err != nil {
+				return nil, err
+			}
+			if isWholeUser {
+				// Transform to a glob that matches all repos of a user:
+				asGlob := parsed.URL() + "/*"
+				repoURLPatterns = append(repoURLPatterns, asGlob)
+			} else {
+				repoURLPatterns = append(repoURLPatterns, parsed.URL())
+			}
+		}
+	}
+	return repoURLPatterns, nil
+}
+
+// AnyRepoURLPattern is a glob that matches the URLs of all repos, on any host.
+const AnyRepoURLPattern = "*/*/*"
+
+// GlobsThatMatchEverything returns all patterns that match
+// any repo.
+func GlobsThatMatchEverything(patterns []string) []string {
+	var res []string
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/*/*") || strings.HasSuffix(pattern, "github.com/*") {
+			res = append(res, pattern)
+		}
+	}
+	return res
+}
+
+// trimSlashes trims initial and final slashes.
+func trimSlashes(s string) string {
+	return strings.Trim(s, "/")
+}
+
+func countSlashes(s string) int {
+	return strings.Count(s, "/")
+}
@@ -0,0 +1,282 @@
+package urlparse
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		raw              string
+		mustHaveRepoName bool
+		want             *GitURL
+		wantURL          string
+		wantErr          bool
+	}{
+		{
+			name:             "slug",
+			raw:              "kubernetes/kubernetes",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes", Repo: "kubernetes"},
+			wantURL:          "https://github.com/kubernetes/kubernetes",
+		},
+		{
+			name:             "full URL",
+			raw:              "https://github.com/kubernetes/dashboard",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes", Repo: "dashboard"},
+			wantURL:          "https://github.com/kubernetes/dashboard",
+		},
+		{
+			name:             "clone URL with .git suffix",
+			raw:              "https://github.com/kubernetes/dashboard.git",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes", Repo: "dashboard"},
+			wantURL:          "https://github.com/kubernetes/dashboard",
+		},
+		{
+			name:             "trailing slash",
+			raw:              "https://github.com/kubernetes/dashboard/",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes", Repo: "dashboard"},
+			wantURL:          "https://github.com/kubernetes/dashboard",
+		},
+		{
+			name:             "without scheme",
+			raw:              "gitlab.com/gitlab-org/gitlab",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "https", Hostname: "gitlab.com", User: "gitlab-org", Repo: "gitlab"},
+			wantURL:          "https://gitlab.com/gitlab-org/gitlab",
+		},
+		{
+			name:             "with port",
+			raw:              "http://git.example.com:8080/foo/bar",
+			mustHaveRepoName: true,
+			want:             &GitURL{Scheme: "http", Hostname: "git.example.com", Port: "8080", User: "foo", Repo: "bar"},
+			wantURL:          "http://git.example.com:8080/foo/bar",
+		},
+		{
+			name:    "owner only",
+			raw:     "kubernetes",
+			want:    &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes"},
+			wantURL: "https://github.com/kubernetes",
+		},
+		{
+			name:    "owner URL",
+			raw:     "https://github.com/kubernetes",
+			want:    &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes"},
+			wantURL: "https://github.com/kubernetes",
+		},
+		{
+			name:    "glob",
+			raw:     "kubernetes/*",
+			want:    &GitURL{Scheme: "https", Hostname: "github.com", User: "kubernetes", Repo: "*"},
+			wantURL: "https://github.com/kubernetes/*",
+		},
+		{
+			name:             "repo required",
+			raw:              "kubernetes",
+			mustHaveRepoName: true,
+			wantErr:          true,
+		},
+		{
+			name:             "too many slashes",
+			raw:              "https://github.com/kubernetes/kubernetes/tree/master",
+			mustHaveRepoName: true,
+			wantErr:          true,
+		},
+		{
+			name:    "too many slashes without repo required",
+			raw:     "https://github.com/kubernetes/kubernetes/tree",
+			wantErr: true,
+		},
+		{
+			name:    "no user",
+			raw:     "https://github.com/",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw, tt.mustHaveRepoName)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): unexpected error: %s", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+			if got.URL() != tt.wantURL {
+				t.Errorf("Parse(%q).URL() = %q, want %q", tt.raw, got.URL(), tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "https://github.com/kubernetes/kubernetes", want: "g/kubernetes/kubernetes"},
+		{raw: "https://gitlab.com/gitlab-org/gitlab", want: "gl/gitlab-org/gitlab"},
+		{raw: "https://bitbucket.org/atlassian/python-bitbucket", want: "b/atlassian/python-bitbucket"},
+		{raw: "https://git.example.com/foo/bar", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			parsed, err := Parse(tt.raw, true)
+			if err != nil {
+				t.Fatalf("Parse(%q): unexpected error: %s", tt.raw, err)
+			}
+			got, err := parsed.Slug()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Slug() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Slug(): unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("Slug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUserOnly(t *testing.T) {
+	tests := []struct {
+		raw        string
+		wantOwner  string
+		wantIsUser bool
+		wantErr    bool
+	}{
+		{raw: "kubernetes", wantOwner: "kubernetes", wantIsUser: true},
+		{raw: "https://github.com/kubernetes", wantOwner: "kubernetes", wantIsUser: true},
+		{raw: "https://github.com/kubernetes/", wantOwner: "kubernetes", wantIsUser: true},
+		{raw: "kubernetes/kubernetes"},
+		{raw: "https://github.com/kubernetes/kubernetes"},
+		{raw: "https://github.com/a/b/c", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			owner, isUser, err := IsUserOnly(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("IsUserOnly(%q): want error", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IsUserOnly(%q): unexpected error: %s", tt.raw, err)
+			}
+			if owner != tt.wantOwner || isUser != tt.wantIsUser {
+				t.Errorf("IsUserOnly(%q) = (%q, %v), want (%q, %v)", tt.raw, owner, isUser, tt.wantOwner, tt.wantIsUser)
+			}
+		})
+	}
+}
+
+func TestCompileRepoURLPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		raws    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "repo",
+			raws: []string{"kubernetes/website"},
+			want: []string{"https://github.com/kubernetes/website"},
+		},
+		{
+			name: "owner",
+			raws: []string{"kubernetes"},
+			want: []string{"https://github.com/kubernetes/*"},
+		},
+		{
+			name: "glob",
+			raws: []string{"kubernetes/kube-*", "https://gitlab.com/gitlab-org/*"},
+			want: []string{"https://github.com/kubernetes/kube-*", "https://gitlab.com/gitlab-org/*"},
+		},
+		{
+			name: "none",
+			raws: []string{},
+			want: []string{},
+		},
+		{
+			name:    "invalid",
+			raws:    []string{"https://github.com/a/b/c"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompileRepoURLPatterns(tt.raws)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CompileRepoURLPatterns(%q) = %q, want error", tt.raws, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileRepoURLPatterns(%q): unexpected error: %s", tt.raws, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompileRepoURLPatterns(%q) = %q, want %q", tt.raws, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobsThatMatchEverything(t *testing.T) {
+	patterns := []string{
+		"https://github.com/*/*",
+		"https://github.com/*",
+		"https://github.com/kubernetes/*",
+		"https://github.com/kubernetes/website",
+		AnyRepoURLPattern,
+	}
+	want := []string{"https://github.com/*/*", "https://github.com/*", AnyRepoURLPattern}
+	if got := GlobsThatMatchEverything(patterns); !reflect.DeepEqual(got, want) {
+		t.Errorf("GlobsThatMatchEverything() = %q, want %q", got, want)
+	}
+}
+
+func TestAnyRepoURLPattern(t *testing.T) {
+	for _, repoURL := range []string{
+		"https://github.com/kubernetes/website",
+		"https://gitlab.com/gitlab-org/gitlab",
+		"https://bitbucket.org/atlassian/python-bitbucket",
+		"https://git.example.com/team/repo",
+	} {
+		if _, ok := HasMatch(repoURL, []string{AnyRepoURLPattern}); !ok {
+			t.Errorf("%s does not match %s", repoURL, AnyRepoURLPattern)
+		}
+	}
+}
+
+func TestIsGlob(t *testing.T) {
+	tests := map[string]bool{
+		"kubernetes/*":       true,
+		"kubernetes/kube-*":  true,
+		"kubernetes/website": false,
+		"kubernetes":         false,
+	}
+	for raw, want := range tests {
+		if got := IsGlob(raw); got != want {
+			t.Errorf("IsGlob(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Package githubutil contains helpers for listing and searching
+// repositories with the GitHub API.
+package githubutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// ResponseCallback, if set, is called with each GitHub API response
+// (e.g. to monitor the rate limit).
+var ResponseCallback func(resp *github.Response)
+
+// RateLimitCallback, if set, is called before waiting
+// for the GitHub rate limit to reset.
+var RateLimitCallback func(wait time.Duration)
+
+// tokenTransport adds the GitHub token to each request.
+type tokenTransport struct {
+	token     string
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+t.token)
+	return t.transport.RoundTrip(req)
+}
+
+// DefaultCacheDir returns the default directory for the GitHub responses cache.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "lgtm-cli", "github")
+}
+
+// NewClient returns a new go-github client that authenticates with the provided token
+// (unauthenticated if empty), and uses the provided transport (http.DefaultTransport if nil);
+// if cacheDir is not empty, responses are cached in that directory
+// and revalidated with conditional requests.
+func NewClient(token string, cacheDir string, transport http.RoundTripper, timeout time.Duration) *github.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if cacheDir != "" {
+		transport = &ETagTransport{
+			Dir:       cacheDir,
+			Transport: transport,
+		}
+	}
+	if token != "" {
+		transport = &tokenTransport{
+			token:     token,
+			transport: transport,
+		}
+	}
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return github.NewClient(httpClient)
+}
+
+func onResponse(resp *github.Response) {
+	if ResponseCallback != nil && resp != nil {
+		ResponseCallback(resp)
+	}
+}
+
+// WaitRateLimit sleeps until the GitHub rate limit resets
+// if the error is a rate limit error, and returns true if the request
+// should be retried.
+func WaitRateLimit(err error) bool {
+	var wait time.Duration
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		wait = time.Until(e.Rate.Reset.Time) + time.Second
+	case *github.AbuseRateLimitError:
+		wait = time.Minute
+		if e.RetryAfter != nil {
+			wait = *e.RetryAfter
+		}
+	default:
+		return false
+	}
+	if RateLimitCallback != nil {
+		RateLimitCallback(wait)
+	}
+	time.Sleep(wait)
+	return true
+}
+
+// ListReposByOwner lists all the repos of a GitHub user or org.
+func ListReposByOwner(client *github.Client, owner string, isOrg bool) ([]*github.Repository, error) {
+	ctx := context.Background()
+	res := make([]*github.Repository, 0)
+	listOpts := github.ListOptions{PerPage: 100}
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		var err error
+		if isOrg {
+			repos, resp, err = client.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{
+				Type:        "all",
+				ListOptions: listOpts,
+			})
+		} else {
+			repos, resp, err = client.Repositories.List(ctx, owner, &github.RepositoryListOptions{
+				Type:        "owner",
+				ListOptions: listOpts,
+			})
+		}
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return nil, err
+		}
+		onResponse(resp)
+		res = append(res, repos...)
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	return res, nil
+}
+
+// ListOrgPublicMembers lists the logins of the public members of a GitHub org.
+func ListOrgPublicMembers(client *github.Client, org string) ([]string, error) {
+	ctx := context.Background()
+	res := make([]string, 0)
+	opts := &github.ListMembersOptions{
+		PublicOnly:  true,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		members, resp, err := client.Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return nil, err
+		}
+		onResponse(resp)
+		for _, member := range members {
+			res = append(res, member.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
+
+// SearchRepos returns the repos that match the provided search query;
+// if limit is zero, it returns all results (max 1K, a GitHub API limit).
+func SearchRepos(client *github.Client, query string, limit int) ([]*github.Repository, error) {
+	ctx := context.Background()
+	res := make([]*github.Repository, 0)
+	opts := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		result, resp, err := client.Search.Repositories(ctx, query, opts)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return nil, err
+		}
+		onResponse(resp)
+		for i := range result.Repositories {
+			res = append(res, &result.Repositories[i])
+			if limit > 0 && len(res) >= limit {
+				return res, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
+
+// SearchCode returns the code results that match the provided search query;
+// if limit is zero, it returns all results (max 1K, a GitHub API limit).
+func SearchCode(client *github.Client, query string, limit int) ([]github.CodeResult, error) {
+	ctx := context.Background()
+	res := make([]github.CodeResult, 0)
+	opts := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		result, resp, err := client.Search.Code(ctx, query, opts)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return nil, err
+		}
+		onResponse(resp)
+		for _, codeResult := range result.CodeResults {
+			res = append(res, codeResult)
+			if limit > 0 && len(res) >= limit {
+				return res, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return res, nil
+}
+
+// GetRepo gets a GitHub repo; it returns nil if the repo does not exist.
+func GetRepo(client *github.Client, owner string, repo string) (*github.Repository, error) {
+	ctx := context.Background()
+	for {
+		got, resp, err := client.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		onResponse(resp)
+		return got, nil
+	}
+}
+
+// IsStarred returns whether the repo is starred by the authenticated user.
+func IsStarred(client *github.Client, owner string, repo string) (bool, error) {
+	ctx := context.Background()
+	for {
+		starred, resp, err := client.Activity.IsStarred(ctx, owner, repo)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return false, err
+		}
+		onResponse(resp)
+		return starred, nil
+	}
+}
+
+// StarRepo stars the repo as the authenticated user.
+func StarRepo(client *github.Client, owner string, repo string) error {
+	ctx := context.Background()
+	for {
+		resp, err := client.Activity.Star(ctx, owner, repo)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return err
+		}
+		onResponse(resp)
+		return nil
+	}
+}
+
+// UploadSARIF uploads a SARIF log to GitHub code scanning,
+// for the provided commit and ref (e.g. refs/heads/main)
+// of the repo; it returns the ID of the upload.
+func UploadSARIF(client *github.Client, owner string, repo string, commitSHA string, ref string, sarif []byte) (string, error) {
+	compressed := new(bytes.Buffer)
+	gz := gzip.NewWriter(compressed)
+	if _, err := gz.Write(sarif); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	body := map[string]string{
+		"commit_sha": commitSHA,
+		"ref":        ref,
+		"sarif":      base64.StdEncoding.EncodeToString(compressed.Bytes()),
+		"tool_name":  "LGTM.com",
+	}
+
+	ctx := context.Background()
+	for {
+		req, err := client.NewRequest(http.MethodPost, Sf("repos/%s/%s/code-scanning/sarifs", owner, repo), body)
+		if err != nil {
+			return "", err
+		}
+		var uploaded struct {
+			ID string `json:"id"`
+		}
+		resp, err := client.Do(ctx, req, &uploaded)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return "", err
+		}
+		onResponse(resp)
+		return uploaded.ID, nil
+	}
+}
@@ -0,0 +1,134 @@
+package githubutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ETagTransport is an http.RoundTripper that caches on disk the responses
+// of GET requests that have an ETag, and revalidates them with conditional
+// requests (If-None-Match); a 304 Not Modified response is served from the cache.
+type ETagTransport struct {
+	// Dir is the directory where the responses are cached.
+	Dir string
+	// Transport is the underlying RoundTripper;
+	// if nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+type cachedResponse struct {
+	ETag       string      `json:"etag"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+func (t *ETagTransport) transport() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ETagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport().RoundTrip(req)
+	}
+
+	key := t.key(req)
+	cached := t.load(key)
+	if cached != nil {
+		// A RoundTripper must not modify the request:
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		// Use the fresh headers (e.g. rate limit info) on top of the cached ones:
+		header := cached.Header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        http.StatusText(cached.StatusCode),
+			StatusCode:    cached.StatusCode,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode == http.StatusOK && etag != "" {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.store(key, &cachedResponse{
+			ETag:       etag,
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+		})
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// key returns the cache key of the request; responses depend
+// on the credentials and on the requested media type.
+func (t *ETagTransport) key(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Authorization")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Accept")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *ETagTransport) load(key string) *cachedResponse {
+	data, err := ioutil.ReadFile(filepath.Join(t.Dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+func (t *ETagTransport) store(key string, cached *cachedResponse) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		return
+	}
+	// Write to a temp file first, to avoid leaving a partially written entry:
+	tmp := filepath.Join(t.Dir, key+".json.tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmp, filepath.Join(t.Dir, key+".json"))
+}
@@ -0,0 +1,80 @@
+package githubutil
+
+import (
+	"context"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+	"github.com/google/go-github/github"
+)
+
+// MaxSearchResults is the max number of results that the GitHub API
+// returns for a single search.
+const MaxSearchResults = 1000
+
+const searchDateFormat = "2006-01-02"
+
+// TruncatedSearchCallback, if set, is called with the query of
+// a single-day search window that has more than MaxSearchResults results,
+// of which only the first MaxSearchResults can be enumerated.
+var TruncatedSearchCallback func(windowQuery string, total int)
+
+// SearchReposSliced calls fn for each repo that matches the search query
+// and was created between from and to (inclusive); the search is sliced
+// by creation date, so that far more results than MaxSearchResults can be
+// enumerated: a date window with too many results is split in two halves,
+// down to windows of a single day.
+// Iteration stops when fn returns false.
+func SearchReposSliced(client *github.Client, query string, from time.Time, to time.Time, fn func(repo *github.Repository) bool) error {
+	_, err := searchReposWindow(client, query, from, to, fn)
+	return err
+}
+
+func searchReposWindow(client *github.Client, query string, from time.Time, to time.Time, fn func(repo *github.Repository) bool) (bool, error) {
+	ctx := context.Background()
+	windowQuery := Sf(
+		"%s created:%s..%s",
+		query,
+		from.Format(searchDateFormat),
+		to.Format(searchDateFormat),
+	)
+	opts := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		result, resp, err := client.Search.Repositories(ctx, windowQuery, opts)
+		if err != nil {
+			if WaitRateLimit(err) {
+				continue
+			}
+			return false, err
+		}
+		onResponse(resp)
+
+		if opts.Page == 0 && result.GetTotal() > MaxSearchResults {
+			days := int(to.Sub(from).Hours() / 24)
+			if days >= 1 {
+				// Too many results: split the window.
+				mid := from.AddDate(0, 0, days/2)
+				more, err := searchReposWindow(client, query, from, mid, fn)
+				if err != nil || !more {
+					return more, err
+				}
+				return searchReposWindow(client, query, mid.AddDate(0, 0, 1), to, fn)
+			}
+			if TruncatedSearchCallback != nil {
+				TruncatedSearchCallback(windowQuery, result.GetTotal())
+			}
+		}
+
+		for i := range result.Repositories {
+			if !fn(&result.Repositories[i]) {
+				return false, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return true, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,204 @@
+package lgtm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/ref"
+	. "github.com/gagliardetto/utilz"
+)
+
+// normalizeProjectURL returns the key of the project URL
+// in the indexes of the cache.
+func normalizeProjectURL(projectURL string) string {
+	return ToLower(projectURL)
+}
+
+// normalizeCloneURL returns the key of the clone URL
+// in the indexes of the cache.
+func normalizeCloneURL(cloneURL string) string {
+	return ToLower(strings.TrimSuffix(cloneURL, ".git"))
+}
+
+type FollowedProjectCache struct {
+	mu       *sync.RWMutex
+	projects []*Project
+	proto    []*ProtoProject
+	client   *Client
+
+	// Indexes of the projects and proto-projects by normalized URL,
+	// built on refresh:
+	projectsByURL map[string]*Project
+	protoByURL    map[string]*ProtoProject
+}
+
+// setFollowed sets the followed projects and proto-projects,
+// and indexes them by URL; the caller must hold the write lock.
+func (fpc *FollowedProjectCache) setFollowed(projects []*Project, protoProjects []*ProtoProject) {
+	fpc.projects = projects
+	fpc.proto = protoProjects
+
+	fpc.projectsByURL = make(map[string]*Project, len(projects))
+	for _, pr := range projects {
+		key := normalizeProjectURL(pr.ExternalURL.URL)
+		if _, ok := fpc.projectsByURL[key]; !ok {
+			fpc.projectsByURL[key] = pr
+		}
+	}
+	fpc.protoByURL = make(map[string]*ProtoProject, len(protoProjects))
+	for _, pr := range protoProjects {
+		key := normalizeCloneURL(pr.CloneURL)
+		if _, ok := fpc.protoByURL[key]; !ok {
+			fpc.protoByURL[key] = pr
+		}
+	}
+}
+
+//
+func (fpc *FollowedProjectCache) IsFollowed(repoURL string) bool {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	_, isFollowed := fpc.projectsByURL[normalizeProjectURL(repoURL)]
+	_, isFollowedProto := fpc.protoByURL[normalizeCloneURL(repoURL)]
+	return isFollowed || isFollowedProto
+}
+
+// Has returns true if the project/proto-project is followed.
+func (fpc *FollowedProjectCache) HasAny(repoURL string) bool {
+	return fpc.IsFollowed(repoURL)
+}
+
+// Get returns a Project if it is present in the followed projects cache.
+func (fpc *FollowedProjectCache) GetProject(repoURL string) *Project {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return fpc.projectsByURL[normalizeProjectURL(repoURL)]
+}
+
+// GetProto returns a ProtoProject if it is present in the followed proto-projects cache.
+func (fpc *FollowedProjectCache) GetProto(repoURL string) *ProtoProject {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return fpc.protoByURL[normalizeCloneURL(repoURL)]
+}
+
+// Lookup finds the followed projects and proto-projects among the provided URLs;
+// the URLs that are not followed are returned as unknown.
+func (fpc *FollowedProjectCache) Lookup(repoURLs []string) (map[string]*Project, map[string]*ProtoProject, []string) {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	projects := make(map[string]*Project)
+	proto := make(map[string]*ProtoProject)
+	unknown := make([]string, 0)
+	for _, repoURL := range repoURLs {
+		if pr, ok := fpc.projectsByURL[normalizeProjectURL(repoURL)]; ok {
+			projects[repoURL] = pr
+			continue
+		}
+		if pr, ok := fpc.protoByURL[normalizeCloneURL(repoURL)]; ok {
+			proto[repoURL] = pr
+			continue
+		}
+		unknown = append(unknown, repoURL)
+	}
+	return projects, proto, unknown
+}
+
+//
+func (fpc *FollowedProjectCache) IsProto(repoURL string) bool {
+	pr := fpc.GetProto(repoURL)
+	return pr != nil
+}
+
+//
+func (fpc *FollowedProjectCache) Refresh() error {
+	if fpc.client == nil {
+		return errors.New("the cache has no client, and cannot be refreshed")
+	}
+	projects, protoProjects, err := fpc.client.ListFollowedProjects()
+	if err != nil {
+		return fmt.Errorf("error while getting list of followed projects: %w", err)
+	}
+
+	fpc.mu.Lock()
+	defer fpc.mu.Unlock()
+	fpc.setFollowed(projects, protoProjects)
+
+	return nil
+}
+func (fpc *FollowedProjectCache) RemoveFollowed(candidates []string) []string {
+	toBeFollowed := ref.Filter(candidates, func(i int, repoURL string) bool {
+		isNOTFollowed := !fpc.HasAny(repoURL)
+		return isNOTFollowed
+	}).([]string)
+	return Deduplicate(toBeFollowed)
+}
+func (fpc *FollowedProjectCache) NumProjects() int {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return len(fpc.projects)
+}
+func (fpc *FollowedProjectCache) NumProto() int {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return len(fpc.proto)
+}
+func (fpc *FollowedProjectCache) Projects() []*Project {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return ref.Filter(fpc.projects, func(i int) bool {
+		return true
+	}).([]*Project)
+}
+func (fpc *FollowedProjectCache) ProtoProjects() []*ProtoProject {
+	fpc.mu.RLock()
+	defer fpc.mu.RUnlock()
+
+	return ref.Filter(fpc.proto, func(i int) bool {
+		return true
+	}).([]*ProtoProject)
+}
+func (cl *Client) GetFollowedCache(dont bool) (*FollowedProjectCache, error) {
+	if dont {
+		return nil, errors.New("decided to not fetch the cache")
+	}
+	fpc := NewFollowedProjectCache(cl)
+	err := fpc.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	return fpc, nil
+}
+
+// MergeFollowedCaches returns a cache with the followed projects
+// and proto-projects of all the provided caches (e.g. of several accounts);
+// the merged cache is a snapshot, and cannot be refreshed.
+func MergeFollowedCaches(caches ...*FollowedProjectCache) *FollowedProjectCache {
+	projects := make([]*Project, 0)
+	protoProjects := make([]*ProtoProject, 0)
+	for _, fpc := range caches {
+		projects = append(projects, fpc.Projects()...)
+		protoProjects = append(protoProjects, fpc.ProtoProjects()...)
+	}
+	merged := &FollowedProjectCache{
+		mu: &sync.RWMutex{},
+	}
+	merged.setFollowed(projects, protoProjects)
+	return merged
+}
+
+func NewFollowedProjectCache(cl *Client) *FollowedProjectCache {
+	return &FollowedProjectCache{
+		client: cl,
+		mu:     &sync.RWMutex{},
+	}
+}
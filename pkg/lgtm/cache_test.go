@@ -0,0 +1,113 @@
+package lgtm
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newTestCache(projects []*Project, protoProjects []*ProtoProject) *FollowedProjectCache {
+	fpc := &FollowedProjectCache{
+		mu: &sync.RWMutex{},
+	}
+	fpc.setFollowed(projects, protoProjects)
+	return fpc
+}
+
+func newTestProject(key string, repoURL string) *Project {
+	return &Project{
+		Key:         key,
+		ExternalURL: ExternalURL{URL: repoURL},
+	}
+}
+
+func TestFollowedProjectCacheLookups(t *testing.T) {
+	kubernetes := newTestProject("1", "https://github.com/kubernetes/kubernetes")
+	dashboard := newTestProject("2", "https://github.com/Kubernetes/Dashboard")
+	proto := &ProtoProject{Key: "3", CloneURL: "https://github.com/foo/bar.git"}
+	fpc := newTestCache([]*Project{kubernetes, dashboard}, []*ProtoProject{proto})
+
+	tests := []struct {
+		repoURL     string
+		wantProject *Project
+		wantProto   *ProtoProject
+	}{
+		{repoURL: "https://github.com/kubernetes/kubernetes", wantProject: kubernetes},
+		{repoURL: "https://github.com/Kubernetes/Kubernetes", wantProject: kubernetes},
+		{repoURL: "https://github.com/kubernetes/dashboard", wantProject: dashboard},
+		{repoURL: "https://github.com/foo/bar", wantProto: proto},
+		{repoURL: "https://github.com/foo/bar.git", wantProto: proto},
+		{repoURL: "https://github.com/FOO/bar", wantProto: proto},
+		{repoURL: "https://github.com/kubernetes/website"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			if got := fpc.GetProject(tt.repoURL); got != tt.wantProject {
+				t.Errorf("GetProject() = %+v, want %+v", got, tt.wantProject)
+			}
+			if got := fpc.GetProto(tt.repoURL); got != tt.wantProto {
+				t.Errorf("GetProto() = %+v, want %+v", got, tt.wantProto)
+			}
+			wantFollowed := tt.wantProject != nil || tt.wantProto != nil
+			if got := fpc.IsFollowed(tt.repoURL); got != wantFollowed {
+				t.Errorf("IsFollowed() = %v, want %v", got, wantFollowed)
+			}
+			if got := fpc.IsProto(tt.repoURL); got != (tt.wantProto != nil) {
+				t.Errorf("IsProto() = %v, want %v", got, tt.wantProto != nil)
+			}
+		})
+	}
+}
+
+func TestFollowedProjectCacheFirstMatchWins(t *testing.T) {
+	first := newTestProject("1", "https://github.com/foo/bar")
+	second := newTestProject("2", "https://github.com/Foo/Bar")
+	fpc := newTestCache([]*Project{first, second}, nil)
+
+	if got := fpc.GetProject("https://github.com/foo/bar"); got != first {
+		t.Errorf("GetProject() = %+v, want the first project", got)
+	}
+	if got := fpc.NumProjects(); got != 2 {
+		t.Errorf("NumProjects() = %v, want 2", got)
+	}
+}
+
+func TestFollowedProjectCacheLookup(t *testing.T) {
+	kubernetes := newTestProject("1", "https://github.com/kubernetes/kubernetes")
+	proto := &ProtoProject{Key: "3", CloneURL: "https://github.com/foo/bar.git"}
+	fpc := newTestCache([]*Project{kubernetes}, []*ProtoProject{proto})
+
+	projects, protoProjects, unknown := fpc.Lookup([]string{
+		"https://github.com/kubernetes/kubernetes",
+		"https://github.com/foo/bar",
+		"https://github.com/kubernetes/website",
+	})
+	wantProjects := map[string]*Project{"https://github.com/kubernetes/kubernetes": kubernetes}
+	if !reflect.DeepEqual(projects, wantProjects) {
+		t.Errorf("Lookup() projects = %v, want %v", projects, wantProjects)
+	}
+	wantProto := map[string]*ProtoProject{"https://github.com/foo/bar": proto}
+	if !reflect.DeepEqual(protoProjects, wantProto) {
+		t.Errorf("Lookup() proto-projects = %v, want %v", protoProjects, wantProto)
+	}
+	wantUnknown := []string{"https://github.com/kubernetes/website"}
+	if !reflect.DeepEqual(unknown, wantUnknown) {
+		t.Errorf("Lookup() unknown = %q, want %q", unknown, wantUnknown)
+	}
+}
+
+func TestFollowedProjectCacheRemoveFollowed(t *testing.T) {
+	fpc := newTestCache(
+		[]*Project{newTestProject("1", "https://github.com/kubernetes/kubernetes")},
+		[]*ProtoProject{{Key: "3", CloneURL: "https://github.com/foo/bar.git"}},
+	)
+	got := fpc.RemoveFollowed([]string{
+		"https://github.com/kubernetes/website",
+		"https://github.com/kubernetes/kubernetes",
+		"https://github.com/foo/bar",
+	})
+	want := []string{"https://github.com/kubernetes/website"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveFollowed() = %q, want %q", got, want)
+	}
+}
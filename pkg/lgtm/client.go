@@ -1,6 +1,8 @@
-package main
+// Package lgtm is an unofficial client for the lgtm.com API.
+package lgtm
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,12 +15,20 @@ import (
 
 	"github.com/gagliardetto/request"
 	. "github.com/gagliardetto/utilz"
+	"go.uber.org/ratelimit"
 )
 
+// Client is a client for the lgtm.com API.
 type Client struct {
 	conf *Config
+	// rateLimiter is the rate limiter of the client;
+	// if nil, the shared RateLimiter is used.
+	rateLimiter ratelimit.Limiter
+	// ctx is the context of the client (see WithContext).
+	ctx context.Context
 }
 
+// NewClient returns a new Client for the account of the provided config.
 func NewClient(conf *Config) (*Client, error) {
 	if conf == nil {
 		return nil, errors.New("conf is nil")
@@ -35,21 +45,34 @@ func NewClient(conf *Config) (*Client, error) {
 
 var (
 	DefaultMaxIdleConnsPerHost = 50
-	Timeout                    = 5 * time.Minute
-	DefaultKeepAlive           = 180 * time.Second
+	// Timeout is the timeout of a whole request (including reading the response body).
+	Timeout = 5 * time.Minute
+	// ConnectTimeout is the timeout for establishing a connection.
+	ConnectTimeout   = 30 * time.Second
+	DefaultKeepAlive = 180 * time.Second
 )
 
 var (
-	httpClient = NewHTTP()
+	// HTTPClient is the http.Client used for all the requests.
+	HTTPClient = NewHTTP()
+	// RateLimiter limits the rate of requests to the lgtm.com API.
+	RateLimiter = ratelimit.New(1, ratelimit.WithSlack(3))
 )
 
 func NewHTTPTransport() *http.Transport {
+	return NewHTTPTransportWithConnectTimeout(ConnectTimeout)
+}
+
+// NewHTTPTransportWithConnectTimeout returns a new transport
+// that gives up on establishing a connection after connectTimeout.
+func NewHTTPTransportWithConnectTimeout(connectTimeout time.Duration) *http.Transport {
 	return &http.Transport{
 		IdleConnTimeout:     Timeout,
+		TLSHandshakeTimeout: connectTimeout,
 		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
-		Proxy:               http.ProxyFromEnvironment,
+		Proxy:               Proxy,
 		Dial: (&net.Dialer{
-			Timeout:   Timeout,
+			Timeout:   connectTimeout,
 			KeepAlive: DefaultKeepAlive,
 		}).Dial,
 		//TLSClientConfig: &tls.Config{
@@ -69,20 +92,70 @@ func NewHTTP() *http.Client {
 	}
 }
 
+// SetRateLimiter makes the client use its own rate limiter
+// instead of the shared RateLimiter (e.g. one per account).
+func (cl *Client) SetRateLimiter(rl ratelimit.Limiter) {
+	cl.rateLimiter = rl
+}
+
+// WithRateLimiter returns a copy of the client that uses the provided
+// rate limiter (instead of the one of the client), e.g. for a batch
+// of requests that has its own rate.
+func (cl *Client) WithRateLimiter(rl ratelimit.Limiter) *Client {
+	clone := *cl
+	clone.rateLimiter = rl
+	return &clone
+}
+
+// WithContext returns a copy of the client that does not start new requests
+// once the provided context is done (e.g. on Ctrl-C): they fail with the error
+// of the context, while the requests that are in flight are completed.
+func (cl *Client) WithContext(ctx context.Context) *Client {
+	clone := *cl
+	clone.ctx = ctx
+	return &clone
+}
+
+// Context returns the context of the client
+// (context.Background() if it was not set with WithContext).
+func (cl *Client) Context() context.Context {
+	if cl.ctx == nil {
+		return context.Background()
+	}
+	return cl.ctx
+}
+
+// BaseURL returns the base URL of the lgtm.com (or LGTM Enterprise)
+// instance the client talks to, without the trailing slash.
+func (cl *Client) BaseURL() string {
+	return cl.conf.GetBaseURL()
+}
+
 func (cl *Client) newRequest() (*request.Request, error) {
-	apiRateLimiter.Take()
+	if err := cl.Context().Err(); err != nil {
+		return nil, err
+	}
+	if cl.rateLimiter != nil {
+		cl.rateLimiter.Take()
+	} else {
+		RateLimiter.Take()
+	}
+	// The context might have been canceled while waiting:
+	if err := cl.Context().Err(); err != nil {
+		return nil, err
+	}
 
-	req := request.NewRequest(httpClient)
+	req := request.NewRequest(HTTPClient)
 	req.Headers = map[string]string{
-		"authority":        "lgtm.com",
+		"authority":        cl.conf.GetBaseHost(),
 		"accept":           "*/*",
 		"lgtm-nonce":       cl.conf.Session.Nonce,
 		"dnt":              "1",
 		"x-requested-with": "XMLHttpRequest",
-		"user-agent":       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/73.0.3683.103 Safari/537.36",
+		"user-agent":       DefaultUserAgent,
 		"sec-fetch-site":   "same-origin",
 		"sec-fetch-mode":   "cors",
-		"referer":          "https://lgtm.com/dashboard",
+		"referer":          cl.BaseURL() + "/dashboard",
 		"accept-encoding":  "gzip",
 	}
 
@@ -91,6 +164,7 @@ func (cl *Client) newRequest() (*request.Request, error) {
 		"lgtm_short_session": cl.conf.Session.ShortSession,
 		"_consent_settings":  "accepted",
 	}
+	applyHeaders(req, cl.conf.Headers)
 
 	return req, nil
 }
@@ -101,43 +175,28 @@ func (cl *Client) ListFollowedProjects() ([]*Project, []*ProtoProject, error) {
 		return nil, nil, err
 	}
 
-	resp, err := req.Get("https://lgtm.com/internal_api/v0.2/getMyProjects?apiVersion=" + cl.conf.APIVersion)
+	resp, err := req.Get(cl.BaseURL() + "/internal_api/v0.2/getMyProjects?apiVersion=" + cl.conf.APIVersion)
 	if err != nil {
 		return nil, nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
 	if err != nil {
 		return nil, nil, fmt.Errorf("error while getting Reader: %w", err)
 	}
-	var response ProjectListResponse
-	err = func() error {
-		defer closer()
-		defer resp.Body.Close()
-		decoder := json.NewDecoder(reader)
+	defer closer()
+	defer resp.Body.Close()
 
-		return decoder.Decode(&response)
-	}()
+	projectList, protoProjectList, err := decodeMyProjects(reader)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error while unmarshaling: %w", err)
-	}
-	projectList := make([]*Project, 0)
-	protoProjectList := make([]*ProtoProject, 0)
-	for _, envelope := range response.Data {
-		prj := envelope.MustGetProject()
-		if prj != nil {
-			projectList = append(projectList, prj)
-		}
-
-		protoPrj := envelope.MustGetProtoProject()
-		if protoPrj != nil {
-			protoProjectList = append(protoProjectList, protoPrj)
+		if status, ok := err.(*StatusResponse); ok {
+			return nil, nil, status
 		}
+		return nil, nil, fmt.Errorf("error while unmarshaling: %w", err)
 	}
-
 	return projectList, protoProjectList, nil
 }
 
@@ -157,12 +216,12 @@ func (cl *Client) UnfollowProject(key string) error {
 		"apiVersion":  cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/unfollowProject")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/unfollowProject")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -198,12 +257,12 @@ func (cl *Client) UnfollowProtoProject(key string) error {
 		"apiVersion":       cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/unfollowProtoproject")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/unfollowProtoproject")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -245,12 +304,12 @@ func (cl *Client) FollowProject(u string) (*Envelope, error) {
 		"apiVersion": cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/followProject")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/followProject")
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -272,6 +331,11 @@ func (cl *Client) FollowProject(u string) (*Envelope, error) {
 	if response.Status != STATUS_SUCCESS_STRING {
 		return nil, response.StatusResponse
 	}
+	if response.Data != nil {
+		if err := response.Data.Parse(); err != nil {
+			return nil, err
+		}
+	}
 
 	return response.Data, nil
 }
@@ -287,12 +351,12 @@ func (cl *Client) DeleteProjectSelection(name string) error {
 		"apiVersion": cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/deleteProjectSelection")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/deleteProjectSelection")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -329,12 +393,12 @@ func (cl *Client) CreateProjectSelection(name string) error {
 		"apiVersion": cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/createProjectSelection")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/createProjectSelection")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -363,13 +427,21 @@ func formatStringArray(sl ...string) string {
 	if len(sl) == 0 {
 		return "[]"
 	}
-	marshaled, err := json.Marshal(sl)
-	if err != nil {
-		panic(err)
-	}
+	// Marshaling a slice of strings can't fail:
+	marshaled, _ := json.Marshal(sl)
 	return string(marshaled)
 }
 func (cl *Client) AddProjectToSelection(selectionID string, projectKeys ...string) error {
+	return cl.updateProjectSelection(selectionID, projectKeys, nil)
+}
+
+// RemoveProjectsFromSelection removes the projects with the provided keys
+// from the list with the provided ID.
+func (cl *Client) RemoveProjectsFromSelection(selectionID string, projectKeys ...string) error {
+	return cl.updateProjectSelection(selectionID, nil, projectKeys)
+}
+
+func (cl *Client) updateProjectSelection(selectionID string, addedKeys []string, removedKeys []string) error {
 
 	req, err := cl.newRequest()
 	if err != nil {
@@ -377,17 +449,17 @@ func (cl *Client) AddProjectToSelection(selectionID string, projectKeys ...strin
 	}
 	req.Data = map[string]string{
 		"projectSelectionId": selectionID,
-		"addedProjects":      formatStringArray(projectKeys...),
-		"removedProjects":    "[]",
+		"addedProjects":      formatStringArray(addedKeys...),
+		"removedProjects":    formatStringArray(removedKeys...),
 		"apiVersion":         cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/updateProjectSelection")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/updateProjectSelection")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -431,7 +503,7 @@ func (cl *Client) GetSearchSuggestions(str string) ([]*SearchSuggestionItem, err
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/getSearchSuggestions?searchSuggestions=%s&apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/getSearchSuggestions?searchSuggestions=%s&apiVersion=%s",
 			str,
 			cl.conf.APIVersion,
 		),
@@ -440,7 +512,7 @@ func (cl *Client) GetSearchSuggestions(str string) ([]*SearchSuggestionItem, err
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -486,6 +558,16 @@ func (lists ProjectSelectionBareSlice) ByName(name string) *ProjectSelectionBare
 	return nil
 }
 
+// ByKey returns the list with the provided key (or nil).
+func (lists ProjectSelectionBareSlice) ByKey(key string) *ProjectSelectionBare {
+	for _, v := range lists {
+		if v.Key == key {
+			return v
+		}
+	}
+	return nil
+}
+
 func (cl *Client) ListProjectSelections() (ProjectSelectionBareSlice, error) {
 
 	req, err := cl.newRequest()
@@ -496,12 +578,12 @@ func (cl *Client) ListProjectSelections() (ProjectSelectionBareSlice, error) {
 		"apiVersion": cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/getUsedProjectSelections")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/getUsedProjectSelections")
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -549,7 +631,7 @@ func (cl *Client) ListProjectsInSelection(name string) (*ProjectSelectionFull, e
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/getProjectSelectionByName?name=%s&apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/getProjectSelectionByName?name=%s&apiVersion=%s",
 			name,
 			cl.conf.APIVersion,
 		),
@@ -558,7 +640,7 @@ func (cl *Client) ListProjectsInSelection(name string) (*ProjectSelectionFull, e
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -612,11 +694,18 @@ type QueryResponseData struct {
 	ProjectSelectionKeys []string           `json:"projectSelectionKeys"`
 	QueryAllProjects     bool               `json:"queryAllProjects"`
 	Stats                QueryResponseStats `json:"stats"`
+
+	// baseURL is the base URL of the instance that ran the query.
+	baseURL string
 }
 
 //
 func (qrd *QueryResponseData) GetResultLink() string {
-	return Sf("https://lgtm.com/query/%s/", qrd.Key)
+	baseURL := qrd.baseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return Sf("%s/query/%s/", baseURL, qrd.Key)
 }
 
 func (cl *Client) Query(conf *QueryConfig) (*QueryResponseData, error) {
@@ -635,12 +724,12 @@ func (cl *Client) Query(conf *QueryConfig) (*QueryResponseData, error) {
 		"apiVersion":           cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/runQuery")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/runQuery")
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -663,6 +752,7 @@ func (cl *Client) Query(conf *QueryConfig) (*QueryResponseData, error) {
 		return nil, response.StatusResponse
 	}
 
+	response.Data.baseURL = cl.BaseURL()
 	return &response.Data, nil
 }
 
@@ -673,52 +763,65 @@ type Envelope struct {
 	parsedProtoProject *ProtoProject
 }
 
-//
-func (env *Envelope) MustGetProject() *Project {
-	if env.parsedproject != nil {
-		return env.parsedproject
-	}
-	if env.RawRealProject == nil {
-		return nil
+// Parse parses the project and the proto-project of the envelope;
+// the envelopes returned by FollowProject are already parsed.
+func (env *Envelope) Parse() error {
+	if env.parsedproject == nil && env.RawRealProject != nil {
+		var slice []interface{}
+		if err := TranscodeJSON(env.RawRealProject, &slice); err != nil {
+			return fmt.Errorf("error while parsing realProject: %w", err)
+		}
+		if len(slice) > 0 {
+			var parsedproject Project
+			if err := TranscodeJSON(slice[0], &parsedproject); err != nil {
+				return fmt.Errorf("error while parsing realProject: %w", err)
+			}
+			env.parsedproject = &parsedproject
+		}
 	}
-
-	var slice []interface{}
-	err := TranscodeJSON(env.RawRealProject, &slice)
-	if err != nil {
-		panic(err)
+	if env.parsedProtoProject == nil && env.RawProtoProject != nil {
+		var proto ProtoProject
+		if err := TranscodeJSON(env.RawProtoProject, &proto); err != nil {
+			return fmt.Errorf("error while parsing protoproject: %w", err)
+		}
+		env.parsedProtoProject = &proto
 	}
-	firstObjectInterface := slice[0]
+	return nil
+}
 
-	var parsedproject Project
-	err = TranscodeJSON(firstObjectInterface, &parsedproject)
-	if err != nil {
-		panic(err)
+// GetProject returns the project of the envelope
+// (nil if the project is not built yet, or if it can't be parsed).
+func (env *Envelope) GetProject() *Project {
+	if env.Parse() != nil {
+		return nil
 	}
-	env.parsedproject = &parsedproject
 	return env.parsedproject
 }
 
 // IsKnown returns whether the projects was already known to lgtm.com
 func (env *Envelope) IsKnown() bool {
-	isFirstBuild := env.MustGetProject() == nil && env.MustGetProtoProject() != nil
+	isFirstBuild := env.GetProject() == nil && env.GetProtoProject() != nil
 	return !isFirstBuild
 }
 
-func (env *Envelope) MustGetProtoProject() *ProtoProject {
-	if env.parsedProtoProject != nil {
-		return env.parsedProtoProject
+// Key returns the key of the project (or of the proto-project
+// if the project is not built yet).
+func (env *Envelope) Key() string {
+	if pr := env.GetProject(); pr != nil {
+		return pr.Key
 	}
-	if env.RawProtoProject == nil {
-		return nil
+	if proto := env.GetProtoProject(); proto != nil {
+		return proto.Key
 	}
+	return ""
+}
 
-	var proto ProtoProject
-	err := TranscodeJSON(env.RawProtoProject, &proto)
-	if err != nil {
-		panic(err)
+// GetProtoProject returns the proto-project of the envelope
+// (nil if there is none, or if it can't be parsed).
+func (env *Envelope) GetProtoProject() *ProtoProject {
+	if env.Parse() != nil {
+		return nil
 	}
-	env.parsedProtoProject = &proto
-
 	return env.parsedProtoProject
 }
 
@@ -775,12 +878,12 @@ func (cl *Client) RebuildProtoProject(key string) error {
 		"apiVersion":       cl.conf.APIVersion,
 	}
 
-	resp, err := req.Post("https://lgtm.com/internal_api/v0.2/rebuildProtoproject")
+	resp, err := req.Post(cl.BaseURL() + "/internal_api/v0.2/rebuildProtoproject")
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -824,7 +927,7 @@ func (cl *Client) NewBuildAttempt(projectKey string, lang string) error {
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/newBuildAttempt?projectKey=%s&language=%s&apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/newBuildAttempt?projectKey=%s&language=%s&apiVersion=%s",
 			projectKey,
 			lang,
 			cl.conf.APIVersion,
@@ -833,7 +936,7 @@ func (cl *Client) NewBuildAttempt(projectKey string, lang string) error {
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -866,7 +969,7 @@ func (cl *Client) RequestTestBuild(urlIdentifier string, langs ...string) error
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/"+
+			cl.BaseURL()+"/internal_api/v0.2/"+
 				"urlIdentifier=%s&languages=%s&config=&apiVersion=%s",
 			urlIdentifier,
 			url.QueryEscape(formatStringArray(langs...)),
@@ -876,7 +979,7 @@ func (cl *Client) RequestTestBuild(urlIdentifier string, langs ...string) error
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return formatHTTPNotOKStatusCodeError(resp)
+		return FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -941,7 +1044,7 @@ func (cl *Client) GetProjectLatestStateStats(projectKey string) (*LatestStateSta
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/getProjectLatestStateStats?key=%s&apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/getProjectLatestStateStats?key=%s&apiVersion=%s",
 			projectKey,
 			cl.conf.APIVersion,
 		),
@@ -950,7 +1053,7 @@ func (cl *Client) GetProjectLatestStateStats(projectKey string) (*LatestStateSta
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -1002,7 +1105,7 @@ func (cl *Client) GetProjectsByKey(keys ...string) (*GetProjectsByKeyResponseDat
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/getProjectsByKey?keys=%s&apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/getProjectsByKey?keys=%s&apiVersion=%s",
 			formatStringArray(keys...),
 			cl.conf.APIVersion,
 		),
@@ -1011,7 +1114,7 @@ func (cl *Client) GetProjectsByKey(keys ...string) (*GetProjectsByKeyResponseDat
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -1053,7 +1156,7 @@ func (cl *Client) GetQueryResults(queryID string, orderBy OrderBy, startCursor s
 		return nil, err
 	}
 
-	base := "https://lgtm.com/internal_api/v0.2/getQueryResults"
+	base := cl.BaseURL() + "/internal_api/v0.2/getQueryResults"
 	vals := url.Values{}
 	{
 		vals.Set("queryId", queryID)
@@ -1071,7 +1174,7 @@ func (cl *Client) GetQueryResults(queryID string, orderBy OrderBy, startCursor s
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -1159,7 +1262,8 @@ func (status *StatusResponse) IsFork() bool {
 		strings.Contains(status.Message, "This project appears to be a fork")
 }
 
-func asStatusResponseError(err error) *StatusResponse {
+// AsStatusResponseError returns the *StatusResponse in the error chain (if any).
+func AsStatusResponseError(err error) *StatusResponse {
 	var e *StatusResponse
 	// Note: *StatusResponse is the type of the error.
 	if errors.As(err, &e) {
@@ -1192,7 +1296,7 @@ func (cl *Client) GetProjectBySlug(slug string) (*Project, error) {
 		return nil, fmt.Errorf("error while cl.newRequest: %w", err)
 	}
 
-	base := "https://lgtm.com/internal_api/v0.2/getProjectBySlug"
+	base := cl.BaseURL() + "/internal_api/v0.2/getProjectBySlug"
 	vals := url.Values{}
 	{
 		vals.Set("slug", slug)
@@ -1205,7 +1309,7 @@ func (cl *Client) GetProjectBySlug(slug string) (*Project, error) {
 		return nil, fmt.Errorf("error while req.Get: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
@@ -1239,12 +1343,12 @@ func (cl *Client) GetProjectBySlug(slug string) (*Project, error) {
 	return response.Data.Right.Redirect, nil
 }
 
-// formatHTTPNotOKStatusCodeError is used to format an error when the status code is not 200.
-func formatHTTPNotOKStatusCodeError(resp *request.Response) error {
+// FormatHTTPNotOKStatusCodeError is used to format an error when the status code is not 200.
+func FormatHTTPNotOKStatusCodeError(resp *request.Response) error {
 	{ // Try parsing the response body as a StatusResponse:
 		reader, closer, err := resp.DecompressedReaderFromPool()
 		if err != nil {
-			panic(fmt.Errorf("error while getting Reader: %w", err))
+			return addRequestInfoToError(resp, fmt.Errorf("error while getting Reader: %w", err))
 		}
 		var errResponse StatusResponse
 		err = func() error {
@@ -1271,6 +1375,15 @@ type EnrichedError struct {
 
 func (e *EnrichedError) Unwrap() error { return e.err }
 
+// StatusCode returns the HTTP status code of the response
+// (zero if the response is not available).
+func (e *EnrichedError) StatusCode() int {
+	if e.resp == nil {
+		return 0
+	}
+	return e.resp.StatusCode
+}
+
 //
 func (eerr *EnrichedError) Error() string {
 	if eerr.err == nil {
@@ -1329,7 +1442,7 @@ func (cl *Client) GetLoggedInUser() (*GetLoggedInUserResponseData, error) {
 
 	resp, err := req.Get(
 		Sf(
-			"https://lgtm.com/internal_api/v0.2/getLoggedInUser?apiVersion=%s",
+			cl.BaseURL()+"/internal_api/v0.2/getLoggedInUser?apiVersion=%s",
 			cl.conf.APIVersion,
 		),
 	)
@@ -1337,7 +1450,7 @@ func (cl *Client) GetLoggedInUser() (*GetLoggedInUserResponseData, error) {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, formatHTTPNotOKStatusCodeError(resp)
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
 	}
 
 	reader, closer, err := resp.DecompressedReaderFromPool()
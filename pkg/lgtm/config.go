@@ -0,0 +1,375 @@
+package lgtm
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LoadConfigFromFile loads the config from the provided file;
+// the format (JSON, YAML or TOML) is picked by file extension.
+func LoadConfigFromFile(filepath string) (*Config, error) {
+	content, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading config file from %q: %w", filepath, err)
+	}
+
+	var conf Config
+	err = unmarshalConfig(ConfigFormat(filepath), content, &conf)
+	if err != nil {
+		return nil, fmt.Errorf("error while unmarshaling config file: %w", err)
+	}
+
+	return &conf, nil
+}
+
+// SaveConfigToFile writes the config to the provided file,
+// in the format of its extension.
+func SaveConfigToFile(filepath string, conf *Config) error {
+	js, err := marshalConfig(ConfigFormat(filepath), conf)
+	if err != nil {
+		return fmt.Errorf("error while marshaling config: %w", err)
+	}
+	// Write to a temp file first, to avoid leaving a partially written config:
+	tmp := filepath + ".tmp"
+	if err := ioutil.WriteFile(tmp, js, 0600); err != nil {
+		return fmt.Errorf("error while writing config file to %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, filepath); err != nil {
+		return fmt.Errorf("error while writing config file to %q: %w", filepath, err)
+	}
+	return nil
+}
+
+type LGTMSession struct {
+	Nonce        string `json:"nonce"`
+	ShortSession string `json:"short_session"`
+	LongSession  string `json:"long_session"`
+}
+
+// Validate validates
+func (sess *LGTMSession) Validate() error {
+	if sess.Nonce == "" {
+		return errors.New("session.nonce is not set")
+	}
+	if sess.ShortSession == "" {
+		return errors.New("session.short_session is not set")
+	}
+	if sess.LongSession == "" {
+		return errors.New("session.long_session is not set")
+	}
+	return nil
+}
+
+// DefaultBaseURL is the base URL of lgtm.com.
+const DefaultBaseURL = "https://lgtm.com"
+
+type Config struct {
+	// BaseURL is the base URL of the LGTM Enterprise instance
+	// (e.g. https://lgtm.example.com); if empty, lgtm.com is used.
+	BaseURL string `json:"base_url,omitempty"`
+
+	APIVersion string        `json:"api_version,omitempty"`
+	Session    *LGTMSession  `json:"session,omitempty"`
+	GitHub     *GithubConfig `json:"github,omitempty"`
+
+	// FollowLimit is the max number of projects the account can follow;
+	// it is used when lgtm.com does not advertise it.
+	FollowLimit int `json:"follow_limit,omitempty"`
+
+	// Headers override the user-agent, headers and cookies of the requests;
+	// a profile that sets them replaces the ones of the top-level config.
+	Headers *HeadersConfig `json:"headers,omitempty"`
+
+	// Timeouts are the timeouts of the HTTP requests;
+	// they are the same for all profiles.
+	Timeouts *TimeoutsConfig `json:"timeouts,omitempty"`
+
+	// Proxies are the proxies of the HTTP requests;
+	// they are the same for all profiles.
+	Proxies *ProxiesConfig `json:"proxies,omitempty"`
+
+	// Profiles are named lgtm.com accounts; the values
+	// that a profile does not set are taken from the top-level config.
+	Profiles       map[string]*Config `json:"profiles,omitempty"`
+	DefaultProfile string             `json:"default_profile,omitempty"`
+}
+
+// SelectedProfileName returns the provided profile name,
+// or the default profile name if empty.
+func (conf *Config) SelectedProfileName(name string) string {
+	if name == "" {
+		return conf.DefaultProfile
+	}
+	return name
+}
+
+// GetProfile returns the config of the named profile;
+// if name is empty, the default profile is returned (or the top-level
+// config if no default profile is set).
+func (conf *Config) GetProfile(name string) (*Config, error) {
+	name = conf.SelectedProfileName(name)
+	if name == "" {
+		return conf, nil
+	}
+	profile, ok := conf.Profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("profile %q not found in config", name)
+	}
+	merged := &Config{
+		BaseURL:     profile.BaseURL,
+		APIVersion:  profile.APIVersion,
+		Session:     profile.Session,
+		GitHub:      profile.GitHub,
+		FollowLimit: profile.FollowLimit,
+		Headers:     profile.Headers,
+		Timeouts:    conf.Timeouts,
+		Proxies:     conf.Proxies,
+	}
+	if merged.BaseURL == "" {
+		merged.BaseURL = conf.BaseURL
+	}
+	if merged.APIVersion == "" {
+		merged.APIVersion = conf.APIVersion
+	}
+	if merged.Session == nil {
+		merged.Session = conf.Session
+	}
+	if merged.GitHub == nil {
+		merged.GitHub = conf.GitHub
+	}
+	if merged.FollowLimit == 0 {
+		merged.FollowLimit = conf.FollowLimit
+	}
+	if merged.Headers == nil {
+		merged.Headers = conf.Headers
+	}
+	return merged, nil
+}
+
+// GetBaseURL returns the base URL of the lgtm.com (or LGTM Enterprise)
+// instance, without the trailing slash.
+func (conf *Config) GetBaseURL() string {
+	if conf.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(conf.BaseURL, "/")
+}
+
+// GetBaseHost returns the host (and port) of the base URL.
+func (conf *Config) GetBaseHost() string {
+	return baseHost(conf.GetBaseURL())
+}
+
+func baseHost(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return "lgtm.com"
+	}
+	return parsed.Host
+}
+
+// validateBaseURL checks that the base URL is an http(s) URL
+// without path, query or fragment.
+func validateBaseURL(baseURL string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("scheme must be http or https, got %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return errors.New("host is not set")
+	}
+	if strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return errors.New("must not have a path, query or fragment")
+	}
+	return nil
+}
+
+// SetSession sets the session of the named profile
+// (or of the top-level config if the name is empty).
+func (conf *Config) SetSession(name string, sess *LGTMSession) error {
+	if name == "" {
+		conf.Session = sess
+		return nil
+	}
+	profile, ok := conf.Profiles[name]
+	if !ok || profile == nil {
+		return fmt.Errorf("profile %q not found in config", name)
+	}
+	profile.Session = sess
+	return nil
+}
+
+// SetFollowLimit sets the follow limit of the named profile
+// (or of the top-level config if the name is empty).
+func (conf *Config) SetFollowLimit(name string, limit int) error {
+	if name == "" {
+		conf.FollowLimit = limit
+		return nil
+	}
+	profile, ok := conf.Profiles[name]
+	if !ok || profile == nil {
+		return fmt.Errorf("profile %q not found in config", name)
+	}
+	profile.FollowLimit = limit
+	return nil
+}
+
+// SaveFollowLimitToFile sets the follow limit of the provided profile
+// (or of the top-level config if the profile name is empty)
+// and saves it to the config file.
+func SaveFollowLimitToFile(configFilepath string, profileName string, limit int) error {
+	fileConf, err := LoadConfigFromFile(configFilepath)
+	if err != nil {
+		return err
+	}
+	if err := fileConf.SetFollowLimit(fileConf.SelectedProfileName(profileName), limit); err != nil {
+		return err
+	}
+	return SaveConfigToFile(configFilepath, fileConf)
+}
+
+// ProfileNames returns the sorted names of the profiles in the config.
+func (conf *Config) ProfileNames() []string {
+	names := make([]string, 0, len(conf.Profiles))
+	for name := range conf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Environment variables that can supply the credentials
+// instead of (or on top of) the config file.
+const (
+	EnvBaseURL      = "LGTM_BASE_URL"
+	EnvAPIVersion   = "LGTM_API_VERSION"
+	EnvNonce        = "LGTM_NONCE"
+	EnvShortSession = "LGTM_SHORT_SESSION"
+	EnvLongSession  = "LGTM_LONG_SESSION"
+	EnvGitHubToken  = "GITHUB_TOKEN"
+)
+
+// HasEnvCredentials returns true if any of the credentials
+// is set via environment variables.
+func HasEnvCredentials() bool {
+	for _, name := range []string{EnvAPIVersion, EnvNonce, EnvShortSession, EnvLongSession, EnvGitHubToken} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WithEnv returns a copy of the config where the credentials
+// set via environment variables override the ones of the config.
+func (conf *Config) WithEnv() *Config {
+	merged := *conf
+	if val := os.Getenv(EnvBaseURL); val != "" {
+		merged.BaseURL = val
+	}
+	if val := os.Getenv(EnvAPIVersion); val != "" {
+		merged.APIVersion = val
+	}
+
+	sess := &LGTMSession{}
+	if conf.Session != nil {
+		*sess = *conf.Session
+	}
+	if val := os.Getenv(EnvNonce); val != "" {
+		sess.Nonce = val
+	}
+	if val := os.Getenv(EnvShortSession); val != "" {
+		sess.ShortSession = val
+	}
+	if val := os.Getenv(EnvLongSession); val != "" {
+		sess.LongSession = val
+	}
+	if conf.Session != nil || *sess != (LGTMSession{}) {
+		merged.Session = sess
+	}
+
+	if val := os.Getenv(EnvGitHubToken); val != "" {
+		merged.GitHub = &GithubConfig{Token: val}
+	}
+	return &merged
+}
+
+type GithubConfig struct {
+	Token string `json:"token"`
+}
+
+// TimeoutsConfig contains the timeouts of the HTTP requests
+// to lgtm.com and to GitHub, as duration strings (e.g. "30s").
+type TimeoutsConfig struct {
+	Connect       string `json:"connect,omitempty"`
+	Request       string `json:"request,omitempty"`
+	GitHubConnect string `json:"github_connect,omitempty"`
+	GitHubRequest string `json:"github_request,omitempty"`
+}
+
+// Validate validates
+func (tc *TimeoutsConfig) Validate() error {
+	for name, val := range map[string]string{
+		"connect":        tc.Connect,
+		"request":        tc.Request,
+		"github_connect": tc.GitHubConnect,
+		"github_request": tc.GitHubRequest,
+	} {
+		if val == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(val); err != nil {
+			return fmt.Errorf("conf.timeouts.%s is not valid: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates
+func (conf *Config) Validate() error {
+	if conf.BaseURL != "" {
+		if err := validateBaseURL(conf.BaseURL); err != nil {
+			return fmt.Errorf("conf.base_url is not valid: %w", err)
+		}
+	}
+	if conf.APIVersion == "" {
+		return errors.New("conf.api_version is not set")
+	}
+	if conf.Session == nil {
+		return errors.New("conf.session is not set")
+	}
+	if err := conf.Session.Validate(); err != nil {
+		return fmt.Errorf("error while validating conf.session: %w", err)
+	}
+	if conf.GitHub == nil {
+		return errors.New("conf.github is not set")
+	}
+	if conf.GitHub.Token == "" {
+		return errors.New("conf.github.token is not set")
+	}
+	if conf.Headers != nil {
+		if err := conf.Headers.Validate(); err != nil {
+			return err
+		}
+	}
+	if conf.Timeouts != nil {
+		if err := conf.Timeouts.Validate(); err != nil {
+			return err
+		}
+	}
+	if conf.Proxies != nil {
+		if err := conf.Proxies.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package lgtm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testConfigJSON = `{
+  "api_version": "v1",
+  "session": {"nonce": "n0", "short_session": "s0", "long_session": "l0"},
+  "github": {"token": "t"},
+  "follow_limit": 5000,
+  "profiles": {"work": {"session": {"nonce": "wn0", "short_session": "ws0", "long_session": "wl0"}}}
+}`
+	testConfigYAML = `api_version: v1
+session:
+  nonce: n0
+  short_session: s0
+  long_session: l0
+github:
+  token: t
+follow_limit: 5000
+profiles:
+  work:
+    session:
+      nonce: wn0
+      short_session: ws0
+      long_session: wl0
+`
+	testConfigTOML = `api_version = "v1"
+follow_limit = 5000
+
+[session]
+nonce = "n0"
+short_session = "s0"
+long_session = "l0"
+
+[github]
+token = "t"
+
+[profiles.work.session]
+nonce = "wn0"
+short_session = "ws0"
+long_session = "wl0"
+`
+)
+
+// setTestEnv sets the env vars for the duration of the test,
+// and unsets the other credential env vars.
+func setTestEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range []string{EnvBaseURL, EnvAPIVersion, EnvNonce, EnvShortSession, EnvLongSession, EnvGitHubToken} {
+		previous, ok := os.LookupEnv(name)
+		if value, set := env[name]; set {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.json": testConfigJSON,
+		"config.yaml": testConfigYAML,
+		"config.yml":  testConfigYAML,
+		"config.toml": testConfigTOML,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeTestFile(t, name, content)
+			conf, err := LoadConfigFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := conf.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if conf.APIVersion != "v1" || conf.Session.Nonce != "n0" || conf.GitHub.Token != "t" || conf.FollowLimit != 5000 {
+				t.Errorf("unexpected config: %+v", conf)
+			}
+			work, err := conf.GetProfile("work")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if work.Session.LongSession != "wl0" || work.GitHub.Token != "t" {
+				t.Errorf("unexpected profile: %+v", work)
+			}
+
+			// Saving keeps the format:
+			if err := SaveConfigToFile(path, conf); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := LoadConfigFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.FollowLimit != 5000 || saved.Profiles["work"].Session.Nonce != "wn0" {
+				t.Errorf("unexpected saved config: %+v", saved)
+			}
+		})
+	}
+}
+
+func TestConfigWithEnv(t *testing.T) {
+	setTestEnv(t, map[string]string{
+		EnvNonce:       "env-nonce",
+		EnvLongSession: "env-long",
+		EnvGitHubToken: "env-token",
+	})
+	if !HasEnvCredentials() {
+		t.Fatal("HasEnvCredentials() = false")
+	}
+	conf := &Config{
+		APIVersion: "v1",
+		Session:    &LGTMSession{Nonce: "n", ShortSession: "s", LongSession: "l"},
+		GitHub:     &GithubConfig{Token: "t"},
+	}
+	merged := conf.WithEnv()
+	want := LGTMSession{Nonce: "env-nonce", ShortSession: "s", LongSession: "env-long"}
+	if *merged.Session != want || merged.GitHub.Token != "env-token" || merged.APIVersion != "v1" {
+		t.Errorf("unexpected merged config: %+v, %+v", merged, merged.Session)
+	}
+	if conf.Session.Nonce != "n" || conf.GitHub.Token != "t" {
+		t.Errorf("WithEnv modified the config")
+	}
+
+	// Without a config file:
+	merged = (&Config{}).WithEnv()
+	if merged.Session == nil || merged.Session.LongSession != "env-long" || merged.GitHub.Token != "env-token" {
+		t.Errorf("unexpected config from env: %+v", merged)
+	}
+
+	setTestEnv(t, nil)
+	if HasEnvCredentials() {
+		t.Error("HasEnvCredentials() = true")
+	}
+	if merged := conf.WithEnv(); *merged.Session != *conf.Session || merged.GitHub.Token != "t" {
+		t.Errorf("WithEnv without env vars changed the config: %+v", merged)
+	}
+}
+
+func TestSaveSessionToFile(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", testConfigYAML)
+	sess := &LGTMSession{Nonce: "wn1", ShortSession: "ws1", LongSession: "wl1"}
+	if err := SaveSessionToFile(path, "work", sess, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *saved.Profiles["work"].Session != *sess || saved.APIVersion != "v2" {
+		t.Errorf("unexpected saved config: %+v, %+v", saved, saved.Profiles["work"].Session)
+	}
+	if saved.Session.Nonce != "n0" || saved.GitHub.Token != "t" || saved.FollowLimit != 5000 {
+		t.Errorf("saving the session of a profile changed the rest of the config: %+v", saved)
+	}
+
+	if err := SaveSessionToFile(path, "missing", sess, "v2"); err == nil {
+		t.Error("saving the session of a missing profile should fail")
+	}
+}
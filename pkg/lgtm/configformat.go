@@ -0,0 +1,128 @@
+package lgtm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of the config file, by extension;
+// files with any other extension are JSON.
+const (
+	ConfigFormatJSON = "json"
+	ConfigFormatYAML = "yaml"
+	ConfigFormatTOML = "toml"
+)
+
+// ConfigFormat returns the format of the config file
+// from its extension.
+func ConfigFormat(configFilepath string) string {
+	switch strings.ToLower(filepath.Ext(configFilepath)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML
+	case ".toml":
+		return ConfigFormatTOML
+	default:
+		return ConfigFormatJSON
+	}
+}
+
+// unmarshalConfig parses the config in the provided format;
+// YAML and TOML configs have the same keys as JSON configs.
+func unmarshalConfig(format string, content []byte, conf *Config) error {
+	if format == ConfigFormatJSON {
+		return json.Unmarshal(content, conf)
+	}
+
+	var generic interface{}
+	switch format {
+	case ConfigFormatYAML:
+		if err := yaml.Unmarshal(content, &generic); err != nil {
+			return err
+		}
+		generic = stringKeys(generic)
+	case ConfigFormatTOML:
+		m := make(map[string]interface{})
+		if err := toml.Unmarshal(content, &m); err != nil {
+			return err
+		}
+		generic = m
+	default:
+		return fmt.Errorf("unknown config format %q", format)
+	}
+	// Convert to JSON, to use the same field names:
+	js, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, conf)
+}
+
+// marshalConfig encodes the config in the provided format.
+func marshalConfig(format string, conf *Config) ([]byte, error) {
+	js, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format == ConfigFormatJSON {
+		return js, nil
+	}
+
+	var generic map[string]interface{}
+	if err := json.Unmarshal(js, &generic); err != nil {
+		return nil, err
+	}
+	integralNumbers(generic)
+	switch format {
+	case ConfigFormatYAML:
+		return yaml.Marshal(generic)
+	case ConfigFormatTOML:
+		buf := new(bytes.Buffer)
+		if err := toml.NewEncoder(buf).Encode(generic); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// integralNumbers converts (recursively) the float64 values of the map
+// that are integral to int64, so that they are not encoded as floats.
+func integralNumbers(m map[string]interface{}) {
+	for k, v := range m {
+		switch val := v.(type) {
+		case float64:
+			if val == float64(int64(val)) {
+				m[k] = int64(val)
+			}
+		case map[string]interface{}:
+			integralNumbers(val)
+		}
+	}
+}
+
+// stringKeys converts the maps decoded from YAML (which have
+// interface{} keys) to maps with string keys, recursively.
+func stringKeys(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range val {
+			val[i] = stringKeys(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
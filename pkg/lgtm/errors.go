@@ -0,0 +1,172 @@
+package lgtm
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind is the kind of an error returned by the lgtm.com API,
+// which tells whether (and how) the request can be retried.
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown is any error that has no specific kind.
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindStaleSession means that the session is not valid anymore;
+	// the requests will fail until the session is refreshed.
+	ErrorKindStaleSession
+	// ErrorKindQuotaExceeded means that the account has reached
+	// the max number of projects it can follow.
+	ErrorKindQuotaExceeded
+	// ErrorKindRateLimited means that the requests are too frequent.
+	ErrorKindRateLimited
+	// ErrorKindNotFound means that the project (or repo) does not exist.
+	ErrorKindNotFound
+	// ErrorKindFork means that the repo is a fork (lgtm.com does not analyze forks).
+	ErrorKindFork
+	// ErrorKindAlreadyFollowed means that the project is already followed.
+	ErrorKindAlreadyFollowed
+	// ErrorKindTransient is a timeout or server error.
+	ErrorKindTransient
+)
+
+var errorKindNames = map[ErrorKind]string{
+	ErrorKindUnknown:         "unknown",
+	ErrorKindStaleSession:    "stale_session",
+	ErrorKindQuotaExceeded:   "quota_exceeded",
+	ErrorKindRateLimited:     "rate_limited",
+	ErrorKindNotFound:        "not_found",
+	ErrorKindFork:            "fork",
+	ErrorKindAlreadyFollowed: "already_followed",
+	ErrorKindTransient:       "transient",
+}
+
+// String returns the name of the kind (e.g. not_found).
+func (kind ErrorKind) String() string {
+	if name, ok := errorKindNames[kind]; ok {
+		return name
+	}
+	return errorKindNames[ErrorKindUnknown]
+}
+
+// IsRetryable returns true if retrying the request later
+// (with backoff) can succeed.
+func (kind ErrorKind) IsRetryable() bool {
+	return kind == ErrorKindRateLimited || kind == ErrorKindTransient
+}
+
+// ClassifyError returns the kind of the error (ErrorKindUnknown for nil errors,
+// and for errors that have no specific kind).
+func ClassifyError(err error) ErrorKind {
+	switch {
+	case err == nil:
+		return ErrorKindUnknown
+	case IsStaleSession(err):
+		return ErrorKindStaleSession
+	case IsRateLimited(err):
+		// Checked before the quota, since a rate-limit message
+		// can mention the follow requests too.
+		return ErrorKindRateLimited
+	case IsQuotaExceeded(err):
+		return ErrorKindQuotaExceeded
+	case IsNotFound(err):
+		return ErrorKindNotFound
+	case IsFork(err):
+		return ErrorKindFork
+	case IsAlreadyFollowed(err):
+		return ErrorKindAlreadyFollowed
+	case IsTransientError(err):
+		return ErrorKindTransient
+	}
+	return ErrorKindUnknown
+}
+
+// IsNotFound returns true if the error is about
+// a project (or repo) that does not exist.
+func IsNotFound(err error) bool {
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsNotFound()
+}
+
+// IsFork returns true if the error is about
+// a repo that can't be followed because it is a fork.
+func IsFork(err error) bool {
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsFork()
+}
+
+// IsAlreadyFollowed returns true if the error is about
+// a project that is already followed.
+func IsAlreadyFollowed(err error) bool {
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsAlreadyFollowed()
+}
+
+// IsQuotaExceeded returns true if the error is about the account
+// having reached the max number of projects it can follow.
+func IsQuotaExceeded(err error) bool {
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsProjectLimitReached()
+}
+
+// IsRateLimited returns true if the request was rejected
+// because the requests are too frequent.
+func IsRateLimited(err error) bool {
+	if statusCodeOf(err) == http.StatusTooManyRequests {
+		return true
+	}
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsRateLimited()
+}
+
+// IsStaleSession returns true if the request was rejected
+// because the session is not valid anymore.
+func IsStaleSession(err error) bool {
+	if errors.Is(err, ErrStaleSession) || statusCodeOf(err) == http.StatusUnauthorized {
+		return true
+	}
+	status := AsStatusResponseError(err)
+	return status != nil && status.IsStaleSession()
+}
+
+// statusCodeOf returns the HTTP status code of the response
+// of the request that failed with the error (or zero).
+func statusCodeOf(err error) int {
+	var enriched *EnrichedError
+	if errors.As(err, &enriched) {
+		return enriched.StatusCode()
+	}
+	return 0
+}
+
+// IsAlreadyFollowed returns true if the error is about
+// a project that is already followed.
+func (status *StatusResponse) IsAlreadyFollowed() bool {
+	return status.Status == STATUS_ERROR_STRING &&
+		strings.Contains(strings.ToLower(status.Message), "already follow")
+}
+
+// IsRateLimited returns true if the error is about
+// the requests being too frequent.
+func (status *StatusResponse) IsRateLimited() bool {
+	if status.Status != STATUS_ERROR_STRING {
+		return false
+	}
+	msg := strings.ToLower(status.Message)
+	return status.ErrorString == "too many requests" ||
+		strings.Contains(msg, "rate limit") ||
+		strings.Contains(msg, "too many requests")
+}
+
+// IsStaleSession returns true if the error is about
+// the session not being valid.
+func (status *StatusResponse) IsStaleSession() bool {
+	if status.Status != STATUS_ERROR_STRING {
+		return false
+	}
+	msg := strings.ToLower(status.Message)
+	return status.ErrorString == "unauthorized" ||
+		strings.Contains(msg, "not logged in") ||
+		strings.Contains(msg, "session expired")
+}
@@ -0,0 +1,138 @@
+package lgtm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gagliardetto/request"
+)
+
+func newTestStatusError(errorString string, message string) error {
+	return &StatusResponse{
+		Status:      STATUS_ERROR_STRING,
+		ErrorString: errorString,
+		Message:     message,
+	}
+}
+
+func newTestHTTPError(statusCode int, err error) error {
+	return &EnrichedError{
+		err:  err,
+		resp: &request.Response{Response: &http.Response{StatusCode: statusCode}},
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: ErrorKindUnknown,
+		},
+		{
+			name: "plain error",
+			err:  errors.New("something went wrong"),
+			want: ErrorKindUnknown,
+		},
+		{
+			name: "stale session sentinel",
+			err:  fmt.Errorf("error while following: %w", ErrStaleSession),
+			want: ErrorKindStaleSession,
+		},
+		{
+			name: "http 401",
+			err:  newTestHTTPError(http.StatusUnauthorized, errors.New("unauthorized")),
+			want: ErrorKindStaleSession,
+		},
+		{
+			name: "status unauthorized",
+			err:  newTestStatusError("unauthorized", ""),
+			want: ErrorKindStaleSession,
+		},
+		{
+			name: "status session expired",
+			err:  newTestStatusError("", "Your session expired, please log in again"),
+			want: ErrorKindStaleSession,
+		},
+		{
+			name: "project limit reached",
+			err:  newTestStatusError("bad request", "You have reached the maximum number of projects you can follow (5000)"),
+			want: ErrorKindQuotaExceeded,
+		},
+		{
+			name: "rate limit on follow requests",
+			err:  newTestStatusError("", "Rate limit exceeded for follow requests"),
+			want: ErrorKindRateLimited,
+		},
+		{
+			name: "status too many requests",
+			err:  newTestStatusError("too many requests", "Too many project requests, the limit is 10 per minute"),
+			want: ErrorKindRateLimited,
+		},
+		{
+			name: "http 429",
+			err:  newTestHTTPError(http.StatusTooManyRequests, errors.New("too many requests")),
+			want: ErrorKindRateLimited,
+		},
+		{
+			name: "http 429 wrapping a status",
+			err:  newTestHTTPError(http.StatusTooManyRequests, newTestStatusError("", "Follow limit exceeded, try again later")),
+			want: ErrorKindRateLimited,
+		},
+		{
+			name: "not found",
+			err:  fmt.Errorf("error while getting project: %w", newTestStatusError("not found", "")),
+			want: ErrorKindNotFound,
+		},
+		{
+			name: "fork",
+			err:  newTestStatusError("bad request", "This project appears to be a fork of foo/bar"),
+			want: ErrorKindFork,
+		},
+		{
+			name: "already followed",
+			err:  newTestStatusError("bad request", "You already follow this project"),
+			want: ErrorKindAlreadyFollowed,
+		},
+		{
+			name: "http 502",
+			err:  newTestHTTPError(http.StatusBadGateway, errors.New("bad gateway")),
+			want: ErrorKindTransient,
+		},
+		{
+			name: "http 400",
+			err:  newTestHTTPError(http.StatusBadRequest, errors.New("bad request")),
+			want: ErrorKindUnknown,
+		},
+		{
+			name: "success status",
+			err:  &StatusResponse{Status: STATUS_SUCCESS_STRING},
+			want: ErrorKindUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorKindIsRetryable(t *testing.T) {
+	retryable := map[ErrorKind]bool{
+		ErrorKindRateLimited: true,
+		ErrorKindTransient:   true,
+	}
+	for kind := range errorKindNames {
+		if got := kind.IsRetryable(); got != retryable[kind] {
+			t.Errorf("%s.IsRetryable() = %v, want %v", kind, got, retryable[kind])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package lgtm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/request"
+)
+
+// DefaultUserAgent is the user-agent of the requests to lgtm.com,
+// unless overridden with HeadersConfig.UserAgent.
+const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/73.0.3683.103 Safari/537.36"
+
+// HeadersConfig overrides the headers and cookies of the requests to lgtm.com,
+// e.g. to match the browser the session cookies were exported from.
+type HeadersConfig struct {
+	UserAgent string `json:"user_agent,omitempty"`
+	// Extra are headers added to the requests;
+	// they replace the default headers with the same name.
+	Extra map[string]string `json:"extra,omitempty"`
+	// Cookies are cookies added to the requests
+	// (the session cookies can't be overridden).
+	Cookies map[string]string `json:"cookies,omitempty"`
+}
+
+// HeaderOverrides are applied to all the requests to lgtm.com
+// after the headers config of the profile (e.g. set from flags).
+var HeaderOverrides *HeadersConfig
+
+// protectedHeaders and protectedCookies carry the session,
+// and are always set from conf.session.
+var (
+	protectedHeaders = []string{"lgtm-nonce", "cookie"}
+	protectedCookies = []string{lgtmLongSessionCookie, lgtmShortSessionCookie}
+)
+
+// Validate returns an error if a header or cookie name is invalid,
+// or if the config overrides the headers that carry the session
+// (lgtm-nonce and cookie) or the session cookies.
+func (hc *HeadersConfig) Validate() error {
+	for name := range hc.Extra {
+		if !isValidHeaderName(name) {
+			return fmt.Errorf("conf.headers.extra: invalid header name %q", name)
+		}
+		for _, protected := range protectedHeaders {
+			if strings.EqualFold(name, protected) {
+				return fmt.Errorf("conf.headers.extra: the %q header can't be overridden", name)
+			}
+		}
+	}
+	for name := range hc.Cookies {
+		if name == "" || strings.ContainsAny(name, "=; \t") {
+			return fmt.Errorf("conf.headers.cookies: invalid cookie name %q", name)
+		}
+		for _, protected := range protectedCookies {
+			if name == protected {
+				return fmt.Errorf("conf.headers.cookies: the %q cookie can't be overridden (it is set from conf.session)", name)
+			}
+		}
+	}
+	return nil
+}
+
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`()<>@,;:\"/[]?={}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseHeader parses a header in the "Name: value" format.
+func ParseHeader(raw string) (string, string, error) {
+	i := strings.Index(raw, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("invalid header %q: must be in the Name: value format", raw)
+	}
+	name, value := strings.TrimSpace(raw[:i]), strings.TrimSpace(raw[i+1:])
+	if !isValidHeaderName(name) {
+		return "", "", fmt.Errorf("invalid header %q: invalid name", raw)
+	}
+	return name, value, nil
+}
+
+// ParseCookie parses a cookie in the "name=value" format.
+func ParseCookie(raw string) (string, string, error) {
+	i := strings.Index(raw, "=")
+	if i < 1 {
+		return "", "", fmt.Errorf("invalid cookie %q: must be in the name=value format", raw)
+	}
+	return strings.TrimSpace(raw[:i]), strings.TrimSpace(raw[i+1:]), nil
+}
+
+// applyHeaders applies the headers config (which can be nil)
+// and then the HeaderOverrides to the request.
+func applyHeaders(req *request.Request, hc *HeadersConfig) {
+	for _, cfg := range []*HeadersConfig{hc, HeaderOverrides} {
+		if cfg == nil {
+			continue
+		}
+		if cfg.UserAgent != "" {
+			req.Headers["user-agent"] = cfg.UserAgent
+		}
+		for name, value := range cfg.Extra {
+			req.Headers[strings.ToLower(name)] = value
+		}
+		for name, value := range cfg.Cookies {
+			req.Cookies[name] = value
+		}
+	}
+}
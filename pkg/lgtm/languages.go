@@ -0,0 +1,153 @@
+package lgtm
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// Languages contains all the languages supported by lgtm.com.
+var Languages = []string{
+	LangCPP,
+	LangCSharp,
+	LangGo,
+	LangJava,
+	LangJavaScript,
+	LangPython,
+}
+
+// IsSupportedLanguage returns true if the language is supported by lgtm.com.
+func IsSupportedLanguage(lang string) bool {
+	return SliceContains(Languages, lang)
+}
+
+var (
+	qlImportRegex = regexp.MustCompile(`^\s*(?:private\s+)?import\s+([A-Za-z0-9_.]+)`)
+	// e.g. "codeql-go", "codeql/go-all", "codeql/javascript-queries"
+	qlpackDependencyRegex = regexp.MustCompile(`codeql[-/](cpp|csharp|go|java|javascript|python)\b`)
+	qlpackExtractorRegex  = regexp.MustCompile(`^\s*extractor:\s*["']?([a-z]+)`)
+)
+
+// qlModulePrefixes are the leading components of the QL module names
+// that come before the language (e.g. semmle.code.java.dataflow.DataFlow).
+var qlModulePrefixes = []string{"semmle", "experimental", "code"}
+
+// languageOfImport returns the language of an imported QL module
+// (e.g. "go", "semmle.javascript.security.dataflow.Xss",
+// "semmle.code.java.dataflow.DataFlow"), if any.
+func languageOfImport(module string) string {
+	parts := strings.Split(module, ".")
+	for len(parts) > 1 && SliceContains(qlModulePrefixes, parts[0]) {
+		parts = parts[1:]
+	}
+	if IsSupportedLanguage(parts[0]) {
+		return parts[0]
+	}
+	return ""
+}
+
+// languagesOfImports returns the languages of the modules
+// imported by the query.
+func languagesOfImports(queryString string) []string {
+	found := make(map[string]bool)
+	for _, line := range strings.Split(queryString, "\n") {
+		matches := qlImportRegex.FindStringSubmatch(line)
+		if len(matches) != 2 {
+			continue
+		}
+		if lang := languageOfImport(matches[1]); lang != "" {
+			found[lang] = true
+		}
+	}
+	return sortedKeys(found)
+}
+
+// languagesOfQlpack returns the languages of the dependencies
+// (or of the extractor) of the qlpack.yml file.
+func languagesOfQlpack(qlpackFilepath string) ([]string, error) {
+	content, err := ioutil.ReadFile(qlpackFilepath)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		if matches := qlpackExtractorRegex.FindStringSubmatch(line); len(matches) == 2 && IsSupportedLanguage(matches[1]) {
+			found[matches[1]] = true
+		}
+		for _, matches := range qlpackDependencyRegex.FindAllStringSubmatch(line, -1) {
+			found[matches[1]] = true
+		}
+	}
+	return sortedKeys(found), nil
+}
+
+// findQlpack returns the path of the qlpack.yml file
+// of the directory or of its closest parent.
+func findQlpack(dir string) (string, bool) {
+	for {
+		candidate := filepath.Join(dir, "qlpack.yml")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// DetectQueryLanguage infers the language of a query from the
+// modules it imports; if that is not enough, the qlpack.yml
+// of the query (or of its parent directories) is used.
+// If the query filepath is empty (e.g. for downloaded queries), only the imports are used.
+// An error is returned if the language is ambiguous or cannot be detected.
+func DetectQueryLanguage(queryFilepath string, queryString string) (string, error) {
+	langs := languagesOfImports(queryString)
+	if len(langs) == 1 {
+		return langs[0], nil
+	}
+	if len(langs) > 1 {
+		return "", fmt.Errorf("ambiguous query language: the query imports modules of %s", strings.Join(langs, ", "))
+	}
+	if queryFilepath == "" {
+		return "", errors.New("cannot detect the language of the query: no language imports")
+	}
+
+	absPath, err := filepath.Abs(queryFilepath)
+	if err != nil {
+		return "", err
+	}
+	qlpackFilepath, ok := findQlpack(filepath.Dir(absPath))
+	if !ok {
+		return "", fmt.Errorf("cannot detect the language of %s: no language imports and no qlpack.yml found", queryFilepath)
+	}
+	langs, err = languagesOfQlpack(qlpackFilepath)
+	if err != nil {
+		return "", fmt.Errorf("error while reading %s: %w", qlpackFilepath, err)
+	}
+	switch len(langs) {
+	case 0:
+		return "", fmt.Errorf("cannot detect the language of %s: %s has no language dependencies", queryFilepath, qlpackFilepath)
+	case 1:
+		return langs[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous query language: %s depends on %s", qlpackFilepath, strings.Join(langs, ", "))
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	res := make([]string, 0, len(m))
+	for key := range m {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,62 @@
+package lgtm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLanguageOfImport(t *testing.T) {
+	tests := map[string]string{
+		"go":         "go",
+		"java":       "java",
+		"cpp":        "cpp",
+		"csharp":     "csharp",
+		"python":     "python",
+		"javascript": "javascript",
+		"semmle.javascript.security.dataflow.Xss":           "javascript",
+		"semmle.python.security.TaintTracking":              "python",
+		"semmle.code.java.dataflow.DataFlow":                "java",
+		"semmle.code.cpp.dataflow.TaintTracking":            "cpp",
+		"semmle.code.csharp.security.dataflow.SqlInjection": "csharp",
+		"experimental.semmle.code.java.Logging":             "java",
+		"experimental.semmle.go.security.Foo":               "go",
+		"DataFlow":                                          "",
+		"semmle.code.Foo":                                   "",
+		"code":                                              "",
+		"mylib.java.Foo":                                    "",
+	}
+	for module, want := range tests {
+		if got := languageOfImport(module); got != want {
+			t.Errorf("languageOfImport(%q) = %q, want %q", module, got, want)
+		}
+	}
+}
+
+func TestDetectQueryLanguage(t *testing.T) {
+	lang, err := DetectQueryLanguage("", "import semmle.code.java.dataflow.DataFlow\nimport DataFlow::PathGraph\nselect 1")
+	if err != nil || lang != "java" {
+		t.Errorf("got %q, %v; want java", lang, err)
+	}
+	if _, err := DetectQueryLanguage("", "import java\nimport semmle.code.cpp.Foo\nselect 1"); err == nil {
+		t.Error("imports of several languages should be ambiguous")
+	}
+	if _, err := DetectQueryLanguage("", "select 1"); err == nil {
+		t.Error("a query without imports and without filepath should fail")
+	}
+
+	// Fallback to the qlpack.yml of a parent directory:
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "qlpack.yml"), []byte("name: my-queries\ndependencies:\n  codeql/go-all: \"*\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	queryDir := filepath.Join(dir, "src", "security")
+	if err := os.MkdirAll(queryDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lang, err = DetectQueryLanguage(filepath.Join(queryDir, "Query.ql"), "import MyLib\nselect 1")
+	if err != nil || lang != "go" {
+		t.Errorf("got %q, %v; want go", lang, err)
+	}
+}
@@ -0,0 +1,101 @@
+package lgtm
+
+import (
+	"fmt"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// ListStats contains info about a project selection (a.k.a. "list").
+type ListStats struct {
+	Name         string         `json:"name"`
+	Key          string         `json:"key"`
+	ProjectCount int            `json:"projectCount"`
+	Languages    map[string]int `json:"languages,omitempty"`
+}
+
+// GetListStats gets the number of projects in the provided list;
+// if withLangs is true, it also gets the number of projects per language.
+func (cl *Client) GetListStats(list *ProjectSelectionBare, withLangs bool) (*ListStats, error) {
+	resp, err := cl.ListProjectsInSelection(list.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", list.Name, err)
+	}
+	stats := &ListStats{
+		Name:         list.Name,
+		Key:          list.Key,
+		ProjectCount: len(resp.ProjectKeys),
+	}
+	if !withLangs || len(resp.ProjectKeys) == 0 {
+		return stats, nil
+	}
+
+	stats.Languages = make(map[string]int)
+	got, err := cl.GetProjectsByKeyBulk(cl.Context(), DefaultBulkMaxWorkers, resp.ProjectKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", list.Name, err)
+	}
+	for _, pr := range got.Projects {
+		for _, lang := range pr.Languages {
+			stats.Languages[lang]++
+		}
+	}
+	return stats, nil
+}
+
+// GetOrCreateProjectSelection returns the list with the provided name,
+// creating it if it does not exist; created tells whether it was created.
+func (cl *Client) GetOrCreateProjectSelection(name string) (list *ProjectSelectionBare, created bool, err error) {
+	lists, err := cl.ListProjectSelections()
+	if err != nil {
+		return nil, false, fmt.Errorf("error while getting lists: %w", err)
+	}
+	if list := lists.ByName(name); list != nil {
+		return list, false, nil
+	}
+
+	if err := cl.CreateProjectSelection(name); err != nil {
+		return nil, false, fmt.Errorf("error while creating list %q: %w", name, err)
+	}
+	lists, err = cl.ListProjectSelections()
+	if err != nil {
+		return nil, true, fmt.Errorf("error while getting lists: %w", err)
+	}
+	list = lists.ByName(name)
+	if list == nil {
+		return nil, true, fmt.Errorf("list %q not found after creation", name)
+	}
+	return list, true, nil
+}
+
+// CalcChunkCount returns the number of chunks of max chunkSize
+// needed to split total items.
+func CalcChunkCount(total int, chunkSize int) int {
+	partsNumber := total / chunkSize
+	if total < chunkSize {
+		partsNumber = 1
+	} else {
+		partsNumber++
+	}
+	return partsNumber
+}
+
+// GetProjectsInSelection gets the full info of all the projects
+// in the list with the provided name.
+func (cl *Client) GetProjectsInSelection(name string) ([]*Project, error) {
+	resp, err := cl.ListProjectsInSelection(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", name, err)
+	}
+	got, err := cl.GetProjectsByKeyBulk(cl.Context(), DefaultBulkMaxWorkers, resp.ProjectKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting projects of list %q: %w", name, err)
+	}
+	projects := make([]*Project, 0, len(got.Projects))
+	for _, key := range Deduplicate(resp.ProjectKeys) {
+		if pr := got.GetProject(key); pr != nil {
+			projects = append(projects, pr)
+		}
+	}
+	return projects, nil
+}
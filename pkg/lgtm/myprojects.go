@@ -0,0 +1,103 @@
+package lgtm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// followedEnvelope is an item of the getMyProjects response;
+// unlike Envelope, the projects are decoded directly into their structs.
+type followedEnvelope struct {
+	RealProject  []*Project    `json:"realProject"`
+	ProtoProject *ProtoProject `json:"protoproject"`
+}
+
+// decodeMyProjects decodes a getMyProjects response one item
+// of the data array at a time, so that the whole response
+// (which can be hundreds of MBs for accounts that follow
+// many projects) is never held in memory; r must already be
+// decompressed (the responses are requested gzip-compressed).
+func decodeMyProjects(r io.Reader) ([]*Project, []*ProtoProject, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return nil, nil, err
+	}
+
+	status := &StatusResponse{}
+	projectList := make([]*Project, 0)
+	protoProjectList := make([]*ProtoProject, 0)
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch tok {
+		case "status":
+			err = decoder.Decode(&status.Status)
+		case "error":
+			err = decoder.Decode(&status.ErrorString)
+		case "message":
+			err = decoder.Decode(&status.Message)
+		case "data":
+			err = decodeEachJSONArrayItem(decoder, func() error {
+				var envelope followedEnvelope
+				if err := decoder.Decode(&envelope); err != nil {
+					return err
+				}
+				if len(envelope.RealProject) > 0 && envelope.RealProject[0] != nil {
+					projectList = append(projectList, envelope.RealProject[0])
+				}
+				if envelope.ProtoProject != nil {
+					protoProjectList = append(protoProjectList, envelope.ProtoProject)
+				}
+				return nil
+			})
+		default:
+			err = decoder.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := expectJSONDelim(decoder, '}'); err != nil {
+		return nil, nil, err
+	}
+
+	if status.Status != "" && status.Status != STATUS_SUCCESS_STRING {
+		return nil, nil, status
+	}
+	return projectList, protoProjectList, nil
+}
+
+// decodeEachJSONArrayItem calls decodeItem for each item of the array
+// that is next in the decoder; a null array is treated as empty.
+func decodeEachJSONArrayItem(decoder *json.Decoder, decodeItem func() error) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected array, got %v", tok)
+	}
+	for decoder.More() {
+		if err := decodeItem(); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(decoder, ']')
+}
+
+func expectJSONDelim(decoder *json.Decoder, expected json.Delim) error {
+	tok, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("expected %q, got %v", expected, tok)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package lgtm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/ratelimit"
+)
+
+const testMyProjects = `{
+  "status": "success",
+  "data": [
+    {"realProject": [{"key": "1", "slug": "g/foo/bar", "languages": ["go"], "externalURL": {"url": "https://github.com/foo/bar"}}]},
+    {"protoproject": {"key": "2", "displayName": "foo/baz", "state": "build_attempt_in_progress", "cloneUrl": "https://github.com/foo/baz.git"}},
+    {"realProject": []},
+    {"unknown": {"key": "3"}}
+  ],
+  "extra": {"ignored": [1, 2, 3]}
+}`
+
+func TestDecodeMyProjects(t *testing.T) {
+	projects, protoProjects, err := decodeMyProjects(strings.NewReader(testMyProjects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Key != "1" || projects[0].Slug != "g/foo/bar" || projects[0].ExternalURL.URL != "https://github.com/foo/bar" {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+	if len(protoProjects) != 1 || protoProjects[0].Key != "2" || protoProjects[0].CloneURL != "https://github.com/foo/baz.git" {
+		t.Errorf("unexpected proto-projects: %+v", protoProjects)
+	}
+
+	projects, protoProjects, err = decodeMyProjects(strings.NewReader(`{"status": "success", "data": null}`))
+	if err != nil || len(projects) != 0 || len(protoProjects) != 0 {
+		t.Errorf("null data: got %v, %v, %v", projects, protoProjects, err)
+	}
+
+	_, _, err = decodeMyProjects(strings.NewReader(`{"status": "error", "error": "unauthorized", "message": "not logged in"}`))
+	if status, ok := err.(*StatusResponse); !ok || status.ErrorString != "unauthorized" {
+		t.Errorf("error status: got %v", err)
+	}
+
+	for _, invalid := range []string{``, `[]`, `{"data": {}}`, `{"data": [{"realProject": [`} {
+		if _, _, err := decodeMyProjects(strings.NewReader(invalid)); err == nil {
+			t.Errorf("decodeMyProjects(%q) should fail", invalid)
+		}
+	}
+}
+
+func newTestClient(t *testing.T, baseURL string) *Client {
+	t.Helper()
+	cl, err := NewClient(&Config{
+		BaseURL:    baseURL,
+		APIVersion: "1",
+		Session: &LGTMSession{
+			Nonce:        "nonce",
+			ShortSession: "short",
+			LongSession:  "long",
+		},
+		GitHub: &GithubConfig{Token: "token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cl.WithRateLimiter(ratelimit.NewUnlimited())
+}
+
+func TestListFollowedProjectsGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal_api/v0.2/getMyProjects" {
+			http.NotFound(w, r)
+			return
+		}
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		gz.Write([]byte(testMyProjects))
+		gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	projects, protoProjects, err := newTestClient(t, server.URL).ListFollowedProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || len(protoProjects) != 1 {
+		t.Errorf("got %v projects and %v proto-projects, want 1 and 1", len(projects), len(protoProjects))
+	}
+}
@@ -0,0 +1,129 @@
+package lgtm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/gagliardetto/utilz"
+	"golang.org/x/sync/semaphore"
+)
+
+// GetProjectsByKeyChunkSize is the max number of keys
+// that can be looked up with one getProjectsByKey request.
+const GetProjectsByKeyChunkSize = 100
+
+// DefaultBulkMaxWorkers is the number of concurrent requests
+// of GetProjectsByKeyBulk for callers that don't have their own setting.
+var DefaultBulkMaxWorkers int64 = 4
+
+// DefaultBulkMaxRetries is the number of times a chunk of GetProjectsByKeyBulk
+// is retried when it fails with a transient error.
+var DefaultBulkMaxRetries = 3
+
+// ProjectsByKey is the combined result of GetProjectsByKeyBulk.
+type ProjectsByKey struct {
+	// Projects are the projects found, by key.
+	Projects map[string]*Project
+	// FailedKeys are the keys whose chunk could not be looked up.
+	FailedKeys []string
+}
+
+// GetProject returns the project with the provided key, or nil.
+func (res *ProjectsByKey) GetProject(key string) *Project {
+	return res.Projects[key]
+}
+
+// GetProjectsByKeyBulk looks up the projects with the provided keys,
+// in chunks of GetProjectsByKeyChunkSize keys, with at most maxWorkers
+// concurrent requests; a chunk that fails with a transient error is retried
+// with backoff up to DefaultBulkMaxRetries times.
+// The result always contains the projects of the chunks that succeeded;
+// if any chunk failed, its keys are in FailedKeys and an error is returned too.
+func (cl *Client) GetProjectsByKeyBulk(ctx context.Context, maxWorkers int64, keys ...string) (*ProjectsByKey, error) {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+	keys = Deduplicate(keys)
+	res := &ProjectsByKey{
+		Projects:   make(map[string]*Project, len(keys)),
+		FailedKeys: make([]string, 0),
+	}
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	partsNumber := CalcChunkCount(len(keys), GetProjectsByKeyChunkSize)
+	chunks := make([][]string, 0, partsNumber)
+	for _, chunk := range SplitStringSlice(partsNumber, keys) {
+		if len(chunk) > 0 {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	var failedChunks int
+	var firstErr error
+	mu := &sync.Mutex{}
+	fail := func(chunk []string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failedChunks++
+		if firstErr == nil {
+			firstErr = err
+		}
+		res.FailedKeys = append(res.FailedKeys, chunk...)
+	}
+
+	wg := &sync.WaitGroup{}
+	sem := semaphore.NewWeighted(maxWorkers)
+	for _, chunk := range chunks {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			fail(chunk, err)
+			continue
+		}
+		wg.Add(1)
+
+		go func(chunk []string) {
+			defer wg.Done()
+			defer sem.Release(1)
+
+			got, err := cl.getProjectsByKeyWithRetry(ctx, chunk)
+			if err != nil {
+				fail(chunk, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for key, pr := range got.FullProjects {
+				res.Projects[key] = pr
+			}
+		}(chunk)
+	}
+	wg.Wait()
+
+	if failedChunks > 0 {
+		return res, fmt.Errorf(
+			"%v of %v chunks of projects could not be looked up (%v keys): %w",
+			failedChunks,
+			len(chunks),
+			len(res.FailedKeys),
+			firstErr,
+		)
+	}
+	return res, nil
+}
+
+func (cl *Client) getProjectsByKeyWithRetry(ctx context.Context, keys []string) (*GetProjectsByKeyResponseData, error) {
+	for attempt := 1; ; attempt++ {
+		got, err := cl.GetProjectsByKey(keys...)
+		if err == nil || !ClassifyError(err).IsRetryable() || attempt > DefaultBulkMaxRetries || ctx.Err() != nil {
+			return got, err
+		}
+		select {
+		case <-time.After(RetryBackoff(attempt)):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
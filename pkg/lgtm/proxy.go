@@ -0,0 +1,58 @@
+package lgtm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ProxyNone disables the proxy (including the one set with env vars).
+const ProxyNone = "none"
+
+// Proxy is the proxy used by the transports returned by NewHTTPTransport
+// (by default, the one set with the HTTP_PROXY/HTTPS_PROXY/NO_PROXY env vars).
+var Proxy = http.ProxyFromEnvironment
+
+// ParseProxy returns the proxy func for the provided proxy URL
+// (http://, https:// or socks5://); an empty string selects the proxy
+// set with env vars, and "none" disables the proxy.
+func ParseProxy(raw string) (func(*http.Request) (*url.URL, error), error) {
+	switch raw {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyNone:
+		return nil, nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: the scheme must be http, https or socks5", raw)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: host not set", raw)
+	}
+	return http.ProxyURL(parsed), nil
+}
+
+// ProxiesConfig contains the proxies of the requests to lgtm.com
+// and to GitHub (and all the other websites); the values are
+// proxy URLs (http://, https:// or socks5://) or "none".
+type ProxiesConfig struct {
+	LGTM   string `json:"lgtm,omitempty"`
+	GitHub string `json:"github,omitempty"`
+}
+
+// Validate validates the proxy URLs.
+func (pc *ProxiesConfig) Validate() error {
+	if _, err := ParseProxy(pc.LGTM); err != nil {
+		return fmt.Errorf("conf.proxies.lgtm is not valid: %w", err)
+	}
+	if _, err := ParseProxy(pc.GitHub); err != nil {
+		return fmt.Errorf("conf.proxies.github is not valid: %w", err)
+	}
+	return nil
+}
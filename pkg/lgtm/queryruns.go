@@ -0,0 +1,65 @@
+package lgtm
+
+import (
+	"fmt"
+)
+
+// GetAllQueryResults gets all the result items of a query run.
+func (cl *Client) GetAllQueryResults(queryID string, orderBy OrderBy) ([]*GetQueryResultsResponseItem, error) {
+	var startCursor string
+	items := make([]*GetQueryResultsResponseItem, 0)
+	for {
+		resp, err := cl.GetQueryResults(queryID, orderBy, startCursor)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Items == nil {
+			break
+		}
+		items = append(items, resp.Items...)
+		if resp.Cursor == "" {
+			break
+		}
+		startCursor = resp.Cursor
+	}
+	return items, nil
+}
+
+// QueryRunStatus contains the stats of a query run.
+type QueryRunStatus struct {
+	Key   string             `json:"key"`
+	Link  string             `json:"link"`
+	Stats QueryResponseStats `json:"stats"`
+}
+
+// IsDone returns true if all the runs of the query have completed.
+func (st *QueryRunStatus) IsDone() bool {
+	return st.Stats.Incomplete == 0 && st.Stats.PendingSchedulingTasks == 0
+}
+
+// GetQueryRunStatus gets the stats of a query run, computing them
+// from the result items of each project.
+func (cl *Client) GetQueryRunStatus(queryID string) (*QueryRunStatus, error) {
+	items, err := cl.GetAllQueryResults(queryID, OrderByNumResults)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting results of query run %s: %w", queryID, err)
+	}
+	status := &QueryRunStatus{
+		Key:  queryID,
+		Link: (&QueryResponseData{Key: queryID, baseURL: cl.BaseURL()}).GetResultLink(),
+	}
+	status.Stats.AllRuns = len(items)
+	for _, item := range items {
+		switch {
+		case item.Error != "":
+			status.Stats.Failed++
+		case !item.Done:
+			status.Stats.Incomplete++
+		case item.Stats != nil && item.Stats.NumResults > 0:
+			status.Stats.FinishedWithResults++
+		default:
+			status.Stats.FinishedWithoutResults++
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,104 @@
+package lgtm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FollowQuota contains the number of projects followed by the account,
+// and the max number of projects it can follow.
+type FollowQuota struct {
+	Followed      int `json:"followed"`
+	FollowedProto int `json:"followedProto"`
+	// Max is zero if the limit is unknown.
+	Max int `json:"max,omitempty"`
+}
+
+// Total returns the number of followed projects and proto-projects.
+func (q *FollowQuota) Total() int {
+	return q.Followed + q.FollowedProto
+}
+
+// Remaining returns the number of projects that can still be followed;
+// it returns -1 if the limit is unknown.
+func (q *FollowQuota) Remaining() int {
+	if q.Max == 0 {
+		return -1
+	}
+	if q.Total() >= q.Max {
+		return 0
+	}
+	return q.Max - q.Total()
+}
+
+// GetFollowQuota gets the follow quota of the account; the provided cache
+// (if not nil) is used to count the followed projects.
+// The limit is the follow_limit of the config, which is saved from the
+// "project limit reached" errors of lgtm.com (see StatusResponse.ProjectLimit).
+func (cl *Client) GetFollowQuota(cache *FollowedProjectCache) (*FollowQuota, error) {
+	quota := &FollowQuota{}
+	if cache != nil {
+		quota.Followed = cache.NumProjects()
+		quota.FollowedProto = cache.NumProto()
+	} else {
+		projects, protoProjects, err := cl.ListFollowedProjects()
+		if err != nil {
+			return nil, fmt.Errorf("error while getting list of followed projects: %w", err)
+		}
+		quota.Followed = len(projects)
+		quota.FollowedProto = len(protoProjects)
+	}
+	quota.Max = cl.conf.FollowLimit
+	return quota, nil
+}
+
+// projectLimitMessageRegex matches the (lowercased) messages of lgtm.com
+// about the account having reached the max number of followed projects,
+// e.g. "You have reached the maximum number of projects you can follow (5000)".
+var projectLimitMessageRegex = regexp.MustCompile(
+	`maximum number of (?:followed )?projects|` +
+		`(?:followed )?projects? limit|` +
+		`limit of [\d,]+ (?:followed )?projects|` +
+		`(?:at most|more than) [\d,]+ (?:followed )?projects`,
+)
+
+// IsProjectLimitReached returns true if the error is about the account
+// having reached the max number of projects it can follow.
+func (status *StatusResponse) IsProjectLimitReached() bool {
+	if status.Status != STATUS_ERROR_STRING || status.IsRateLimited() {
+		return false
+	}
+	return projectLimitMessageRegex.MatchString(strings.ToLower(status.Message))
+}
+
+// projectLimitRegexes extract the limit from a "project limit reached"
+// message, in order of preference: the number that follows the limit
+// phrase, the number of projects, or the number in parentheses.
+var projectLimitRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?:limit of|limit is|at most|more than|maximum of|up to) \(?([\d,]+)`),
+	regexp.MustCompile(`([\d,]+) (?:followed )?projects`),
+	regexp.MustCompile(`projects[^.\d]*\(([\d,]+)\)`),
+}
+
+// ProjectLimit returns the max number of projects that can be followed
+// as stated in a "project limit reached" error message;
+// it returns zero if the message does not contain it.
+func (status *StatusResponse) ProjectLimit() int {
+	if !status.IsProjectLimitReached() {
+		return 0
+	}
+	msg := strings.ToLower(status.Message)
+	for _, rx := range projectLimitRegexes {
+		match := rx.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.Replace(match[1], ",", "", -1))
+		if err == nil && limit > 0 {
+			return limit
+		}
+	}
+	return 0
+}
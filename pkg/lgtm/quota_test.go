@@ -0,0 +1,130 @@
+package lgtm
+
+import (
+	"testing"
+)
+
+func TestStatusResponseProjectLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      *StatusResponse
+		wantReached bool
+		wantLimit   int
+	}{
+		{
+			name: "maximum number of projects",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "You have reached the maximum number of projects you can follow (5000)",
+			},
+			wantReached: true,
+			wantLimit:   5000,
+		},
+		{
+			name: "limit of projects",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "You can't follow this project: there is a limit of 1,000 followed projects per account.",
+			},
+			wantReached: true,
+			wantLimit:   1000,
+		},
+		{
+			name: "project limit with an unrelated first number",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "Error 403: project limit reached (the limit is 2500)",
+			},
+			wantReached: true,
+			wantLimit:   2500,
+		},
+		{
+			name: "cannot follow more than",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "Cannot follow more than 300 projects",
+			},
+			wantReached: true,
+			wantLimit:   300,
+		},
+		{
+			name: "limit without a number",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "Project limit reached",
+			},
+			wantReached: true,
+			wantLimit:   0,
+		},
+		{
+			name: "rate limit on follow requests",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "Rate limit exceeded for follow requests: at most 10 projects per minute",
+			},
+		},
+		{
+			name: "too many requests",
+			status: &StatusResponse{
+				Status:      STATUS_ERROR_STRING,
+				ErrorString: "too many requests",
+				Message:     "Too many project requests, the limit is 10 per minute",
+			},
+		},
+		{
+			name: "follow limit exceeded",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "Follow limit exceeded, try again in 60 seconds",
+			},
+		},
+		{
+			name: "query size limit",
+			status: &StatusResponse{
+				Status:  STATUS_ERROR_STRING,
+				Message: "The query exceeds the maximum size of 1000 projects per run",
+			},
+		},
+		{
+			name: "fork",
+			status: &StatusResponse{
+				Status:      STATUS_ERROR_STRING,
+				ErrorString: "bad request",
+				Message:     "This project appears to be a fork of foo/bar",
+			},
+		},
+		{
+			name: "success",
+			status: &StatusResponse{
+				Status:  STATUS_SUCCESS_STRING,
+				Message: "You have reached the maximum number of projects you can follow (5000)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.IsProjectLimitReached(); got != tt.wantReached {
+				t.Errorf("IsProjectLimitReached() = %v, want %v", got, tt.wantReached)
+			}
+			if got := tt.status.ProjectLimit(); got != tt.wantLimit {
+				t.Errorf("ProjectLimit() = %v, want %v", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestFollowQuotaRemaining(t *testing.T) {
+	tests := []struct {
+		quota *FollowQuota
+		want  int
+	}{
+		{quota: &FollowQuota{Followed: 10, FollowedProto: 5}, want: -1},
+		{quota: &FollowQuota{Followed: 10, FollowedProto: 5, Max: 20}, want: 5},
+		{quota: &FollowQuota{Followed: 20, FollowedProto: 5, Max: 20}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := tt.quota.Remaining(); got != tt.want {
+			t.Errorf("%+v.Remaining() = %v, want %v", tt.quota, got, tt.want)
+		}
+	}
+}
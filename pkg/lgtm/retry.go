@@ -0,0 +1,39 @@
+package lgtm
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultRetryBackoff is the base wait duration before retrying
+// requests that failed with a transient error.
+var DefaultRetryBackoff = 5 * time.Second
+
+// IsTransientError returns true if the error is likely to go away
+// by retrying the request (timeouts, 5xx status codes, rate limiting).
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var enriched *EnrichedError
+	if errors.As(err, &enriched) && enriched.resp != nil {
+		return enriched.resp.StatusCode >= http.StatusInternalServerError ||
+			enriched.resp.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// RetryBackoff returns the duration to wait before the nth (one-indexed) retry;
+// the wait doubles at every attempt.
+func RetryBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return DefaultRetryBackoff * time.Duration(1<<uint(attempt-1))
+}
@@ -0,0 +1,98 @@
+package lgtm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// PublicAnalysis is an analysis of a commit of a project,
+// as returned by the lgtm.com public API (v1.0).
+type PublicAnalysis struct {
+	ID        string                    `json:"id"`
+	CommitID  string                    `json:"commit-id"`
+	Languages []*PublicAnalysisLanguage `json:"languages"`
+}
+
+// PublicAnalysisLanguage is the analysis of a commit for a language.
+type PublicAnalysisLanguage struct {
+	Language string `json:"language"`
+	Status   string `json:"status"`
+	Alerts   int    `json:"alerts"`
+}
+
+// GetAnalysisForCommit gets the analysis of the provided commit
+// of the project, using the lgtm.com public API.
+func (cl *Client) GetAnalysisForCommit(projectKey string, commitID string) (*PublicAnalysis, error) {
+	content, err := cl.getPublicAPI(
+		Sf(
+			cl.BaseURL()+"/api/v1.0/analyses/%s/commits/%s",
+			url.PathEscape(projectKey),
+			url.PathEscape(commitID),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var analysis PublicAnalysis
+	if err := json.Unmarshal(content, &analysis); err != nil {
+		return nil, fmt.Errorf("error while unmarshaling: %w", err)
+	}
+	if analysis.ID == "" {
+		return nil, errors.New("analysis not found")
+	}
+	return &analysis, nil
+}
+
+// GetAnalysisSARIF gets the alerts of the analysis
+// as a SARIF 2.1.0 log, using the lgtm.com public API.
+func (cl *Client) GetAnalysisSARIF(analysisID string) ([]byte, error) {
+	content, err := cl.getPublicAPI(
+		Sf(
+			cl.BaseURL()+"/api/v1.0/analyses/%s/alerts?sarif-version=2.1.0",
+			url.PathEscape(analysisID),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return nil, fmt.Errorf("error while unmarshaling: %w", err)
+	}
+	if header.Version != "2.1.0" {
+		return nil, fmt.Errorf("unexpected SARIF version %q", header.Version)
+	}
+	return content, nil
+}
+
+func (cl *Client) getPublicAPI(dst string) ([]byte, error) {
+	req, err := cl.newRequest()
+	if err != nil {
+		return nil, err
+	}
+	req.Headers["accept"] = "application/json"
+
+	resp, err := req.Get(dst)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, FormatHTTPNotOKStatusCodeError(resp)
+	}
+
+	reader, closer, err := resp.DecompressedReaderFromPool()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting Reader: %w", err)
+	}
+	defer closer()
+	defer resp.Body.Close()
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,27 @@
+package lgtm
+
+const (
+	lgtmLongSessionCookie  = "lgtm_long_session"
+	lgtmShortSessionCookie = "lgtm_short_session"
+)
+
+// SaveSessionToFile sets the session of the provided profile
+// (or of the top-level config if the profile name is empty)
+// and the API version, and saves them to the config file.
+func SaveSessionToFile(configFilepath string, profileName string, sess *LGTMSession, apiVersion string) error {
+	fileConf, err := LoadConfigFromFile(configFilepath)
+	if err != nil {
+		return err
+	}
+	if err := fileConf.SetSession(fileConf.SelectedProfileName(profileName), sess); err != nil {
+		return err
+	}
+	// The API version is the same for all accounts:
+	fileConf.APIVersion = apiVersion
+	for _, profile := range fileConf.Profiles {
+		if profile != nil && profile.APIVersion != "" {
+			profile.APIVersion = apiVersion
+		}
+	}
+	return SaveConfigToFile(configFilepath, fileConf)
+}
@@ -0,0 +1,43 @@
+package lgtm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	. "github.com/gagliardetto/utilz"
+)
+
+// DownloadSnapshot downloads the CodeQL database of the latest snapshot
+// of the project for the provided language (a zip archive, as exposed by the
+// lgtm.com public API), writes it to w and returns the number of written bytes.
+func (cl *Client) DownloadSnapshot(projectKey string, lang string, w io.Writer) (int64, error) {
+	req, err := cl.newRequest()
+	if err != nil {
+		return 0, err
+	}
+	req.Headers["accept"] = "application/zip"
+
+	resp, err := req.Get(
+		Sf(
+			cl.BaseURL()+"/api/v1.0/snapshots/%s/%s",
+			url.PathEscape(projectKey),
+			url.PathEscape(lang),
+		),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, FormatHTTPNotOKStatusCodeError(resp)
+	}
+
+	reader, closer, err := resp.DecompressedReaderFromPool()
+	if err != nil {
+		return 0, fmt.Errorf("error while getting Reader: %w", err)
+	}
+	defer closer()
+	defer resp.Body.Close()
+	return io.Copy(w, reader)
+}